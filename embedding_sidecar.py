@@ -48,10 +48,17 @@ class EmbedRequest(BaseModel):
 class EmbedResponse(BaseModel):
     embedding: list[float]
 
+class EmbedBatchRequest(BaseModel):
+    texts: list[str]
+
+class EmbedBatchResponse(BaseModel):
+    embeddings: list[list[float]]
+
 class QueryRequest(BaseModel):
     system: str
     user: str
     max_tokens: int = 256
+    temperature: float = 0.2
 
 class QueryResponse(BaseModel):
     answer: str
@@ -62,6 +69,12 @@ def embed(req: EmbedRequest):
     vec = model.encode([req.text])[0].tolist()
     return {"embedding": vec}
 
+@app.post("/embed_batch", response_model=EmbedBatchResponse)
+def embed_batch(req: EmbedBatchRequest):
+    model = get_embed_model()
+    vecs = model.encode(req.texts)
+    return {"embeddings": [v.tolist() for v in vecs]}
+
 @app.post("/query", response_model=QueryResponse)
 def query(req: QueryRequest):
     import torch
@@ -88,7 +101,7 @@ def query(req: QueryRequest):
         generated_ids = model.generate(
             **model_inputs,
             max_new_tokens=req.max_tokens,
-            temperature=0.2
+            temperature=req.temperature
         )
     
     # Decode response, removing the input prompt
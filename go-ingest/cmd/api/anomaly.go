@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/analytics"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// Anomaly detection defaults and limits for /api/v1/metrics/series.
+const (
+	defaultAnomalyWindow    = 20
+	maxAnomalyWindow        = 1000
+	defaultAnomalyThreshold = 3.0
+)
+
+// anomalyParams configures rolling z-score flagging; Window is in points.
+type anomalyParams struct {
+	Window    int
+	Threshold float64
+}
+
+// anomalyPoint is a series point annotated with its rolling z-score. ZScore is
+// null until a full window of earlier points exists or when that window is
+// constant.
+type anomalyPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+	ZScore    *float64  `json:"z_score"`
+	Anomaly   bool      `json:"anomaly"`
+}
+
+// parseAnomalyParams reads anomaly_window and anomaly_threshold. It returns
+// nil when neither is set, leaving the series unannotated.
+func parseAnomalyParams(q url.Values) (*anomalyParams, error) {
+	wv, tv := q.Get("anomaly_window"), q.Get("anomaly_threshold")
+	if wv == "" && tv == "" {
+		return nil, nil
+	}
+
+	p := &anomalyParams{Window: defaultAnomalyWindow, Threshold: defaultAnomalyThreshold}
+	if wv != "" {
+		n, err := strconv.Atoi(wv)
+		if err != nil {
+			return nil, badParam("anomaly_window", "must be an integer")
+		}
+		if n < 2 || n > maxAnomalyWindow {
+			return nil, badParam("anomaly_window", "must be between 2 and %d", maxAnomalyWindow)
+		}
+		p.Window = n
+	}
+	if tv != "" {
+		f, err := strconv.ParseFloat(tv, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f <= 0 {
+			return nil, badParam("anomaly_threshold", "must be a positive number")
+		}
+		p.Threshold = f
+	}
+	return p, nil
+}
+
+// annotateAnomalies flags points whose z-score against the preceding window
+// exceeds the threshold.
+func annotateAnomalies(series []store.TimeSeriesPoint, p anomalyParams) []anomalyPoint {
+	values := make([]float64, len(series))
+	for i, pt := range series {
+		values[i] = pt.Value
+	}
+	scores := analytics.RollingZScores(values, p.Window, p.Threshold)
+
+	out := make([]anomalyPoint, len(series))
+	for i, pt := range series {
+		out[i] = anomalyPoint{
+			Timestamp: pt.Timestamp,
+			Value:     pt.Value,
+			ZScore:    scores[i].Z,
+			Anomaly:   scores[i].Anomaly,
+		}
+	}
+	return out
+}
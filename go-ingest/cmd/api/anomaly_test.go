@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func TestMetricSeriesAnomalies(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		snap := models.Snapshot{Location: "Denver", Timestamp: base.Add(time.Duration(i) * time.Hour)}
+		snap.Weather.TemperatureC = 10 + float64(i%2)
+		if i == 25 {
+			snap.Weather.TemperatureC = 30 // synthetic spike
+		}
+		if err := s.store.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+	h := s.Router()
+	const series = "/api/v1/metrics/series?metric=temp_c&location=Denver&start=2026-10-01T00:00:00Z&end=2026-10-03T00:00:00Z"
+
+	get := func(path string) (int, []map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Data
+	}
+
+	code, points := get(series + "&anomaly_window=10&anomaly_threshold=3")
+	if code != http.StatusOK || len(points) != 40 {
+		t.Fatalf("got %d with %d points, want 200 with 40", code, len(points))
+	}
+	for i, p := range points {
+		if want := i == 25; p["anomaly"] != want {
+			t.Errorf("point %d (%v) anomaly = %v, want %v", i, p["value"], p["anomaly"], want)
+		}
+		if i < 10 && p["z_score"] != nil {
+			t.Errorf("point %d has z_score %v before a full window", i, p["z_score"])
+		}
+	}
+
+	code, points = get(series)
+	if code != http.StatusOK || len(points) != 40 {
+		t.Fatalf("plain series: got %d with %d points", code, len(points))
+	}
+	if _, ok := points[25]["anomaly"]; ok {
+		t.Error("series without anomaly params carries anomaly flags")
+	}
+
+	for _, bad := range []string{"&anomaly_window=1", "&anomaly_window=x", "&anomaly_threshold=0", "&anomaly_threshold=NaN"} {
+		if code, _ := get(series + bad); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, code)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// answerCacheBucket is the time granularity of cache keys, so a repeated
+// question is only served from cache within the same 5-minute window.
+const answerCacheBucket = 5 * time.Minute
+
+// cachedAnswer is a stored /api/v1/query result.
+type cachedAnswer struct {
+	Answer    string
+	Sources   []querySource
+	Citations []queryCitation
+	CreatedAt time.Time
+}
+
+type answerCacheEntry struct {
+	key   string
+	value cachedAnswer
+}
+
+// answerCache is a fixed-size LRU of query answers with a TTL.
+type answerCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+// newAnswerCache returns nil when size <= 0 (cache disabled).
+func newAnswerCache(size int, ttl time.Duration) *answerCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &answerCache{
+		size:    size,
+		ttl:     ttl,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// answerCacheKey normalizes the question (case and whitespace) and combines it
+// with the location, the options that change the answer, and the current
+// time bucket.
+func answerCacheKey(opts *queryOptions, now time.Time) string {
+	question := strings.Join(strings.Fields(strings.ToLower(opts.Question)), " ")
+	return fmt.Sprintf("%s|%s|%d|%g|%d|%s|%s|%d|%.2f|%s|%d",
+		question, opts.Location, opts.TopK, opts.MinScore, opts.PerLocation, opts.Start, opts.End,
+		opts.MaxTokens, *opts.Temperature, opts.Detail, now.Truncate(answerCacheBucket).Unix())
+}
+
+// Get returns the entry for key unless it is expired or older than
+// newestSnapshot (a newer snapshot may change the answer).
+func (c *answerCache) Get(key string, newestSnapshot time.Time) (cachedAnswer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return cachedAnswer{}, false
+	}
+	entry := el.Value.(*answerCacheEntry)
+	if time.Since(entry.value.CreatedAt) > c.ttl || newestSnapshot.After(entry.value.CreatedAt) {
+		c.ll.Remove(el)
+		delete(c.entries, key)
+		return cachedAnswer{}, false
+	}
+	c.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+// Put stores value under key, evicting the least recently used entry when full.
+func (c *answerCache) Put(key string, value cachedAnswer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*answerCacheEntry).value = value
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.ll.PushFront(&answerCacheEntry{key: key, value: value})
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*answerCacheEntry).key)
+	}
+}
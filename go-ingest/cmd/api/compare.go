@@ -0,0 +1,205 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// maxCompareLocations caps how many locations one compare request may name.
+const maxCompareLocations = 10
+
+// compareSummary holds summary stats over a location's raw (unbucketed)
+// series.
+type compareSummary struct {
+	Count  int       `json:"count"`
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Mean   float64   `json:"mean"`
+	Latest float64   `json:"latest"`
+	LastTS time.Time `json:"latest_ts"`
+}
+
+// compareEntry is one location's result. Summary and Rank are null when the
+// location has no data in the window.
+type compareEntry struct {
+	Location string                  `json:"location"`
+	Rank     *int                    `json:"rank"`
+	Summary  *compareSummary         `json:"summary"`
+	Series   []store.TimeSeriesPoint `json:"series"`
+}
+
+// handleCompareMetric returns one metric's series and summary stats for up to
+// maxCompareLocations locations over the same window. Locations are ranked by
+// mean (1 = highest); those without data are kept with an empty series. With
+// bucket (e.g. 1h) the series is averaged per bucket; stats always use the
+// raw points.
+func (s *APIServer) handleCompareMetric(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	metric := q.Get("metric")
+	if metric == "" {
+		respondParamError(w, r, badParam("metric", "missing metric"))
+		return
+	}
+	if !store.IsMetricColumn(metric) {
+		respondParamError(w, r, badParam("metric", "unknown metric %q", metric))
+		return
+	}
+
+	locations, err := parseLocationList(q.Get("locations"))
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	var bucket time.Duration
+	if v := q.Get("bucket"); v != "" {
+		if bucket, err = time.ParseDuration(v); err != nil || bucket < time.Minute {
+			respondParamError(w, r, badParam("bucket", "must be a duration of at least 1m, e.g. 1h"))
+			return
+		}
+	}
+
+	// Default to last 7 days if not specified
+	start, end, err := parseTimeRange(q, false, 7*24*time.Hour, s.cfg.MaxRangeSpan)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	entries := make([]compareEntry, len(locations))
+	g, ctx := errgroup.WithContext(r.Context())
+	for i, loc := range locations {
+		g.Go(func() error {
+			series, err := s.store.GetMetricSeriesContext(ctx, metric, loc, start, end)
+			if err != nil {
+				return err
+			}
+			entries[i] = compareEntry{Location: loc, Summary: summarizeSeries(series), Series: series}
+			if bucket > 0 {
+				entries[i].Series = bucketSeries(series, bucket)
+			}
+			if entries[i].Series == nil {
+				entries[i].Series = []store.TimeSeriesPoint{}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		respondStoreError(w, r, err, "metric series")
+		return
+	}
+	rankByMean(entries)
+
+	response := map[string]interface{}{
+		"metric": metric,
+		"start":  start.Format(time.RFC3339),
+		"end":    end.Format(time.RFC3339),
+		"count":  len(entries),
+		"data":   entries,
+	}
+	if bucket > 0 {
+		response["bucket"] = bucket.String()
+	}
+
+	respondJSON(w, r, http.StatusOK, response)
+}
+
+// parseLocationList splits a comma-separated locations parameter, dropping
+// blanks and duplicates.
+func parseLocationList(v string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(v, ",") {
+		loc := strings.TrimSpace(part)
+		if loc == "" || seen[loc] {
+			continue
+		}
+		seen[loc] = true
+		out = append(out, loc)
+	}
+	if len(out) == 0 {
+		return nil, badParam("locations", "missing locations; pass a comma-separated list, e.g. Los Angeles,Seattle")
+	}
+	if len(out) > maxCompareLocations {
+		return nil, badParam("locations", "at most %d locations may be compared", maxCompareLocations)
+	}
+	return out, nil
+}
+
+// summarizeSeries computes stats over an ascending series, or nil when it is
+// empty.
+func summarizeSeries(series []store.TimeSeriesPoint) *compareSummary {
+	if len(series) == 0 {
+		return nil
+	}
+	last := series[len(series)-1]
+	sum := &compareSummary{
+		Count:  len(series),
+		Min:    series[0].Value,
+		Max:    series[0].Value,
+		Latest: last.Value,
+		LastTS: last.Timestamp,
+	}
+	var total float64
+	for _, p := range series {
+		total += p.Value
+		if p.Value < sum.Min {
+			sum.Min = p.Value
+		}
+		if p.Value > sum.Max {
+			sum.Max = p.Value
+		}
+	}
+	sum.Mean = total / float64(len(series))
+	return sum
+}
+
+// bucketSeries averages an ascending series into fixed-width buckets, each
+// stamped with its start time.
+func bucketSeries(series []store.TimeSeriesPoint, bucket time.Duration) []store.TimeSeriesPoint {
+	var (
+		out   []store.TimeSeriesPoint
+		total float64
+		n     int
+	)
+	for _, p := range series {
+		ts := p.Timestamp.Truncate(bucket)
+		if n > 0 && !ts.Equal(out[len(out)-1].Timestamp) {
+			out[len(out)-1].Value = total / float64(n)
+			total, n = 0, 0
+		}
+		if n == 0 {
+			out = append(out, store.TimeSeriesPoint{Timestamp: ts})
+		}
+		total += p.Value
+		n++
+	}
+	if n > 0 {
+		out[len(out)-1].Value = total / float64(n)
+	}
+	return out
+}
+
+// rankByMean assigns ranks by descending mean to entries that have data,
+// leaving the request order of entries unchanged.
+func rankByMean(entries []compareEntry) {
+	var ranked []*compareEntry
+	for i := range entries {
+		if entries[i].Summary != nil {
+			ranked = append(ranked, &entries[i])
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Summary.Mean > ranked[j].Summary.Mean
+	})
+	for i, e := range ranked {
+		rank := i + 1
+		e.Rank = &rank
+	}
+}
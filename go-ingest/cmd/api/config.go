@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/semantic"
+)
+
+// apiConfig holds deployment settings read once from the environment at
+// startup and threaded into APIServer.
+type apiConfig struct {
+	// CORSOrigins lists the origins allowed to call the API. Empty means any
+	// origin ("*"), which is the historical behavior.
+	CORSOrigins []string
+
+	// QueryCacheSize and QueryCacheTTL bound the /api/v1/query answer cache.
+	// A size of 0 disables caching.
+	QueryCacheSize int
+	QueryCacheTTL  time.Duration
+
+	// StaleAfter is how old the newest snapshot may get before /health/ready
+	// reports the instance as degraded.
+	StaleAfter time.Duration
+
+	// DefaultLocation is used when a request omits location. Empty means
+	// location is required.
+	DefaultLocation string
+
+	// MaxRangeSpan caps the start..end span accepted by range queries.
+	MaxRangeSpan time.Duration
+
+	// ForecastCacheTTL is how long live forecast responses are reused.
+	ForecastCacheTTL time.Duration
+
+	// AdminToken is the bearer token for /api/v1/admin routes. Empty
+	// disables them.
+	AdminToken string
+
+	// ReindexBatchSize and ReindexInterval pace embedding re-index jobs
+	// against the sidecar: texts per request and the pause between requests.
+	ReindexBatchSize int
+	ReindexInterval  time.Duration
+
+	// Summarizer renders the text that re-index jobs embed, from
+	// EDGESIGHT_SUMMARY_SECTIONS. Nil means the default sections.
+	Summarizer *semantic.Summarizer
+
+	// TLSCert and TLSKey are PEM files that switch the server to HTTPS.
+	// TLSClientCA additionally requires client certificates signed by that
+	// CA (mutual TLS). All empty means plain HTTP.
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string
+}
+
+// loadConfig reads apiConfig from environment variables.
+func loadConfig() apiConfig {
+	return apiConfig{
+		CORSOrigins:      splitList(os.Getenv("EDGESIGHT_CORS_ORIGINS")),
+		QueryCacheSize:   envInt("EDGESIGHT_QUERY_CACHE_SIZE", 256),
+		QueryCacheTTL:    envDuration("EDGESIGHT_QUERY_CACHE_TTL", 5*time.Minute),
+		StaleAfter:       envDuration("EDGESIGHT_STALE_AFTER", 2*time.Hour),
+		DefaultLocation:  strings.TrimSpace(os.Getenv("EDGESIGHT_DEFAULT_LOCATION")),
+		MaxRangeSpan:     envDuration("EDGESIGHT_MAX_RANGE", 90*24*time.Hour),
+		ForecastCacheTTL: envDuration("EDGESIGHT_FORECAST_CACHE_TTL", 10*time.Minute),
+		AdminToken:       os.Getenv("EDGESIGHT_ADMIN_TOKEN"),
+		ReindexBatchSize: envInt("EDGESIGHT_REINDEX_BATCH", 32),
+		ReindexInterval:  envDuration("EDGESIGHT_REINDEX_INTERVAL", 500*time.Millisecond),
+		TLSCert:          os.Getenv("EDGESIGHT_TLS_CERT"),
+		TLSKey:           os.Getenv("EDGESIGHT_TLS_KEY"),
+		TLSClientCA:      os.Getenv("EDGESIGHT_TLS_CLIENT_CA"),
+	}
+}
+
+// queryEndpoint returns the sidecar /query URL: QUERY_ENDPOINT, or
+// embedEndpoint + "/query" when that is unset. QUERY_ENDPOINT=off disables
+// sidecar answers.
+func queryEndpoint(embedEndpoint string) string {
+	v := strings.TrimSpace(os.Getenv("QUERY_ENDPOINT"))
+	switch {
+	case strings.EqualFold(v, "off"):
+		return ""
+	case v != "":
+		return v
+	case embedEndpoint != "":
+		return strings.TrimRight(embedEndpoint, "/") + "/query"
+	}
+	return ""
+}
+
+// envInt reads a non-negative integer env var, falling back to def.
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// envDuration reads a Go duration env var (e.g. "90s"), falling back to def.
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return def
+}
+
+// splitList parses a comma-separated env value, dropping blanks.
+func splitList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
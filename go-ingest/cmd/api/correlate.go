@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/analytics"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// Correlation request limits.
+const (
+	maxCorrelateMetrics = 8
+	minCorrelateSamples = 10
+)
+
+// handleCorrelateMetrics returns the pairwise Pearson correlation of several
+// metrics at one location, aligned on snapshot timestamps. matrix[i][j] is
+// null when metrics i and j share fewer than minCorrelateSamples points (or
+// one is constant over them); samples[i][j] is the shared point count.
+func (s *APIServer) handleCorrelateMetrics(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	metrics, err := parseMetricList(q.Get("metrics"))
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	location, err := requireLocation(q, s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	// Default to last 7 days if not specified
+	start, end, err := parseTimeRange(q, false, 7*24*time.Hour, s.cfg.MaxRangeSpan)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	series := make([][]analytics.Point, len(metrics))
+	g, ctx := errgroup.WithContext(r.Context())
+	for i, metric := range metrics {
+		g.Go(func() error {
+			points, err := s.store.GetMetricSeriesContext(ctx, metric, location, start, end)
+			if err != nil {
+				return err
+			}
+			series[i] = make([]analytics.Point, len(points))
+			for k, p := range points {
+				series[i][k] = analytics.Point{Timestamp: p.Timestamp, Value: p.Value}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		respondStoreError(w, r, err, "metric series")
+		return
+	}
+
+	corr := analytics.CorrelationMatrix(series, minCorrelateSamples)
+	matrix := make([][]*float64, len(corr))
+	samples := make([][]int, len(corr))
+	for i, row := range corr {
+		matrix[i] = make([]*float64, len(row))
+		samples[i] = make([]int, len(row))
+		for j, c := range row {
+			matrix[i][j] = c.R
+			samples[i][j] = c.Samples
+		}
+	}
+
+	response := map[string]interface{}{
+		"location":    location,
+		"start":       start.Format(time.RFC3339),
+		"end":         end.Format(time.RFC3339),
+		"metrics":     metrics,
+		"matrix":      matrix,
+		"samples":     samples,
+		"min_samples": minCorrelateSamples,
+	}
+
+	respondJSON(w, r, http.StatusOK, response)
+}
+
+// parseMetricList splits a comma-separated metrics parameter, dropping blanks
+// and duplicates. At least two and at most maxCorrelateMetrics metrics are
+// allowed, each a known metric column.
+func parseMetricList(v string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(v, ",") {
+		metric := strings.TrimSpace(part)
+		if metric == "" || seen[metric] {
+			continue
+		}
+		if !store.IsMetricColumn(metric) {
+			return nil, badParam("metrics", "unknown metric %q", metric)
+		}
+		seen[metric] = true
+		out = append(out, metric)
+	}
+	if len(out) < 2 {
+		return nil, badParam("metrics", "pass at least two comma-separated metrics, e.g. pm25,temp_c")
+	}
+	if len(out) > maxCorrelateMetrics {
+		return nil, badParam("metrics", "at most %d metrics may be correlated", maxCorrelateMetrics)
+	}
+	return out, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnableCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name        string
+		allowed     []string
+		origin      string
+		wantOrigin  string
+		wantVary    bool
+		wantCredsOK bool
+	}{
+		{"wildcard fallback", nil, "https://anywhere.example.com", "*", false, false},
+		{"allowed origin", []string{"https://dash.example.com/"}, "https://dash.example.com", "https://dash.example.com", true, true},
+		{"disallowed origin", []string{"https://dash.example.com"}, "https://evil.example.com", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/locations", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			enableCORS(tt.allowed, ok).ServeHTTP(rec, req)
+
+			h := rec.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := h.Get("Vary") == "Origin"; got != tt.wantVary {
+				t.Errorf("Vary: Origin set = %v, want %v", got, tt.wantVary)
+			}
+			if got := h.Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredsOK {
+				t.Errorf("Allow-Credentials set = %v, want %v", got, tt.wantCredsOK)
+			}
+		})
+	}
+}
+
+func TestEnableCORSPreflight(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/query", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	rec := httptest.NewRecorder()
+	enableCORS([]string{"https://dash.example.com"}, next).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || called {
+		t.Errorf("preflight = %d (handler called %v), want 200 without calling the handler", rec.Code, called)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// dashboardWindow is the lookback for dashboard events and aggregates.
+const dashboardWindow = 24 * time.Hour
+
+// dashboardMetrics are the snapshot columns aggregated for the dashboard.
+var dashboardMetrics = []string{
+	"temp_c", "humidity", "pm25", "pm10", "ozone",
+	"grid_load", "renewable_percent", "carbon_intensity_gco2_kwh",
+}
+
+// dashboardFreshness reports how old the newest snapshot is.
+type dashboardFreshness struct {
+	LatestTS   time.Time `json:"latest_ts"`
+	AgeSeconds int64     `json:"age_seconds"`
+}
+
+// dashboardResponse is everything the dashboard home screen needs. A section
+// whose query failed is null and its error is listed under errors.
+type dashboardResponse struct {
+	Location    string                           `json:"location"`
+	GeneratedAt time.Time                        `json:"generated_at"`
+	Latest      *models.Snapshot                 `json:"latest"`
+	Previous    *models.Snapshot                 `json:"previous"`
+	Events      []store.Event                    `json:"events"`
+	Aggregates  map[string]store.MetricAggregate `json:"aggregates_24h"`
+	Freshness   *dashboardFreshness              `json:"freshness"`
+	Errors      map[string]string                `json:"errors,omitempty"`
+}
+
+// handleDashboard returns the latest and previous snapshots, recent events,
+// 24h aggregates and data freshness for a location in one payload. The store
+// queries run concurrently and fail independently. The ETag follows the
+// newest snapshot, so an unchanged dashboard revalidates with a 304.
+func (s *APIServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	location, err := requireLocation(r.URL.Query(), s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	// Revalidate against the newest snapshot before running the full query set
+	if newest, err := s.store.GetLatestSnapshotTimeContext(r.Context(), location); err == nil && !newest.IsZero() {
+		if checkNotModified(w, r, snapshotETag(location, newest)) {
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-dashboardWindow)
+
+	var (
+		recent               []models.Snapshot
+		events               []store.Event
+		aggregates           map[string]store.MetricAggregate
+		recentErr, eventsErr error
+		aggErr               error
+	)
+
+	ctx := r.Context()
+	var g errgroup.Group
+	g.Go(func() error {
+		recent, recentErr = s.store.GetRecentSnapshotsContext(ctx, location, 2)
+		return nil
+	})
+	g.Go(func() error {
+		events, eventsErr = s.store.GetEventsSinceContext(ctx, location, since)
+		return nil
+	})
+	g.Go(func() error {
+		aggregates, aggErr = s.store.GetMetricAggregatesContext(ctx, location, dashboardMetrics, since, now)
+		return nil
+	})
+	g.Wait()
+
+	resp := dashboardResponse{
+		Location:    location,
+		GeneratedAt: now,
+		Errors:      map[string]string{},
+	}
+
+	if recentErr != nil {
+		log.Printf("dashboard snapshots for %s: %v", location, recentErr)
+		resp.Errors["latest"] = "failed to load snapshots"
+	} else {
+		if len(recent) > 0 {
+			resp.Latest = &recent[0]
+			resp.Freshness = &dashboardFreshness{
+				LatestTS:   recent[0].Timestamp,
+				AgeSeconds: int64(now.Sub(recent[0].Timestamp).Seconds()),
+			}
+		}
+		if len(recent) > 1 {
+			resp.Previous = &recent[1]
+		}
+	}
+
+	if eventsErr != nil {
+		log.Printf("dashboard events for %s: %v", location, eventsErr)
+		resp.Errors["events"] = "failed to load events"
+	} else {
+		resp.Events = events
+	}
+
+	if aggErr != nil {
+		log.Printf("dashboard aggregates for %s: %v", location, aggErr)
+		resp.Errors["aggregates_24h"] = "failed to load aggregates"
+	} else {
+		resp.Aggregates = aggregates
+	}
+
+	if len(resp.Errors) == 0 {
+		resp.Errors = nil
+	}
+	respondJSON(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// defaultDiffLag is the comparison window when neither from nor lag is given.
+const defaultDiffLag = 24 * time.Hour
+
+// diffSide is one end of a comparison: the requested time and the newest
+// snapshot at or before it (nil when there is none).
+type diffSide struct {
+	Requested time.Time        `json:"requested"`
+	Snapshot  *models.Snapshot `json:"snapshot"`
+}
+
+// handleSnapshotDiff compares a location's snapshots at two times and returns
+// per-field deltas. "to" defaults to now and "from" to "to" minus lag
+// (default 24h). Each side uses the newest snapshot at or before its time;
+// when one side has none, changes is null and missing names that side.
+func (s *APIServer) handleSnapshotDiff(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	location, err := requireLocation(q, s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := q.Get("to"); v != "" {
+		if to, err = parseRFC3339("to", v); err != nil {
+			respondParamError(w, r, err)
+			return
+		}
+	}
+
+	var from time.Time
+	switch fromStr, lagStr := q.Get("from"), q.Get("lag"); {
+	case fromStr != "" && lagStr != "":
+		respondParamError(w, r, badParam("lag", "pass either from or lag, not both"))
+		return
+	case fromStr != "":
+		if from, err = parseRFC3339("from", fromStr); err != nil {
+			respondParamError(w, r, err)
+			return
+		}
+	default:
+		lag := defaultDiffLag
+		if lagStr != "" {
+			if lag, err = time.ParseDuration(lagStr); err != nil || lag <= 0 {
+				respondParamError(w, r, badParam("lag", "must be a positive duration, e.g. 24h"))
+				return
+			}
+		}
+		from = to.Add(-lag)
+	}
+	if !from.Before(to) {
+		respondParamError(w, r, badParam("from", "must be before to"))
+		return
+	}
+	if s.cfg.MaxRangeSpan > 0 && to.Sub(from) > s.cfg.MaxRangeSpan {
+		respondParamError(w, r, badParam("from", "comparison window must not exceed %s", formatSpan(s.cfg.MaxRangeSpan)))
+		return
+	}
+
+	fromSide := diffSide{Requested: from}
+	toSide := diffSide{Requested: to}
+	var missing []string
+	for _, side := range []struct {
+		name string
+		d    *diffSide
+	}{{"from", &fromSide}, {"to", &toSide}} {
+		snap, err := s.store.GetSnapshotAtOrBeforeContext(r.Context(), location, side.d.Requested)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			missing = append(missing, side.name)
+		case err != nil:
+			respondStoreError(w, r, err, "snapshot")
+			return
+		default:
+			side.d.Snapshot = snap
+		}
+	}
+	if len(missing) == 2 {
+		respondError(w, r, http.StatusNotFound, codeNotFound, "no snapshots found for location: "+location)
+		return
+	}
+
+	var changes map[string]models.FieldDelta
+	if fromSide.Snapshot != nil && toSide.Snapshot != nil {
+		changes = fromSide.Snapshot.Diff(*toSide.Snapshot)
+	}
+
+	respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"location": location,
+		"from":     fromSide,
+		"to":       toSide,
+		"changes":  changes,
+		"missing":  missing,
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+type diffResponse struct {
+	From    diffSide                     `json:"from"`
+	To      diffSide                     `json:"to"`
+	Changes map[string]models.FieldDelta `json:"changes"`
+	Missing []string                     `json:"missing"`
+}
+
+func getDiff(t *testing.T, h http.Handler, path string) (int, diffResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var body diffResponse
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+func TestSnapshotDiffHandler(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i, temp := range []float64{20, 15} {
+		snap := models.Snapshot{Location: "Denver", Timestamp: base.Add(time.Duration(i) * 24 * time.Hour), Weather: models.Weather{TemperatureC: temp}}
+		if err := s.store.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+	h := s.Router()
+
+	code, body := getDiff(t, h, "/api/v1/snapshots/diff?location=Denver&to=2026-10-17T13:00:00Z&lag=24h")
+	if code != http.StatusOK || len(body.Missing) != 0 {
+		t.Fatalf("got %d missing %v, want 200 with both sides", code, body.Missing)
+	}
+	if d := body.Changes["weather.temperature_c"]; d.Delta != -5 {
+		t.Errorf("temperature delta = %v, want -5", d.Delta)
+	}
+
+	code, body = getDiff(t, h, "/api/v1/snapshots/diff?location=Denver&to=2026-10-17T13:00:00Z&lag=72h")
+	if code != http.StatusOK || len(body.Missing) != 1 || body.Missing[0] != "from" {
+		t.Errorf("from before any data: got %d missing %v, want 200 missing [from]", code, body.Missing)
+	}
+	if body.Changes != nil || body.To.Snapshot == nil {
+		t.Errorf("one side missing: changes %v, to %v; want null changes and the to snapshot", body.Changes, body.To.Snapshot)
+	}
+
+	if code, _ := getDiff(t, h, "/api/v1/snapshots/diff?location=Boston"); code != http.StatusNotFound {
+		t.Errorf("no data on either side = %d, want 404", code)
+	}
+}
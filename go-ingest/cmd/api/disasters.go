@@ -0,0 +1,21 @@
+package main
+
+import "net/http"
+
+// handleGetDisasters returns the newest FEMA detail recorded for a location:
+// the snapshot's coarse disaster numbers plus counts per incident type and
+// the most recent declarations with their counties.
+func (s *APIServer) handleGetDisasters(w http.ResponseWriter, r *http.Request) {
+	location, err := requireLocation(r.URL.Query(), s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	report, err := s.store.GetLatestDisasterReportContext(r.Context(), location)
+	if err != nil {
+		respondStoreError(w, r, err, "disaster report")
+		return
+	}
+	respondJSON(w, r, http.StatusOK, report)
+}
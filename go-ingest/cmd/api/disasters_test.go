@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+func TestGetDisasters(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	report := store.DisasterReport{
+		Location:           "Los Angeles",
+		Timestamp:          time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+		State:              "CA",
+		County:             "037",
+		ActiveDisasters:    2,
+		TopIncidentType:    "Fire",
+		IncidentTypeCounts: map[string]int{"Fire": 1, "Severe Storm": 1},
+		Recent: []store.DisasterDeclaration{{
+			DisasterNumber:  4856,
+			IncidentType:    "Fire",
+			DeclarationType: "DR",
+			DeclarationDate: time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+			Open:            true,
+			Counties:        []string{"Los Angeles (County)"},
+		}},
+	}
+	if err := s.store.InsertDisasterReport(report); err != nil {
+		t.Fatalf("InsertDisasterReport: %v", err)
+	}
+	h := s.Router()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/disasters?location=Los+Angeles", nil))
+	var got store.DisasterReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET disasters = %d %q (%v), want 200 with a report", rec.Code, rec.Body.String(), err)
+	}
+	if got.County != "037" || got.IncidentTypeCounts["Severe Storm"] != 1 ||
+		len(got.Recent) != 1 || got.Recent[0].DisasterNumber != 4856 || got.Recent[0].Counties[0] != "Los Angeles (County)" {
+		t.Errorf("report = %+v, want the stored county detail", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/disasters?location=Boston", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown location = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// Error codes used in the "code" field of the error envelope.
+const (
+	codeInvalidParameter = "invalid_parameter"
+	codeBadRequest       = "bad_request"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeInternal         = "internal"
+	codeUnavailable      = "unavailable"
+	codeBadGateway       = "bad_gateway"
+	codeGatewayTimeout   = "gateway_timeout"
+)
+
+// apiError is the body of every error response, wrapped as
+// {"error": {"code": ..., "message": ..., "request_id": ...}}. Param is set
+// for validation errors tied to one query parameter, and Suggestions lists
+// valid alternatives when there are some.
+type apiError struct {
+	Code        string   `json:"code"`
+	Message     string   `json:"message"`
+	RequestID   string   `json:"request_id,omitempty"`
+	Param       string   `json:"param,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// newAPIError builds an apiError stamped with the request's ID. Handlers that
+// embed an error in a larger payload (or an SSE event) use it directly.
+func newAPIError(r *http.Request, code, message string) apiError {
+	return apiError{Code: code, Message: message, RequestID: requestIDFrom(r.Context())}
+}
+
+// respondError writes the standard error envelope.
+func respondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	respondJSON(w, r, status, map[string]apiError{"error": newAPIError(r, code, message)})
+}
+
+// respondMethodNotAllowed writes a 405 listing the allowed methods.
+func respondMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	respondError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method "+r.Method+" not allowed")
+}
+
+// respondStoreError maps a store error to a response: store.ErrNotFound is a
+// 404 and store.ErrInvalidInput a 400. A query cancelled because the client
+// went away writes nothing. Anything else is logged and returned as a
+// generic 500 so driver details never reach the client.
+func respondStoreError(w http.ResponseWriter, r *http.Request, err error, what string) {
+	switch {
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		return
+	case errors.Is(err, store.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, codeNotFound, sentinelMessage(err, store.ErrNotFound))
+	case errors.Is(err, store.ErrInvalidInput):
+		respondError(w, r, http.StatusBadRequest, codeBadRequest, sentinelMessage(err, store.ErrInvalidInput))
+	default:
+		log.Printf("[%s] %s %s: failed to fetch %s: %v", requestIDFrom(r.Context()), r.Method, r.URL.Path, what, err)
+		respondError(w, r, http.StatusInternalServerError, codeInternal, "failed to fetch "+what)
+	}
+}
+
+// sentinelMessage strips the trailing ": <sentinel>" that store methods add
+// when wrapping, leaving the descriptive part for the client.
+func sentinelMessage(err, sentinel error) string {
+	return strings.TrimSuffix(err.Error(), ": "+sentinel.Error())
+}
+
+// respondInternalError logs err and writes a generic 5xx with the given
+// status, code and client-facing message.
+func respondInternalError(w http.ResponseWriter, r *http.Request, status int, code, message string, err error) {
+	log.Printf("[%s] %s %s: %s: %v", requestIDFrom(r.Context()), r.Method, r.URL.Path, message, err)
+	respondError(w, r, status, code, message)
+}
+
+// respondEmbedError reports a failed embedding call: 503 when the sidecar
+// is unreachable, 502 when it answered with an error.
+func respondEmbedError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, embeddings.ErrSidecarUnavailable) {
+		respondInternalError(w, r, http.StatusServiceUnavailable, codeUnavailable, "embedding service unavailable", err)
+		return
+	}
+	respondInternalError(w, r, http.StatusBadGateway, codeBadGateway, "embedding request failed", err)
+}
+
+type requestIDKey struct{}
+
+// requestIDHeader carries the request ID in both directions.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware assigns each request an ID, reusing a well-formed
+// X-Request-ID from the client, and echoes it in the response header so it
+// can be matched against error bodies and server logs.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRandomID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestIDFrom returns the request ID stored in ctx, or "".
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRandomID returns 16 random hex characters, used for request and job IDs.
+func newRandomID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID accepts short IDs made of printable, non-space ASCII so a
+// client cannot inject anything odd into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonNotFound answers requests the mux has no route for with the JSON error
+// envelope instead of ServeMux's text/plain bodies: a 405 (with the Allow
+// header ServeMux computed) when the path exists under another method, and a
+// 404 otherwise.
+func jsonNotFound(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		// Let ServeMux decide between 404, 405 and redirects without
+		// writing its own body
+		probe := &statusProbe{header: http.Header{}}
+		h.ServeHTTP(probe, r)
+		switch probe.status {
+		case http.StatusMethodNotAllowed:
+			respondMethodNotAllowed(w, r, probe.header.Values("Allow")...)
+		case http.StatusNotFound:
+			respondError(w, r, http.StatusNotFound, codeNotFound, "no route for "+r.URL.Path)
+		default:
+			mux.ServeHTTP(w, r)
+		}
+	})
+}
+
+// statusProbe is a throwaway ResponseWriter that records the status and
+// headers a handler would send.
+type statusProbe struct {
+	header http.Header
+	status int
+}
+
+func (p *statusProbe) Header() http.Header { return p.header }
+
+func (p *statusProbe) Write(b []byte) (int, error) {
+	if p.status == 0 {
+		p.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (p *statusProbe) WriteHeader(status int) {
+	if p.status == 0 {
+		p.status = status
+	}
+}
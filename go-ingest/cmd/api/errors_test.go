@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	driverErr := errors.New("sqlite: disk I/O error at page 42")
+	tests := []struct {
+		name      string
+		respond   func(w http.ResponseWriter, r *http.Request)
+		status    int
+		code      string
+		param     string
+		forbidden string // must not appear in the message
+	}{
+		{
+			name: "invalid parameter",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondParamError(w, r, badParam("hours", "must be positive"))
+			},
+			status: http.StatusBadRequest, code: codeInvalidParameter, param: "hours",
+		},
+		{
+			name: "store not found",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondStoreError(w, r, fmt.Errorf("no snapshot for Denver: %w", store.ErrNotFound), "snapshot")
+			},
+			status: http.StatusNotFound, code: codeNotFound,
+		},
+		{
+			name: "store invalid input",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondStoreError(w, r, fmt.Errorf("unknown metric: %w", store.ErrInvalidInput), "history")
+			},
+			status: http.StatusBadRequest, code: codeBadRequest,
+		},
+		{
+			name:    "store internal",
+			respond: func(w http.ResponseWriter, r *http.Request) { respondStoreError(w, r, driverErr, "snapshot") },
+			status:  http.StatusInternalServerError, code: codeInternal, forbidden: "sqlite",
+		},
+		{
+			name: "embedding sidecar down",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondEmbedError(w, r, fmt.Errorf("call /embed: %w: connection refused", embeddings.ErrSidecarUnavailable))
+			},
+			status: http.StatusServiceUnavailable, code: codeUnavailable, forbidden: "refused",
+		},
+		{
+			name: "embedding failed",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondEmbedError(w, r, fmt.Errorf("/embed returned 500: %w", embeddings.ErrEmbedFailed))
+			},
+			status: http.StatusBadGateway, code: codeBadGateway, forbidden: "500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := requestIDMiddleware(http.HandlerFunc(tt.respond))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+			req.Header.Set(requestIDHeader, "req-123")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+			}
+			e := body.Error
+			if e.Code != tt.code || e.Param != tt.param || e.RequestID != "req-123" || e.Message == "" {
+				t.Errorf("error = %+v, want code %q, param %q, request_id req-123", e, tt.code, tt.param)
+			}
+			if tt.forbidden != "" && strings.Contains(e.Message, tt.forbidden) {
+				t.Errorf("message %q leaks %q", e.Message, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestJSONNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/locations", func(w http.ResponseWriter, r *http.Request) {})
+	h := jsonNotFound(mux)
+
+	tests := []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/api/v1/nope", http.StatusNotFound, codeNotFound},
+		{http.MethodDelete, "/api/v1/locations", http.StatusMethodNotAllowed, codeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		var body struct {
+			Error apiError `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: decode body %q: %v", tt.method, tt.path, rec.Body.String(), err)
+		}
+		if rec.Code != tt.status || body.Error.Code != tt.code {
+			t.Errorf("%s %s = %d %q, want %d %q", tt.method, tt.path, rec.Code, body.Error.Code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	h := newTestAPIServer(t, nil, apiConfig{}).Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/snapshots/latest", nil)
+	req.Header.Set(requestIDHeader, "trace-7f3a")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusBadRequest || body.Error.RequestID != "trace-7f3a" {
+		t.Errorf("got %d with request_id %q, want 400 with trace-7f3a", rec.Code, body.Error.RequestID)
+	}
+	if got := rec.Header().Get(requestIDHeader); got != "trace-7f3a" {
+		t.Errorf("%s header = %q, want trace-7f3a", requestIDHeader, got)
+	}
+	if want := "[trace-7f3a] GET /api/v1/snapshots/latest status=400"; !strings.Contains(logs.String(), want) {
+		t.Errorf("access log %q does not contain %q", logs.String(), want)
+	}
+
+	// A malformed client ID is replaced rather than echoed.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/snapshots/latest", nil)
+	req.Header.Set(requestIDHeader, "bad id")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if id := rec.Header().Get(requestIDHeader); id == "bad id" || id == "" || body.Error.RequestID != id {
+		t.Errorf("malformed ID: header %q, body request_id %q; want a fresh ID in both", id, body.Error.RequestID)
+	}
+}
+
+func TestLatestSnapshotMissingLocation(t *testing.T) {
+	h := newTestAPIServer(t, nil, apiConfig{}).Router()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/snapshots/latest?location=Atlantis", nil))
+
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusNotFound || body.Error.Code != codeNotFound {
+		t.Errorf("got %d %+v, want 404 %s", rec.Code, body.Error, codeNotFound)
+	}
+}
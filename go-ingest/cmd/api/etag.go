@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// snapshotETag identifies the newest snapshot for a location. It is derived
+// from the snapshot key rather than the body, and is weak because the gzip
+// middleware may re-encode the representation.
+func snapshotETag(location string, ts time.Time) string {
+	return fmt.Sprintf(`W/"%s@%d"`, url.PathEscape(location), ts.Unix())
+}
+
+// checkNotModified sets the ETag and Cache-Control headers and, when the
+// request's If-None-Match matches etag, writes 304 and returns true.
+// Cache-Control: no-cache lets intermediaries store the response but makes
+// them revalidate before reusing it.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches implements the weak comparison used for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// getWithETag requests path through the router, sending ifNoneMatch when set.
+func getWithETag(t *testing.T, h http.Handler, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLatestSnapshotETag(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	if err := s.store.InsertSnapshot(models.Snapshot{Location: "Denver", Timestamp: ts}); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+	h := s.Router()
+
+	for _, path := range []string{"/api/v1/snapshots/latest?location=Denver", "/api/v1/dashboard?location=Denver"} {
+		t.Run(path, func(t *testing.T) {
+			first := getWithETag(t, h, path, "")
+			etag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("first request = %d with ETag %q, want 200 with an ETag", first.Code, etag)
+			}
+			if cc := first.Header().Get("Cache-Control"); cc != "no-cache" {
+				t.Errorf("Cache-Control = %q, want no-cache", cc)
+			}
+
+			match := getWithETag(t, h, path, etag)
+			if match.Code != http.StatusNotModified || match.Body.Len() != 0 {
+				t.Errorf("matching If-None-Match = %d with %d body bytes, want 304 and no body", match.Code, match.Body.Len())
+			}
+			if got := match.Header().Get("ETag"); got != etag {
+				t.Errorf("304 ETag = %q, want %q", got, etag)
+			}
+
+			mismatch := getWithETag(t, h, path, `W/"Denver@1"`)
+			if mismatch.Code != http.StatusOK || mismatch.Body.Len() == 0 {
+				t.Errorf("mismatched If-None-Match = %d, want 200 with a body", mismatch.Code)
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := snapshotETag("Los Angeles", time.Unix(1700000000, 0))
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{etag, true},
+		{`"Los%20Angeles@1700000000"`, true}, // strong form of the same tag
+		{`W/"other@1", ` + etag, true},
+		{"*", true},
+		{`W/"Los%20Angeles@1700000001"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.header, etag, got, tt.want)
+		}
+	}
+}
+
+func TestLatestSnapshotETagChangesWithNewSnapshot(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	if err := s.store.InsertSnapshot(models.Snapshot{Location: "Denver", Timestamp: ts}); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+	h := s.Router()
+	const path = "/api/v1/snapshots/latest?location=Denver"
+
+	etag := getWithETag(t, h, path, "").Header().Get("ETag")
+	if rec := getWithETag(t, h, path, etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("unchanged snapshot = %d, want 304", rec.Code)
+	}
+
+	if err := s.store.InsertSnapshot(models.Snapshot{Location: "Denver", Timestamp: ts.Add(time.Minute)}); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+	rec := getWithETag(t, h, path, etag)
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Errorf("changed snapshot = %d, want 200 with a body", rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got == "" || got == etag {
+		t.Errorf("changed snapshot ETag = %q, want a new tag (old %q)", got, etag)
+	}
+}
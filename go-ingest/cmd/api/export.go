@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// exportFlushEvery is how many NDJSON lines are written between flushes.
+const exportFlushEvery = 100
+
+// handleExportSnapshots streams every snapshot for a location within
+// start..end as newline-delimited JSON, one snapshot per line, with no
+// envelope. Rows are encoded as they are scanned and flushed periodically,
+// so large ranges neither buffer in memory nor stall the client.
+func (s *APIServer) handleExportSnapshots(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	location, err := requireLocation(q, s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+	start, end, err := parseTimeRange(q, true, 0, s.cfg.MaxRangeSpan)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	written := 0
+	err = s.store.EachSnapshotInRangeContext(r.Context(), location, start, end, func(snap models.Snapshot) error {
+		if written == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="snapshots.ndjson"`)
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := enc.Encode(snap); err != nil {
+			return err
+		}
+		written++
+		if flusher != nil && written%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	switch {
+	case err != nil && written == 0:
+		respondStoreError(w, r, err, "snapshots")
+	case err != nil:
+		// Headers are gone; the truncated stream is all the client gets
+		if r.Context().Err() == nil {
+			log.Printf("[%s] export for %s aborted after %d snapshots: %v", requestIDFrom(r.Context()), location, written, err)
+		}
+	case written == 0:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+}
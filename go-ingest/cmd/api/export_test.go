@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func TestExportSnapshotsNDJSON(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	const total = exportFlushEvery + 50 // crosses a flush boundary
+	for i := 0; i < total; i++ {
+		snap := models.Snapshot{Location: "Denver", Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		snap.Weather.TemperatureC = float64(i)
+		if err := s.store.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/snapshots/export?location=Denver&start=2026-10-01T00:00:00Z&end=2026-10-02T00:00:00Z", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+
+	lines := 0
+	sc := bufio.NewScanner(rec.Body)
+	for sc.Scan() {
+		var snap models.Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &snap); err != nil {
+			t.Fatalf("line %d is not a snapshot: %v\n%s", lines+1, err, sc.Text())
+		}
+		if snap.Location != "Denver" || snap.Weather.TemperatureC != float64(lines) {
+			t.Errorf("line %d = %s at %.0f°C, want Denver at %d°C (oldest first)", lines+1, snap.Location, snap.Weather.TemperatureC, lines)
+		}
+		lines++
+	}
+	if lines != total {
+		t.Errorf("exported %d lines, want %d", lines, total)
+	}
+}
+
+func TestExportSnapshotsEmptyRange(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/snapshots/export?location=Denver&start=2026-10-01T00:00:00Z&end=2026-10-02T00:00:00Z", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("empty range = %d with %q, want 200 and no lines", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+)
+
+const (
+	defaultForecastHours = 24
+	maxForecastHours     = 168
+	forecastTimeout      = 10 * time.Second
+)
+
+// forecaster fetches hourly forecasts. It is satisfied by
+// *clients.OpenMeteoClient and stubbed in tests.
+type forecaster interface {
+	GetHourlyForecastContext(ctx context.Context, lat, lon float64, hours int) ([]clients.ForecastPoint, error)
+}
+
+// handleForecast returns a live hourly forecast for a named location, which
+// is geocoded, or explicit lat/lon. Forecasts are not persisted; upstream responses are
+// cached briefly by the client's transport.
+func (s *APIServer) handleForecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	hours := defaultForecastHours
+	if v := q.Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxForecastHours {
+			respondParamError(w, r, badParam("hours", "must be between 1 and %d", maxForecastHours))
+			return
+		}
+		hours = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), forecastTimeout)
+	defer cancel()
+
+	loc, err := s.resolveForecastLocation(ctx, q.Get("location"), q.Get("lat"), q.Get("lon"))
+	if err != nil {
+		var pe *paramError
+		if errors.As(err, &pe) {
+			respondParamError(w, r, err)
+		} else {
+			respondInternalError(w, r, http.StatusBadGateway, codeBadGateway, "geocoding provider unavailable", err)
+		}
+		return
+	}
+
+	points, err := s.forecast.GetHourlyForecastContext(ctx, loc.Lat, loc.Lon, hours)
+	if err != nil {
+		log.Printf("forecast for %s: %v", loc.Name, err)
+		status, code := http.StatusBadGateway, codeBadGateway
+		if isTimeout(err) {
+			status, code = http.StatusGatewayTimeout, codeGatewayTimeout
+		}
+		respondError(w, r, status, code, "forecast provider unavailable")
+		return
+	}
+
+	respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"location": loc.Name,
+		"lat":      loc.Lat,
+		"lon":      loc.Lon,
+		"hours":    hours,
+		"count":    len(points),
+		"data":     points,
+	})
+}
+
+// resolveForecastLocation maps the request to coordinates: explicit lat/lon
+// win, otherwise the location name is geocoded. Unknown and ambiguous names
+// are returned as paramErrors; other errors mean the geocoder failed.
+func (s *APIServer) resolveForecastLocation(ctx context.Context, name, latStr, lonStr string) (locations.Location, error) {
+	if latStr != "" || lonStr != "" {
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return locations.Location{}, badParam("lat", "must be a latitude between -90 and 90")
+		}
+		lon, err := strconv.ParseFloat(lonStr, 64)
+		if err != nil || lon < -180 || lon > 180 {
+			return locations.Location{}, badParam("lon", "must be a longitude between -180 and 180")
+		}
+		return locations.Location{Name: name, Lat: lat, Lon: lon}, nil
+	}
+
+	if name == "" {
+		name = s.cfg.DefaultLocation
+	}
+	if name == "" {
+		return locations.Location{}, badParam("location", "missing location; see /api/v1/locations for available locations")
+	}
+	loc, err := s.geocoder.Resolve(ctx, name)
+	var amb *locations.AmbiguousError
+	switch {
+	case err == nil:
+		return loc, nil
+	case errors.As(err, &amb):
+		return locations.Location{}, &paramError{
+			Param:       "location",
+			Message:     fmt.Sprintf("ambiguous location %q; qualify it with a region or country", name),
+			Suggestions: amb.Suggestions(),
+		}
+	case errors.Is(err, locations.ErrUnknownLocation):
+		return locations.Location{}, badParam("location", "unknown location %q; pass lat and lon instead", name)
+	}
+	return locations.Location{}, err
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipMinSize is the smallest body worth compressing; below this the gzip
+// header and CPU cost outweigh the savings.
+const gzipMinSize = 1024
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// gzipMiddleware compresses JSON responses for clients that send
+// Accept-Encoding: gzip. The first gzipMinSize bytes are held back so small
+// bodies can go out uncompressed; after that the body is streamed through a
+// pooled gzip.Writer, so streaming handlers are never fully buffered.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || isWebSocketUpgrade(r) || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.Close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		coding := strings.TrimSpace(fields[0])
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if len(fields) > 1 && strings.ReplaceAll(strings.TrimSpace(fields[1]), " ", "") == "q=0" {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// isWebSocketUpgrade reports whether r is a WebSocket handshake, which needs
+// the raw (hijackable) connection.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// compressibleType reports whether a Content-Type should be gzipped.
+func compressibleType(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "application/json" || ct == "application/x-ndjson" || strings.HasSuffix(ct, "+json")
+}
+
+// gzipResponseWriter defers the compress/passthrough decision until it has
+// seen enough of the body (or the handler flushes or finishes).
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		return w.writeBody(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < gzipMinSize {
+		return len(p), nil
+	}
+	w.decide(true)
+	if err := w.flushBuf(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush forces a decision and pushes any buffered bytes to the client.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+		_ = w.flushBuf()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Close finalizes the response; small bodies that never crossed the
+// threshold are written uncompressed.
+func (w *gzipResponseWriter) Close() {
+	if !w.decided {
+		if w.status == 0 {
+			return // handler wrote nothing; let net/http send its default
+		}
+		w.decide(false)
+		_ = w.flushBuf()
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(io.Discard)
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func (w *gzipResponseWriter) decide(compress bool) {
+	w.decided = true
+	h := w.Header()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if compress && h.Get("Content-Encoding") == "" && compressibleType(h.Get("Content-Type")) && bodyAllowed(w.status) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		gz := gzipWriterPool.Get().(*gzip.Writer)
+		gz.Reset(w.ResponseWriter)
+		w.gz = gz
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *gzipResponseWriter) flushBuf() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.writeBody(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *gzipResponseWriter) writeBody(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// bodyAllowed reports whether a status code may carry a response body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+}
+
+func serveGzip(t *testing.T, h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	gzipMiddleware(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func decodedBody(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		return rec.Body.String()
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	return string(b)
+}
+
+func TestGzipMatchesIdentity(t *testing.T) {
+	body := `{"results":[` + strings.Repeat(`{"location":"la","temp_c":21.5},`, 100) + `{}]}`
+	h := jsonHandler(body)
+
+	plain := serveGzip(t, h, "")
+	zipped := serveGzip(t, h, "gzip")
+
+	if ce := plain.Header().Get("Content-Encoding"); ce != "" {
+		t.Errorf("identity response has Content-Encoding %q", ce)
+	}
+	if ce := zipped.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("gzip response has Content-Encoding %q, want gzip", ce)
+	}
+	if zipped.Body.Len() >= len(body) {
+		t.Errorf("gzip body is %d bytes, not smaller than %d", zipped.Body.Len(), len(body))
+	}
+	if got, want := decodedBody(t, zipped), decodedBody(t, plain); got != want {
+		t.Errorf("decoded bodies differ:\ngzip:     %q\nidentity: %q", got, want)
+	}
+	if vary := zipped.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", vary)
+	}
+}
+
+func TestGzipSkipsSmallResponses(t *testing.T) {
+	body := `{"status":"ok"}`
+	rec := serveGzip(t, jsonHandler(body), "gzip")
+	if ce := rec.Header().Get("Content-Encoding"); ce != "" {
+		t.Errorf("small response has Content-Encoding %q", ce)
+	}
+	if got := rec.Body.String(); got != body {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+}
+
+func TestGzipLargeJSONThroughServer(t *testing.T) {
+	body := `[` + strings.Repeat(`{"location":"Denver","aqi":42,"temp_c":18.25},`, 200) + `{}]`
+	var deadlineErr error
+	srv := httptest.NewServer(loggingMiddleware(gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Long exports extend their deadline; that has to reach the
+		// connection through both wrappers.
+		deadlineErr = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(time.Minute))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))))
+	defer srv.Close()
+	cli := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	for _, accept := range []string{"gzip", ""} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if accept != "" {
+			req.Header.Set("Accept-Encoding", accept)
+		}
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if deadlineErr != nil {
+			t.Errorf("SetWriteDeadline through the middleware: %v", deadlineErr)
+		}
+
+		got := string(raw)
+		if accept == "gzip" {
+			if ce := resp.Header.Get("Content-Encoding"); ce != "gzip" {
+				t.Fatalf("Accept-Encoding: gzip gave Content-Encoding %q", ce)
+			}
+			zr, err := gzip.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("gzip reader: %v", err)
+			}
+			b, _ := io.ReadAll(zr)
+			got = string(b)
+		} else if ce := resp.Header.Get("Content-Encoding"); ce != "" {
+			t.Errorf("no Accept-Encoding gave Content-Encoding %q", ce)
+		}
+		if got != body {
+			t.Errorf("Accept-Encoding %q: body of %d bytes does not match the %d written", accept, len(got), len(body))
+		}
+	}
+}
+
+func TestGzipFlushesStreamsAndSkipsEncodedBodies(t *testing.T) {
+	rec := serveGzip(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"status\":\"started\"}\n\n")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+	}), "gzip")
+	if !rec.Flushed || rec.Header().Get("Content-Encoding") != "" || !strings.HasPrefix(rec.Body.String(), "data: ") {
+		t.Errorf("event stream: flushed %v, Content-Encoding %q, body %q; want a flushed plain event",
+			rec.Flushed, rec.Header().Get("Content-Encoding"), rec.Body.String())
+	}
+
+	encoded := strings.Repeat("x", 2*gzipMinSize)
+	rec = serveGzip(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "br")
+		io.WriteString(w, encoded)
+	}), "gzip")
+	if ce := rec.Header().Get("Content-Encoding"); ce != "br" || rec.Body.String() != encoded {
+		t.Errorf("already-encoded body: Content-Encoding %q, want it passed through untouched", ce)
+	}
+}
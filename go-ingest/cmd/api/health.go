@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// healthProbeTimeout bounds each dependency check so a hung dependency can't
+// hang the health endpoint itself.
+const healthProbeTimeout = 2 * time.Second
+
+// dependencyStatus is the per-dependency entry in the health and readiness
+// responses.
+type dependencyStatus struct {
+	Status    string `json:"status"` // "up", "down" or "disabled"
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// dataFreshness reports the age of the newest snapshot in the database.
+type dataFreshness struct {
+	Status            string     `json:"status"` // "fresh", "stale" or "empty"
+	NewestSnapshot    *time.Time `json:"newest_snapshot,omitempty"`
+	AgeSeconds        int64      `json:"age_seconds,omitempty"`
+	StaleAfterSeconds int64      `json:"stale_after_seconds,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// pinger is implemented by dependencies with a cheap reachability check.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// handleHealth returns API health status. By default it checks the database
+// and the embedding sidecar and returns 503 when a critical dependency is
+// down; ?shallow=1 skips the checks for a cheap liveness probe.
+func (s *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"version":   "1.0.0",
+	}
+
+	if shallow := r.URL.Query().Get("shallow"); shallow == "1" || shallow == "true" {
+		respondJSON(w, r, http.StatusOK, response)
+		return
+	}
+
+	deps := s.probeCore(r.Context())
+	response["dependencies"] = deps
+	status := http.StatusOK
+	switch critical, optional := outages(deps); {
+	case critical:
+		response["status"] = "unhealthy"
+		status = http.StatusServiceUnavailable
+	case optional:
+		response["status"] = "degraded"
+	}
+
+	respondJSON(w, r, status, response)
+}
+
+// handleReady is the readiness check. Beyond what /health checks it pings
+// the LLM (when configured) and reports the age of the newest snapshot. It
+// returns 503 when a critical dependency (the database) is down; a down
+// optional dependency or stale data reports "degraded" with 200.
+func (s *APIServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	deps := s.probeCore(r.Context())
+	if p, ok := s.llm.(pinger); ok {
+		deps["llm"] = probe(r.Context(), false, p.Ping)
+	} else if s.llm == nil {
+		deps["llm"] = dependencyStatus{Status: "disabled"}
+	}
+
+	response := map[string]interface{}{
+		"status":       "ready",
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+		"dependencies": deps,
+	}
+
+	status := http.StatusOK
+	switch critical, optional := outages(deps); {
+	case critical:
+		response["status"] = "unavailable"
+		status = http.StatusServiceUnavailable
+	case optional:
+		response["status"] = "degraded"
+	}
+
+	if deps["database"].Status == "up" {
+		fresh := s.checkFreshness(r.Context())
+		response["data"] = fresh
+		if fresh.Status == "stale" && status == http.StatusOK {
+			response["status"] = "degraded"
+		}
+	}
+
+	respondJSON(w, r, status, response)
+}
+
+// probeCore checks the dependencies both health endpoints report: the
+// database (critical) and the embedding sidecar.
+func (s *APIServer) probeCore(ctx context.Context) map[string]dependencyStatus {
+	deps := map[string]dependencyStatus{
+		"database": probe(ctx, true, s.store.Ping),
+	}
+	if s.embedClient != nil {
+		deps["embeddings"] = probe(ctx, false, s.embedClient.Ping)
+	} else {
+		deps["embeddings"] = dependencyStatus{Status: "disabled"}
+	}
+	return deps
+}
+
+// outages reports whether any critical, and any optional, dependency is down.
+func outages(deps map[string]dependencyStatus) (critical, optional bool) {
+	for _, d := range deps {
+		if d.Status != "down" {
+			continue
+		}
+		if d.Critical {
+			critical = true
+		} else {
+			optional = true
+		}
+	}
+	return critical, optional
+}
+
+// checkFreshness compares the newest snapshot's age with cfg.StaleAfter. A
+// zero StaleAfter disables the staleness check.
+func (s *APIServer) checkFreshness(ctx context.Context) dataFreshness {
+	newest, err := s.store.GetLatestSnapshotTimeContext(ctx, "")
+	if err != nil {
+		log.Printf("readiness: newest snapshot lookup failed: %v", err)
+		return dataFreshness{Status: "unknown", Error: "snapshot lookup failed"}
+	}
+	if newest.IsZero() {
+		return dataFreshness{Status: "empty"}
+	}
+
+	age := time.Since(newest)
+	f := dataFreshness{
+		Status:         "fresh",
+		NewestSnapshot: &newest,
+		AgeSeconds:     int64(age.Seconds()),
+	}
+	if s.cfg.StaleAfter > 0 {
+		f.StaleAfterSeconds = int64(s.cfg.StaleAfter.Seconds())
+		if age > s.cfg.StaleAfter {
+			f.Status = "stale"
+		}
+	}
+	return f
+}
+
+// probe runs check with a timeout and records its outcome. Failure details
+// are logged rather than returned, since they may carry driver or network
+// internals.
+func probe(ctx context.Context, critical bool, check func(context.Context) error) dependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	d := dependencyStatus{
+		Status:    "up",
+		Critical:  critical,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		log.Printf("readiness probe failed: %v", err)
+		d.Status = "down"
+		d.Error = "unreachable"
+		if errors.Is(err, context.DeadlineExceeded) {
+			d.Error = "timeout"
+		}
+	}
+	return d
+}
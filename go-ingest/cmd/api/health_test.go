@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/llm"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// newTestAPIServer builds an APIServer over a fresh database. embedCli may
+// be nil.
+func newTestAPIServer(t *testing.T, embedCli *embeddings.Client, cfg apiConfig) *APIServer {
+	t.Helper()
+	db, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "edgesight.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewAPIServer(db, embedCli, nil, cfg)
+}
+
+// sidecar returns an embedding sidecar whose /health answers with status.
+func sidecar(t *testing.T, status int) *embeddings.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return embeddings.NewClient(srv.URL, embeddings.WithRetries(0, 0))
+}
+
+type readyResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+	Data         dataFreshness               `json:"data"`
+}
+
+func getReady(t *testing.T, s *APIServer) (int, readyResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.handleReady(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	var body readyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+func TestReadyHealthy(t *testing.T) {
+	s := newTestAPIServer(t, sidecar(t, http.StatusOK), apiConfig{})
+
+	code, body := getReady(t, s)
+	if code != http.StatusOK || body.Status != "ready" {
+		t.Errorf("got %d %q, want 200 ready", code, body.Status)
+	}
+	for _, name := range []string{"database", "embeddings"} {
+		if got := body.Dependencies[name].Status; got != "up" {
+			t.Errorf("%s status = %q, want up", name, got)
+		}
+	}
+	if got := body.Dependencies["llm"].Status; got != "disabled" {
+		t.Errorf("llm status = %q, want disabled", got)
+	}
+}
+
+func TestReadyDegradedWhenSidecarDown(t *testing.T) {
+	s := newTestAPIServer(t, sidecar(t, http.StatusServiceUnavailable), apiConfig{})
+
+	code, body := getReady(t, s)
+	if code != http.StatusOK || body.Status != "degraded" {
+		t.Errorf("got %d %q, want 200 degraded", code, body.Status)
+	}
+	if d := body.Dependencies["embeddings"]; d.Status != "down" || d.Critical {
+		t.Errorf("embeddings = %+v, want down and not critical", d)
+	}
+}
+
+func TestReadyUnavailableWhenDatabaseDown(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	s.store.Close()
+
+	code, body := getReady(t, s)
+	if code != http.StatusServiceUnavailable || body.Status != "unavailable" {
+		t.Errorf("got %d %q, want 503 unavailable", code, body.Status)
+	}
+	if d := body.Dependencies["database"]; d.Status != "down" || !d.Critical {
+		t.Errorf("database = %+v, want down and critical", d)
+	}
+}
+
+func TestHealthChecksDependencies(t *testing.T) {
+	getHealth := func(s *APIServer, query string) (int, readyResponse) {
+		rec := httptest.NewRecorder()
+		s.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/health"+query, nil))
+		var body readyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, body
+	}
+
+	s := newTestAPIServer(t, sidecar(t, http.StatusOK), apiConfig{})
+	if code, body := getHealth(s, ""); code != http.StatusOK || body.Status != "healthy" || body.Dependencies["database"].Status != "up" {
+		t.Errorf("all up: got %d %q %+v, want 200 healthy", code, body.Status, body.Dependencies)
+	}
+
+	s = newTestAPIServer(t, sidecar(t, http.StatusServiceUnavailable), apiConfig{})
+	if code, body := getHealth(s, ""); code != http.StatusOK || body.Status != "degraded" {
+		t.Errorf("sidecar down: got %d %q, want 200 degraded", code, body.Status)
+	}
+
+	s = newTestAPIServer(t, nil, apiConfig{})
+	s.store.Close()
+	code, body := getHealth(s, "")
+	if code != http.StatusServiceUnavailable || body.Status != "unhealthy" || body.Dependencies["database"].Status != "down" {
+		t.Errorf("database down: got %d %q %+v, want 503 unhealthy", code, body.Status, body.Dependencies)
+	}
+	// The shallow liveness probe never touches the database
+	code, body = getHealth(s, "?shallow=1")
+	if code != http.StatusOK || body.Status != "healthy" || body.Dependencies != nil {
+		t.Errorf("shallow: got %d %q %+v, want 200 healthy without dependencies", code, body.Status, body.Dependencies)
+	}
+}
+
+func TestReadyChecksLLM(t *testing.T) {
+	var modelsStatus atomic.Int32
+	modelsStatus.Store(http.StatusOK)
+	llmSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(int(modelsStatus.Load()))
+	}))
+	defer llmSrv.Close()
+
+	s := newTestAPIServer(t, sidecar(t, http.StatusOK), apiConfig{})
+	s.llm = llm.NewClient(llmSrv.URL+"/v1/chat/completions", "test")
+
+	if code, body := getReady(t, s); code != http.StatusOK || body.Dependencies["llm"].Status != "up" {
+		t.Errorf("LLM up: got %d, llm %+v", code, body.Dependencies["llm"])
+	}
+
+	modelsStatus.Store(http.StatusBadGateway)
+	code, body := getReady(t, s)
+	if code != http.StatusOK || body.Status != "degraded" || body.Dependencies["llm"].Status != "down" {
+		t.Errorf("LLM down: got %d %q, llm %+v; want 200 degraded with llm down", code, body.Status, body.Dependencies["llm"])
+	}
+}
+
+func TestReadyDegradedWhenDataStale(t *testing.T) {
+	s := newTestAPIServer(t, sidecar(t, http.StatusOK), apiConfig{StaleAfter: time.Hour})
+	old := models.Snapshot{Location: "Denver", Timestamp: time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Second)}
+	if err := s.store.InsertSnapshot(old); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+
+	code, body := getReady(t, s)
+	if code != http.StatusOK || body.Status != "degraded" {
+		t.Errorf("got %d %q, want 200 degraded", code, body.Status)
+	}
+	if body.Data.Status != "stale" || body.Data.StaleAfterSeconds != 3600 {
+		t.Errorf("data = %+v, want stale with a 3600s threshold", body.Data)
+	}
+}
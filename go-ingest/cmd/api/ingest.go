@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pipeline"
+)
+
+// ingester runs one ingest pass. It is satisfied by *pipeline.Pipeline and
+// stubbed in tests.
+type ingester interface {
+	RunOnce(ctx context.Context, locationName string) (*pipeline.Result, error)
+}
+
+// ingestRequest is the optional body of POST /api/v1/ingest.
+type ingestRequest struct {
+	Location string `json:"location"` // place name; default EDGESIGHT_DEFAULT_LOCATION
+}
+
+// handleIngest runs one ingest pass synchronously and returns the snapshot
+// it built along with the per-source report. A stored snapshot also reaches
+// WebSocket clients through the store's insert hook. Only one pass runs at a
+// time; a trigger that overlaps a running pass gets 409.
+func (s *APIServer) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	if s.ingest == nil {
+		respondError(w, r, http.StatusServiceUnavailable, codeUnavailable, "ingest pipeline not configured")
+		return
+	}
+
+	var req ingestRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, r, http.StatusBadRequest, codeBadRequest, "invalid JSON body")
+		return
+	}
+	name := strings.TrimSpace(req.Location)
+	if name == "" {
+		name = s.cfg.DefaultLocation
+	}
+	if name == "" {
+		name = pipeline.LocationFromEnv()
+	}
+
+	res, err := s.ingest.RunOnce(r.Context(), name)
+	var amb *locations.AmbiguousError
+	switch {
+	case err == nil:
+		respondJSON(w, r, http.StatusOK, res)
+	case errors.Is(err, pipeline.ErrBusy):
+		respondError(w, r, http.StatusConflict, codeConflict, err.Error())
+	case errors.As(err, &amb):
+		respondParamError(w, r, &paramError{
+			Param:       "location",
+			Message:     fmt.Sprintf("ambiguous location %q; qualify it with a region or country", name),
+			Suggestions: amb.Suggestions(),
+		})
+	case errors.Is(err, locations.ErrUnknownLocation):
+		respondParamError(w, r, badParam("location", "unknown location %q", name))
+	default:
+		respondInternalError(w, r, http.StatusInternalServerError, codeInternal, "ingest failed", err)
+	}
+}
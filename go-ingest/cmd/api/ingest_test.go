@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pipeline"
+)
+
+// stubIngester returns res and err from RunOnce and records the location
+// it was asked for.
+type stubIngester struct {
+	res      *pipeline.Result
+	err      error
+	location string
+	calls    int
+}
+
+func (s *stubIngester) RunOnce(ctx context.Context, locationName string) (*pipeline.Result, error) {
+	s.calls++
+	s.location = locationName
+	return s.res, s.err
+}
+
+// postIngest sends POST /api/v1/ingest with body and, when token is set,
+// a bearer token.
+func postIngest(t *testing.T, h http.Handler, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/ingest", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIngestReturnsSnapshot(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{AdminToken: "secret", DefaultLocation: "Denver"})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	stub := &stubIngester{res: &pipeline.Result{
+		Snapshot: models.Snapshot{Location: "Boston", Timestamp: ts, Weather: models.Weather{TemperatureC: 12.5}},
+		Summary:  "Boston: 12.5°C",
+		Sources:  []pipeline.SourceResult{{Source: "meteo", Status: "ok"}, {Source: "openaq", Status: "skipped", Detail: "OPENAQ_API_KEY not set"}},
+		Stored:   true,
+	}}
+	s.ingest = stub
+	h := s.Router()
+
+	rec := postIngest(t, h, "secret", `{"location": " Boston "}`)
+	var got pipeline.Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusOK || stub.location != "Boston" {
+		t.Fatalf("got %d after ingesting %q, want 200 for Boston", rec.Code, stub.location)
+	}
+	if got.Snapshot.Location != "Boston" || got.Snapshot.Weather.TemperatureC != 12.5 || !got.Snapshot.Timestamp.Equal(ts) {
+		t.Errorf("snapshot = %+v, want the pass's Boston snapshot", got.Snapshot)
+	}
+	if !got.Stored || len(got.Sources) != 2 || got.Sources[1].Status != "skipped" {
+		t.Errorf("stored %v sources %+v, want stored with both source results", got.Stored, got.Sources)
+	}
+
+	// An empty body ingests the default location
+	if rec := postIngest(t, h, "secret", ""); rec.Code != http.StatusOK || stub.location != "Denver" {
+		t.Errorf("empty body = %d for %q, want 200 for Denver", rec.Code, stub.location)
+	}
+}
+
+func TestIngestErrors(t *testing.T) {
+	ambiguous := &locations.AmbiguousError{Name: "Portland", Candidates: []locations.Location{{Name: "Portland, Oregon"}, {Name: "Portland, Maine"}}}
+	tests := []struct {
+		name     string
+		cfg      apiConfig
+		token    string
+		body     string
+		err      error
+		wantCode int
+		wantErr  string
+		wantRun  bool
+	}{
+		{"admin disabled", apiConfig{}, "secret", "", nil, http.StatusForbidden, codeForbidden, false},
+		{"missing token", apiConfig{AdminToken: "secret"}, "", "", nil, http.StatusUnauthorized, codeUnauthorized, false},
+		{"wrong token", apiConfig{AdminToken: "secret"}, "guess", "", nil, http.StatusUnauthorized, codeUnauthorized, false},
+		{"bad JSON", apiConfig{AdminToken: "secret"}, "secret", "{", nil, http.StatusBadRequest, codeBadRequest, false},
+		{"pass running", apiConfig{AdminToken: "secret"}, "secret", "", pipeline.ErrBusy, http.StatusConflict, codeConflict, true},
+		{"unknown location", apiConfig{AdminToken: "secret"}, "secret", `{"location":"Atlantis"}`,
+			fmt.Errorf("resolve location %q: %w", "Atlantis", locations.ErrUnknownLocation), http.StatusBadRequest, codeInvalidParameter, true},
+		{"ambiguous location", apiConfig{AdminToken: "secret"}, "secret", `{"location":"Portland"}`,
+			fmt.Errorf("resolve location %q: %w", "Portland", ambiguous), http.StatusBadRequest, codeInvalidParameter, true},
+		{"store failure", apiConfig{AdminToken: "secret"}, "secret", "",
+			errors.New("insert snapshot: database is locked (/var/lib/edgesight/edgesight.db)"), http.StatusInternalServerError, codeInternal, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAPIServer(t, nil, tt.cfg)
+			stub := &stubIngester{err: tt.err}
+			s.ingest = stub
+
+			rec := postIngest(t, s.Router(), tt.token, tt.body)
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if rec.Code != tt.wantCode || body.Error.Code != tt.wantErr {
+				t.Errorf("got %d %+v, want %d %s", rec.Code, body.Error, tt.wantCode, tt.wantErr)
+			}
+			if ran := stub.calls > 0; ran != tt.wantRun {
+				t.Errorf("pass ran = %v, want %v", ran, tt.wantRun)
+			}
+			if tt.wantErr == codeInvalidParameter && body.Error.Param != "location" {
+				t.Errorf("param = %q, want location", body.Error.Param)
+			}
+			// Internal error text is logged, not returned
+			if tt.wantErr == codeInternal && body.Error.Message != "ingest failed" {
+				t.Errorf("message = %q, want the generic \"ingest failed\"", body.Error.Message)
+			}
+			if tt.name == "ambiguous location" && len(body.Error.Suggestions) != 2 {
+				t.Errorf("suggestions = %v, want both Portlands", body.Error.Suggestions)
+			}
+		})
+	}
+}
+
+func TestIngestUnavailableWithoutPipeline(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{AdminToken: "secret"})
+
+	rec := postIngest(t, s.Router(), "secret", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d without a pipeline, want 503", rec.Code)
+	}
+}
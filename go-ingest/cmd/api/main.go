@@ -1,19 +1,30 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
 	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/httpcache"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/llm"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pipeline"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pubsub"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/semantic"
 	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+	"github.com/gorilla/websocket"
 )
 
 func main() {
@@ -29,6 +40,11 @@ func main() {
 	}
 	defer db.Close()
 
+	// Reindex jobs cut short by a restart can be resumed
+	if err := db.InterruptRunningReindexJobs(); err != nil {
+		log.Printf("Failed to mark interrupted reindex jobs: %v", err)
+	}
+
 	// Embedding sidecar client (optional)
 	embedEndpoint := os.Getenv("EMBEDDING_ENDPOINT")
 	if embedEndpoint == "" {
@@ -39,157 +55,284 @@ func main() {
 		embedCli = embeddings.NewClient(embedEndpoint)
 	}
 
+	// LLM for /api/v1/query answers: an OpenAI-compatible LLM_ENDPOINT if
+	// set, otherwise the sidecar's /query route
+	var llmCli answerer
+	if llmEndpoint := os.Getenv("LLM_ENDPOINT"); llmEndpoint != "" {
+		llmCli = llm.NewClient(llmEndpoint, os.Getenv("LLM_MODEL"))
+	} else if queryEndpoint := queryEndpoint(embedEndpoint); queryEndpoint != "" {
+		llmCli = llm.NewSidecarClient(queryEndpoint)
+	}
+
 	port := os.Getenv("API_PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("EdgeSight API Server starting on port %s", port)
-	apiServer := NewAPIServer(db, embedCli)
-	log.Fatal(http.ListenAndServe(":"+port, apiServer.Router()))
+	// How often to look for snapshots written by the ingest process
+	wsPoll := envDuration("EDGESIGHT_WS_POLL", 5*time.Second)
+
+	cfg := loadConfig()
+	if cfg.Summarizer, err = semantic.SummarizerFromEnv(); err != nil {
+		log.Fatalf("Invalid %s: %v", semantic.SectionsEnv, err)
+	}
+	// Stop background work and drain connections on Ctrl+C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	apiServer := NewAPIServer(db, embedCli, llmCli, cfg)
+	defer apiServer.Close()
+	db.SetInsertHook(apiServer.hub.Publish)
+	// On-demand ingest passes share the database and embedding sidecar. The
+	// endpoint needs the admin token, so without one the pipeline (and its
+	// MQTT subscriber) is never started.
+	if cfg.AdminToken != "" {
+		if ingest, err := pipeline.New(pipeline.Options{Store: db, Embed: embedCli, Summarizer: cfg.Summarizer}); err != nil {
+			log.Printf("Ingest endpoint disabled: %v", err)
+		} else {
+			ingest.Start(ctx)
+			defer ingest.Close()
+			apiServer.ingest = ingest
+		}
+	}
+	go apiServer.watchSnapshots(ctx, wsPoll)
+
+	srv := &http.Server{Addr: ":" + port, Handler: apiServer.Router()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}()
+
+	// Plain HTTP unless a certificate is configured
+	if cfg.TLSCert == "" && cfg.TLSKey == "" {
+		if cfg.TLSClientCA != "" {
+			log.Fatalf("EDGESIGHT_TLS_CLIENT_CA requires EDGESIGHT_TLS_CERT and EDGESIGHT_TLS_KEY")
+		}
+		log.Printf("EdgeSight API Server starting on port %s", port)
+		serveUntilShutdown(srv.ListenAndServe())
+		return
+	}
+
+	tlsCfg, err := loadTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSClientCA)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	srv.TLSConfig = tlsCfg
+	if cfg.TLSClientCA != "" {
+		log.Printf("EdgeSight API Server starting on port %s (TLS, client certificates required)", port)
+	} else {
+		log.Printf("EdgeSight API Server starting on port %s (TLS)", port)
+	}
+	serveUntilShutdown(srv.ListenAndServeTLS("", ""))
+}
+
+// serveUntilShutdown handles the error returned by ListenAndServe: a server
+// closed by Shutdown is a clean exit, anything else is fatal.
+func serveUntilShutdown(err error) {
+	if !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	log.Printf("EdgeSight API Server stopped")
 }
 
 // APIServer holds the database connection and HTTP handlers
 type APIServer struct {
 	store       *store.SQLiteStore
 	embedClient *embeddings.Client
+	llm         answerer
+	forecast    forecaster
+	geocoder    *locations.Geocoder
+	reindex     *reindexer
+	ingest      ingester // nil without an admin token or when the ingest clients could not be set up
+	hub         *pubsub.Hub
+	ws          *websocket.Upgrader
+	cfg         apiConfig
+	metrics     *metricsRegistry
+	answers     *answerCache
+	cacheHits   *counter
+	cacheMisses *counter
 }
 
-// NewAPIServer creates a new API server instance
-func NewAPIServer(db *store.SQLiteStore, embedCli *embeddings.Client) *APIServer {
-	return &APIServer{store: db, embedClient: embedCli}
+// NewAPIServer creates a new API server instance. llmCli may be nil, in
+// which case /api/v1/query returns search results without an LLM answer.
+func NewAPIServer(db *store.SQLiteStore, embedCli *embeddings.Client, llmCli answerer, cfg apiConfig) *APIServer {
+	metrics := newMetricsRegistry()
+	// Forecasts are fetched live; a short in-memory cache absorbs pollers
+	forecastCache := httpcache.NewTransport(httpcache.NewMemoryStore(), cfg.ForecastCacheTTL)
+	forecastCache.Next = clients.SharedTransport()
+	var reindex *reindexer
+	if embedCli != nil {
+		reindex = newReindexer(db, embedCli, cfg.ReindexBatchSize, cfg.ReindexInterval, cfg.Summarizer)
+	}
+	return &APIServer{
+		store:       db,
+		embedClient: embedCli,
+		llm:         llmCli,
+		forecast:    clients.NewOpenMeteoClient(clients.WithTransport(forecastCache)),
+		geocoder:    locations.NewGeocoder(clients.NewGeocodingClient()),
+		reindex:     reindex,
+		hub:         pubsub.NewHub(),
+		ws:          newWSUpgrader(newOriginSet(cfg.CORSOrigins)),
+		cfg:         cfg,
+		metrics:     metrics,
+		answers:     newAnswerCache(cfg.QueryCacheSize, cfg.QueryCacheTTL),
+		cacheHits:   metrics.Counter("edgesight_query_cache_hits_total", "Query answers served from cache."),
+		cacheMisses: metrics.Counter("edgesight_query_cache_misses_total", "Query answers not found in cache."),
+	}
+}
+
+// Close stops running reindex jobs, recording them as interrupted so they
+// can be resumed. Call it before closing the database.
+func (s *APIServer) Close() {
+	if s.reindex != nil {
+		s.reindex.close()
+	}
 }
 
 // Router configures all HTTP routes
 func (s *APIServer) Router() http.Handler {
-	mux := http.NewServeMux()
+	mux := &routeMux{ServeMux: http.NewServeMux()}
 
-	// Health check
-	mux.HandleFunc("/health", s.handleHealth)
+	// Liveness/readiness checks and Prometheus metrics
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /health/ready", s.handleReady)
+	mux.Handle("GET /metrics", s.metrics)
 
 	// Snapshot endpoints
-	mux.HandleFunc("/api/v1/snapshots/latest", s.handleGetLatestSnapshot)
-	mux.HandleFunc("/api/v1/snapshots/range", s.handleGetSnapshotsByRange)
-	mux.HandleFunc("/api/v1/snapshots", s.handleGetSnapshots)
+	mux.HandleFunc("GET /api/v1/snapshots/latest", s.handleGetLatestSnapshot)
+	mux.HandleFunc("GET /api/v1/snapshots/range", s.handleGetSnapshotsByRange)
+	mux.HandleFunc("GET /api/v1/snapshots/diff", s.handleSnapshotDiff)
+	mux.HandleFunc("GET /api/v1/snapshots/export", s.handleExportSnapshots)
+	mux.HandleFunc("GET /api/v1/snapshots", s.handleGetSnapshots)
+
+	// Locations with stored data
+	mux.HandleFunc("GET /api/v1/locations", s.handleGetLocations)
 
 	// Metrics endpoints
-	mux.HandleFunc("/api/v1/metrics/series", s.handleGetMetricSeries)
+	mux.HandleFunc("GET /api/v1/metrics/series", s.handleGetMetricSeries)
+	mux.HandleFunc("GET /api/v1/metrics/compare", s.handleCompareMetric)
+	mux.HandleFunc("GET /api/v1/metrics/correlate", s.handleCorrelateMetrics)
+	mux.HandleFunc("GET /api/v1/metrics/top", s.handleTopLocations)
+
+	// FEMA disaster detail
+	mux.HandleFunc("GET /api/v1/disasters", s.handleGetDisasters)
+
+	// Live hourly forecast
+	mux.HandleFunc("GET /api/v1/forecast", s.handleForecast)
+
+	// Dashboard home screen in one call
+	mux.HandleFunc("GET /api/v1/dashboard", s.handleDashboard)
 
 	// Embedding search / query
-	mux.HandleFunc("/api/v1/search", s.handleSearch)
-	mux.HandleFunc("/api/v1/query", s.handleQuery)
+	mux.HandleFunc("GET /api/v1/search", s.handleSearch)
+	mux.HandleFunc("GET /api/v1/query", s.handleQuery)
+	mux.HandleFunc("POST /api/v1/query", s.handleQuery)
 
-	// CORS and logging middleware
-	return enableCORS(loggingMiddleware(mux))
-}
+	// Live snapshot push
+	mux.HandleFunc("GET /api/v1/ws", s.handleWebSocket)
 
-// handleHealth returns API health status
-func (s *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	// Admin: embedding re-index jobs
+	mux.HandleFunc("POST /api/v1/admin/reindex", s.handleReindex)
+	mux.HandleFunc("GET /api/v1/admin/jobs/{id}", s.handleGetJob)
 
-	response := map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-		"version":   "1.0.0",
+	// Admin: run one ingest pass now
+	mux.HandleFunc("POST /api/v1/ingest", s.handleIngest)
+
+	// API description, generated from the routes above
+	var spec []byte
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	})
+	spec, err := buildOpenAPI(mux.patterns)
+	if err != nil {
+		log.Fatalf("Failed to build OpenAPI document: %v", err)
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	return enableCORS(s.cfg.CORSOrigins, requestIDMiddleware(clientIdentityMiddleware(loggingMiddleware(gzipMiddleware(jsonNotFound(mux.ServeMux))))))
 }
 
-// handleSearch returns top similar snapshot summaries for a query.
+// handleSearch returns top similar snapshot summaries for a query. Without a
+// location, or with location=all, it searches all locations, optionally
+// capped by per_location; a comma-separated list searches those locations.
+// Results from several locations are ranked together by score, computed
+// with the metric parameter (cosine by default); those below min_score are
+// dropped.
 func (s *APIServer) handleSearch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	q := r.URL.Query().Get("q")
-	location := r.URL.Query().Get("location")
-	if location == "" {
-		location = "Los Angeles"
+	locations := parseSearchLocations(r.URL.Query().Get("location"))
+	perLocation := 0
+	if v := r.URL.Query().Get("per_location"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondParamError(w, r, badParam("per_location", "must be a non-negative integer"))
+			return
+		}
+		perLocation = n
 	}
 	if q == "" {
-		http.Error(w, "missing q", http.StatusBadRequest)
+		respondParamError(w, r, badParam("q", "missing q"))
 		return
 	}
-	if s.embedClient == nil {
-		http.Error(w, "embedding service not configured", http.StatusServiceUnavailable)
-		return
-	}
-	vec, err := s.embedClient.Embed(q)
+	topK, err := parseTopK(r.URL.Query(), defaultTopK)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("embed error: %v", err), http.StatusBadGateway)
+		respondParamError(w, r, err)
 		return
 	}
-	results, err := s.store.SearchEmbeddings(location, vec, 5)
+	metric, err := store.ParseMetric(r.URL.Query().Get("metric"))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("search error: %v", err), http.StatusInternalServerError)
+		respondParamError(w, r, badParam("metric", "must be cosine, dot or euclidean"))
 		return
 	}
-
-	type res struct {
-		Summary    string  `json:"summary"`
-		SnapshotTS string  `json:"snapshot_ts"`
-		Location   string  `json:"location"`
-		Score      float64 `json:"score"`
-	}
-	out := make([]res, 0, len(results))
-	for _, r := range results {
-		out = append(out, res{
-			Summary:    r.Summary,
-			SnapshotTS: r.SnapshotTS,
-			Location:   r.Location,
-			Score:      r.Score,
-		})
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"results": out,
-	})
-}
-
-// handleQuery performs search then (placeholder) LLM answer.
-func (s *APIServer) handleQuery(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	minScore, err := parseMinScore(r.URL.Query())
+	if err != nil {
+		respondParamError(w, r, err)
 		return
 	}
-	q := r.URL.Query().Get("q")
-	location := r.URL.Query().Get("location")
-	if location == "" {
-		location = "Los Angeles"
-	}
-	if q == "" {
-		http.Error(w, "missing q", http.StatusBadRequest)
+	if metric == store.MetricEuclidean && r.URL.Query().Has("min_score") {
+		respondParamError(w, r, badParam("min_score", "not supported with metric=euclidean, whose score is a distance"))
 		return
 	}
 	if s.embedClient == nil {
-		http.Error(w, "embedding service not configured", http.StatusServiceUnavailable)
+		respondError(w, r, http.StatusServiceUnavailable, codeUnavailable, "embedding service not configured")
 		return
 	}
-	vec, err := s.embedClient.Embed(q)
+	vec, err := s.embedClient.EmbedContext(r.Context(), q)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("embed error: %v", err), http.StatusBadGateway)
+		respondEmbedError(w, r, err)
 		return
 	}
-	results, err := s.store.SearchEmbeddings(location, vec, 5)
+	// No location means search every location
+	var results []store.SearchResult
+	switch len(locations) {
+	case 0:
+		results, err = s.store.SearchEmbeddingsAllLocationsContext(r.Context(), vec, topK, perLocation, time.Time{}, time.Time{}, metric)
+	case 1:
+		results, err = s.store.SearchEmbeddingsContext(r.Context(), locations[0], vec, topK, metric)
+	default:
+		results, err = s.store.SearchEmbeddingsMultiContext(r.Context(), locations, vec, topK, metric)
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("search error: %v", err), http.StatusInternalServerError)
+		respondStoreError(w, r, err, "search results")
 		return
 	}
+	if metric != store.MetricEuclidean {
+		results = dropBelowScore(results, minScore)
+	}
 
-	type src struct {
+	type res struct {
 		Summary    string  `json:"summary"`
 		SnapshotTS string  `json:"snapshot_ts"`
 		Location   string  `json:"location"`
 		Score      float64 `json:"score"`
 	}
-	sources := make([]src, 0, len(results))
+	out := make([]res, 0, len(results))
 	for _, r := range results {
-		sources = append(sources, src{
+		out = append(out, res{
 			Summary:    r.Summary,
 			SnapshotTS: r.SnapshotTS,
 			Location:   r.Location,
@@ -197,118 +340,94 @@ func (s *APIServer) handleQuery(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	answer := "LLM not configured; showing similar snapshots."
-	if s.embedClient != nil {
-		ctx, cancel := context.WithTimeout(r.Context(), 45*time.Second)
-		defer cancel()
+	respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"metric":  metric,
+		"results": out,
+	})
+}
 
-		var sb strings.Builder
-		sb.WriteString("Question: ")
-		sb.WriteString(q)
-		sb.WriteString("\nLocation: ")
-		sb.WriteString(location)
-		sb.WriteString("\nTop snapshots:\n")
-		for i, src := range sources {
-			sb.WriteString(fmt.Sprintf("%d) [%s] %s (score %.3f)\n", i+1, src.SnapshotTS, src.Summary, src.Score))
+// dropBelowScore removes the results scoring below minScore, keeping the
+// rest in rank order.
+func dropBelowScore(results []store.SearchResult, minScore float64) []store.SearchResult {
+	kept := results[:0]
+	for _, r := range results {
+		if r.Score >= minScore {
+			kept = append(kept, r)
 		}
-		sb.WriteString("Provide a concise answer (<=3 sentences). If the context is insufficient, say so briefly.")
-
-		systemPrompt := "You are EdgeSight's analyst. You summarize local conditions using provided snapshots only. Be concise, avoid speculation, and mention timestamps/metrics when relevant. If data is insufficient, say so."
+	}
+	return kept
+}
 
-		// Call Python sidecar /query endpoint
-		queryPayload := map[string]interface{}{
-			"system":     systemPrompt,
-			"user":       sb.String(),
-			"max_tokens": 256,
-		}
-		body, _ := json.Marshal(queryPayload)
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost:9000/query", bytes.NewReader(body))
-		if err == nil {
-			req.Header.Set("Content-Type", "application/json")
-			client := &http.Client{Timeout: 45 * time.Second}
-			if resp, err := client.Do(req); err == nil && resp.StatusCode == http.StatusOK {
-				var result struct {
-					Answer string `json:"answer"`
-				}
-				json.NewDecoder(resp.Body).Decode(&result)
-				resp.Body.Close()
-				answer = strings.TrimSpace(result.Answer)
-			} else {
-				if err != nil {
-					answer = fmt.Sprintf("LLM error: %v", err)
-				}
-			}
+// parseSearchLocations splits a search location parameter into distinct
+// location names. An empty value or "all" returns nil, meaning every
+// location.
+func parseSearchLocations(v string) []string {
+	if strings.EqualFold(strings.TrimSpace(v), "all") {
+		return nil
+	}
+	var locations []string
+	for _, part := range strings.Split(v, ",") {
+		if loc := strings.TrimSpace(part); loc != "" && !slices.Contains(locations, loc) {
+			locations = append(locations, loc)
 		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"answer":  answer,
-		"sources": sources,
-	})
+	return locations
 }
 
-// handleGetLatestSnapshot returns the most recent snapshot for a location
+// handleGetLatestSnapshot returns the most recent snapshot for a location.
+// It carries an ETag so pollers get 304 until a newer snapshot lands.
 func (s *APIServer) handleGetLatestSnapshot(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	location, err := requireLocation(r.URL.Query(), s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
 		return
 	}
 
-	location := r.URL.Query().Get("location")
-	if location == "" {
-		location = "Los Angeles" // Default location
-	}
-
-	snapshot, err := s.store.GetLatestSnapshot(location)
+	snapshot, err := s.store.GetLatestSnapshotContext(r.Context(), location)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch snapshot: "+err.Error())
+		respondStoreError(w, r, err, "snapshot")
 		return
 	}
 
-	if snapshot == nil {
-		respondError(w, http.StatusNotFound, "No snapshot found for location: "+location)
+	if checkNotModified(w, r, snapshotETag(snapshot.Location, snapshot.Timestamp)) {
 		return
 	}
-
-	respondJSON(w, http.StatusOK, snapshot)
+	respondJSON(w, r, http.StatusOK, snapshot)
 }
 
-// handleGetSnapshotsByRange returns snapshots within a time range
-func (s *APIServer) handleGetSnapshotsByRange(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleGetLocations lists the locations that have snapshots, so clients can
+// discover valid values for the location parameter.
+func (s *APIServer) handleGetLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := s.store.GetLocationsContext(r.Context())
+	if err != nil {
+		respondStoreError(w, r, err, "locations")
 		return
 	}
 
-	location := r.URL.Query().Get("location")
-	if location == "" {
-		location = "Los Angeles"
-	}
-
-	startStr := r.URL.Query().Get("start")
-	endStr := r.URL.Query().Get("end")
-
-	if startStr == "" || endStr == "" {
-		respondError(w, http.StatusBadRequest, "Missing required parameters: start and end")
-		return
-	}
+	respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"count": len(locations),
+		"data":  locations,
+	})
+}
 
-	start, err := time.Parse(time.RFC3339, startStr)
+// handleGetSnapshotsByRange returns snapshots within a time range
+func (s *APIServer) handleGetSnapshotsByRange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	location, err := requireLocation(q, s.cfg.DefaultLocation)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid start time format. Use RFC3339: "+err.Error())
+		respondParamError(w, r, err)
 		return
 	}
 
-	end, err := time.Parse(time.RFC3339, endStr)
+	start, end, err := parseTimeRange(q, true, 0, s.cfg.MaxRangeSpan)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid end time format. Use RFC3339: "+err.Error())
+		respondParamError(w, r, err)
 		return
 	}
 
-	snapshots, err := s.store.GetSnapshotsByTimeRange(location, start, end)
+	snapshots, err := s.store.GetSnapshotsByTimeRangeContext(r.Context(), location, start, end)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch snapshots: "+err.Error())
+		respondStoreError(w, r, err, "snapshots")
 		return
 	}
 
@@ -320,35 +439,31 @@ func (s *APIServer) handleGetSnapshotsByRange(w http.ResponseWriter, r *http.Req
 		"data":     snapshots,
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	respondJSON(w, r, http.StatusOK, response)
 }
 
 // handleGetSnapshots returns recent snapshots with pagination
 func (s *APIServer) handleGetSnapshots(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	q := r.URL.Query()
+	location, err := requireLocation(q, s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
 		return
 	}
 
-	location := r.URL.Query().Get("location")
-	if location == "" {
-		location = "Los Angeles"
-	}
-
 	// Default to last 24 hours
-	hours := 24
-	if h := r.URL.Query().Get("hours"); h != "" {
-		if parsed, err := strconv.Atoi(h); err == nil && parsed > 0 {
-			hours = parsed
-		}
+	hours, err := parseHours(q, 24)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
 	}
 
 	end := time.Now().UTC()
 	start := end.Add(-time.Duration(hours) * time.Hour)
 
-	snapshots, err := s.store.GetSnapshotsByTimeRange(location, start, end)
+	snapshots, err := s.store.GetSnapshotsByTimeRangeContext(r.Context(), location, start, end)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch snapshots: "+err.Error())
+		respondStoreError(w, r, err, "snapshots")
 		return
 	}
 
@@ -359,53 +474,46 @@ func (s *APIServer) handleGetSnapshots(w http.ResponseWriter, r *http.Request) {
 		"data":     snapshots,
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	respondJSON(w, r, http.StatusOK, response)
 }
 
-// handleGetMetricSeries returns time series data for a specific metric
+// handleGetMetricSeries returns time series data for a specific metric. With
+// anomaly_window or anomaly_threshold set, each point also carries a rolling
+// z-score and an anomaly flag.
 func (s *APIServer) handleGetMetricSeries(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	q := r.URL.Query()
+	metric := q.Get("metric")
+	if metric == "" {
+		respondParamError(w, r, badParam("metric", "missing metric"))
 		return
 	}
-
-	metric := r.URL.Query().Get("metric")
-	location := r.URL.Query().Get("location")
-	startStr := r.URL.Query().Get("start")
-	endStr := r.URL.Query().Get("end")
-
-	if metric == "" {
-		respondError(w, http.StatusBadRequest, "Missing required parameter: metric")
+	if !store.IsMetricColumn(metric) {
+		respondParamError(w, r, badParam("metric", "unknown metric %q", metric))
 		return
 	}
 
-	if location == "" {
-		location = "Los Angeles"
+	location, err := requireLocation(q, s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
 	}
 
 	// Default to last 7 days if not specified
-	var start, end time.Time
-	if startStr == "" || endStr == "" {
-		end = time.Now().UTC()
-		start = end.Add(-7 * 24 * time.Hour)
-	} else {
-		var err error
-		start, err = time.Parse(time.RFC3339, startStr)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid start time format: "+err.Error())
-			return
-		}
+	start, end, err := parseTimeRange(q, false, 7*24*time.Hour, s.cfg.MaxRangeSpan)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
 
-		end, err = time.Parse(time.RFC3339, endStr)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid end time format: "+err.Error())
-			return
-		}
+	anomalies, err := parseAnomalyParams(q)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
 	}
 
-	series, err := s.store.GetMetricSeries(metric, location, start, end)
+	series, err := s.store.GetMetricSeriesContext(r.Context(), metric, location, start, end)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch metric series: "+err.Error())
+		respondStoreError(w, r, err, "metric series")
 		return
 	}
 
@@ -417,40 +525,115 @@ func (s *APIServer) handleGetMetricSeries(w http.ResponseWriter, r *http.Request
 		"count":    len(series),
 		"data":     series,
 	}
+	if anomalies != nil {
+		response["data"] = annotateAnomalies(series, *anomalies)
+		response["anomaly_window"] = anomalies.Window
+		response["anomaly_threshold"] = anomalies.Threshold
+	}
 
-	respondJSON(w, http.StatusOK, response)
+	respondJSON(w, r, http.StatusOK, response)
 }
 
 // respondJSON sends a JSON response
-func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
+func respondJSON(w http.ResponseWriter, r *http.Request, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		log.Printf("[%s] Error encoding JSON response: %v", requestIDFrom(r.Context()), err)
 	}
 }
 
-// respondError sends an error response
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
-}
-
-// loggingMiddleware logs all incoming requests
+// loggingMiddleware writes one access-log line per request, tagged with the
+// request ID so it can be matched against error bodies and handler logs.
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		log.Printf("%s %s %s", r.Method, r.RequestURI, time.Since(start))
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		line := fmt.Sprintf("[%s] %s %s status=%d dur=%s", requestIDFrom(r.Context()), r.Method, r.RequestURI, rec.status, time.Since(start))
+		if cn := clientCNFrom(r.Context()); cn != "" {
+			line += " client=" + cn
+		}
+		log.Print(line)
 	})
 }
 
-// enableCORS adds CORS headers to allow frontend access
-func enableCORS(next http.Handler) http.Handler {
+// statusRecorder remembers the status a handler sent for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush passes through so streaming handlers keep working behind the logger.
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusRecorder) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// originSet is the CORS allow list. An empty set allows any origin.
+type originSet map[string]struct{}
+
+func newOriginSet(allowed []string) originSet {
+	set := make(originSet, len(allowed))
+	for _, o := range allowed {
+		set[strings.TrimRight(o, "/")] = struct{}{}
+	}
+	return set
+}
+
+// allows reports whether a browser at origin may call the API.
+func (s originSet) allows(origin string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	_, ok := s[origin]
+	return ok
+}
+
+// enableCORS adds CORS headers to allow frontend access. With no configured
+// origins any origin is allowed ("*"); otherwise the request Origin is echoed
+// back only when it is in the allow list.
+func enableCORS(allowed []string, next http.Handler) http.Handler {
+	allowSet := newOriginSet(allowed)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if len(allowSet) == 0 {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" {
+				if allowSet.allows(origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+requestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// counter is a monotonically increasing Prometheus counter.
+type counter struct {
+	name string
+	help string
+	val  atomic.Uint64
+}
+
+// Inc adds one to the counter.
+func (c *counter) Inc() { c.val.Add(1) }
+
+// metricsRegistry is a minimal Prometheus text-format exposition of the
+// API's counters; it avoids pulling in the full client library for a handful
+// of values.
+type metricsRegistry struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{counters: make(map[string]*counter)}
+}
+
+// Counter returns the named counter, creating it on first use.
+func (m *metricsRegistry) Counter(name, help string) *counter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.counters[name]; ok {
+		return c
+	}
+	c := &counter{name: name, help: help}
+	m.counters[name] = c
+	return c
+}
+
+// ServeHTTP writes all counters in the Prometheus text format.
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, name := range names {
+		m.mu.Lock()
+		c := m.counters[name]
+		m.mu.Unlock()
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.val.Load())
+	}
+}
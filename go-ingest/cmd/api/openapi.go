@@ -0,0 +1,425 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pipeline"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// routeMux is a ServeMux that remembers the patterns registered on it, so
+// /openapi.json lists every route whether or not it is documented.
+type routeMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *routeMux) Handle(pattern string, h http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.Handle(pattern, h)
+}
+
+func (m *routeMux) HandleFunc(pattern string, h func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, h)
+}
+
+// apiParam documents one query or path parameter.
+type apiParam struct {
+	Name        string
+	In          string // "query" (default) or "path"
+	Type        string // JSON schema type; "string" when empty
+	Format      string
+	Required    bool
+	Description string
+}
+
+// apiRoute documents one route. Response is the type of the success body,
+// nil for a free-form JSON object; ContentType overrides application/json.
+type apiRoute struct {
+	Summary     string
+	Params      []apiParam
+	Body        reflect.Type
+	Response    reflect.Type
+	Status      int // success status, 200 when zero
+	ContentType string
+	Admin       bool
+}
+
+// Parameters shared by many routes.
+var (
+	paramLocation = apiParam{Name: "location", Description: "Location name; defaults to EDGESIGHT_DEFAULT_LOCATION when configured."}
+	paramStart    = apiParam{Name: "start", Format: "date-time", Description: "Start of the window (RFC 3339)."}
+	paramEnd      = apiParam{Name: "end", Format: "date-time", Description: "End of the window (RFC 3339)."}
+	paramMetric   = apiParam{Name: "metric", Required: true, Description: "Metric name, e.g. pm25 or temp_c."}
+)
+
+// apiRoutes documents the routes registered in Router, keyed by pattern.
+var apiRoutes = map[string]apiRoute{
+	"GET /health": {
+		Summary: "Health check of the database and embedding sidecar; 503 when the database is down.",
+		Params:  []apiParam{{Name: "shallow", Description: "1 or true skips the dependency checks (liveness only)."}},
+	},
+	"GET /health/ready": {Summary: "Readiness check with dependency status and data freshness; 503 when degraded."},
+	"GET /metrics":      {Summary: "Prometheus metrics.", ContentType: "text/plain"},
+	"GET /openapi.json": {Summary: "This OpenAPI document."},
+
+	"GET /api/v1/snapshots/latest": {
+		Summary:  "Latest snapshot for a location.",
+		Params:   []apiParam{paramLocation},
+		Response: reflect.TypeFor[models.Snapshot](),
+	},
+	"GET /api/v1/snapshots/range": {
+		Summary: "Snapshots for a location within start..end.",
+		Params:  []apiParam{paramLocation, withRequired(paramStart), withRequired(paramEnd)},
+	},
+	"GET /api/v1/snapshots/diff": {
+		Summary: "Per-field deltas between a location's snapshots at two times.",
+		Params: []apiParam{paramLocation,
+			{Name: "to", Format: "date-time", Description: "Later time; defaults to now."},
+			{Name: "from", Format: "date-time", Description: "Earlier time; pass from or lag."},
+			{Name: "lag", Description: "Duration before to, e.g. 24h (the default)."},
+		},
+	},
+	"GET /api/v1/snapshots/export": {
+		Summary:     "Snapshots within start..end as newline-delimited JSON, one Snapshot per line.",
+		Params:      []apiParam{paramLocation, withRequired(paramStart), withRequired(paramEnd)},
+		Response:    reflect.TypeFor[models.Snapshot](),
+		ContentType: "application/x-ndjson",
+	},
+	"GET /api/v1/snapshots": {
+		Summary: "Snapshots for a location over the last hours.",
+		Params:  []apiParam{paramLocation, {Name: "hours", Type: "integer", Description: "Look-back window in hours (default 24)."}},
+	},
+	"GET /api/v1/locations": {Summary: "Locations with stored data."},
+	"GET /api/v1/metrics/series": {
+		Summary: "Time series of one metric, optionally flagging anomalies.",
+		Params: []apiParam{paramMetric, paramLocation, paramStart, paramEnd,
+			{Name: "anomaly_window", Type: "integer", Description: "Rolling window, in points, for anomaly detection."},
+			{Name: "anomaly_threshold", Type: "number", Description: "Z-score above which a point is an anomaly."},
+		},
+	},
+	"GET /api/v1/metrics/compare": {
+		Summary: "One metric across up to 10 locations.",
+		Params: []apiParam{paramMetric,
+			{Name: "locations", Required: true, Description: "Comma-separated location names."},
+			paramStart, paramEnd,
+			{Name: "bucket", Description: "Averaging interval, at least 1m."},
+		},
+	},
+	"GET /api/v1/metrics/correlate": {
+		Summary: "Pairwise Pearson correlation between 2-8 metrics at one location.",
+		Params:  []apiParam{{Name: "metrics", Required: true, Description: "Comma-separated metric names."}, paramLocation, paramStart, paramEnd},
+	},
+	"GET /api/v1/metrics/top": {
+		Summary: "Locations ranked by a metric in their latest snapshot.",
+		Params: []apiParam{paramMetric,
+			{Name: "order", Description: "asc or desc (the default)."},
+			{Name: "limit", Type: "integer", Description: "1-100, default 10."},
+		},
+	},
+	"GET /api/v1/disasters": {
+		Summary:  "Latest FEMA disaster detail for a location.",
+		Params:   []apiParam{paramLocation},
+		Response: reflect.TypeFor[store.DisasterReport](),
+	},
+	"GET /api/v1/forecast": {
+		Summary: "Live hourly forecast for a location or coordinates.",
+		Params: []apiParam{paramLocation,
+			{Name: "lat", Type: "number", Description: "Latitude; with lon, used instead of location."},
+			{Name: "lon", Type: "number", Description: "Longitude."},
+			{Name: "hours", Type: "integer", Description: "Forecast horizon in hours."},
+		},
+	},
+	"GET /api/v1/dashboard": {
+		Summary:  "Latest and previous snapshots, events, 24h aggregates and freshness in one call.",
+		Params:   []apiParam{paramLocation},
+		Response: reflect.TypeFor[dashboardResponse](),
+	},
+	"GET /api/v1/search": {
+		Summary: "Snapshot summaries most similar to a query.",
+		Params: []apiParam{
+			{Name: "q", Required: true, Description: "Search text."},
+			{Name: "location", Description: "One location, a comma-separated list, or all (the default)."},
+			{Name: "top_k", Type: "integer", Description: "Number of results; k is an alias."},
+			{Name: "per_location", Type: "integer", Description: "Cap per location when searching several."},
+			{Name: "metric", Description: "cosine (the default) or dot, ranked by highest score, or euclidean, ranked by lowest distance."},
+			{Name: "min_score", Type: "number", Description: "Drop results scoring below this (default 0); not with euclidean."},
+		},
+	},
+	"GET /api/v1/query": {
+		Summary: "Answer a question from retrieved snapshot summaries.",
+		Params: []apiParam{
+			{Name: "q", Required: true, Description: "Question."},
+			paramLocation, paramStart, paramEnd,
+			{Name: "top_k", Type: "integer", Description: "Number of snapshots to retrieve (at most 20); k is an alias."},
+			{Name: "min_score", Type: "number", Description: "Drop snapshots scoring below this (default 0)."},
+			{Name: "per_location", Type: "integer"},
+			{Name: "max_tokens", Type: "integer"},
+			{Name: "temperature", Type: "number"},
+			{Name: "stream", Type: "boolean", Description: "Stream the answer as server-sent events."},
+			{Name: "detail", Description: "full adds citations and source metrics."},
+		},
+	},
+	"POST /api/v1/query": {
+		Summary: "Answer a question; the options are read from the JSON body.",
+		Body:    reflect.TypeFor[queryOptions](),
+	},
+	"GET /api/v1/ws": {
+		Summary: "WebSocket pushing each new snapshot for a location.",
+		Params:  []apiParam{paramLocation},
+		Status:  http.StatusSwitchingProtocols,
+	},
+	"POST /api/v1/admin/reindex": {
+		Summary:  "Start or resume an embedding re-index job.",
+		Body:     reflect.TypeFor[reindexRequest](),
+		Response: reflect.TypeFor[store.ReindexJob](),
+		Status:   http.StatusAccepted,
+		Admin:    true,
+	},
+	"GET /api/v1/admin/jobs/{id}": {
+		Summary:  "Progress of a re-index job.",
+		Params:   []apiParam{{Name: "id", In: "path", Required: true}},
+		Response: reflect.TypeFor[store.ReindexJob](),
+		Admin:    true,
+	},
+	"POST /api/v1/ingest": {
+		Summary:  "Run one ingest pass and return its snapshot and source report.",
+		Body:     reflect.TypeFor[ingestRequest](),
+		Response: reflect.TypeFor[pipeline.Result](),
+		Admin:    true,
+	},
+}
+
+func withRequired(p apiParam) apiParam {
+	p.Required = true
+	return p
+}
+
+// extraProperties lists fields a type's MarshalJSON adds beyond its struct
+// fields.
+var extraProperties = map[reflect.Type]map[string]any{
+	reflect.TypeFor[models.Disasters](): {"severity_label": map[string]any{"type": "string"}},
+}
+
+// buildOpenAPI generates the OpenAPI 3.1 document for the given mux
+// patterns. Schemas are derived from the Go types' JSON tags.
+func buildOpenAPI(patterns []string) ([]byte, error) {
+	g := &schemaGen{components: map[string]any{}}
+	g.components["Error"] = map[string]any{
+		"type":                 "object",
+		"required":             []string{"error"},
+		"properties":           map[string]any{"error": g.schema(reflect.TypeFor[apiError]())},
+		"additionalProperties": false,
+	}
+	errorResponse := map[string]any{
+		"description": "Error envelope",
+		"content":     map[string]any{"application/json": map[string]any{"schema": ref("Error")}},
+	}
+
+	paths := map[string]map[string]any{}
+	for _, pattern := range patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			method, path = "GET", pattern
+		}
+		route := apiRoutes[pattern]
+
+		params := []any{}
+		for _, p := range route.Params {
+			in := p.In
+			if in == "" {
+				in = "query"
+			}
+			typ := p.Type
+			if typ == "" {
+				typ = "string"
+			}
+			schema := map[string]any{"type": typ}
+			if p.Format != "" {
+				schema["format"] = p.Format
+			}
+			param := map[string]any{"name": p.Name, "in": in, "required": p.Required, "schema": schema}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		var schema any = map[string]any{"type": "object"}
+		switch {
+		case contentType == "text/plain":
+			schema = map[string]any{"type": "string"}
+		case route.Response != nil:
+			schema = g.schema(route.Response)
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		if status != http.StatusSwitchingProtocols {
+			success["content"] = map[string]any{contentType: map[string]any{"schema": schema}}
+		}
+
+		op := map[string]any{
+			"operationId": operationID(method, path),
+			"parameters":  params,
+			"responses": map[string]any{
+				strconv.Itoa(status): success,
+				"default":            errorResponse,
+			},
+		}
+		if route.Summary != "" {
+			op["summary"] = route.Summary
+		}
+		if route.Body != nil {
+			op["requestBody"] = map[string]any{
+				"required": false,
+				"content":  map[string]any{"application/json": map[string]any{"schema": g.schema(route.Body)}},
+			}
+		}
+		if route.Admin {
+			op["security"] = []any{map[string]any{"adminToken": []string{}}}
+		}
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(method)] = op
+	}
+
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "EdgeSight API",
+			"version":     "v1",
+			"description": "Snapshots of weather, air quality, mobility, finance, energy, health, agriculture and disaster data per location.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.components,
+			"securitySchemes": map[string]any{
+				"adminToken": map[string]any{"type": "http", "scheme": "bearer", "description": "EDGESIGHT_ADMIN_TOKEN"},
+			},
+		},
+	}
+	return json.Marshal(doc)
+}
+
+// operationID turns "GET /api/v1/snapshots/{id}" into "getApiV1SnapshotsId".
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '_' || r == '-'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaGen derives JSON schemas from Go types, collecting named structs
+// under components.
+type schemaGen struct {
+	components map[string]any
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (g *schemaGen) schema(t reflect.Type) any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return map[string]any{"anyOf": []any{g.schema(t.Elem()), map[string]any{"type": "null"}}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": []string{"array", "null"}, "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := g.components[name]; !ok {
+			g.components[name] = nil // placeholder for recursive types
+			g.components[name] = g.object(t)
+		}
+		return ref(name)
+	}
+	return map[string]any{}
+}
+
+// object builds the schema of a struct from its exported, JSON-tagged
+// fields. Embedded structs without a tag are flattened, as encoding/json
+// does.
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = g.schema(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+	for name, schema := range extraProperties[t] {
+		props[name] = schema
+	}
+	sort.Strings(required)
+	obj := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
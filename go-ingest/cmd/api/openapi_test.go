@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// registeredRoutes returns the patterns Router passes to mux.Handle and
+// mux.HandleFunc, read from main.go so the check does not depend on
+// routeMux recording them.
+func registeredRoutes(t *testing.T) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse main.go: %v", err)
+	}
+	var patterns []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "Handle" && sel.Sel.Name != "HandleFunc") {
+			return true
+		}
+		if recv, ok := sel.X.(*ast.Ident); !ok || recv.Name != "mux" {
+			return true
+		}
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			pattern, _ := strconv.Unquote(lit.Value)
+			patterns = append(patterns, pattern)
+		}
+		return true
+	})
+	if len(patterns) == 0 {
+		t.Fatal("found no routes registered in main.go")
+	}
+	return patterns
+}
+
+func TestOpenAPIListsEveryRoute(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /openapi.json = %d %q, want 200 application/json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want a 3.x version", doc.OpenAPI)
+	}
+	for _, name := range []string{"Error", "Snapshot"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("components.schemas has no %s", name)
+		}
+	}
+
+	routes := registeredRoutes(t)
+	operations := 0
+	for _, path := range doc.Paths {
+		operations += len(path)
+	}
+	if operations != len(routes) {
+		t.Errorf("spec has %d operations, want one per registered route (%d)", operations, len(routes))
+	}
+	for _, pattern := range routes {
+		method, path, _ := strings.Cut(pattern, " ")
+		op := doc.Paths[path][strings.ToLower(method)]
+		if op == nil {
+			t.Errorf("%s is registered but missing from the spec", pattern)
+			continue
+		}
+		if op["summary"] == nil {
+			t.Errorf("%s has no summary; document it in apiRoutes", pattern)
+		}
+	}
+	for pattern := range apiRoutes {
+		if !slices.Contains(routes, pattern) {
+			t.Errorf("apiRoutes documents %s, which Router does not register", pattern)
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/llm"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// analystSystemPrompt frames every /api/v1/query answer.
+const analystSystemPrompt = "You are EdgeSight's analyst. You summarize local conditions using provided snapshots only. Be concise, avoid speculation, and mention timestamps/metrics when relevant. If data is insufficient, say so."
+
+// llmTimeout bounds a single LLM call made on behalf of a request.
+const llmTimeout = 45 * time.Second
+
+// answerer produces an LLM answer for a system + user prompt. It is
+// satisfied by *llm.Client and stubbed in tests.
+type answerer interface {
+	ChatWithOptions(ctx context.Context, system, user string, opts llm.ChatOptions) (string, error)
+}
+
+// streamingAnswerer is implemented by answerers that can emit tokens as they
+// are generated. Others are streamed as a single delta.
+type streamingAnswerer interface {
+	ChatStream(ctx context.Context, system, user string, opts llm.ChatOptions, onDelta func(string) error) (string, error)
+}
+
+// querySource is one retrieved snapshot summary backing an answer.
+type querySource struct {
+	Summary    string  `json:"summary"`
+	SnapshotTS string  `json:"snapshot_ts"`
+	Location   string  `json:"location"`
+	Score      float64 `json:"score"`
+
+	// Metrics holds the snapshot's numeric fields (detail=full only).
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+const (
+	defaultQueryMaxTokens   = 256
+	maxQueryMaxTokens       = 1024
+	defaultQueryTemperature = 0.2
+	maxQueryTemperature     = 2.0
+	maxQueryBodyBytes       = 64 << 10
+)
+
+// queryOptions are the knobs accepted by /api/v1/query, either as a JSON POST
+// body or as GET query parameters. The effective values are echoed back.
+type queryOptions struct {
+	Question    string   `json:"question"`
+	Location    string   `json:"location"`
+	TopK        int      `json:"top_k"`
+	MinScore    float64  `json:"min_score"`
+	PerLocation int      `json:"per_location,omitempty"`
+	Start       string   `json:"start,omitempty"`
+	End         string   `json:"end,omitempty"`
+	MaxTokens   int      `json:"max_tokens"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+	Detail      string   `json:"detail"`
+
+	start, end time.Time
+}
+
+// parseQueryOptions reads options from the request and fills in defaults.
+// Invalid values are reported as paramErrors; maxSpan caps start..end.
+func parseQueryOptions(w http.ResponseWriter, r *http.Request, maxSpan time.Duration) (*queryOptions, error) {
+	opts := &queryOptions{}
+
+	switch r.Method {
+	case http.MethodPost:
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQueryBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(opts); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %v", err)
+		}
+	default:
+		q := r.URL.Query()
+		opts.Question = q.Get("q")
+		opts.Location = q.Get("location")
+		opts.Start = q.Get("start")
+		opts.End = q.Get("end")
+		opts.Detail = q.Get("detail")
+		if param := topKParam(q); q.Get(param) != "" {
+			n, err := strconv.Atoi(q.Get(param))
+			if err != nil {
+				return nil, badParam(param, "must be an integer")
+			}
+			opts.TopK = n
+		}
+		if v := q.Get("min_score"); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, badParam("min_score", "must be a number")
+			}
+			opts.MinScore = f
+		}
+		if v := q.Get("per_location"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, badParam("per_location", "must be an integer")
+			}
+			opts.PerLocation = n
+		}
+		if v := q.Get("max_tokens"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, badParam("max_tokens", "must be an integer")
+			}
+			opts.MaxTokens = n
+		}
+		if v := q.Get("stream"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, badParam("stream", "must be true or false")
+			}
+			opts.Stream = b
+		}
+		if v := q.Get("temperature"); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, badParam("temperature", "must be a number")
+			}
+			opts.Temperature = &f
+		}
+	}
+
+	opts.Question = strings.TrimSpace(opts.Question)
+	if opts.Question == "" {
+		if r.Method == http.MethodPost {
+			return nil, badParam("question", "missing question")
+		}
+		return nil, badParam("q", "missing q")
+	}
+	if opts.TopK == 0 {
+		opts.TopK = defaultTopK
+	}
+	if err := validateTopK(opts.TopK, maxQueryTopK); err != nil {
+		return nil, err
+	}
+	if err := validateMinScore(opts.MinScore); err != nil {
+		return nil, err
+	}
+	if opts.PerLocation < 0 {
+		return nil, badParam("per_location", "must not be negative")
+	}
+
+	switch opts.Detail {
+	case "":
+		opts.Detail = queryDetailSummary
+	case queryDetailSummary, queryDetailFull:
+	default:
+		return nil, badParam("detail", "must be %q or %q", queryDetailSummary, queryDetailFull)
+	}
+
+	if opts.MaxTokens == 0 {
+		opts.MaxTokens = defaultQueryMaxTokens
+	}
+	if opts.MaxTokens < 1 || opts.MaxTokens > maxQueryMaxTokens {
+		return nil, badParam("max_tokens", "must be between 1 and %d", maxQueryMaxTokens)
+	}
+
+	if opts.Temperature == nil {
+		t := defaultQueryTemperature
+		opts.Temperature = &t
+	}
+	if *opts.Temperature < 0 || *opts.Temperature > maxQueryTemperature {
+		return nil, badParam("temperature", "must be between 0 and %.1f", maxQueryTemperature)
+	}
+
+	if opts.Start != "" {
+		t, err := parseRFC3339("start", opts.Start)
+		if err != nil {
+			return nil, err
+		}
+		opts.start = t
+	}
+	if opts.End != "" {
+		t, err := parseRFC3339("end", opts.End)
+		if err != nil {
+			return nil, err
+		}
+		opts.end = t
+	}
+	if err := validateRange(opts.start, opts.end, maxSpan); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// isTimeout reports whether err came from a deadline or client timeout.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// handleQuery performs search then asks the LLM to answer from the results.
+// GET takes q/location (plus optional option params); POST takes a JSON body.
+func (s *APIServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseQueryOptions(w, r, s.cfg.MaxRangeSpan)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+	// Serve repeated questions from the answer cache (non-streaming only)
+	var cacheKey string
+	if s.answers != nil && s.llm != nil && !opts.Stream {
+		cacheKey = answerCacheKey(opts, time.Now())
+		newest, _ := s.store.GetLatestSnapshotTimeContext(r.Context(), opts.Location)
+		if hit, ok := s.answers.Get(cacheKey, newest); ok {
+			s.cacheHits.Inc()
+			w.Header().Set("X-Cache", "HIT")
+			payload := map[string]interface{}{
+				"answer":  hit.Answer,
+				"sources": hit.Sources,
+				"options": opts,
+			}
+			if opts.Detail == queryDetailFull {
+				payload["citations"] = hit.Citations
+			}
+			respondJSON(w, r, http.StatusOK, payload)
+			return
+		}
+		s.cacheMisses.Inc()
+		w.Header().Set("X-Cache", "MISS")
+	}
+
+	if s.embedClient == nil {
+		respondError(w, r, http.StatusServiceUnavailable, codeUnavailable, "embedding service not configured")
+		return
+	}
+	vec, err := s.embedClient.EmbedContext(r.Context(), opts.Question)
+	if err != nil {
+		respondEmbedError(w, r, err)
+		return
+	}
+	// An empty location searches across every location
+	var results []store.SearchResult
+	if opts.Location == "" {
+		results, err = s.store.SearchEmbeddingsAllLocationsContext(r.Context(), vec, opts.TopK, opts.PerLocation, opts.start, opts.end, store.MetricCosine)
+	} else {
+		results, err = s.store.SearchEmbeddingsInRangeContext(r.Context(), opts.Location, vec, opts.TopK, opts.start, opts.end, store.MetricCosine)
+	}
+	if err != nil {
+		respondStoreError(w, r, err, "search results")
+		return
+	}
+	results = dropBelowScore(results, opts.MinScore)
+
+	sources := make([]querySource, 0, len(results))
+	for _, r := range results {
+		sources = append(sources, querySource{
+			Summary:    r.Summary,
+			SnapshotTS: r.SnapshotTS,
+			Location:   r.Location,
+			Score:      r.Score,
+		})
+	}
+	if opts.Detail == queryDetailFull {
+		if err := s.attachMetrics(r.Context(), sources); err != nil {
+			respondStoreError(w, r, err, "snapshot metrics")
+			return
+		}
+	}
+
+	if opts.Stream {
+		s.streamQueryAnswer(w, r, opts, sources)
+		return
+	}
+
+	if s.llm == nil {
+		respondJSON(w, r, http.StatusOK, map[string]interface{}{
+			"answer":  "LLM not configured; showing similar snapshots.",
+			"sources": sources,
+			"options": opts,
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), llmTimeout)
+	defer cancel()
+
+	answer, err := s.llm.ChatWithOptions(ctx, analystSystemPrompt, buildQueryPrompt(opts, sources), llm.ChatOptions{
+		MaxTokens:   opts.MaxTokens,
+		Temperature: *opts.Temperature,
+	})
+	if err != nil {
+		log.Printf("[%s] LLM error: %v", requestIDFrom(r.Context()), err)
+		status := http.StatusBadGateway
+		if isTimeout(err) {
+			status = http.StatusGatewayTimeout
+		}
+		respondJSON(w, r, status, map[string]interface{}{
+			"error":   llmAPIError(r, err),
+			"answer":  "",
+			"sources": sources,
+			"options": opts,
+		})
+		return
+	}
+	answer = strings.TrimSpace(answer)
+
+	var citations []queryCitation
+	if opts.Detail == queryDetailFull {
+		citations = extractCitations(answer, sources)
+	}
+	if cacheKey != "" {
+		s.answers.Put(cacheKey, cachedAnswer{Answer: answer, Sources: sources, Citations: citations, CreatedAt: time.Now().UTC()})
+	}
+
+	payload := map[string]interface{}{
+		"answer":  answer,
+		"sources": sources,
+		"options": opts,
+	}
+	if opts.Detail == queryDetailFull {
+		payload["citations"] = citations
+	}
+	respondJSON(w, r, http.StatusOK, payload)
+}
+
+// llmAPIError describes an LLM failure for the client without the upstream
+// error text.
+func llmAPIError(r *http.Request, err error) apiError {
+	if isTimeout(err) {
+		return newAPIError(r, codeGatewayTimeout, "LLM request timed out")
+	}
+	return newAPIError(r, codeBadGateway, "LLM request failed")
+}
+
+// buildQueryPrompt renders the user prompt from the question and sources.
+func buildQueryPrompt(opts *queryOptions, sources []querySource) string {
+	var sb strings.Builder
+	sb.WriteString("Question: ")
+	sb.WriteString(opts.Question)
+	sb.WriteString("\nLocation: ")
+	if opts.Location == "" {
+		sb.WriteString("all locations")
+	} else {
+		sb.WriteString(opts.Location)
+	}
+	sb.WriteString("\nTop snapshots:\n")
+	if len(sources) == 0 {
+		sb.WriteString("(none: no stored snapshot matched the question closely enough)\n")
+		sb.WriteString("The context is empty. Say briefly that there is no relevant data to answer from.")
+		return sb.String()
+	}
+	if opts.Detail == queryDetailFull {
+		writeFullQueryContext(&sb, sources)
+		sb.WriteString("Provide a concise answer (<=3 sentences) that quotes the relevant metric values. End each sentence with the marker of the snapshot it relies on, e.g. [S1]. If the context is insufficient, say so briefly.")
+		return sb.String()
+	}
+	for i, src := range sources {
+		sb.WriteString(fmt.Sprintf("%d) [%s, %s] %s (score %.3f)\n", i+1, src.SnapshotTS, src.Location, src.Summary, src.Score))
+	}
+	sb.WriteString("Provide a concise answer (<=3 sentences). If the context is insufficient, say so briefly.")
+	return sb.String()
+}
+
+// streamQueryAnswer sends the answer as Server-Sent Events: a "token" event
+// per delta, then a "done" event carrying the full answer and sources (or an
+// "error" event). The request context is passed upstream, so a client
+// disconnect cancels generation.
+func (s *APIServer) streamQueryAnswer(w http.ResponseWriter, r *http.Request, opts *queryOptions, sources []querySource) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, r, http.StatusInternalServerError, codeInternal, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, payload interface{}) error {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if s.llm == nil {
+		send("done", map[string]interface{}{
+			"answer":  "LLM not configured; showing similar snapshots.",
+			"sources": sources,
+			"options": opts,
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), llmTimeout)
+	defer cancel()
+
+	prompt := buildQueryPrompt(opts, sources)
+	chatOpts := llm.ChatOptions{MaxTokens: opts.MaxTokens, Temperature: *opts.Temperature}
+	onDelta := func(delta string) error {
+		return send("token", map[string]string{"delta": delta})
+	}
+
+	var answer string
+	var err error
+	if sa, ok := s.llm.(streamingAnswerer); ok {
+		answer, err = sa.ChatStream(ctx, analystSystemPrompt, prompt, chatOpts, onDelta)
+	} else if answer, err = s.llm.ChatWithOptions(ctx, analystSystemPrompt, prompt, chatOpts); err == nil {
+		err = onDelta(answer)
+	}
+
+	if r.Context().Err() != nil {
+		return // client went away
+	}
+	if err != nil {
+		log.Printf("[%s] LLM stream error: %v", requestIDFrom(r.Context()), err)
+		send("error", map[string]interface{}{
+			"error":   llmAPIError(r, err),
+			"timeout": isTimeout(err),
+			"sources": sources,
+		})
+		return
+	}
+
+	done := map[string]interface{}{
+		"answer":  strings.TrimSpace(answer),
+		"sources": sources,
+		"options": opts,
+	}
+	if opts.Detail == queryDetailFull {
+		done["citations"] = extractCitations(strings.TrimSpace(answer), sources)
+	}
+	send("done", done)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// Values of the /api/v1/query detail option. "summary" sends only the
+// embedded summaries to the LLM; "full" adds each snapshot's numeric fields
+// and asks for [S<n>] citations, returned as a citations array.
+const (
+	queryDetailSummary = "summary"
+	queryDetailFull    = "full"
+)
+
+// maxQueryContextBytes caps the snapshot context of a detail=full prompt.
+// Snapshots past the cap are left out of the prompt (but still returned as
+// sources).
+const maxQueryContextBytes = 6000
+
+// queryMetrics are the snapshot fields included in a detail=full prompt,
+// named like the metric columns. Zero values are left out as "no data".
+var queryMetrics = []struct {
+	name  string
+	value func(*models.Snapshot) float64
+}{
+	{"temp_c", func(s *models.Snapshot) float64 { return s.Weather.TemperatureC }},
+	{"humidity", func(s *models.Snapshot) float64 { return s.Weather.Humidity }},
+	{"wind", func(s *models.Snapshot) float64 { return s.Weather.WindSpeedMS }},
+	{"precip", func(s *models.Snapshot) float64 { return s.Weather.PrecipMM }},
+	{"pm25", func(s *models.Snapshot) float64 { return s.Environment.PM25 }},
+	{"pm10", func(s *models.Snapshot) float64 { return s.Environment.PM10 }},
+	{"ozone", func(s *models.Snapshot) float64 { return s.Environment.Ozone }},
+	{"no2", func(s *models.Snapshot) float64 { return s.Environment.NO2 }},
+	{"traffic_speed_kmh", func(s *models.Snapshot) float64 { return s.Mobility.TrafficSpeedKmH }},
+	{"traffic_jam_factor", func(s *models.Snapshot) float64 { return s.Mobility.TrafficJamFactor }},
+	{"flight_count", func(s *models.Snapshot) float64 { return float64(s.Mobility.FlightCount) }},
+	{"bike_share_bikes_available", func(s *models.Snapshot) float64 { return float64(s.Mobility.BikeShareBikesAvailable) }},
+	{"bike_share_utilization_percent", func(s *models.Snapshot) float64 { return s.Mobility.BikeShareUtilizationPercent }},
+	{"grid_load", func(s *models.Snapshot) float64 { return s.Energy.GridLoad }},
+	{"renewable_percent", func(s *models.Snapshot) float64 { return s.Energy.RenewablePercent }},
+	{"carbon_intensity_gco2_kwh", func(s *models.Snapshot) float64 { return s.Energy.CarbonIntensity }},
+	{"electricity_price_usd", func(s *models.Snapshot) float64 { return s.Energy.ElectricityPriceUSD }},
+	{"stock_price", func(s *models.Snapshot) float64 { return s.Finance.StockPrice }},
+	{"nasdaq_index", func(s *models.Snapshot) float64 { return s.Finance.NASDAQIndex }},
+	{"ili_percent", func(s *models.Snapshot) float64 { return s.Health.ILIPercent }},
+	{"rsv_percent_positive", func(s *models.Snapshot) float64 { return s.Health.RSVPercentPositive }},
+	{"crop_yield", func(s *models.Snapshot) float64 { return s.Agriculture.CropYield }},
+	{"soil_moisture_percent", func(s *models.Snapshot) float64 { return s.Agriculture.SoilMoisture }},
+	{"active_disasters", func(s *models.Snapshot) float64 { return float64(s.Disasters.ActiveDisasters) }},
+}
+
+// queryCitation ties one sentence of the answer to the snapshot it cites.
+// Source is the 1-based position in sources.
+type queryCitation struct {
+	Claim      string `json:"claim"`
+	Source     int    `json:"source"`
+	SnapshotTS string `json:"snapshot_ts"`
+	Location   string `json:"location"`
+}
+
+// snapshotMetrics returns the non-zero queryMetrics of snap.
+func snapshotMetrics(snap *models.Snapshot) map[string]float64 {
+	out := make(map[string]float64)
+	for _, m := range queryMetrics {
+		if v := m.value(snap); v != 0 {
+			out[m.name] = v
+		}
+	}
+	return out
+}
+
+// attachMetrics loads the snapshot behind each source and fills in its
+// Metrics. A source whose snapshot has since been deleted keeps only its
+// summary.
+func (s *APIServer) attachMetrics(ctx context.Context, sources []querySource) error {
+	for i := range sources {
+		ts, err := time.Parse(time.RFC3339, sources[i].SnapshotTS)
+		if err != nil {
+			continue
+		}
+		snap, err := s.store.GetSnapshotAtOrBeforeContext(ctx, sources[i].Location, ts)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !snap.Timestamp.Equal(ts) {
+			continue
+		}
+		sources[i].Metrics = snapshotMetrics(snap)
+	}
+	return nil
+}
+
+// writeFullQueryContext writes the detail=full snapshot lines: a [S<n>]
+// marker, the summary, and the metrics in queryMetrics order, stopping
+// before maxQueryContextBytes.
+func writeFullQueryContext(sb *strings.Builder, sources []querySource) {
+	var used int
+	for i, src := range sources {
+		var line strings.Builder
+		fmt.Fprintf(&line, "[S%d] %s, %s: %s (score %.3f)\n", i+1, src.SnapshotTS, src.Location, src.Summary, src.Score)
+		if len(src.Metrics) > 0 {
+			line.WriteString("    metrics:")
+			for _, m := range queryMetrics {
+				if v, ok := src.Metrics[m.name]; ok {
+					fmt.Fprintf(&line, " %s=%s", m.name, strconv.FormatFloat(v, 'f', -1, 64))
+				}
+			}
+			line.WriteString("\n")
+		}
+		if used+line.Len() > maxQueryContextBytes && i > 0 {
+			fmt.Fprintf(sb, "(%d more snapshots omitted)\n", len(sources)-i)
+			return
+		}
+		used += line.Len()
+		sb.WriteString(line.String())
+	}
+}
+
+var (
+	citationMarker = regexp.MustCompile(`\s*\[S(\d+)\]`)
+	// leadingMarkers are markers opening a sentence, i.e. placed after the
+	// previous sentence's full stop
+	leadingMarkers = regexp.MustCompile(`^(\s*\[S\d+\])+`)
+)
+
+// sentenceEnd splits an answer into sentences: terminal punctuation followed
+// by whitespace, or a line break.
+var sentenceEnd = regexp.MustCompile(`[.!?]\s+|\n+`)
+
+// extractCitations maps each [S<n>] marker in answer to its source, with its
+// sentence (markers removed) as the claim. Markers opening a sentence cite
+// the one before it, and markers naming no source are ignored.
+func extractCitations(answer string, sources []querySource) []queryCitation {
+	citations := []queryCitation{}
+	seen := make(map[string]bool)
+	cite := func(claim, markers string) {
+		if claim == "" {
+			return
+		}
+		for _, m := range citationMarker.FindAllStringSubmatch(markers, -1) {
+			n, err := strconv.Atoi(m[1])
+			if err != nil || n < 1 || n > len(sources) {
+				continue
+			}
+			key := strconv.Itoa(n) + "|" + claim
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			citations = append(citations, queryCitation{
+				Claim:      claim,
+				Source:     n,
+				SnapshotTS: sources[n-1].SnapshotTS,
+				Location:   sources[n-1].Location,
+			})
+		}
+	}
+
+	var prev string
+	for _, sentence := range splitSentences(answer) {
+		lead := leadingMarkers.FindString(sentence)
+		cite(prev, lead)
+		rest := sentence[len(lead):]
+		claim := strings.TrimSpace(citationMarker.ReplaceAllString(rest, ""))
+		cite(claim, rest)
+		if claim != "" {
+			prev = claim
+		}
+	}
+	return citations
+}
+
+// splitSentences splits text at sentence ends, keeping the punctuation.
+func splitSentences(text string) []string {
+	var out []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		// Keep the terminal punctuation with its sentence
+		end := loc[0]
+		if c := text[loc[0]]; c == '.' || c == '!' || c == '?' {
+			end++
+		}
+		if s := strings.TrimSpace(text[start:end]); s != "" {
+			out = append(out, s)
+		}
+		start = loc[1]
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/llm"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+func TestParseQueryOptionsCapsTopK(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *http.Request
+		wantErr bool
+	}{
+		{"GET at cap", httptest.NewRequest(http.MethodGet, "/api/v1/query?q=smog&top_k=20", nil), false},
+		{"GET over cap", httptest.NewRequest(http.MethodGet, "/api/v1/query?q=smog&top_k=21", nil), true},
+		{"GET alias over cap", httptest.NewRequest(http.MethodGet, "/api/v1/query?q=smog&k=50", nil), true},
+		{"POST at cap", httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(`{"question":"smog","top_k":20}`)), false},
+		{"POST over cap", httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(`{"question":"smog","top_k":50}`)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseQueryOptions(httptest.NewRecorder(), tt.req, 90*24*time.Hour)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("parseQueryOptions: %v", err)
+				}
+				if opts.TopK != maxQueryTopK {
+					t.Errorf("TopK = %d, want %d", opts.TopK, maxQueryTopK)
+				}
+				return
+			}
+			var pe *paramError
+			if !errors.As(err, &pe) || pe.Param != "top_k" {
+				t.Errorf("err = %v, want a top_k paramError", err)
+			}
+		})
+	}
+}
+
+func TestQueryEndpoint(t *testing.T) {
+	tests := []struct {
+		env, embed, want string
+	}{
+		{"", "http://embed.internal:9000/", "http://embed.internal:9000/query"},
+		{"", "", ""},
+		{"http://llm.internal:8000/query", "http://localhost:9000", "http://llm.internal:8000/query"},
+		{"off", "http://localhost:9000", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("QUERY_ENDPOINT", tt.env)
+		if got := queryEndpoint(tt.embed); got != tt.want {
+			t.Errorf("QUERY_ENDPOINT=%q, embed %q: got %q, want %q", tt.env, tt.embed, got, tt.want)
+		}
+	}
+}
+
+// stubAnswerer records the prompt it was asked and returns answer.
+type stubAnswerer struct {
+	answer string
+	prompt string
+}
+
+func (a *stubAnswerer) ChatWithOptions(ctx context.Context, system, user string, opts llm.ChatOptions) (string, error) {
+	a.prompt = user
+	return a.answer, nil
+}
+
+func TestQueryDetailFullIncludesMetrics(t *testing.T) {
+	s := newTestAPIServer(t, embedSidecar(t, []float64{1, 0}), apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	snap := models.Snapshot{Location: "Denver", Timestamp: ts}
+	snap.Weather.TemperatureC = 18.5
+	snap.Environment.PM25 = 35.2
+	snap.Energy.GridLoad = 4200
+	if err := s.store.InsertSnapshot(snap); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+	if err := s.store.InsertEmbedding(store.SnapshotEmbedding{
+		SnapshotTS: ts.Format(time.RFC3339), Location: "Denver", Summary: "Smoky morning in Denver",
+		Embedding: []float64{1, 0}, CreatedAt: ts,
+	}); err != nil {
+		t.Fatalf("InsertEmbedding: %v", err)
+	}
+	stub := &stubAnswerer{answer: "PM2.5 reached 35.2 µg/m³ [S1]. Nothing else stands out."}
+	s.llm = stub
+	h := s.Router()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/query?q=smoke&location=Denver&detail=full", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("detail=full = %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, want := range []string{"[S1] 2026-10-17T09:00:00Z, Denver: Smoky morning in Denver", "temp_c=18.5", "pm25=35.2", "grid_load=4200"} {
+		if !strings.Contains(stub.prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, stub.prompt)
+		}
+	}
+	var body struct {
+		Citations []queryCitation `json:"citations"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []queryCitation{{Claim: "PM2.5 reached 35.2 µg/m³.", Source: 1, SnapshotTS: "2026-10-17T09:00:00Z", Location: "Denver"}}
+	if !reflect.DeepEqual(body.Citations, want) {
+		t.Errorf("citations = %+v, want %+v", body.Citations, want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/query?q=smoke&location=Denver", nil))
+	if strings.Contains(stub.prompt, "pm25=") || strings.Contains(rec.Body.String(), `"citations"`) {
+		t.Errorf("default detail sent metrics or returned citations:\n%s", stub.prompt)
+	}
+}
+
+func TestWriteFullQueryContextCapsSize(t *testing.T) {
+	var sources []querySource
+	for i := 0; i < 50; i++ {
+		sources = append(sources, querySource{
+			Summary:    strings.Repeat("hazy ", 40),
+			SnapshotTS: "2026-10-17T09:00:00Z",
+			Location:   "Denver",
+			Metrics:    map[string]float64{"pm25": float64(i)},
+		})
+	}
+	var sb strings.Builder
+	writeFullQueryContext(&sb, sources)
+	out := sb.String()
+	if len(out) > maxQueryContextBytes+100 || !strings.Contains(out, "more snapshots omitted") {
+		t.Errorf("context is %d bytes, want at most about %d with an omission note", len(out), maxQueryContextBytes)
+	}
+	if !strings.Contains(out, "[S1] ") || !strings.Contains(out, "metrics: pm25=0") {
+		t.Errorf("context does not start with the first source:\n%.200s", out)
+	}
+}
+
+func TestQueryMinScore(t *testing.T) {
+	s := newTestAPIServer(t, embedSidecar(t, []float64{1, 0}), apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	for loc, vec := range map[string][]float64{"Denver": {0.6, 0.8}, "Boston": {1, 0}, "Austin": {0.8, 0.6}} {
+		if err := s.store.InsertEmbedding(store.SnapshotEmbedding{
+			SnapshotTS: ts.Format(time.RFC3339), Location: loc, Summary: loc + " summary", Embedding: vec, CreatedAt: ts,
+		}); err != nil {
+			t.Fatalf("InsertEmbedding: %v", err)
+		}
+	}
+	stub := &stubAnswerer{answer: "Nothing relevant."}
+	s.llm = stub
+	h := s.Router()
+
+	query := func(path string) []querySource {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d: %s", path, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Sources []querySource `json:"sources"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body.Sources
+	}
+
+	// Denver scores 0.6 and is dropped from both the response and the prompt
+	sources := query("/api/v1/query?q=smog&min_score=0.7")
+	if len(sources) != 2 || sources[0].Location != "Boston" || sources[1].Location != "Austin" {
+		t.Errorf("min_score=0.7 sources = %+v, want Boston and Austin", sources)
+	}
+	if !strings.Contains(stub.prompt, "Austin summary") || strings.Contains(stub.prompt, "Denver summary") {
+		t.Errorf("prompt should hold Austin but not Denver:\n%s", stub.prompt)
+	}
+
+	// With everything filtered out the LLM is told the context is empty
+	if sources := query("/api/v1/query?q=smog&min_score=1.5"); len(sources) != 0 {
+		t.Errorf("min_score=1.5 sources = %+v, want none", sources)
+	}
+	if !strings.Contains(stub.prompt, "The context is empty") || strings.Contains(stub.prompt, "summary") {
+		t.Errorf("prompt for an empty context:\n%s", stub.prompt)
+	}
+
+	// POST bodies take min_score too
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(`{"question":"smog","min_score":0.9}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(stub.prompt, "Boston summary") || strings.Contains(stub.prompt, "Austin summary") {
+		t.Errorf("POST min_score=0.9 = %d with prompt:\n%s", rec.Code, stub.prompt)
+	}
+}
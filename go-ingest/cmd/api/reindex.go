@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/semantic"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// batchEmbedder embeds many texts per request. It is satisfied by
+// *embeddings.Client.
+type batchEmbedder interface {
+	EmbedBatchContext(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// errReindexConflict is returned when a reindex overlaps a running one.
+var errReindexConflict = errors.New("a reindex job is already running for this scope")
+
+// reindexer runs embedding re-index jobs in the background. Jobs walk the
+// snapshot table in (ts, location) order, regenerate each summary, embed it in
+// batches and rewrite snapshot_embeddings. Progress is saved after every
+// batch, so a failed or interrupted job resumes from its cursor. close stops
+// running jobs, which are then marked interrupted.
+type reindexer struct {
+	store     *store.SQLiteStore
+	embed     batchEmbedder
+	batchSize int
+	interval  time.Duration // pause between batches, to spare the sidecar
+	summarize *semantic.Summarizer
+
+	ctx    context.Context // cancelled by close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // running jobs
+
+	mu     sync.Mutex
+	active map[string]string // scope (location, "" for all) -> job ID
+}
+
+func newReindexer(db *store.SQLiteStore, embed batchEmbedder, batchSize int, interval time.Duration, summarize *semantic.Summarizer) *reindexer {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if summarize == nil {
+		summarize, _ = semantic.NewSummarizer(nil)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &reindexer{
+		store:     db,
+		embed:     embed,
+		batchSize: batchSize,
+		interval:  interval,
+		summarize: summarize,
+		ctx:       ctx,
+		cancel:    cancel,
+		active:    make(map[string]string),
+	}
+}
+
+// close cancels running jobs and waits for them to record their progress.
+func (ri *reindexer) close() {
+	ri.cancel()
+	ri.wg.Wait()
+}
+
+// claim reserves a scope for jobID. The all-locations scope overlaps every
+// location, so it conflicts with any running job and vice versa.
+func (ri *reindexer) claim(location, jobID string) error {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	for scope := range ri.active {
+		if scope == location || scope == "" || location == "" {
+			return errReindexConflict
+		}
+	}
+	ri.active[location] = jobID
+	return nil
+}
+
+func (ri *reindexer) release(location string) {
+	ri.mu.Lock()
+	delete(ri.active, location)
+	ri.mu.Unlock()
+}
+
+// start begins a new job for location, or resumes the job resumeID when set.
+// The job runs in its own goroutine; the returned copy is its initial state.
+func (ri *reindexer) start(location, resumeID string) (*store.ReindexJob, error) {
+	job := &store.ReindexJob{
+		ID:        newRandomID(),
+		Location:  location,
+		CreatedAt: time.Now().UTC(),
+	}
+	if resumeID != "" {
+		prev, err := ri.store.GetReindexJob(resumeID)
+		if err != nil {
+			return nil, err
+		}
+		if prev.Status != store.ReindexFailed && prev.Status != store.ReindexInterrupted {
+			return nil, fmt.Errorf("job %s is %s; only failed or interrupted jobs can be resumed: %w", prev.ID, prev.Status, store.ErrInvalidInput)
+		}
+		job = prev
+		job.Error = ""
+	}
+
+	if err := ri.claim(job.Location, job.ID); err != nil {
+		return nil, err
+	}
+
+	remaining, err := ri.store.CountSnapshotsAfter(job.Location, job.LastTS, job.LastLocation)
+	if err != nil {
+		ri.release(job.Location)
+		return nil, err
+	}
+	job.Total = job.Processed + remaining
+	job.Status = store.ReindexRunning
+	job.UpdatedAt = time.Now().UTC()
+	if err := ri.store.SaveReindexJob(*job); err != nil {
+		ri.release(job.Location)
+		return nil, err
+	}
+
+	snapshot := *job
+	ri.wg.Add(1)
+	go func() {
+		defer ri.wg.Done()
+		ri.run(ri.ctx, job)
+	}()
+	return &snapshot, nil
+}
+
+// run processes batches until the scope is exhausted, a step fails or ctx
+// is cancelled.
+func (ri *reindexer) run(ctx context.Context, job *store.ReindexJob) {
+	defer ri.release(job.Location)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			ri.finish(job, err)
+			return
+		}
+		snaps, err := ri.store.GetSnapshotsPage(job.Location, job.LastTS, job.LastLocation, ri.batchSize)
+		if err != nil {
+			ri.finish(job, fmt.Errorf("list snapshots: %w", err))
+			return
+		}
+		if len(snaps) == 0 {
+			ri.finish(job, nil)
+			return
+		}
+
+		summaries := make([]string, len(snaps))
+		for i, snap := range snaps {
+			summaries[i] = ri.summarize.Summarize(snap)
+		}
+		vecs, err := ri.embed.EmbedBatchContext(ctx, summaries)
+		if err != nil {
+			ri.finish(job, fmt.Errorf("embed batch: %w", err))
+			return
+		}
+
+		now := time.Now().UTC()
+		embs := make([]store.SnapshotEmbedding, len(snaps))
+		for i, snap := range snaps {
+			embs[i] = store.SnapshotEmbedding{
+				SnapshotTS: snap.Timestamp.Format(time.RFC3339),
+				Location:   snap.Location,
+				Summary:    summaries[i],
+				Embedding:  vecs[i],
+				CreatedAt:  now,
+			}
+		}
+		if err := ri.store.ReplaceEmbeddings(embs); err != nil {
+			ri.finish(job, fmt.Errorf("write embeddings: %w", err))
+			return
+		}
+
+		last := embs[len(embs)-1]
+		job.LastTS, job.LastLocation = last.SnapshotTS, last.Location
+		job.Processed += len(snaps)
+		if job.Processed > job.Total {
+			job.Total = job.Processed // snapshots inserted while running
+		}
+		job.UpdatedAt = now
+		if err := ri.store.SaveReindexJob(*job); err != nil {
+			log.Printf("reindex %s: save progress: %v", job.ID, err)
+		}
+
+		if ri.interval > 0 {
+			timer := time.NewTimer(ri.interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// finish records the job's final status. A job stopped by close is
+// interrupted rather than failed, so it can be resumed.
+func (ri *reindexer) finish(job *store.ReindexJob, err error) {
+	job.Status = store.ReindexCompleted
+	switch {
+	case err != nil && ri.ctx.Err() != nil:
+		job.Status = store.ReindexInterrupted
+		log.Printf("reindex %s interrupted after %d/%d snapshots", job.ID, job.Processed, job.Total)
+	case err != nil:
+		job.Status = store.ReindexFailed
+		job.Error = err.Error()
+		log.Printf("reindex %s failed after %d/%d snapshots: %v", job.ID, job.Processed, job.Total, err)
+	default:
+		log.Printf("reindex %s completed: %d snapshots", job.ID, job.Processed)
+	}
+	job.UpdatedAt = time.Now().UTC()
+	if err := ri.store.SaveReindexJob(*job); err != nil {
+		log.Printf("reindex %s: save final status: %v", job.ID, err)
+	}
+}
+
+// reindexRequest is the optional JSON body of POST /api/v1/admin/reindex.
+// Location "" re-indexes every location; Resume continues a failed or
+// interrupted job instead of starting a new one.
+type reindexRequest struct {
+	Location string `json:"location"`
+	Resume   string `json:"resume"`
+}
+
+// handleReindex starts (or resumes) an asynchronous embedding re-index and
+// returns 202 with the job; poll /api/v1/admin/jobs/{id} for progress.
+func (s *APIServer) handleReindex(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	if s.reindex == nil {
+		respondError(w, r, http.StatusServiceUnavailable, codeUnavailable, "embedding service not configured")
+		return
+	}
+
+	var req reindexRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, r, http.StatusBadRequest, codeBadRequest, "invalid JSON body")
+		return
+	}
+
+	job, err := s.reindex.start(strings.TrimSpace(req.Location), req.Resume)
+	if errors.Is(err, errReindexConflict) {
+		respondError(w, r, http.StatusConflict, codeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondStoreError(w, r, err, "reindex job")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/admin/jobs/"+job.ID)
+	respondJSON(w, r, http.StatusAccepted, job)
+}
+
+// handleGetJob reports the progress of a reindex job.
+func (s *APIServer) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	id := r.PathValue("id")
+	job, err := s.store.GetReindexJobContext(r.Context(), id)
+	if err != nil {
+		respondStoreError(w, r, err, "job")
+		return
+	}
+	respondJSON(w, r, http.StatusOK, job)
+}
+
+// authorizeAdmin checks the bearer token for admin routes. Admin routes are
+// disabled unless EDGESIGHT_ADMIN_TOKEN is set. The token is compared in
+// constant time.
+func (s *APIServer) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.cfg.AdminToken == "" {
+		respondError(w, r, http.StatusForbidden, codeForbidden, "admin endpoints are disabled; set EDGESIGHT_ADMIN_TOKEN to enable them")
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) != 1 {
+		respondError(w, r, http.StatusUnauthorized, codeUnauthorized, "missing or invalid admin token")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// stubEmbedder returns one vector per text after signalling each call on
+// called. When block is set it waits for ctx instead.
+type stubEmbedder struct {
+	called chan struct{}
+	block  bool
+}
+
+func (e *stubEmbedder) EmbedBatchContext(ctx context.Context, texts []string) ([][]float64, error) {
+	e.called <- struct{}{}
+	if e.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	vecs := make([][]float64, len(texts))
+	for i := range vecs {
+		vecs[i] = []float64{1, 0}
+	}
+	return vecs, nil
+}
+
+func TestAdminTokenCheck(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{AdminToken: "secret"})
+	h := s.Router()
+
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"Bearer secret", http.StatusNotFound}, // authorized; the job does not exist
+		{"", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer secre", http.StatusUnauthorized},
+		{"Bearer secret2", http.StatusUnauthorized},
+		{"Basic secret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/jobs/missing", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Authorization %q = %d, want %d", tt.header, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestReindexCloseInterruptsJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		block         bool // stop while embedding rather than between batches
+		wantProcessed int
+	}{
+		{"while embedding", true, 0},
+		{"between batches", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAPIServer(t, nil, apiConfig{})
+			base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+			for i := range 3 {
+				if err := s.store.InsertSnapshot(models.Snapshot{Location: "Denver", Timestamp: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+					t.Fatalf("InsertSnapshot: %v", err)
+				}
+			}
+			embed := &stubEmbedder{called: make(chan struct{}, 3), block: tt.block}
+			// An hour between batches: only close can end the wait
+			ri := newReindexer(s.store, embed, 1, time.Hour, nil)
+
+			job, err := ri.start("Denver", "")
+			if err != nil {
+				t.Fatalf("start: %v", err)
+			}
+			<-embed.called
+
+			closed := make(chan struct{})
+			go func() {
+				ri.close()
+				close(closed)
+			}()
+			select {
+			case <-closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("close did not stop the running job")
+			}
+
+			got, err := s.store.GetReindexJob(job.ID)
+			if err != nil {
+				t.Fatalf("GetReindexJob: %v", err)
+			}
+			if got.Status != store.ReindexInterrupted || got.Processed != tt.wantProcessed || got.Error != "" {
+				t.Errorf("job = %s with %d processed (%q), want interrupted after %d", got.Status, got.Processed, got.Error, tt.wantProcessed)
+			}
+		})
+	}
+}
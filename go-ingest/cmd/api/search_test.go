@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// embedSidecar returns an embedding sidecar that embeds every text as vec.
+func embedSidecar(t *testing.T, vec []float64) *embeddings.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(embeddings.EmbedResponse{Embedding: vec})
+	}))
+	t.Cleanup(srv.Close)
+	return embeddings.NewClient(srv.URL, embeddings.WithRetries(0, 0))
+}
+
+type searchResponse struct {
+	Metric  string `json:"metric"`
+	Results []struct {
+		Location string  `json:"location"`
+		Score    float64 `json:"score"`
+	} `json:"results"`
+}
+
+// search runs path through the router and decodes a 200 response.
+func search(t *testing.T, h http.Handler, path string) searchResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s = %d: %s", path, rec.Code, rec.Body.String())
+	}
+	var body searchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return body
+}
+
+func TestSearchAcrossLocations(t *testing.T) {
+	s := newTestAPIServer(t, embedSidecar(t, []float64{1, 0}), apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	for loc, vec := range map[string][]float64{"Denver": {0.6, 0.8}, "Boston": {1, 0}, "Austin": {0.8, 0.6}} {
+		if err := s.store.InsertEmbedding(store.SnapshotEmbedding{SnapshotTS: ts.Format(time.RFC3339), Location: loc, Summary: loc, Embedding: vec, CreatedAt: ts}); err != nil {
+			t.Fatalf("InsertEmbedding: %v", err)
+		}
+	}
+	h := s.Router()
+
+	for path, want := range map[string][]string{
+		"/api/v1/search?q=smog&location=Denver,Boston": {"Boston", "Denver"},
+		"/api/v1/search?q=smog&location=all":           {"Boston", "Austin", "Denver"},
+		"/api/v1/search?q=smog&location=Denver":        {"Denver"},
+	} {
+		var got []string
+		for _, r := range search(t, h, path).Results {
+			got = append(got, r.Location)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s ranked %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestParseSearchLocations(t *testing.T) {
+	tests := map[string][]string{
+		"":                        nil,
+		"all":                     nil,
+		" ALL ":                   nil,
+		"Denver":                  {"Denver"},
+		"Denver, Boston,,Denver ": {"Denver", "Boston"},
+	}
+	for in, want := range tests {
+		if got := parseSearchLocations(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseSearchLocations(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchMetricParam(t *testing.T) {
+	s := newTestAPIServer(t, embedSidecar(t, []float64{1, 0}), apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	for loc, vec := range map[string][]float64{"Austin": {10, 0}, "Boston": {1, 0.1}, "Chicago": {2, 2}} {
+		if err := s.store.InsertEmbedding(store.SnapshotEmbedding{SnapshotTS: ts.Format(time.RFC3339), Location: loc, Summary: loc, Embedding: vec, CreatedAt: ts}); err != nil {
+			t.Fatalf("InsertEmbedding: %v", err)
+		}
+	}
+	h := s.Router()
+
+	tests := []struct {
+		metric string
+		name   string // metric reported in the response
+		want   []string
+	}{
+		{"", "cosine", []string{"Austin", "Boston", "Chicago"}},
+		{"cosine", "cosine", []string{"Austin", "Boston", "Chicago"}},
+		{"DOT", "dot", []string{"Austin", "Chicago", "Boston"}},
+		{"euclidean", "euclidean", []string{"Boston", "Chicago", "Austin"}},
+	}
+	for _, tt := range tests {
+		body := search(t, h, "/api/v1/search?q=smog&metric="+tt.metric)
+		var got []string
+		for _, r := range body.Results {
+			got = append(got, r.Location)
+		}
+		if !reflect.DeepEqual(got, tt.want) || body.Metric != tt.name {
+			t.Errorf("metric=%s ranked %v by %q, want %v by %q", tt.metric, got, body.Metric, tt.want, tt.name)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=smog&metric=manhattan", nil))
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusBadRequest || body.Error.Param != "metric" {
+		t.Errorf("metric=manhattan = %d %+v, want 400 naming metric", rec.Code, body.Error)
+	}
+}
+
+func TestSearchMinScore(t *testing.T) {
+	s := newTestAPIServer(t, embedSidecar(t, []float64{1, 0}), apiConfig{})
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	for loc, vec := range map[string][]float64{"Denver": {0.6, 0.8}, "Boston": {1, 0}, "Austin": {0.8, 0.6}} {
+		if err := s.store.InsertEmbedding(store.SnapshotEmbedding{SnapshotTS: ts.Format(time.RFC3339), Location: loc, Summary: loc, Embedding: vec, CreatedAt: ts}); err != nil {
+			t.Fatalf("InsertEmbedding: %v", err)
+		}
+	}
+	h := s.Router()
+
+	for minScore, want := range map[string][]string{
+		"":    {"Boston", "Austin", "Denver"},
+		"0.8": {"Boston", "Austin"}, // a score equal to the floor is kept
+		"0.9": {"Boston"},
+		"1.5": nil,
+	} {
+		body := search(t, h, "/api/v1/search?q=smog&min_score="+minScore)
+		var got []string
+		for _, r := range body.Results {
+			got = append(got, r.Location)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("min_score=%s returned %v, want %v", minScore, got, want)
+		}
+	}
+
+	for _, path := range []string{
+		"/api/v1/search?q=smog&min_score=high",
+		"/api/v1/search?q=smog&min_score=NaN",
+		"/api/v1/search?q=smog&metric=euclidean&min_score=0.5",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Error apiError `json:"error"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if rec.Code != http.StatusBadRequest || body.Error.Param != "min_score" {
+			t.Errorf("%s = %d %+v, want 400 naming min_score", path, rec.Code, body.Error)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// loadTLSConfig builds the server TLS config from PEM files. When clientCA
+// is set, clients must present a certificate signed by it (mutual TLS).
+func loadTLSConfig(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("EDGESIGHT_TLS_CERT and EDGESIGHT_TLS_KEY must be set together")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCA)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+type clientCNKey struct{}
+
+// clientIdentityMiddleware stores the verified client certificate's common
+// name in the request context. Without mTLS it is a no-op.
+func clientIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			cn := r.TLS.PeerCertificates[0].Subject.CommonName
+			r = r.WithContext(context.WithValue(r.Context(), clientCNKey{}, cn))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientCNFrom returns the client certificate common name stored in ctx, or
+// "" when the request was not authenticated with a client certificate.
+func clientCNFrom(ctx context.Context) string {
+	cn, _ := ctx.Value(clientCNKey{}).(string)
+	return cn
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a generated certificate and its key.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert creates a certificate for cn, signed by parent or self-signed
+// when parent is nil.
+func newTestCert(t *testing.T, cn string, isCA bool, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// writePEM writes the certificate and key as PEM files and returns their paths.
+func (c *testCert) writePEM(t *testing.T, name string) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "EdgeSight Test CA", true, nil)
+	server := newTestCert(t, "127.0.0.1", false, ca)
+	trusted := newTestCert(t, "edge-node-7", false, ca)
+	untrusted := newTestCert(t, "intruder", false, newTestCert(t, "Other CA", true, nil))
+
+	certFile, keyFile := server.writePEM(t, "server")
+	caFile, _ := ca.writePEM(t, "ca")
+	cfg, err := loadTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("loadTLSConfig: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(clientIdentityMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, clientCNFrom(r.Context()))
+	})))
+	srv.TLS = cfg
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // rejected handshakes are expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	// GetClientCertificate sends the certificate even when its issuer is not
+	// among the CAs the server advertises, so verification is what rejects it.
+	clientWith := func(cert *testCert) *http.Client {
+		cfg := &tls.Config{RootCAs: roots}
+		if cert != nil {
+			cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				c := cert.tlsCertificate()
+				return &c, nil
+			}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	}
+
+	resp, err := clientWith(trusted).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("trusted client cert rejected: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "edge-node-7" {
+		t.Errorf("client CN in context = %q, want edge-node-7", body)
+	}
+
+	if resp, err := clientWith(untrusted).Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("untrusted client cert was accepted")
+	}
+	if resp, err := clientWith(nil).Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("request without a client cert was accepted")
+	}
+}
+
+func TestLoadTLSConfigWithoutClientCA(t *testing.T) {
+	certFile, keyFile := newTestCert(t, "127.0.0.1", false, nil).writePEM(t, "server")
+	cfg, err := loadTLSConfig(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("loadTLSConfig: %v", err)
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Errorf("ClientAuth = %v without a client CA, want NoClientCert", cfg.ClientAuth)
+	}
+	if _, err := loadTLSConfig(certFile, "", ""); err == nil {
+		t.Error("cert without key accepted")
+	}
+}
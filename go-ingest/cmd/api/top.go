@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// Leaderboard limits.
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 100
+)
+
+// handleTopLocations ranks locations by the latest value of one metric, e.g.
+// the worst air quality right now with metric=pm25&order=desc.
+func (s *APIServer) handleTopLocations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	metric := q.Get("metric")
+	if metric == "" {
+		respondParamError(w, r, badParam("metric", "missing metric"))
+		return
+	}
+	if !store.IsMetricColumn(metric) {
+		respondParamError(w, r, badParam("metric", "unknown metric %q", metric))
+		return
+	}
+
+	order := q.Get("order")
+	switch order {
+	case "":
+		order = "desc"
+	case "asc", "desc":
+	default:
+		respondParamError(w, r, badParam("order", "must be asc or desc"))
+		return
+	}
+
+	limit := defaultTopLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTopLimit {
+			respondParamError(w, r, badParam("limit", "must be an integer between 1 and %d", maxTopLimit))
+			return
+		}
+		limit = n
+	}
+
+	ranked, err := s.store.GetTopLocationsByMetricContext(r.Context(), metric, order == "asc", limit)
+	if err != nil {
+		respondStoreError(w, r, err, "leaderboard")
+		return
+	}
+
+	respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"metric": metric,
+		"order":  order,
+		"count":  len(ranked),
+		"data":   ranked,
+	})
+}
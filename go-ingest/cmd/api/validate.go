@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query parameter limits shared by all handlers.
+const (
+	maxHours     = 2160 // 90 days
+	defaultTopK  = 5
+	maxTopK      = 50
+	maxQueryTopK = 20 // every retrieved snapshot goes into the LLM prompt
+)
+
+// paramError is a client error tied to one request parameter. Handlers
+// return it as a 400 whose body names the parameter.
+type paramError struct {
+	Param       string
+	Message     string
+	Suggestions []string
+}
+
+func (e *paramError) Error() string {
+	return e.Param + ": " + e.Message
+}
+
+func badParam(param, format string, args ...interface{}) error {
+	return &paramError{Param: param, Message: fmt.Sprintf(format, args...)}
+}
+
+// respondParamError writes a 400 for a paramError (or any other validation
+// error) using the standard envelope, with "param" naming the parameter.
+func respondParamError(w http.ResponseWriter, r *http.Request, err error) {
+	body := newAPIError(r, codeInvalidParameter, err.Error())
+	var pe *paramError
+	if errors.As(err, &pe) {
+		body.Message = pe.Message
+		body.Param = pe.Param
+		body.Suggestions = pe.Suggestions
+	}
+	respondJSON(w, r, http.StatusBadRequest, map[string]apiError{"error": body})
+}
+
+// requireLocation returns the location parameter, or def when it is absent
+// (EDGESIGHT_DEFAULT_LOCATION). With no default configured the parameter is
+// required; callers discover valid values through /api/v1/locations.
+func requireLocation(q url.Values, def string) (string, error) {
+	location := q.Get("location")
+	if location == "" {
+		location = def
+	}
+	if location == "" {
+		return "", badParam("location", "missing location; see /api/v1/locations for available locations")
+	}
+	return location, nil
+}
+
+// parseHours reads an hours lookback in [1, maxHours], defaulting to def.
+func parseHours(q url.Values, def int) (int, error) {
+	v := q.Get("hours")
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, badParam("hours", "must be an integer")
+	}
+	if n < 1 || n > maxHours {
+		return 0, badParam("hours", "must be between 1 and %d", maxHours)
+	}
+	return n, nil
+}
+
+// parseTopK reads top_k (or its alias k) in [1, maxTopK], defaulting to def.
+func parseTopK(q url.Values, def int) (int, error) {
+	param := topKParam(q)
+	v := q.Get(param)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, badParam(param, "must be an integer")
+	}
+	if n < 1 || n > maxTopK {
+		return 0, badParam(param, "must be between 1 and %d", maxTopK)
+	}
+	return n, nil
+}
+
+// topKParam returns the name the request uses for top-K: top_k, or k when
+// only the alias is given.
+func topKParam(q url.Values) string {
+	if !q.Has("top_k") && q.Has("k") {
+		return "k"
+	}
+	return "top_k"
+}
+
+// validateTopK checks top_k is in [1, limit] for callers that decode it
+// themselves.
+func validateTopK(n, limit int) error {
+	if n < 1 || n > limit {
+		return badParam("top_k", "must be between 1 and %d", limit)
+	}
+	return nil
+}
+
+// parseMinScore reads min_score, the lowest score a search result may have,
+// defaulting to 0.
+func parseMinScore(q url.Values) (float64, error) {
+	v := q.Get("min_score")
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, badParam("min_score", "must be a number")
+	}
+	return f, validateMinScore(f)
+}
+
+// validateMinScore checks min_score for callers that decode it themselves.
+func validateMinScore(f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return badParam("min_score", "must be a finite number")
+	}
+	return nil
+}
+
+// parseRFC3339 parses a timestamp parameter.
+func parseRFC3339(param, v string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, badParam(param, "invalid time format; use RFC3339 (e.g. 2025-12-08T00:00:00Z)")
+	}
+	return t, nil
+}
+
+// validateRange checks that end is not before start (an instant, start ==
+// end, is allowed) and that the span does not exceed maxSpan (0 disables the
+// span check). Zero times are open ends and skip the checks that need them.
+func validateRange(start, end time.Time, maxSpan time.Duration) error {
+	if start.IsZero() || end.IsZero() {
+		return nil
+	}
+	if end.Before(start) {
+		return badParam("end", "must not be before start")
+	}
+	if maxSpan > 0 && end.Sub(start) > maxSpan {
+		return badParam("end", "range must not exceed %s", formatSpan(maxSpan))
+	}
+	return nil
+}
+
+// parseTimeRange reads start/end. When both are absent it returns the
+// defaultSpan ending now; when required is true both must be given.
+func parseTimeRange(q url.Values, required bool, defaultSpan, maxSpan time.Duration) (time.Time, time.Time, error) {
+	startStr, endStr := q.Get("start"), q.Get("end")
+	if startStr == "" && endStr == "" && !required {
+		end := time.Now().UTC()
+		return end.Add(-defaultSpan), end, nil
+	}
+	if startStr == "" {
+		return time.Time{}, time.Time{}, badParam("start", "missing start")
+	}
+	if endStr == "" {
+		return time.Time{}, time.Time{}, badParam("end", "missing end")
+	}
+
+	start, err := parseRFC3339("start", startStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseRFC3339("end", endStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if err := validateRange(start, end, maxSpan); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// formatSpan renders a duration in days when it is a whole number of days.
+func formatSpan(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	}
+	return d.String()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func TestParameterValidation(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{MaxRangeSpan: 90 * 24 * time.Hour})
+	h := s.Router()
+
+	tests := []struct {
+		name  string
+		path  string
+		param string // "" means the request must not fail validation
+	}{
+		{"hours at cap", "/api/v1/snapshots?location=Denver&hours=2160", ""},
+		{"hours over cap", "/api/v1/snapshots?location=Denver&hours=999999", "hours"},
+		{"hours not a number", "/api/v1/snapshots?location=Denver&hours=abc", "hours"},
+		{"missing location", "/api/v1/snapshots?hours=24", "location"},
+		{"missing location on latest", "/api/v1/snapshots/latest", "location"},
+		{"range ok", "/api/v1/snapshots/range?location=Denver&start=2026-01-01T00:00:00Z&end=2026-01-02T00:00:00Z", ""},
+		{"range end before start", "/api/v1/snapshots/range?location=Denver&start=2026-01-02T00:00:00Z&end=2026-01-01T00:00:00Z", "end"},
+		{"range too long", "/api/v1/snapshots/range?location=Denver&start=2026-01-01T00:00:00Z&end=2026-06-01T00:00:00Z", "end"},
+		{"range bad time", "/api/v1/snapshots/range?location=Denver&start=yesterday&end=2026-01-01T00:00:00Z", "start"},
+		{"search top_k zero", "/api/v1/search?q=smog&top_k=0", "top_k"},
+		{"search top_k over cap", "/api/v1/search?q=smog&top_k=51", "top_k"},
+		{"search k alias over cap", "/api/v1/search?q=smog&k=51", "k"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if tt.param == "" {
+				if body.Error.Code == codeInvalidParameter {
+					t.Errorf("rejected %s: %s", body.Error.Param, body.Error.Message)
+				}
+				return
+			}
+			if rec.Code != http.StatusBadRequest || body.Error.Code != codeInvalidParameter || body.Error.Param != tt.param {
+				t.Errorf("got %d %+v, want 400 invalid_parameter naming %q", rec.Code, body.Error, tt.param)
+			}
+		})
+	}
+}
+
+func TestValidateRange(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		end     time.Time
+		maxSpan time.Duration
+		want    string // expected message, "" for valid
+	}{
+		{"instant", start, 90 * day, ""},
+		{"at the cap", start.Add(90 * day), 90 * day, ""},
+		{"reversed", start.Add(-time.Second), 90 * day, "must not be before start"},
+		{"one second over", start.Add(90*day + time.Second), 90 * day, "range must not exceed 90 days"},
+		{"custom cap", start.Add(8 * day), 7 * day, "range must not exceed 7 days"},
+		{"sub-day cap", start.Add(2 * time.Hour), 90 * time.Minute, "range must not exceed 1h30m0s"},
+		{"no cap", start.AddDate(5, 0, 0), 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRange(start, tt.end, tt.maxSpan)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validateRange = %v, want nil", err)
+				}
+				return
+			}
+			pe, ok := err.(*paramError)
+			if !ok || pe.Param != "end" || pe.Message != tt.want {
+				t.Errorf("validateRange = %#v, want end: %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxRangeSpanFromEnv(t *testing.T) {
+	t.Setenv("EDGESIGHT_MAX_RANGE", "")
+	if got := loadConfig().MaxRangeSpan; got != 90*24*time.Hour {
+		t.Errorf("default MaxRangeSpan = %s, want 90 days", got)
+	}
+	t.Setenv("EDGESIGHT_MAX_RANGE", "168h")
+	if got := loadConfig().MaxRangeSpan; got != 7*24*time.Hour {
+		t.Errorf("EDGESIGHT_MAX_RANGE=168h gave %s, want 7 days", got)
+	}
+}
+
+func TestDefaultLocation(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{DefaultLocation: "Denver"})
+	for _, loc := range []string{"Denver", "Boston"} {
+		ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+		if loc == "Boston" {
+			ts = ts.Add(time.Minute)
+		}
+		if err := s.store.InsertSnapshot(models.Snapshot{Location: loc, Timestamp: ts}); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+	h := s.Router()
+
+	for path, want := range map[string]string{
+		"/api/v1/snapshots/latest":                 "Denver",
+		"/api/v1/snapshots/latest?location=Boston": "Boston",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var snap models.Snapshot
+		json.Unmarshal(rec.Body.Bytes(), &snap)
+		if rec.Code != http.StatusOK || snap.Location != want {
+			t.Errorf("%s = %d for %q, want 200 for %s", path, rec.Code, snap.Location, want)
+		}
+	}
+
+	t.Setenv("EDGESIGHT_DEFAULT_LOCATION", " Denver ")
+	if got := loadConfig().DefaultLocation; got != "Denver" {
+		t.Errorf("EDGESIGHT_DEFAULT_LOCATION gave %q, want Denver", got)
+	}
+}
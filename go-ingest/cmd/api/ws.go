@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+// newWSUpgrader returns an upgrader that accepts handshakes from the same
+// origins as the CORS layer. Requests without an Origin header come from
+// non-browser clients and are accepted.
+func newWSUpgrader(origins originSet) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || origins.allows(origin)
+		},
+	}
+}
+
+// wsClientMessage lets a connected client change its subscription, e.g.
+// {"action": "subscribe", "location": "Los Angeles"}.
+type wsClientMessage struct {
+	Action   string `json:"action"`
+	Location string `json:"location"`
+}
+
+// handleWebSocket streams newly inserted snapshots for the requested location
+// to the client as JSON text frames.
+func (s *APIServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	location, err := requireLocation(r.URL.Query(), s.cfg.DefaultLocation)
+	if err != nil {
+		respondParamError(w, r, err)
+		return
+	}
+
+	conn, err := s.ws.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an HTTP error response.
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	subCh := make(chan string, 1)
+	done := make(chan struct{})
+	go wsReadLoop(conn, subCh, done)
+
+	sub := s.hub.Subscribe(location)
+	defer func() { sub.Close() }()
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case loc := <-subCh:
+			sub.Close()
+			sub = s.hub.Subscribe(loc)
+		case snap, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(snap); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsReadLoop handles pongs and subscription changes, and signals done when
+// the client goes away.
+func wsReadLoop(conn *websocket.Conn, subCh chan<- string, done chan<- struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(4096)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var msg wsClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Action == "subscribe" && msg.Location != "" {
+			select {
+			case subCh <- msg.Location:
+			default:
+			}
+		}
+	}
+}
+
+// watchSnapshots publishes snapshots written by other processes (the ingest
+// binary) to WebSocket subscribers by polling for rows inserted since the
+// last one seen. The cursor follows insertion order, not ts, so backfilled
+// and late rows are pushed too.
+func (s *APIServer) watchSnapshots(ctx context.Context, interval time.Duration) {
+	// Start after the rows already stored; retried on each tick on failure
+	last, err := s.store.LastSnapshotSeqContext(ctx)
+	started := err == nil
+	if err != nil {
+		log.Printf("Snapshot watcher error: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !started {
+			seq, err := s.store.LastSnapshotSeqContext(ctx)
+			if err != nil {
+				log.Printf("Snapshot watcher error: %v", err)
+				continue
+			}
+			last, started = seq, true
+			continue
+		}
+
+		snaps, seq, err := s.store.GetSnapshotsInsertedAfterContext(ctx, last)
+		if err != nil {
+			log.Printf("Snapshot watcher error: %v", err)
+			continue
+		}
+		for _, snap := range snaps {
+			s.hub.Publish(snap)
+		}
+		last = seq
+	}
+}
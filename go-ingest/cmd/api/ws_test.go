@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pubsub"
+	"github.com/gorilla/websocket"
+)
+
+func newWSTestServer(origins ...string) (*APIServer, *httptest.Server) {
+	s := &APIServer{
+		hub: pubsub.NewHub(),
+		ws:  newWSUpgrader(newOriginSet(origins)),
+	}
+	return s, httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+}
+
+func wsURL(srv *httptest.Server, location string) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/?location=" + location
+}
+
+func TestWebSocketPushesPublishedSnapshot(t *testing.T) {
+	s, srv := newWSTestServer()
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, "Denver"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.hub.SubscriberCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscriber never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ts := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	s.hub.Publish(models.Snapshot{Location: "Boston", Timestamp: ts})
+	s.hub.Publish(models.Snapshot{Location: "Denver", Timestamp: ts})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var got models.Snapshot
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.Location != "Denver" || !got.Timestamp.Equal(ts) {
+		t.Errorf("got snapshot %s at %s, want Denver at %s", got.Location, got.Timestamp, ts)
+	}
+}
+
+func TestWebSocketChecksOrigin(t *testing.T) {
+	_, srv := newWSTestServer("https://dash.example.com")
+	defer srv.Close()
+
+	header := http.Header{"Origin": {"https://evil.example.com"}}
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL(srv, "Denver"), header)
+	if err == nil {
+		t.Fatal("handshake from a disallowed origin succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("disallowed origin: got response %v, want 403", resp)
+	}
+
+	header = http.Header{"Origin": {"https://dash.example.com"}}
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, "Denver"), header)
+	if err != nil {
+		t.Fatalf("handshake from an allowed origin: %v", err)
+	}
+	conn.Close()
+}
+
+func TestWatchSnapshotsPushesLateRows(t *testing.T) {
+	s := newTestAPIServer(t, nil, apiConfig{})
+	base := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	insert := func(location string, ts time.Time) {
+		t.Helper()
+		if err := s.store.InsertSnapshot(models.Snapshot{Location: location, Timestamp: ts}); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+	insert("Denver", base) // stored before the watcher starts: not pushed
+
+	sub := s.hub.Subscribe("")
+	defer sub.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.watchSnapshots(ctx, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	// Newer, then older rows: another location's backfill and a late row
+	// for the same location
+	want := []models.Snapshot{
+		{Location: "Denver", Timestamp: base.Add(time.Hour)},
+		{Location: "Boston", Timestamp: base.Add(-3 * time.Hour)},
+		{Location: "Denver", Timestamp: base.Add(-time.Hour)},
+	}
+	for _, snap := range want {
+		insert(snap.Location, snap.Timestamp)
+	}
+
+	for i, w := range want {
+		select {
+		case got := <-sub.C:
+			if got.Location != w.Location || !got.Timestamp.Equal(w.Timestamp) {
+				t.Errorf("push %d = %s at %s, want %s at %s", i, got.Location, got.Timestamp, w.Location, w.Timestamp)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("push %d (%s at %s) never arrived", i, w.Location, w.Timestamp)
+		}
+	}
+	select {
+	case got := <-sub.C:
+		t.Errorf("unexpected push of %s at %s", got.Location, got.Timestamp)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/semantic"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+	"github.com/joho/godotenv"
+)
+
+// backfill embeds snapshots that were collected before the embedding sidecar
+// was available. Only snapshots without an embedding row are selected, so an
+// interrupted run can simply be restarted.
+func main() {
+	_ = godotenv.Load()
+
+	defaultDB := os.Getenv("EDGESIGHT_DB_PATH")
+	if defaultDB == "" {
+		defaultDB = "edgesight.db"
+	}
+	defaultEndpoint := os.Getenv("EMBEDDING_ENDPOINT")
+	if defaultEndpoint == "" {
+		defaultEndpoint = "http://localhost:9000"
+	}
+
+	dbPath := flag.String("db", defaultDB, "SQLite database path")
+	endpoint := flag.String("endpoint", defaultEndpoint, "embedding sidecar base URL")
+	location := flag.String("location", "", "only backfill this location (default: all)")
+	sinceStr := flag.String("since", "", "only backfill snapshots at or after this RFC3339 time")
+	batchSize := flag.Int("batch", 32, "texts per embedding request")
+	flag.Parse()
+
+	var since time.Time
+	if *sinceStr != "" {
+		t, err := time.Parse(time.RFC3339, *sinceStr)
+		if err != nil {
+			log.Fatalf("Invalid --since (use RFC3339): %v", err)
+		}
+		since = t
+	}
+	if *batchSize <= 0 {
+		*batchSize = 1
+	}
+	summarizer, err := semantic.SummarizerFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", semantic.SectionsEnv, err)
+	}
+
+	db, err := store.NewSQLiteStore(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	snaps, err := db.GetSnapshotsWithoutEmbedding(*location, since)
+	if err != nil {
+		log.Fatalf("Failed to list snapshots: %v", err)
+	}
+	if len(snaps) == 0 {
+		log.Printf("Nothing to backfill")
+		return
+	}
+	log.Printf("Backfilling embeddings for %d snapshots", len(snaps))
+
+	embedCli := embeddings.NewClient(*endpoint)
+	done := 0
+	for start := 0; start < len(snaps); start += *batchSize {
+		end := start + *batchSize
+		if end > len(snaps) {
+			end = len(snaps)
+		}
+		batch := snaps[start:end]
+
+		summaries := make([]string, len(batch))
+		for i, snap := range batch {
+			summaries[i] = summarizer.Summarize(snap)
+		}
+
+		vecs, err := embedCli.EmbedBatch(summaries)
+		if err != nil {
+			log.Fatalf("Embedding error after %d/%d snapshots (re-run to resume): %v", done, len(snaps), err)
+		}
+
+		for i, snap := range batch {
+			e := store.SnapshotEmbedding{
+				SnapshotTS: snap.Timestamp.Format(time.RFC3339),
+				Location:   snap.Location,
+				Summary:    summaries[i],
+				Embedding:  vecs[i],
+				CreatedAt:  time.Now().UTC(),
+			}
+			if err := db.InsertEmbedding(e); err != nil {
+				log.Fatalf("Insert embedding error after %d/%d snapshots (re-run to resume): %v", done, len(snaps), err)
+			}
+			done++
+		}
+		log.Printf("Embedded %d/%d snapshots", done, len(snaps))
+	}
+
+	log.Printf("Backfill complete")
+}
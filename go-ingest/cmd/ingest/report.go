@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/pipeline"
+)
+
+// dryRunOutput is what --dry-run prints instead of writing to the database.
+// The snapshot is emitted with its canonical JSON tags, as the API serves it.
+type dryRunOutput struct {
+	Snapshot models.Snapshot         `json:"snapshot"`
+	Summary  string                  `json:"summary"`
+	Sources  []pipeline.SourceResult `json:"sources"`
+}
+
+// printDryRun pretty-prints the snapshot, its summary and the source report.
+func printDryRun(w io.Writer, res *pipeline.Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dryRunOutput{
+		Snapshot: res.Snapshot,
+		Summary:  res.Summary,
+		Sources:  res.Sources,
+	})
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"log"
 	"math/rand"
 	"time"
 
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -17,29 +20,67 @@ func main() {
 	baseHum := flag.Float64("hum", 45.0, "base humidity %")
 	basePM := flag.Float64("pm25", 12.0, "base PM2.5")
 	basePower := flag.Float64("power", 1200.0, "base power W")
+	asJSON := flag.Bool("json", false, `publish {"value", "unit", "device"} JSON instead of bare numbers`)
+	devices := flag.Int("devices", 1, "number of simulated devices (with -json)")
+	clientID := flag.String("client-id", "edgesight-sim", "MQTT client ID")
+	username := flag.String("username", "", "broker username")
+	password := flag.String("password", "", "broker password")
+	caFile := flag.String("ca", "", "CA certificate (PEM) for an ssl:// broker")
+	certFile := flag.String("cert", "", "client certificate (PEM)")
+	keyFile := flag.String("key", "", "client key (PEM)")
+	qos := flag.Uint("qos", 1, "publish QoS (0, 1 or 2)")
+	clean := flag.Bool("clean-session", true, "start a clean session")
 	flag.Parse()
 
-	opts := mqtt.NewClientOptions().AddBroker(*broker).SetClientID("edgesight-sim")
+	if *qos > 2 {
+		log.Fatalf("-qos %d out of range 0-2", *qos)
+	}
+	opts := mqtt.NewClientOptions().AddBroker(*broker).SetClientID(*clientID).SetCleanSession(*clean)
+	if *username != "" {
+		opts.SetUsername(*username)
+		opts.SetPassword(*password)
+	}
+	tlsCfg, err := clients.LoadMQTTTLSConfig(*caFile, *certFile, *keyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if tlsCfg != nil {
+		opts.SetTLSConfig(tlsCfg)
+	}
 	cli := mqtt.NewClient(opts)
 	if token := cli.Connect(); token.Wait() && token.Error() != nil {
-		panic(token.Error())
+		log.Fatal(clients.MQTTConnectError(*broker, token.Error()))
 	}
 	defer cli.Disconnect(50)
 
 	rand.Seed(time.Now().UnixNano())
 
+	if !*asJSON {
+		*devices = 1
+	}
 	for {
-		publish(cli, "sensors/temperature", jitter(*baseTemp, *noise))
-		publish(cli, "sensors/humidity", jitter(*baseHum, *noise))
-		publish(cli, "sensors/pm25", jitter(*basePM, *noise))
-		publish(cli, "sensors/power", jitter(*basePower, *noise))
+		for d := 1; d <= *devices; d++ {
+			device := ""
+			if *asJSON {
+				device = fmt.Sprintf("sim-%d", d)
+			}
+			publish(cli, byte(*qos), "sensors/temperature", jitter(*baseTemp, *noise), "C", device)
+			publish(cli, byte(*qos), "sensors/humidity", jitter(*baseHum, *noise), "%", device)
+			publish(cli, byte(*qos), "sensors/pm25", jitter(*basePM, *noise), "ug/m3", device)
+			publish(cli, byte(*qos), "sensors/power", jitter(*basePower, *noise), "W", device)
+		}
 		time.Sleep(*interval)
 	}
 }
 
-func publish(cli mqtt.Client, topic string, val float64) {
+// publish sends val as a bare number, or as JSON when device is set.
+func publish(cli mqtt.Client, qos byte, topic string, val float64, unit, device string) {
 	payload := fmt.Sprintf("%.3f", val)
-	cli.Publish(topic, 1, false, payload)
+	if device != "" {
+		b, _ := json.Marshal(map[string]any{"value": val, "unit": unit, "device": device})
+		payload = string(b)
+	}
+	cli.Publish(topic, qos, false, payload)
 }
 
 func jitter(base, noise float64) float64 {
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+	"github.com/joho/godotenv"
+)
+
+// verify-embeddings checks every stored embedding for vectors search cannot
+// use (undecodable JSON, NaN/Inf components, zero norm, or a length other
+// than the majority's) and reports them by problem. With -reembed the
+// flagged rows are embedded again from their stored summaries. It exits 1
+// when unusable rows remain.
+func main() {
+	_ = godotenv.Load()
+
+	defaultDB := os.Getenv("EDGESIGHT_DB_PATH")
+	if defaultDB == "" {
+		defaultDB = "edgesight.db"
+	}
+	defaultEndpoint := os.Getenv("EMBEDDING_ENDPOINT")
+	if defaultEndpoint == "" {
+		defaultEndpoint = "http://localhost:9000"
+	}
+
+	dbPath := flag.String("db", defaultDB, "SQLite database path")
+	endpoint := flag.String("endpoint", defaultEndpoint, "embedding sidecar base URL")
+	location := flag.String("location", "", "only verify this location (default: all)")
+	reembed := flag.Bool("reembed", false, "re-embed unusable rows from their stored summaries")
+	batchSize := flag.Int("batch", 32, "texts per embedding request")
+	verbose := flag.Bool("v", false, "log each unusable row")
+	flag.Parse()
+	if *batchSize <= 0 {
+		*batchSize = 1
+	}
+
+	db, err := store.NewSQLiteStore(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	health, err := db.ListEmbeddingHealth(*location)
+	if err != nil {
+		log.Fatalf("Failed to scan embeddings: %v", err)
+	}
+
+	var bad []store.EmbeddingHealth
+	byProblem := make(map[string]int)
+	for _, h := range health {
+		if h.Valid() {
+			continue
+		}
+		bad = append(bad, h)
+		byProblem[h.Problem]++
+		if *verbose {
+			log.Printf("Row %d (%s %s): %s", h.ID, h.Location, h.SnapshotTS, h.Problem)
+		}
+	}
+	log.Printf("Checked %d embeddings: %d usable, %d unusable", len(health), len(health)-len(bad), len(bad))
+	problems := make([]string, 0, len(byProblem))
+	for p := range byProblem {
+		problems = append(problems, p)
+	}
+	sort.Strings(problems)
+	for _, p := range problems {
+		log.Printf("  %s: %d", p, byProblem[p])
+	}
+
+	if len(bad) == 0 {
+		return
+	}
+	if !*reembed {
+		log.Printf("Re-run with -reembed to regenerate them")
+		os.Exit(1)
+	}
+
+	embedCli := embeddings.NewClient(*endpoint)
+	done := 0
+	for start := 0; start < len(bad); start += *batchSize {
+		end := min(start+*batchSize, len(bad))
+		batch := bad[start:end]
+
+		summaries := make([]string, len(batch))
+		for i, h := range batch {
+			summaries[i] = h.Summary
+		}
+		vecs, err := embedCli.EmbedBatch(summaries)
+		if err != nil {
+			log.Fatalf("Embedding error after %d/%d rows (re-run to resume): %v", done, len(bad), err)
+		}
+
+		embs := make([]store.SnapshotEmbedding, len(batch))
+		for i, h := range batch {
+			embs[i] = store.SnapshotEmbedding{
+				SnapshotTS: h.SnapshotTS,
+				Location:   h.Location,
+				Summary:    h.Summary,
+				Embedding:  vecs[i],
+				CreatedAt:  time.Now().UTC(),
+			}
+		}
+		if err := db.ReplaceEmbeddings(embs); err != nil {
+			log.Fatalf("Replace embeddings error after %d/%d rows (re-run to resume): %v", done, len(bad), err)
+		}
+		done += len(batch)
+		log.Printf("Re-embedded %d/%d rows", done, len(bad))
+	}
+	log.Printf("Re-embed complete")
+}
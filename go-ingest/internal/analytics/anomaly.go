@@ -0,0 +1,47 @@
+package analytics
+
+import "math"
+
+// Anomaly is a point's rolling z-score and whether it crossed the threshold.
+// Z is nil for points without a full trailing window or whose window is
+// constant.
+type Anomaly struct {
+	Z       *float64
+	Anomaly bool
+}
+
+// RollingZScores scores each value against the mean and standard deviation
+// of the window values before it, so a spike does not inflate its own
+// baseline. A point is anomalous when |z| exceeds threshold.
+func RollingZScores(values []float64, window int, threshold float64) []Anomaly {
+	out := make([]Anomaly, len(values))
+	if window < 2 {
+		return out
+	}
+
+	for i := window; i < len(values); i++ {
+		// Two passes per window rather than running sums: metrics like
+		// nasdaq_index have large values and small variance, where
+		// sum-of-squares cancels badly.
+		prev := values[i-window : i]
+		var mean float64
+		for _, v := range prev {
+			mean += v
+		}
+		mean /= float64(window)
+
+		var variance float64
+		for _, v := range prev {
+			d := v - mean
+			variance += d * d
+		}
+		if variance == 0 {
+			continue
+		}
+		sd := math.Sqrt(variance / float64(window))
+
+		z := (values[i] - mean) / sd
+		out[i] = Anomaly{Z: &z, Anomaly: math.Abs(z) > threshold}
+	}
+	return out
+}
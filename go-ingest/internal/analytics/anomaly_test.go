@@ -0,0 +1,55 @@
+package analytics
+
+import "testing"
+
+// spikeSeries alternates between 10 and 11 with a single spike at index 25.
+func spikeSeries() []float64 {
+	values := make([]float64, 40)
+	for i := range values {
+		values[i] = 10 + float64(i%2)
+	}
+	values[25] = 30
+	return values
+}
+
+func TestRollingZScoresFlagsSpike(t *testing.T) {
+	scores := RollingZScores(spikeSeries(), 10, 3)
+
+	for i, s := range scores {
+		switch {
+		case i < 10:
+			if s.Z != nil || s.Anomaly {
+				t.Errorf("point %d has a score without a full window: %+v", i, s)
+			}
+		case i == 25:
+			if !s.Anomaly || s.Z == nil || *s.Z < 30 {
+				t.Errorf("spike scored %v (anomaly %v), want a large positive z", s.Z, s.Anomaly)
+			}
+		default:
+			if s.Anomaly {
+				t.Errorf("point %d flagged with z = %v", i, *s.Z)
+			}
+		}
+	}
+}
+
+func TestRollingZScoresEdgeCases(t *testing.T) {
+	constant := []float64{5, 5, 5, 5, 5, 9}
+	if s := RollingZScores(constant, 3, 3)[5]; s.Z != nil || s.Anomaly {
+		t.Errorf("point after a constant window = %+v, want no score", s)
+	}
+
+	if got := RollingZScores([]float64{1, 2, 3}, 1, 3); got[2].Z != nil {
+		t.Error("window of 1 produced scores")
+	}
+	if got := RollingZScores([]float64{1, 2}, 5, 3); len(got) != 2 || got[1].Z != nil {
+		t.Errorf("series shorter than the window = %+v, want unscored points", got)
+	}
+
+	// Large values with small variance must not lose precision.
+	big := []float64{15000.1, 15000.2, 15000.1, 15000.2, 15000.1, 15000.2, 15001.5}
+	s := RollingZScores(big, 6, 3)[6]
+	if s.Z == nil || !s.Anomaly || *s.Z < 20 || *s.Z > 30 {
+		t.Errorf("jump on a large baseline scored %v (anomaly %v), want about 25", s.Z, s.Anomaly)
+	}
+}
@@ -0,0 +1,93 @@
+// Package analytics computes cross-metric statistics over snapshot series.
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Point is one timestamped metric value.
+type Point struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Correlation is the Pearson coefficient for a pair of series over their
+// shared timestamps. R is nil when there are too few shared points or either
+// side is constant over them.
+type Correlation struct {
+	R       *float64
+	Samples int
+}
+
+// Pearson returns the Pearson correlation coefficient of two equal-length
+// samples. ok is false when there are fewer than two samples or either
+// sample has zero variance.
+func Pearson(x, y []float64) (r float64, ok bool) {
+	n := len(x)
+	if n < 2 || n != len(y) {
+		return 0, false
+	}
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+
+	r = cov / math.Sqrt(varX*varY)
+	// Guard against rounding pushing |r| just past 1
+	return math.Max(-1, math.Min(1, r)), true
+}
+
+// CorrelationMatrix aligns each pair of series on identical timestamps and
+// returns the symmetric matrix of coefficients. Pairs sharing fewer than
+// minSamples points get a nil R but still report their sample count.
+func CorrelationMatrix(series [][]Point, minSamples int) [][]Correlation {
+	indexed := make([]map[int64]float64, len(series))
+	for i, s := range series {
+		indexed[i] = make(map[int64]float64, len(s))
+		for _, p := range s {
+			indexed[i][p.Timestamp.UnixNano()] = p.Value
+		}
+	}
+
+	matrix := make([][]Correlation, len(series))
+	for i := range matrix {
+		matrix[i] = make([]Correlation, len(series))
+	}
+
+	for i := range series {
+		for j := i; j < len(series); j++ {
+			var x, y []float64
+			for _, p := range series[i] {
+				if v, ok := indexed[j][p.Timestamp.UnixNano()]; ok {
+					x = append(x, p.Value)
+					y = append(y, v)
+				}
+			}
+
+			c := Correlation{Samples: len(x)}
+			if len(x) >= minSamples {
+				if r, ok := Pearson(x, y); ok {
+					c.R = &r
+				}
+			}
+			matrix[i][j], matrix[j][i] = c, c
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,101 @@
+package canonicalizer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Aggregator combines the readings of several sensors reporting the same
+// parameter into one value. It is never called with an empty slice.
+type Aggregator func(values []float64) float64
+
+// Options tunes how BuildSnapshot combines its inputs. The zero value uses
+// the defaults.
+type Options struct {
+	// AQAggregate combines OpenAQ sensors at one location that report the
+	// same canonical parameter. Nil means Mean.
+	AQAggregate Aggregator
+	// AQStaleAfter is the oldest OpenAQ reading used, measured back from the
+	// snapshot time. Zero means DefaultAQStaleAfter; negative disables the
+	// check.
+	AQStaleAfter time.Duration
+	// MQTTStaleAfter is the oldest cached MQTT value used. Zero means
+	// DefaultMQTTStaleAfter; negative disables the check.
+	MQTTStaleAfter time.Duration
+}
+
+// Default staleness limits used when Options leaves them unset.
+const (
+	DefaultAQStaleAfter   = 3 * time.Hour
+	DefaultMQTTStaleAfter = 10 * time.Minute
+)
+
+// aqAggregate returns o.AQAggregate, or Mean when it is unset.
+func (o Options) aqAggregate() Aggregator {
+	if o.AQAggregate == nil {
+		return Mean
+	}
+	return o.AQAggregate
+}
+
+// aqStaleAfter returns the OpenAQ age limit in effect, 0 when disabled.
+func (o Options) aqStaleAfter() time.Duration {
+	return staleLimit(o.AQStaleAfter, DefaultAQStaleAfter)
+}
+
+// mqttStaleAfter returns the MQTT age limit in effect, 0 when disabled.
+func (o Options) mqttStaleAfter() time.Duration {
+	return staleLimit(o.MQTTStaleAfter, DefaultMQTTStaleAfter)
+}
+
+func staleLimit(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	}
+	return d
+}
+
+// Mean returns the arithmetic mean of values.
+func Mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Median returns the middle value, or the mean of the two middle values for
+// an even count.
+func Median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Max returns the largest value.
+func Max(values []float64) float64 {
+	return slices.Max(values)
+}
+
+// ParseAggregator maps "mean", "median" or "max" to its Aggregator. An empty
+// name means Mean.
+func ParseAggregator(name string) (Aggregator, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "mean":
+		return Mean, nil
+	case "median":
+		return Median, nil
+	case "max":
+		return Max, nil
+	}
+	return nil, fmt.Errorf("unknown aggregation %q (valid: mean, median, max)", name)
+}
@@ -0,0 +1,58 @@
+package canonicalizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+)
+
+func TestBuildSnapshotAggregatesThreePM25Sensors(t *testing.T) {
+	at := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	sensors := &clients.SensorsResponse{}
+	for i, v := range []float64{8, 30, 10} {
+		sensors.Results = append(sensors.Results, clients.Sensor{
+			ID:        100 + i,
+			Parameter: clients.Parameter{Name: "pm25", Units: "µg/m³", DisplayName: "PM2.5"},
+			Latest:    clients.SensorReading{Value: v, Datetime: clients.DatetimeInfo{UTC: at}},
+		})
+	}
+
+	tests := []struct {
+		name string
+		want float64
+	}{
+		{"", 16}, // zero Options means Mean
+		{"mean", 16},
+		{"median", 10},
+		{"max", 30},
+	}
+	for _, tt := range tests {
+		agg, err := ParseAggregator(tt.name)
+		if err != nil {
+			t.Fatalf("ParseAggregator(%q): %v", tt.name, err)
+		}
+		opts := Options{AQAggregate: agg}
+		if tt.name == "" {
+			opts = Options{}
+		}
+		env := BuildSnapshot("Denver", Inputs{Sensors: sensors}, opts).Environment
+		if env.PM25 != tt.want {
+			t.Errorf("%q: PM2.5 = %v, want %v", tt.name, env.PM25, tt.want)
+		}
+	}
+
+	if _, err := ParseAggregator("mode"); err == nil {
+		t.Error(`ParseAggregator("mode"): want an error`)
+	}
+}
+
+func TestMedianEvenCount(t *testing.T) {
+	values := []float64{4, 1, 3, 2}
+	if got := Median(values); got != 2.5 {
+		t.Errorf("Median(%v) = %v, want 2.5", values, got)
+	}
+	if values[0] != 4 {
+		t.Errorf("Median reordered its input: %v", values)
+	}
+}
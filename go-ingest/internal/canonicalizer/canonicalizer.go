@@ -1,172 +1,386 @@
 package canonicalizer
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
 	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
 )
 
+// PrecipForecastWindow is how far ahead Agriculture.PrecipForecast sums
+// forecast precipitation.
+const PrecipForecastWindow = 72 * time.Hour
+
+// Inputs are the source responses BuildSnapshot combines. A source that was
+// disabled or failed leaves its field nil.
+type Inputs struct {
+	Meteo      *clients.CurrentWeatherResponse
+	Sensors    *clients.SensorsResponse // OpenAQ
+	AirQuality *clients.AirQualityResponse
+	MQTT       *clients.MQTTSensorReading
+	Stock      *clients.GlobalQuote
+	NASDAQ     *clients.NASDAQMarketSummary
+	Ember      *clients.EmberElectricitySummary
+	Grid       *clients.GridStatus
+	EIA        *clients.EIAEnergySummary
+	NASS       *clients.NASSCropSummary
+	Forecast   []clients.ForecastPoint
+	Disasters  *clients.FEMASummary
+	Flu        *clients.CDCFluSummary
+	Movement   *clients.MovementSummary
+	Traffic    *clients.TrafficSummary
+	Flights    *clients.FlightSummary
+	Commodity  *clients.CommodityPrice
+	Macro      *clients.MacroIndicators
+	Bikes      *clients.BikeShareSummary
+}
+
 // BuildSnapshot unifies data from all sources into a single Snapshot.
 // Why this structure:
-// - OpenMeteo: current weather (temp, humidity, wind)
+// - OpenMeteo: current weather (temp, humidity, wind, precipitation, cloud cover, visibility)
 // - OpenAQ: sensors with latest readings (PM2.5, PM10, Ozone, etc.)
-// - AlphaVantage: stock price
+// - Open-Meteo air quality: modeled pollutants where OpenAQ has no reading
+// - AlphaVantage: stock price and commodity price
 // - NASDAQ: market composite index
+// - FRED: CPI, unemployment rate and 10-year Treasury yield
 // - Ember: carbon intensity and generation mix
 // - Grid: power grid status and load
 // - EIA: US energy generation and prices
 // - NASS: USDA crop production and prices
+// - OpenMeteo hourly forecast: precipitation outlook and soil moisture
 // - FEMA: disaster declarations
-// - CDC FluView: influenza surveillance
+// - CDC FluView: influenza surveillance; NREVSS: RSV lab results
 // - Movebank: animal migration/movement trends
-func BuildSnapshot(
-	location string,
-	meteo *clients.CurrentWeatherResponse,
-	sensors *clients.SensorsResponse,
-	mqttData *clients.MQTTSensorReading,
-	stockPrice float64,
-	nasdaq *clients.NASDAQMarketSummary,
-	ember *clients.EmberElectricitySummary,
-	grid *clients.GridStatus,
-	eia *clients.EIAEnergySummary,
-	nass *clients.NASSCropSummary,
-	disasters *clients.FEMASummary,
-	fluSummary *clients.CDCFluSummary,
-	movementSummary *clients.MovementSummary,
-) models.Snapshot {
-
+// - HERE: road traffic flow
+// - OpenSky: aircraft overhead
+// - CityBikes: bike-share availability
+//
+// Each section's ObservedAt is the newest time its sources reported for the
+// values used; MQTT values count as observed when they were received.
+func BuildSnapshot(location string, in Inputs, opts Options) models.Snapshot {
 	snap := models.Snapshot{
 		Timestamp: time.Now().UTC(),
 		Location:  location,
 	}
 
 	// --- Weather: from OpenMeteo current block ---
-	if meteo != nil {
-		snap.Weather.TemperatureC = meteo.Current.Temperature2m
-		snap.Weather.Humidity = meteo.Current.RelativeHumidity
-		snap.Weather.WindSpeedMS = meteo.Current.WindSpeed10m
-		// OpenMeteo doesn't provide precip in the "current" block by default,
-		// but you could extend it if needed
+	if in.Meteo != nil {
+		snap.Weather.TemperatureC = in.Meteo.Current.Temperature2m
+		snap.Weather.Humidity = in.Meteo.Current.RelativeHumidity
+		snap.Weather.WindSpeedMS = in.Meteo.Current.WindSpeed10m
+		snap.Weather.PrecipMM = in.Meteo.Current.Precipitation
+		snap.Weather.CloudCover = in.Meteo.Current.CloudCover
+		snap.Weather.Visibility = in.Meteo.Current.Visibility / 1000 // m -> km
+		observe(&snap.Weather.ObservedAt, parseOpenMeteoTime(in.Meteo.Current.Time))
 	}
 
 	// --- Environment: from OpenAQ sensors ---
 	// OpenAQ v3 returns a SensorsResponse with each sensor containing:
 	// - Parameter (DisplayName, Units, Name)
 	// - Latest (Value, Datetime)
-	if sensors != nil {
-		for _, sensor := range sensors.Results {
+	// Values are normalized to µg/m³ (particulates) / ppb (gases); the
+	// reported units are kept in Environment.RawUnits, and parameters whose
+	// units could not be converted are listed in UnconvertedUnits. Several
+	// sensors reporting the same parameter are combined with opts.AQAggregate.
+	measured := make(map[string]bool)
+	if in.Sensors != nil {
+		readings := make(map[string][]float64)
+		var order []string
+		maxAge := opts.aqStaleAfter()
+		for _, sensor := range in.Sensors.Results {
 			// Skip sensors with no recent data
-			if sensor.Latest.Datetime.Local == "" {
+			if !freshAQReading(sensor.Latest.Datetime, snap.Timestamp, maxAge) {
 				continue
 			}
 
 			paramName := normalizeAQParam(sensor.Parameter.Name)
-			switch paramName {
-			case "pm25":
-				snap.Environment.PM25 = sensor.Latest.Value
-			case "pm10":
-				snap.Environment.PM10 = sensor.Latest.Value
-			case "o3":
-				snap.Environment.Ozone = sensor.Latest.Value
+			if _, known := aqParams[paramName]; !known {
+				paramName = normalizeAQParam(sensor.Parameter.DisplayName)
+			}
+			field := aqField(&snap.Environment, paramName)
+			if field == nil {
+				continue
+			}
+			value, converted := normalizeAQValue(paramName, sensor.Latest.Value, sensor.Parameter.Units)
+			if _, seen := readings[paramName]; !seen {
+				order = append(order, paramName)
 			}
+			readings[paramName] = append(readings[paramName], value)
+			if t, err := time.Parse(time.RFC3339, sensor.Latest.Datetime.UTC); err == nil {
+				observe(&snap.Environment.ObservedAt, t)
+			}
+			if !converted && !slices.Contains(snap.Environment.UnconvertedUnits, paramName) {
+				snap.Environment.UnconvertedUnits = append(snap.Environment.UnconvertedUnits, paramName)
+			}
+			if sensor.Parameter.Units != "" {
+				if snap.Environment.RawUnits == nil {
+					snap.Environment.RawUnits = make(map[string]string)
+				}
+				snap.Environment.RawUnits[paramName] = sensor.Parameter.Units
+			}
+		}
+		aggregate := opts.aqAggregate()
+		for _, paramName := range order {
+			*aqField(&snap.Environment, paramName) = aggregate(readings[paramName])
+			measured[paramName] = true
 		}
 	}
 
 	// --- Environment: from MQTT simulated sensors (overrides if present) ---
-	if mqttData != nil {
-		if mqttData.PM25 > 0 {
-			snap.Environment.PM25 = mqttData.PM25
+	if in.MQTT != nil {
+		mqttMaxAge := opts.mqttStaleAfter()
+		if at, ok := mqttObservedAt(in.MQTT, clients.MQTTFieldPM25, snap.Timestamp, mqttMaxAge); ok && in.MQTT.PM25 > 0 {
+			snap.Environment.PM25 = in.MQTT.PM25
+			measured["pm25"] = true
+			observe(&snap.Environment.ObservedAt, at)
 		}
-		if mqttData.Temperature != 0 {
-			snap.Weather.TemperatureC = mqttData.Temperature
+		if at, ok := mqttObservedAt(in.MQTT, clients.MQTTFieldTemperature, snap.Timestamp, mqttMaxAge); ok && in.MQTT.Temperature != 0 {
+			snap.Weather.TemperatureC = in.MQTT.Temperature
+			observe(&snap.Weather.ObservedAt, at)
 		}
-		if mqttData.Humidity != 0 {
-			snap.Weather.Humidity = mqttData.Humidity
+		if at, ok := mqttObservedAt(in.MQTT, clients.MQTTFieldHumidity, snap.Timestamp, mqttMaxAge); ok && in.MQTT.Humidity != 0 {
+			snap.Weather.Humidity = in.MQTT.Humidity
+			observe(&snap.Weather.ObservedAt, at)
 		}
-		if mqttData.Power > 0 {
-			snap.Energy.GridLoad = mqttData.Power
+		if at, ok := mqttObservedAt(in.MQTT, clients.MQTTFieldPower, snap.Timestamp, mqttMaxAge); ok && in.MQTT.Power > 0 {
+			snap.Energy.GridLoad = in.MQTT.Power
+			observe(&snap.Energy.ObservedAt, at)
 		}
 	}
 
-	// --- Finance ---
-	snap.Finance.StockPrice = stockPrice
+	// --- Environment: Open-Meteo modeled values fill pollutants nothing
+	// measured; they are listed in Environment.Modeled ---
+	if in.AirQuality != nil {
+		before := len(snap.Environment.Modeled)
+		fillModeledAQ(&snap.Environment, in.AirQuality, measured)
+		if len(snap.Environment.Modeled) > before {
+			observe(&snap.Environment.ObservedAt, parseOpenMeteoTime(in.AirQuality.Current.Time))
+		}
+	}
+
+	// --- Finance: stock quote from AlphaVantage ---
+	if in.Stock != nil {
+		snap.Finance.StockPrice = in.Stock.Price
+		snap.Finance.StockSymbol = in.Stock.Symbol
+		observe(&snap.Finance.ObservedAt, in.Stock.LatestTradingDay)
+	}
+
+	// --- Finance: commodity from AlphaVantage ---
+	if in.Commodity != nil {
+		snap.Finance.CommodityPrice = in.Commodity.Value
+		snap.Finance.CommoditySymbol = in.Commodity.Symbol
+		observe(&snap.Finance.ObservedAt, in.Commodity.Date)
+	}
 
 	// --- Finance: from NASDAQ Data Link ---
-	if nasdaq != nil {
-		snap.Finance.NASDAQIndex = nasdaq.IndexValue
-		snap.Finance.VolumeTraded = nasdaq.VolumeTraded
+	if in.NASDAQ != nil {
+		snap.Finance.NASDAQIndex = in.NASDAQ.IndexValue
+		snap.Finance.VolumeTraded = in.NASDAQ.VolumeTraded
+		if t, err := time.Parse("2006-01-02", in.NASDAQ.BreadthAsOf); err == nil {
+			observe(&snap.Finance.ObservedAt, t)
+		}
+	}
+
+	// --- Finance: macro indicators from FRED ---
+	if in.Macro != nil {
+		snap.Finance.CPI = in.Macro.CPI
+		snap.Finance.UnemploymentRate = in.Macro.UnemploymentRate
+		snap.Finance.Treasury10Y = in.Macro.Treasury10Y
+		observe(&snap.Finance.ObservedAt, in.Macro.ObservedAt)
 	}
 
 	// --- Energy: from Ember Climate ---
-	if ember != nil {
-		snap.Energy.CarbonIntensity = ember.CarbonIntensityGCO2KWh
-		snap.Energy.RenewablePercent = ember.RenewablePercent
-		snap.Energy.GenerationMWh = ember.GenerationTWh * 1000 // Convert TWh to MWh
-		snap.Energy.CoalPercent = ember.CoalPercent
-		snap.Energy.GasPercent = ember.GasPercent
-		snap.Energy.NuclearPercent = ember.NuclearPercent
+	if in.Ember != nil {
+		snap.Energy.CarbonIntensity = in.Ember.CarbonIntensityGCO2KWh
+		snap.Energy.RenewablePercent = in.Ember.RenewablePercent
+		snap.Energy.GenerationMWh = in.Ember.GenerationTWh * 1000 // Convert TWh to MWh
+		snap.Energy.CoalPercent = in.Ember.CoalPercent
+		snap.Energy.GasPercent = in.Ember.GasPercent
+		snap.Energy.NuclearPercent = in.Ember.NuclearPercent
 	}
 
 	// --- Energy: from Grid monitoring ---
-	if grid != nil {
-		snap.Energy.GridLoad = grid.LoadMW
-		snap.Energy.GridUtilizationPercent = grid.UtilizationPercent
+	if in.Grid != nil {
+		snap.Energy.GridLoad = in.Grid.LoadMW
+		snap.Energy.GridUtilizationPercent = in.Grid.UtilizationPercent
 	}
 
 	// --- Energy: from EIA (US Energy Information Administration) ---
-	if eia != nil {
-		snap.Energy.GenerationMWh = eia.ElectricityGenerationMWh
-		snap.Energy.NaturalGasPriceMmbtu = eia.NaturalGasPriceMmbtu
-		// EIA can override Ember data if available
-		if eia.RenewableGenerationMWh > 0 && eia.ElectricityGenerationMWh > 0 {
-			snap.Energy.RenewablePercent = (eia.RenewableGenerationMWh / eia.ElectricityGenerationMWh) * 100
+	if in.EIA != nil {
+		snap.Energy.GenerationMWh = in.EIA.ElectricityGenerationMWh
+		snap.Energy.NaturalGasPriceMmbtu = in.EIA.NaturalGasPriceMmbtu
+		snap.Energy.ElectricityPriceUSD = in.EIA.RetailPriceKWh
+		// EIA can override Ember data if available, when both figures cover
+		// the same month
+		if in.EIA.RenewableGenerationMWh > 0 && in.EIA.ElectricityGenerationMWh > 0 && in.EIA.SourcedAt.Renewables.Equal(in.EIA.SourcedAt.Generation) {
+			snap.Energy.RenewablePercent = (in.EIA.RenewableGenerationMWh / in.EIA.ElectricityGenerationMWh) * 100
 		}
+		observe(&snap.Energy.ObservedAt, in.EIA.Period)
+		observe(&snap.Energy.ObservedAt, in.EIA.SourcedAt.NaturalGas)
+		observe(&snap.Energy.ObservedAt, in.EIA.SourcedAt.RetailPrice)
+	}
+
+	// --- Agriculture: precipitation outlook and root-zone soil moisture
+	// from the Open-Meteo hourly forecast ---
+	if len(in.Forecast) > 0 {
+		snap.Agriculture.PrecipForecast = clients.SumPrecipitation(in.Forecast, PrecipForecastWindow)
+		snap.Agriculture.SoilMoisture = in.Forecast[0].SoilMoisture * 100 // m³/m³ -> percent
+		observe(&snap.Agriculture.ObservedAt, in.Forecast[0].Time)
 	}
 
 	// --- Agriculture: from USDA NASS ---
-	if nass != nil {
-		snap.Agriculture.CropType = nass.CropType
-		snap.Agriculture.CropYield = nass.YieldPerAcre
-		snap.Agriculture.ProductionBushels = nass.ProductionBushels
-		snap.Agriculture.PricePerBushel = nass.PricePerBushel
-		snap.Agriculture.HarvestedAcres = nass.HarvestedAcres
+	if in.NASS != nil {
+		snap.Agriculture.CropType = in.NASS.CropType
+		snap.Agriculture.CropYield = in.NASS.YieldPerAcre
+		snap.Agriculture.ProductionBushels = in.NASS.ProductionBushels
+		snap.Agriculture.PricePerBushel = in.NASS.PricePerBushel
+		snap.Agriculture.HarvestedAcres = in.NASS.HarvestedAcres
+		if in.NASS.Year > 0 {
+			observe(&snap.Agriculture.ObservedAt, time.Date(in.NASS.Year, time.January, 1, 0, 0, 0, 0, time.UTC))
+		}
 	}
 
 	// --- Disasters: from FEMA static JSON ---
-	if disasters != nil {
-		snap.Disasters.ActiveDisasters = disasters.ActiveDisasters
-		snap.Disasters.DisasterType = disasters.TopIncidentType
-		snap.Disasters.Severity = disasters.Severity
-		snap.Disasters.AffectedCounties = disasters.AffectedCounties
+	if in.Disasters != nil {
+		snap.Disasters.ActiveDisasters = in.Disasters.ActiveDisasters
+		snap.Disasters.DisasterType = in.Disasters.TopIncidentType
+		snap.Disasters.Severity = in.Disasters.Severity
+		snap.Disasters.AffectedCounties = in.Disasters.AffectedCounties
+		for _, d := range in.Disasters.Recent {
+			observe(&snap.Disasters.ObservedAt, d.DeclarationDate)
+		}
 	}
 
 	// --- Health: from CDC FluView ---
-	if fluSummary != nil {
-		snap.Health.FluCases = fluSummary.FluCases
-		snap.Health.ILIPercent = fluSummary.UnweightedILI
-		snap.Health.HospitalAdmissions = fluSummary.HospitalAdmissions
+	if in.Flu != nil {
+		snap.Health.FluCases = in.Flu.FluCases
+		snap.Health.ILIPercent = in.Flu.UnweightedILI
+		snap.Health.HospitalAdmissions = in.Flu.HospitalAdmissions
+		snap.Health.RSVPercentPositive = in.Flu.RSVPercentPositive
+		snap.Health.RSVDetections = in.Flu.RSVDetections
+		snap.Health.RSVTests = in.Flu.RSVTests
+		observe(&snap.Health.ObservedAt, in.Flu.WeekEndDate)
+	}
+
+	// --- Mobility: road traffic flow from HERE ---
+	if in.Traffic != nil {
+		snap.Mobility.TrafficSpeedKmH = in.Traffic.AvgSpeedKmH
+		snap.Mobility.TrafficJamFactor = in.Traffic.JamFactor
+		observe(&snap.Mobility.ObservedAt, in.Traffic.ObservedAt)
+	}
+
+	// --- Mobility: airborne aircraft from OpenSky ---
+	if in.Flights != nil {
+		snap.Mobility.FlightCount = in.Flights.Airborne
+		snap.Mobility.AvgAltitudeM = in.Flights.AvgAltitudeM
+		observe(&snap.Mobility.ObservedAt, in.Flights.ObservedAt)
+	}
+
+	// --- Mobility: bike-share availability from CityBikes ---
+	if in.Bikes != nil {
+		snap.Mobility.BikeShareBikesAvailable = in.Bikes.BikesAvailable
+		snap.Mobility.BikeShareUtilizationPercent = in.Bikes.UtilizationPercent
+		observe(&snap.Mobility.ObservedAt, in.Bikes.ObservedAt)
 	}
 
 	// --- Mobility: Animal migration/movement trends from Movebank ---
-	if movementSummary != nil {
-		snap.Mobility.ActiveSpecies = movementSummary.ActiveSpecies
-		snap.Mobility.AnimalsTracked = movementSummary.TotalAnimalsTracked
-		snap.Mobility.AvgMigrationPaceKMDay = movementSummary.AvgMigrationPace
+	if in.Movement != nil {
+		snap.Mobility.ActiveSpecies = in.Movement.ActiveSpecies
+		snap.Mobility.AnimalsTracked = in.Movement.TotalAnimalsTracked
+		snap.Mobility.AvgMigrationPaceKMDay = in.Movement.AvgMigrationPace
 	}
 
 	return snap
 }
 
+// observe records t as a section's ObservedAt when it is newer than the
+// time already recorded. Zero times are ignored.
+func observe(dst **time.Time, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	t = t.UTC()
+	if *dst == nil || t.After(**dst) {
+		*dst = &t
+	}
+}
+
+// parseOpenMeteoTime parses an Open-Meteo "current" time, which is ISO 8601
+// without seconds in the requested (UTC) timezone. It returns the zero time
+// when s does not parse.
+func parseOpenMeteoTime(s string) time.Time {
+	t, _ := time.Parse("2006-01-02T15:04", s)
+	return t
+}
+
+// mqttObservedAt reports when an MQTT field's value was received and
+// whether it is within maxAge of the snapshot time; 0 disables the check. A
+// reading without ReceivedAt (from an older client) counts as received at
+// the snapshot time.
+func mqttObservedAt(r *clients.MQTTSensorReading, f clients.MQTTField, snapTime time.Time, maxAge time.Duration) (time.Time, bool) {
+	if r.ReceivedAt == nil {
+		return snapTime, true
+	}
+	at, ok := r.ReceivedAt[f]
+	if !ok || (maxAge > 0 && snapTime.Sub(at) > maxAge) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// freshAQReading reports whether a sensor's latest reading has a timestamp
+// and is within maxAge of now; 0 disables the age check. A reading whose UTC
+// time cannot be parsed is kept if it has any timestamp at all, as before
+// the check existed.
+func freshAQReading(dt clients.DatetimeInfo, now time.Time, maxAge time.Duration) bool {
+	if dt.UTC == "" && dt.Local == "" {
+		return false
+	}
+	if maxAge <= 0 {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, dt.UTC)
+	if err != nil {
+		return true
+	}
+	return now.Sub(t) <= maxAge
+}
+
+// aqParams maps the spellings providers use for a parameter (names and
+// display names, lower-cased with spaces, dots and subscripts removed) to
+// its canonical form.
+var aqParams = map[string]string{
+	"pm25": "pm25",
+	"pm10": "pm10",
+
+	"o3":    "o3",
+	"ozone": "o3",
+
+	"no2":             "no2",
+	"nitrogendioxide": "no2",
+
+	"so2":            "so2",
+	"sulfurdioxide":  "so2",
+	"sulphurdioxide": "so2",
+
+	"co":             "co",
+	"carbonmonoxide": "co",
+}
+
+// aqParamFolder strips the characters that vary between spellings, e.g.
+// "PM2.5" -> "pm25", "NO₂" -> "no2", "Carbon Monoxide" -> "carbonmonoxide".
+var aqParamFolder = strings.NewReplacer(
+	" ", "", ".", "", "_", "", "-", "",
+	"₀", "0", "₁", "1", "₂", "2", "₃", "3", "₅", "5",
+)
+
 // normalizeAQParam converts various parameter names to canonical forms
 func normalizeAQParam(name string) string {
-	switch name {
-	case "pm25", "pm2.5", "PM2.5":
-		return "pm25"
-	case "pm10", "PM10":
-		return "pm10"
-	case "o3", "ozone", "O3":
-		return "o3"
-	}
-	return name
+	key := aqParamFolder.Replace(strings.ToLower(strings.TrimSpace(name)))
+	if canonical, ok := aqParams[key]; ok {
+		return canonical
+	}
+	return key
 }
@@ -0,0 +1,204 @@
+package canonicalizer
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// loadSensors decodes testdata/name, a recorded OpenAQ sensors response,
+// and restamps every reading age before now so it passes the staleness check.
+func loadSensors(t *testing.T, name string, age time.Duration) *clients.SensorsResponse {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var resp clients.SensorsResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	at := time.Now().UTC().Add(-age).Format(time.RFC3339)
+	for i := range resp.Results {
+		resp.Results[i].Latest.Datetime.UTC = at
+	}
+	return &resp
+}
+
+func buildFromSensors(sensors *clients.SensorsResponse) models.Snapshot {
+	return BuildSnapshot("Denver", Inputs{Sensors: sensors}, Options{})
+}
+
+func TestBuildSnapshotMapsAllSixAQParameters(t *testing.T) {
+	snap := buildFromSensors(loadSensors(t, "openaq_sensors.json", 10*time.Minute))
+	env := snap.Environment
+
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"pm25", env.PM25, 8.4},
+		{"pm10", env.PM10, 21},
+		{"o3 (ppm)", env.Ozone, 35},
+		{"no2 (ppm, long name)", env.NO2, 18.4},
+		{"so2 (µg/m³)", env.SO2, 2.445},
+		{"co (display name only)", env.CO, 400},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if len(env.UnconvertedUnits) != 0 || len(env.RawUnits) != 6 || env.RawUnits["co"] != "ppm" {
+		t.Errorf("unconverted %v, raw units %v; want none unconverted and all six units recorded", env.UnconvertedUnits, env.RawUnits)
+	}
+	if env.ObservedAt == nil {
+		t.Error("Environment.ObservedAt not set")
+	}
+}
+
+func TestBuildSnapshotSkipsStaleAQReadings(t *testing.T) {
+	sensors := loadSensors(t, "openaq_sensors.json", 10*time.Minute)
+	// pm25 last reported five hours ago; pm10 has never reported.
+	sensors.Results[0].Latest.Datetime.UTC = time.Now().UTC().Add(-5 * time.Hour).Format(time.RFC3339)
+	sensors.Results[1].Latest.Datetime = clients.DatetimeInfo{}
+
+	snap := buildFromSensors(sensors)
+	if snap.Environment.PM25 != 0 || snap.Environment.PM10 != 0 {
+		t.Errorf("pm25 = %v, pm10 = %v; want stale and undated readings skipped", snap.Environment.PM25, snap.Environment.PM10)
+	}
+	if snap.Environment.Ozone != 35 {
+		t.Errorf("ozone = %v, want fresh readings kept", snap.Environment.Ozone)
+	}
+
+	snap = BuildSnapshot("Denver", Inputs{Sensors: sensors}, Options{AQStaleAfter: 6 * time.Hour})
+	if snap.Environment.PM25 != 8.4 {
+		t.Errorf("pm25 = %v with a 6h window, want the 5h-old reading kept", snap.Environment.PM25)
+	}
+	// A negative limit disables the age check; undated readings still go
+	snap = BuildSnapshot("Denver", Inputs{Sensors: sensors}, Options{AQStaleAfter: -1})
+	if snap.Environment.PM25 != 8.4 || snap.Environment.PM10 != 0 {
+		t.Errorf("pm25 = %v, pm10 = %v with the check disabled; want 8.4 and 0", snap.Environment.PM25, snap.Environment.PM10)
+	}
+}
+
+func TestBuildSnapshotSkipsStaleMQTTValues(t *testing.T) {
+	now := time.Now().UTC()
+	reading := &clients.MQTTSensorReading{
+		Temperature: 21.5,
+		Power:       340,
+		ReceivedAt: map[clients.MQTTField]time.Time{
+			clients.MQTTFieldTemperature: now.Add(-time.Minute),
+			clients.MQTTFieldPower:       now.Add(-30 * time.Minute),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		wantLoad float64
+	}{
+		{"default 10m", 0, 0},
+		{"1h", time.Hour, 340},
+		{"disabled", -1, 340},
+	}
+	for _, tt := range tests {
+		snap := BuildSnapshot("Denver", Inputs{MQTT: reading}, Options{MQTTStaleAfter: tt.maxAge})
+		if snap.Weather.TemperatureC != 21.5 || snap.Energy.GridLoad != tt.wantLoad {
+			t.Errorf("%s: temperature %v, grid load %v; want 21.5 and %v", tt.name, snap.Weather.TemperatureC, snap.Energy.GridLoad, tt.wantLoad)
+		}
+	}
+}
+
+func TestNormalizeAQParam(t *testing.T) {
+	tests := map[string]string{
+		"pm25": "pm25", "PM2.5": "pm25", "PM10": "pm10",
+		"o3": "o3", "O₃": "o3", "Ozone": "o3",
+		"NO₂": "no2", "nitrogen dioxide": "no2",
+		"SO₂": "so2", "sulphur dioxide": "so2",
+		"CO": "co", "Carbon Monoxide": "co",
+		"bc": "bc",
+	}
+	for in, want := range tests {
+		if got := normalizeAQParam(in); got != want {
+			t.Errorf("normalizeAQParam(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildSnapshotMapsCurrentWeather(t *testing.T) {
+	meteo := &clients.CurrentWeatherResponse{Current: clients.CurrentBlock{
+		Time:          "2026-10-17T15:00",
+		Temperature2m: 14.3,
+		Precipitation: 0.4,
+		CloudCover:    87,
+		Visibility:    24140,
+	}}
+	w := BuildSnapshot("Denver", Inputs{Meteo: meteo}, Options{}).Weather
+
+	if w.TemperatureC != 14.3 || w.PrecipMM != 0.4 || w.CloudCover != 87 {
+		t.Errorf("Weather = %+v, want temperature, precipitation and cloud cover copied", w)
+	}
+	if math.Abs(w.Visibility-24.14) > 1e-9 {
+		t.Errorf("Visibility = %v km, want 24.14", w.Visibility)
+	}
+}
+
+func TestBuildSnapshotModeledAQFillsOnlyMissingValues(t *testing.T) {
+	sensors := loadSensors(t, "openaq_sensors.json", 10*time.Minute)
+	// Keep only the PM2.5 sensor so the other pollutants are unmeasured.
+	sensors.Results = sensors.Results[:1]
+
+	pm25, pm10, ozone := 30.0, 15.5, 80.0
+	airQuality := &clients.AirQualityResponse{
+		CurrentUnits: map[string]string{"pm2_5": "μg/m³", "pm10": "μg/m³", "ozone": "μg/m³"},
+		Current: clients.AirQualityBlock{
+			Time:  "2026-10-17T08:00",
+			PM25:  &pm25,
+			PM10:  &pm10,
+			Ozone: &ozone,
+		},
+	}
+	env := BuildSnapshot("Denver", Inputs{Sensors: sensors, AirQuality: airQuality}, Options{}).Environment
+
+	if env.PM25 != 8.4 {
+		t.Errorf("PM25 = %v, want the measured 8.4 over the modeled 30", env.PM25)
+	}
+	if env.PM10 != 15.5 {
+		t.Errorf("PM10 = %v, want the modeled 15.5", env.PM10)
+	}
+	if env.NO2 != 0 {
+		t.Errorf("NO2 = %v, want 0 where neither source has a value", env.NO2)
+	}
+	if want := []string{"pm10", "o3"}; !slices.Equal(env.Modeled, want) {
+		t.Errorf("Modeled = %v, want %v", env.Modeled, want)
+	}
+
+	modeledOnly := BuildSnapshot("Denver", Inputs{AirQuality: airQuality}, Options{}).Environment
+	if modeledOnly.PM25 != 30 || !slices.Contains(modeledOnly.Modeled, "pm25") {
+		t.Errorf("without OpenAQ: PM25 = %v, Modeled = %v; want the modeled 30", modeledOnly.PM25, modeledOnly.Modeled)
+	}
+}
+
+func TestBuildSnapshotObservedAt(t *testing.T) {
+	meteo := &clients.CurrentWeatherResponse{Current: clients.CurrentBlock{Time: "2026-10-17T15:00"}}
+	stock := &clients.GlobalQuote{Symbol: "SPY", Price: 580, LatestTradingDay: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)}
+	snap := BuildSnapshot("Denver", Inputs{Meteo: meteo, Stock: stock}, Options{})
+
+	if w := snap.Weather.ObservedAt; w == nil || !w.Equal(time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("Weather.ObservedAt = %v, want the Open-Meteo time", w)
+	}
+	if f := snap.Finance.ObservedAt; f == nil || !f.Equal(stock.LatestTradingDay) {
+		t.Errorf("Finance.ObservedAt = %v, want the latest trading day", f)
+	}
+	if snap.Health.ObservedAt != nil || snap.Energy.ObservedAt != nil {
+		t.Errorf("sections without sources have ObservedAt set: health %v, energy %v", snap.Health.ObservedAt, snap.Energy.ObservedAt)
+	}
+}
@@ -0,0 +1,122 @@
+package canonicalizer
+
+import (
+	"strings"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// molarVolume25C is the volume of one mole of ideal gas at 25°C and 1 atm
+// (L/mol), used for µg/m³ <-> ppb conversions.
+const molarVolume25C = 24.45
+
+// gasMolecularWeights are in g/mol.
+var gasMolecularWeights = map[string]float64{
+	"o3":  48.00,
+	"no2": 46.01,
+	"so2": 64.07,
+	"co":  28.01,
+	"no":  30.01,
+}
+
+// normalizeAQValue converts a reading for param (a normalizeAQParam name)
+// from units into canonical units at 25°C. ok is false when units are not
+// recognized for param; the value is then returned unchanged.
+func normalizeAQValue(param string, value float64, units string) (v float64, ok bool) {
+	unit := normalizeAQUnit(units)
+	mw, isGas := gasMolecularWeights[param]
+
+	if isGas {
+		switch unit {
+		case "ppb":
+			return value, true
+		case "ppm":
+			return value * 1000, true
+		case "ug/m3":
+			return value * molarVolume25C / mw, true
+		case "mg/m3":
+			return value * 1000 * molarVolume25C / mw, true
+		}
+		return value, false
+	}
+
+	switch unit {
+	case "ug/m3":
+		return value, true
+	case "mg/m3":
+		return value * 1000, true
+	}
+	return value, false
+}
+
+// normalizeAQUnit folds the spellings providers use for the same unit
+// (µg/m³, μg/m³, ug/m3, ...) into an ASCII key.
+func normalizeAQUnit(units string) string {
+	u := strings.ToLower(strings.TrimSpace(units))
+	u = strings.NewReplacer("µ", "u", "μ", "u", "³", "3", " ", "").Replace(u)
+	switch u {
+	case "ug/m3", "ugm-3", "ug/m^3":
+		return "ug/m3"
+	case "mg/m3", "mgm-3", "mg/m^3":
+		return "mg/m3"
+	}
+	return u
+}
+
+// aqField returns the Environment field for a normalizeAQParam name, or nil
+// for parameters snapshots do not store.
+func aqField(env *models.Environment, param string) *float64 {
+	switch param {
+	case "pm25":
+		return &env.PM25
+	case "pm10":
+		return &env.PM10
+	case "o3":
+		return &env.Ozone
+	case "no2":
+		return &env.NO2
+	case "so2":
+		return &env.SO2
+	case "co":
+		return &env.CO
+	}
+	return nil
+}
+
+// fillModeledAQ copies Open-Meteo modeled concentrations into env for
+// parameters not in measured, converting them to canonical units and
+// recording them in env.Modeled.
+func fillModeledAQ(env *models.Environment, aq *clients.AirQualityResponse, measured map[string]bool) {
+	modeled := []struct {
+		param    string
+		variable string // Open-Meteo variable, the key into CurrentUnits
+		value    *float64
+	}{
+		{"pm25", "pm2_5", aq.Current.PM25},
+		{"pm10", "pm10", aq.Current.PM10},
+		{"o3", "ozone", aq.Current.Ozone},
+		{"no2", "nitrogen_dioxide", aq.Current.NO2},
+		{"so2", "sulphur_dioxide", aq.Current.SO2},
+		{"co", "carbon_monoxide", aq.Current.CO},
+	}
+	for _, m := range modeled {
+		if m.value == nil || measured[m.param] {
+			continue
+		}
+		units := aq.CurrentUnits[m.variable]
+		if units == "" {
+			units = "µg/m³" // the API's default for every pollutant
+		}
+		value, converted := normalizeAQValue(m.param, *m.value, units)
+		*aqField(env, m.param) = value
+		env.Modeled = append(env.Modeled, m.param)
+		if !converted {
+			env.UnconvertedUnits = append(env.UnconvertedUnits, m.param)
+		}
+		if env.RawUnits == nil {
+			env.RawUnits = make(map[string]string)
+		}
+		env.RawUnits[m.param] = units
+	}
+}
@@ -0,0 +1,83 @@
+package canonicalizer
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeAQValue(t *testing.T) {
+	tests := []struct {
+		param, units string
+		in, want     float64
+		ok           bool
+	}{
+		{"o3", "ppb", 42, 42, true},
+		{"o3", "ppm", 0.042, 42, true},
+		{"o3", "µg/m³", 100, 50.9375, true},
+		{"o3", "ug/m3", 100, 50.9375, true},
+		{"no2", "ppb", 12.5, 12.5, true},
+		{"no2", "ppm", 0.0125, 12.5, true},
+		{"no2", "μg/m³", 46.01, 24.45, true},
+		{"no2", "mg/m³", 0.04601, 24.45, true},
+		{"co", "ppm", 0.4, 400, true},
+		{"co", "mg/m³", 1, 872.9025348, true},
+		{"co", "µg/m³", 2500, 2182.2563370, true},
+		{"pm25", "µg/m³", 12, 12, true},
+		{"pm25", "mg/m³", 0.012, 12, true},
+		{"o3", "particles/cm³", 7, 7, false},
+		{"pm25", "ppm", 7, 7, false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeAQValue(tt.param, tt.in, tt.units)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("normalizeAQValue(%s, %v, %q) = %v, %v; want %v, %v",
+				tt.param, tt.in, tt.units, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestGasUnitsAgree checks that 1 ppm of each gas, given in ppm, µg/m³ or
+// mg/m³ (at 25°C, with its molecular weight), normalizes to the same 1000 ppb.
+func TestGasUnitsAgree(t *testing.T) {
+	tests := []struct {
+		param string
+		ugm3  float64 // µg/m³ equivalent of 1 ppm
+	}{
+		{"o3", 1963.19},
+		{"no2", 1881.8},
+		{"so2", 2620.45},
+		{"co", 1145.6},
+	}
+	for _, tt := range tests {
+		for _, in := range []struct {
+			value float64
+			units string
+		}{
+			{1, "ppm"},
+			{tt.ugm3, "µg/m³"},
+			{tt.ugm3 / 1000, "mg/m³"},
+		} {
+			got, ok := normalizeAQValue(tt.param, in.value, in.units)
+			if !ok || math.Abs(got-1000) > 0.5 {
+				t.Errorf("%s: %v %s = %v ppb (ok %v), want 1000", tt.param, in.value, in.units, got, ok)
+			}
+		}
+	}
+}
+
+func TestUnknownUnitsAreFlagged(t *testing.T) {
+	sensors := loadSensors(t, "openaq_sensors.json", 10*time.Minute)
+	sensors.Results[2].Parameter.Units = "particles/cm³" // o3
+
+	env := buildFromSensors(sensors).Environment
+	if env.Ozone != 0.035 {
+		t.Errorf("ozone = %v, want the raw 0.035 passed through", env.Ozone)
+	}
+	if len(env.UnconvertedUnits) != 1 || env.UnconvertedUnits[0] != "o3" {
+		t.Errorf("UnconvertedUnits = %v, want [o3]", env.UnconvertedUnits)
+	}
+	if env.RawUnits["o3"] != "particles/cm³" {
+		t.Errorf("RawUnits[o3] = %q, want the reported unit", env.RawUnits["o3"])
+	}
+}
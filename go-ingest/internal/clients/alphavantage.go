@@ -1,26 +1,57 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Alpha Vantage answers HTTP 200 for both of these, with a message in place
+// of the data.
+var (
+	// ErrRateLimited means the API key's request quota (25/day on the free
+	// tier) is used up; the body carried a "Note" or "Information" message.
+	ErrRateLimited = errors.New("alphavantage: rate limited")
+	// ErrUnknownSymbol means the symbol was rejected ("Error Message") or
+	// the quote came back empty.
+	ErrUnknownSymbol = errors.New("alphavantage: unknown symbol")
 )
 
+// alphaVantageConcurrency caps in-flight requests in GetGlobalQuotes; the
+// per-minute cap is enforced separately by the client's request spacing.
+const alphaVantageConcurrency = 2
+
 // AlphaVantageClient handles interactions with the Alpha Vantage API.
+// Requests are spaced at least minInterval apart (12s, i.e. the free tier's
+// 5 requests/minute, unless ALPHAVANTAGE_MIN_INTERVAL says otherwise).
 type AlphaVantageClient struct {
-	apiKey  string
-	baseURL string
-	httpCli *http.Client
+	apiKey      string
+	baseURL     string
+	httpCli     *http.Client
+	minInterval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
 }
 
 // NewAlphaVantageClient creates a new Alpha Vantage API client.
 func NewAlphaVantageClient(apiKey string) *AlphaVantageClient {
 	return &AlphaVantageClient{
-		apiKey:  apiKey,
-		baseURL: "https://www.alphavantage.co/query",
-		httpCli: &http.Client{Timeout: 15 * time.Second},
+		apiKey:      apiKey,
+		baseURL:     "https://www.alphavantage.co/query",
+		httpCli:     NewHTTPClient(envTimeout("ALPHAVANTAGE_TIMEOUT", 15*time.Second)),
+		minInterval: envTimeout("ALPHAVANTAGE_MIN_INTERVAL", 12*time.Second),
 	}
 }
 
@@ -29,48 +60,381 @@ type GlobalQuoteResponse struct {
 	Quote GlobalQuote `json:"Global Quote"`
 }
 
-// GlobalQuote holds a minimal subset of quote fields.
+// GlobalQuote holds a minimal subset of quote fields, parsed from the
+// strings Alpha Vantage sends.
 type GlobalQuote struct {
-	Symbol           string `json:"01. symbol"`
-	Open             string `json:"02. open"`
-	High             string `json:"03. high"`
-	Low              string `json:"04. low"`
-	Price            string `json:"05. price"`
-	Volume           string `json:"06. volume"`
-	LatestTradingDay string `json:"07. latest trading day"`
+	Symbol           string    `json:"symbol"`
+	Open             float64   `json:"open"`
+	High             float64   `json:"high"`
+	Low              float64   `json:"low"`
+	Price            float64   `json:"price"`
+	Volume           int64     `json:"volume"`
+	LatestTradingDay time.Time `json:"latest_trading_day"`
 }
 
-// GetGlobalQuote fetches the latest quote for the given symbol.
-func (c *AlphaVantageClient) GetGlobalQuote(symbol string) (*GlobalQuoteResponse, error) {
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("alphavantage api key is required")
+// UnmarshalJSON decodes the numbered string fields of a GLOBAL_QUOTE body.
+// An empty object (Alpha Vantage's answer for unknown symbols) decodes to the
+// zero quote.
+func (q *GlobalQuote) UnmarshalJSON(b []byte) error {
+	var wire struct {
+		Symbol           string `json:"01. symbol"`
+		Open             string `json:"02. open"`
+		High             string `json:"03. high"`
+		Low              string `json:"04. low"`
+		Price            string `json:"05. price"`
+		Volume           string `json:"06. volume"`
+		LatestTradingDay string `json:"07. latest trading day"`
+	}
+	if err := json.Unmarshal(b, &wire); err != nil {
+		return err
+	}
+	if wire.Symbol == "" {
+		*q = GlobalQuote{}
+		return nil
+	}
+
+	p := &avParser{}
+	*q = GlobalQuote{
+		Symbol:           wire.Symbol,
+		Open:             p.float("open", wire.Open),
+		High:             p.float("high", wire.High),
+		Low:              p.float("low", wire.Low),
+		Price:            p.float("price", wire.Price),
+		Volume:           p.int("volume", wire.Volume),
+		LatestTradingDay: p.date("latest trading day", wire.LatestTradingDay),
+	}
+	return p.err
+}
+
+// alphaVantageMessages are the fields Alpha Vantage sends instead of data
+// when it refuses a request.
+type alphaVantageMessages struct {
+	Note         string `json:"Note"`
+	Information  string `json:"Information"`
+	ErrorMessage string `json:"Error Message"`
+}
+
+// err maps a refusal message to ErrRateLimited or ErrUnknownSymbol, or nil
+// when there is none.
+func (m alphaVantageMessages) err() error {
+	switch {
+	case m.Note != "":
+		return fmt.Errorf("%w: %s", ErrRateLimited, m.Note)
+	case m.Information != "":
+		return fmt.Errorf("%w: %s", ErrRateLimited, m.Information)
+	case m.ErrorMessage != "":
+		return fmt.Errorf("%w: %s", ErrUnknownSymbol, m.ErrorMessage)
 	}
+	return nil
+}
+
+// GetGlobalQuote fetches the latest quote for the given symbol. It returns
+// ErrRateLimited when the quota is exhausted and ErrUnknownSymbol when there
+// is no quote for symbol.
+func (c *AlphaVantageClient) GetGlobalQuote(symbol string) (*GlobalQuoteResponse, error) {
+	return c.GetGlobalQuoteContext(context.Background(), symbol)
+}
 
+// GetGlobalQuoteContext is GetGlobalQuote with a caller-supplied context.
+func (c *AlphaVantageClient) GetGlobalQuoteContext(ctx context.Context, symbol string) (*GlobalQuoteResponse, error) {
 	q := url.Values{}
 	q.Set("function", "GLOBAL_QUOTE")
 	q.Set("symbol", symbol)
+
+	var parsed GlobalQuoteResponse
+	if err := c.get(ctx, q, &parsed); err != nil {
+		return nil, err
+	}
+	if parsed.Quote.Symbol == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+
+	return &parsed, nil
+}
+
+// GetGlobalQuotes fetches quotes for several symbols, a few at a time and
+// within the client's request spacing. Quotes that succeeded are returned
+// even when others failed; the error joins the per-symbol failures. Once the
+// quota is exhausted the remaining symbols are not requested.
+func (c *AlphaVantageClient) GetGlobalQuotes(symbols []string) (map[string]GlobalQuote, error) {
+	return c.GetGlobalQuotesContext(context.Background(), symbols)
+}
+
+// GetGlobalQuotesContext is GetGlobalQuotes with a caller-supplied context.
+func (c *AlphaVantageClient) GetGlobalQuotesContext(ctx context.Context, symbols []string) (map[string]GlobalQuote, error) {
+	var (
+		mu     sync.Mutex
+		quotes = make(map[string]GlobalQuote, len(symbols))
+		errs   []error
+	)
+
+	// A rate-limit error cancels gctx so queued symbols give up rather than
+	// spend what is left of the quota window waiting.
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(alphaVantageConcurrency)
+	for _, symbol := range symbols {
+		g.Go(func() error {
+			resp, err := c.GetGlobalQuoteContext(gctx, symbol)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if errors.Is(err, ErrRateLimited) {
+					errs = append(errs, err)
+					return err
+				}
+				if gctx.Err() == nil {
+					errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+				}
+				return nil
+			}
+			quotes[symbol] = resp.Quote
+			return nil
+		})
+	}
+	g.Wait()
+
+	if len(errs) == 0 && ctx.Err() != nil {
+		return quotes, ctx.Err()
+	}
+	return quotes, errors.Join(errs...)
+}
+
+// GetDailySeries fetches daily bars for symbol, oldest first. outputSize is
+// "compact" (the latest 100 days; the default when empty) or "full" (up to 20
+// years).
+func (c *AlphaVantageClient) GetDailySeries(symbol, outputSize string) ([]OHLCV, error) {
+	return c.GetDailySeriesContext(context.Background(), symbol, outputSize)
+}
+
+// GetDailySeriesContext is GetDailySeries with a caller-supplied context.
+func (c *AlphaVantageClient) GetDailySeriesContext(ctx context.Context, symbol, outputSize string) ([]OHLCV, error) {
+	switch outputSize {
+	case "":
+		outputSize = "compact"
+	case "compact", "full":
+	default:
+		return nil, fmt.Errorf("alphavantage output size %q (want compact or full)", outputSize)
+	}
+
+	q := url.Values{}
+	q.Set("function", "TIME_SERIES_DAILY")
+	q.Set("symbol", symbol)
+	q.Set("outputsize", outputSize)
+
+	var parsed struct {
+		Series map[string]struct {
+			Open   string `json:"1. open"`
+			High   string `json:"2. high"`
+			Low    string `json:"3. low"`
+			Close  string `json:"4. close"`
+			Volume string `json:"5. volume"`
+		} `json:"Time Series (Daily)"`
+	}
+	if err := c.get(ctx, q, &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed.Series) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+
+	bars := make([]OHLCV, 0, len(parsed.Series))
+	p := &avParser{}
+	for day, v := range parsed.Series {
+		bars = append(bars, OHLCV{
+			Symbol: symbol,
+			Date:   p.date("date", day),
+			Open:   p.float("open", v.Open),
+			High:   p.float("high", v.High),
+			Low:    p.float("low", v.Low),
+			Close:  p.float("close", v.Close),
+			Volume: p.int("volume", v.Volume),
+		})
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("parse %s daily series: %w", symbol, p.err)
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Date.Before(bars[j].Date) })
+	return bars, nil
+}
+
+// CommodityPrice is the latest value of an Alpha Vantage commodity series.
+type CommodityPrice struct {
+	Symbol string // the commodity function, e.g. WTI
+	Name   string // e.g. "Crude Oil Prices WTI"
+	Unit   string // e.g. "dollars per barrel"
+	Date   time.Time
+	Value  float64
+}
+
+// alphaVantageCommodities maps each commodity function to the finest
+// interval it offers; only the energy series are published daily.
+var alphaVantageCommodities = map[string]string{
+	"WTI":         "daily",
+	"BRENT":       "daily",
+	"NATURAL_GAS": "daily",
+	"COPPER":      "monthly",
+	"ALUMINUM":    "monthly",
+	"WHEAT":       "monthly",
+	"CORN":        "monthly",
+	"COTTON":      "monthly",
+	"SUGAR":       "monthly",
+	"COFFEE":      "monthly",
+}
+
+// GetCommodityPrice fetches the latest price of a commodity: WTI, BRENT,
+// NATURAL_GAS, COPPER, ALUMINUM, WHEAT, CORN, COTTON, SUGAR or COFFEE.
+func (c *AlphaVantageClient) GetCommodityPrice(symbol string) (*CommodityPrice, error) {
+	return c.GetCommodityPriceContext(context.Background(), symbol)
+}
+
+// GetCommodityPriceContext is GetCommodityPrice with a caller-supplied context.
+func (c *AlphaVantageClient) GetCommodityPriceContext(ctx context.Context, symbol string) (*CommodityPrice, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	interval, ok := alphaVantageCommodities[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: commodity %q", ErrUnknownSymbol, symbol)
+	}
+
+	q := url.Values{}
+	q.Set("function", symbol)
+	q.Set("interval", interval)
+
+	var parsed alphaVantageCommoditySeries
+	if err := c.get(ctx, q, &parsed); err != nil {
+		return nil, err
+	}
+	return parsed.latest(symbol)
+}
+
+// alphaVantageCommoditySeries is a commodity body; data is newest first and
+// "." marks a day without a price (e.g. a market holiday).
+type alphaVantageCommoditySeries struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+	Data []struct {
+		Date  string `json:"date"`
+		Value string `json:"value"`
+	} `json:"data"`
+}
+
+// latest returns the newest entry that has a price.
+func (s alphaVantageCommoditySeries) latest(symbol string) (*CommodityPrice, error) {
+	for _, d := range s.Data {
+		if d.Value == "." || d.Value == "" {
+			continue
+		}
+		p := &avParser{}
+		price := &CommodityPrice{
+			Symbol: symbol,
+			Name:   s.Name,
+			Unit:   s.Unit,
+			Date:   p.date("date", d.Date),
+			Value:  p.float("value", d.Value),
+		}
+		if p.err != nil {
+			return nil, fmt.Errorf("parse %s: %w", symbol, p.err)
+		}
+		return price, nil
+	}
+	return nil, fmt.Errorf("no %s prices returned", symbol)
+}
+
+// get sends one query (the API key is added here) and decodes the body into
+// out, first checking for a refusal message.
+func (c *AlphaVantageClient) get(ctx context.Context, q url.Values, out interface{}) error {
+	if c.apiKey == "" {
+		return fmt.Errorf("alphavantage api key is required")
+	}
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
 	q.Set("apikey", c.apiKey)
 
 	reqURL := fmt.Sprintf("%s?%s", c.baseURL, q.Encode())
-	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("build request: %w", err)
+		return fmt.Errorf("build request: %w", err)
 	}
 
 	resp, err := c.httpCli.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
 	}
 
-	var parsed GlobalQuoteResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	var msgs alphaVantageMessages
+	if err := json.Unmarshal(body, &msgs); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if err := msgs.err(); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
 	}
+	return nil
+}
 
-	return &parsed, nil
+// wait blocks until the client may start another request, reserving that
+// slot so concurrent callers queue behind each other.
+func (c *AlphaVantageClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	start := c.next
+	if start.Before(now) {
+		start = now
+	}
+	c.next = start.Add(c.minInterval)
+	c.mu.Unlock()
+
+	d := time.Until(start)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// avParser parses Alpha Vantage's string-encoded fields, keeping the first
+// error so a row can be decoded in one expression.
+type avParser struct {
+	err error
+}
+
+func (p *avParser) float(field, s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("%s %q: %w", field, s, err)
+	}
+	return v
+}
+
+func (p *avParser) int(field, s string) int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("%s %q: %w", field, s, err)
+	}
+	return v
+}
+
+func (p *avParser) date(field, s string) time.Time {
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("%s %q: %w", field, s, err)
+	}
+	return t
 }
@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestAlphaVantage(srv *fixtureServer) *AlphaVantageClient {
+	c := NewAlphaVantageClient("test-key")
+	c.baseURL = srv.URL + "/query"
+	c.minInterval = 0
+	return c
+}
+
+func TestAlphaVantageGetGlobalQuote(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/query": "alphavantage_quote.json"})
+
+	resp, err := newTestAlphaVantage(srv).GetGlobalQuote("IBM")
+	if err != nil {
+		t.Fatalf("GetGlobalQuote: %v", err)
+	}
+	want := GlobalQuote{
+		Symbol: "IBM", Open: 231.55, High: 233.72, Low: 230.18, Price: 232.8, Volume: 3615294,
+		LatestTradingDay: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+	}
+	if resp.Quote != want {
+		t.Errorf("quote = %+v, want %+v", resp.Quote, want)
+	}
+	if q := srv.lastQuery(); q.Get("function") != "GLOBAL_QUOTE" || q.Get("symbol") != "IBM" || q.Get("apikey") != "test-key" {
+		t.Errorf("query = %v", q)
+	}
+}
+
+func TestAlphaVantageRefusals(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    error
+	}{
+		{"alphavantage_note.json", ErrRateLimited},
+		{"alphavantage_information.json", ErrRateLimited},
+		{"alphavantage_error.json", ErrUnknownSymbol},
+		{"alphavantage_empty_quote.json", ErrUnknownSymbol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			srv := newFixtureServer(t, map[string]string{"/query": tt.fixture})
+
+			resp, err := newTestAlphaVantage(srv).GetGlobalQuote("IBM")
+			if !errors.Is(err, tt.want) || resp != nil {
+				t.Errorf("GetGlobalQuote = %+v, %v; want nil and %v", resp, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlphaVantageGetCommodityPrice(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/query": "alphavantage_wti.json"})
+
+	got, err := newTestAlphaVantage(srv).GetCommodityPrice(" wti ")
+	if err != nil {
+		t.Fatalf("GetCommodityPrice: %v", err)
+	}
+	// The newest day has no price, so the one before it is used.
+	want := CommodityPrice{
+		Symbol: "WTI",
+		Name:   "Crude Oil Prices WTI",
+		Unit:   "dollars per barrel",
+		Date:   time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Value:  71.42,
+	}
+	if *got != want {
+		t.Errorf("price = %+v, want %+v", *got, want)
+	}
+	if q := srv.lastQuery(); q.Get("function") != "WTI" || q.Get("interval") != "daily" {
+		t.Errorf("query = %v, want the daily WTI function", q)
+	}
+
+	if _, err := newTestAlphaVantage(srv).GetCommodityPrice("GOLD"); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("unsupported commodity: err = %v, want ErrUnknownSymbol", err)
+	}
+}
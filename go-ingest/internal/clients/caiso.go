@@ -0,0 +1,170 @@
+package clients
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// caisoRenewables are the Today's Outlook fuel columns CAISO counts as
+// renewable. Large hydro is excluded, as in CAISO's own renewables trend.
+var caisoRenewables = []string{"solar", "wind", "geothermal", "biomass", "biogas", "small hydro"}
+
+// caisoProvider is the GridProvider for California ISO, reading CAISO's
+// Today's Outlook CSVs.
+type caisoProvider struct {
+	baseURL string // Today's Outlook "current" directory
+	httpCli *http.Client
+	retry   retryPolicy
+}
+
+func newCAISOProvider(cli *http.Client) GridProvider {
+	return &caisoProvider{
+		baseURL: "https://www.caiso.com/outlook/current",
+		httpCli: cli,
+		retry:   defaultRetryPolicy,
+	}
+}
+
+// GetGridStatus builds a GridStatus from the latest five-minute demand
+// against today's day-ahead forecast peak, and the renewable generation of
+// the latest fuel-mix interval. Frequency is not published there and stays
+// 0.
+func (c *caisoProvider) GetGridStatus(ctx context.Context) (*GridStatus, error) {
+	var demand caisoDemand
+	if err := c.fetch(ctx, "demand.csv", func(r io.Reader) (err error) {
+		demand, err = parseCAISODemand(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	var renewables float64
+	if err := c.fetch(ctx, "fuelsource.csv", func(r io.Reader) (err error) {
+		renewables, err = parseCAISORenewables(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	status := &GridStatus{
+		LoadMW:       demand.current,
+		CapacityMW:   demand.forecastPeak,
+		Status:       "Normal",
+		RenewablesMW: renewables,
+		Source:       GridSourceCAISO,
+	}
+	if demand.forecastPeak > 0 {
+		status.UtilizationPercent = demand.current / demand.forecastPeak * 100
+		status.Status = gridStatusLevel(status.UtilizationPercent)
+	}
+	return status, nil
+}
+
+// fetch downloads one Today's Outlook CSV and hands its body to parse.
+func (c *caisoProvider) fetch(ctx context.Context, file string, parse func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+file, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
+	if err != nil {
+		return fmt.Errorf("CAISO %s: %w", file, err)
+	}
+	defer resp.Body.Close()
+	if err := parse(resp.Body); err != nil {
+		return fmt.Errorf("CAISO %s: %w", file, err)
+	}
+	return nil
+}
+
+// caisoDemand is the part of the demand CSV a GridStatus needs.
+type caisoDemand struct {
+	current      float64 // latest reported demand, MW
+	forecastPeak float64 // highest day-ahead forecast for the day, MW
+}
+
+// parseCAISODemand reads the Today's Outlook demand CSV (Time, Day ahead
+// forecast, Hour ahead forecast, Current demand). Rows later in the day carry
+// forecasts only, so the current demand is the last non-empty one.
+func parseCAISODemand(r io.Reader) (caisoDemand, error) {
+	rows, col, err := readCAISOCSV(r, "day ahead forecast", "current demand")
+	if err != nil {
+		return caisoDemand{}, err
+	}
+	var d caisoDemand
+	found := false
+	for _, row := range rows {
+		if v, ok := caisoValue(row, col["day ahead forecast"]); ok && v > d.forecastPeak {
+			d.forecastPeak = v
+		}
+		if v, ok := caisoValue(row, col["current demand"]); ok {
+			d.current, found = v, true
+		}
+	}
+	if !found {
+		return caisoDemand{}, fmt.Errorf("no current demand reported")
+	}
+	return d, nil
+}
+
+// parseCAISORenewables sums the renewable columns of the last fully
+// reported row of the Today's Outlook fuel source CSV.
+func parseCAISORenewables(r io.Reader) (float64, error) {
+	rows, col, err := readCAISOCSV(r, caisoRenewables...)
+	if err != nil {
+		return 0, err
+	}
+	for i := len(rows) - 1; i >= 0; i-- {
+		var sum float64
+		complete := true
+		for _, name := range caisoRenewables {
+			v, ok := caisoValue(rows[i], col[name])
+			if !ok {
+				complete = false
+				break
+			}
+			sum += v
+		}
+		if complete {
+			return sum, nil
+		}
+	}
+	return 0, fmt.Errorf("no fuel mix reported")
+}
+
+// readCAISOCSV reads a Today's Outlook CSV and indexes its header by
+// lower-cased column name, requiring the given columns.
+func readCAISOCSV(r io.Reader, required ...string) ([][]string, map[string]int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("empty CSV")
+	}
+	col := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range required {
+		if _, ok := col[name]; !ok {
+			return nil, nil, fmt.Errorf("CSV has no %q column", name)
+		}
+	}
+	return records[1:], col, nil
+}
+
+// caisoValue parses row[i], reporting false for a missing or blank cell.
+func caisoValue(row []string, i int) (float64, bool) {
+	if i >= len(row) {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+	return v, err == nil
+}
@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// newTestCAISO returns a CAISO GridClient reading Today's Outlook from srv.
+func newTestCAISO(srv *fixtureServer) *GridClient {
+	c := NewGridClient("CAISO")
+	p := c.provider.(*caisoProvider)
+	p.baseURL = srv.URL
+	p.retry = fastRetry
+	return c
+}
+
+func TestCAISOGetGridStatus(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/demand.csv":     "caiso_demand.csv",
+		"/fuelsource.csv": "caiso_fuelsource.csv",
+	})
+
+	got, err := newTestCAISO(srv).GetGridStatusContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetGridStatusContext: %v", err)
+	}
+	// The 00:10 row is the last with a current demand; the capacity is the
+	// 17:00 day-ahead peak, putting the grid at 85%.
+	if got.LoadMW != 26520 || got.CapacityMW != 31200 || got.UtilizationPercent != 85 || got.Status != "Alert" {
+		t.Errorf("load = %+v, want 26520 of 31200 MW (85%%, Alert)", *got)
+	}
+	// Solar, wind, geothermal, biomass, biogas and small hydro at 00:05; the
+	// 00:10 fuel mix is not reported yet
+	if got.RenewablesMW != 4550 {
+		t.Errorf("RenewablesMW = %v, want 4550", got.RenewablesMW)
+	}
+	if got.Source != GridSourceCAISO || got.FrequencyHz != 0 {
+		t.Errorf("Source %q FrequencyHz %v, want caiso with no frequency", got.Source, got.FrequencyHz)
+	}
+}
+
+func TestCAISOMissingDemandColumn(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/demand.csv":     "caiso_demand_no_current.csv",
+		"/fuelsource.csv": "caiso_fuelsource.csv",
+	})
+
+	_, err := newTestCAISO(srv).GetGridStatusContext(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `"current demand"`) {
+		t.Errorf("err = %v, want a missing current demand column", err)
+	}
+}
+
+func TestMockGridClientIsMarked(t *testing.T) {
+	got, err := NewMockGridClient("CAISO").GetGridStatus()
+	if err != nil {
+		t.Fatalf("GetGridStatus: %v", err)
+	}
+	if got.Source != GridSourceMock {
+		t.Errorf("Source = %q, want %q", got.Source, GridSourceMock)
+	}
+	if got.LoadMW <= 0 || got.CapacityMW <= 0 {
+		t.Errorf("mock status = %+v, want positive load and capacity", *got)
+	}
+}
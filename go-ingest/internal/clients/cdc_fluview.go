@@ -1,23 +1,31 @@
 package clients
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// CDCFluViewClient fetches flu surveillance data from CDC FluView.
+// CDCFluViewClient fetches flu surveillance data from CDC FluView, and RSV
+// laboratory data from NREVSS on data.cdc.gov.
 // Uses the public CDC FluView web service.
 type CDCFluViewClient struct {
-	baseURL string
-	httpCli *http.Client
+	baseURL   string
+	nrevssURL string
+	httpCli   *http.Client
+	retry     retryPolicy
 }
 
 // CDCFluSummary aggregates current flu activity metrics.
@@ -25,101 +33,271 @@ type CDCFluSummary struct {
 	WeekEndDate        time.Time
 	UnweightedILI      float64 // Influenza-like illness percentage
 	FluCases           int     // Total ILI cases reported
-	HospitalAdmissions int     // Lab-confirmed influenza hospitalizations
-	Region             string  // "national", "hhs", "state", or "census"
+	HospitalAdmissions int     // Lab-confirmed influenza hospitalizations (not part of ILINet)
+	Region             string  // "national", "state", or "hhs-N (fallback)" when a state had no data
+
+	// NREVSS RSV laboratory results for the latest reported week.
+	RSVPercentPositive float64
+	RSVDetections      int
+	RSVTests           int
 }
 
 // NewCDCFluViewClient creates a new CDC FluView client.
 func NewCDCFluViewClient() *CDCFluViewClient {
 	return &CDCFluViewClient{
-		baseURL: "https://gis.cdc.gov/grasp/flu2",
-		httpCli: &http.Client{Timeout: 20 * time.Second},
+		baseURL:   "https://gis.cdc.gov/grasp/flu2",
+		nrevssURL: "https://data.cdc.gov/resource/" + nrevssDataset + ".json",
+		httpCli:   NewHTTPClient(envTimeout("CDC_TIMEOUT", 20*time.Second)),
+		retry:     defaultRetryPolicy,
 	}
 }
 
+// ILINet region types, as the download service's RegionTypeId.
+const (
+	iliRegionHHS      = 1
+	iliRegionCensus   = 2
+	iliRegionNational = 3
+	iliRegionState    = 5
+)
+
 // GetNationalILIData fetches the most recent national ILINet data.
 // Returns recent flu activity summary for the US.
 func (c *CDCFluViewClient) GetNationalILIData() (*CDCFluSummary, error) {
-	body, err := c.fetchILINetData("-1", "58", "12", "0")
+	return c.GetNationalILIDataContext(context.Background())
+}
+
+// GetNationalILIDataContext is GetNationalILIData with a caller-supplied context.
+func (c *CDCFluViewClient) GetNationalILIDataContext(ctx context.Context) (*CDCFluSummary, error) {
+	csvData, err := c.fetchILINetData(ctx, iliRegionNational, []int{0})
 	if err != nil {
 		return nil, err
 	}
 
-	summary, err := parseILINetData(body)
+	summary, err := parseILINetCSV(bytes.NewReader(csvData), "", time.Now().UTC())
 	if err != nil {
 		return nil, fmt.Errorf("parse ILINet data: %w", err)
 	}
-
+	summary.Region = "national"
 	return summary, nil
 }
 
-// GetStateILIData fetches ILINet data for a specific state.
+// GetStateILIData fetches ILINet data for a state, given as a USPS code
+// ("CA", or "NYC" for New York City) or its ILINet name ("California"). When
+// the state has no complete week in the current or previous season, its HHS
+// region's data is returned instead with Region "hhs-N (fallback)".
 func (c *CDCFluViewClient) GetStateILIData(state string) (*CDCFluSummary, error) {
-	// Similar to national but with a state region ID
-	// For now, implement a simplified version that reuses national data
-	// In production, map state names to CDC region IDs
+	return c.GetStateILIDataContext(context.Background(), state)
+}
 
-	body, err := c.fetchILINetData("-1", "58", "12", "0")
+// GetStateILIDataContext is GetStateILIData with a caller-supplied context.
+func (c *CDCFluViewClient) GetStateILIDataContext(ctx context.Context, state string) (*CDCFluSummary, error) {
+	j, err := lookupILIState(state)
 	if err != nil {
 		return nil, err
 	}
 
-	summary, err := parseILINetData(body)
+	csvData, err := c.fetchILINetData(ctx, iliRegionState, []int{j.ID})
 	if err != nil {
-		return nil, fmt.Errorf("parse ILINet data: %w", err)
+		return nil, err
+	}
+	summary, err := parseILINetCSV(bytes.NewReader(csvData), j.Name, time.Now().UTC())
+	if err == nil {
+		summary.Region = "state"
+		return summary, nil
+	}
+	if !errors.Is(err, errNoILINetWeek) {
+		return nil, fmt.Errorf("parse ILINet data for %s: %w", j.Name, err)
 	}
 
+	stateErr := err
+	csvData, err = c.fetchILINetData(ctx, iliRegionHHS, []int{j.HHS})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w; HHS region %d fallback: %w", j.Name, stateErr, j.HHS, err)
+	}
+	summary, err = parseILINetCSV(bytes.NewReader(csvData), hhsRegionName(j.HHS), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w; HHS region %d fallback: %w", j.Name, stateErr, j.HHS, err)
+	}
+	summary.Region = fmt.Sprintf("hhs-%d (fallback)", j.HHS)
 	return summary, nil
 }
 
-// parseILINetData attempts to extract current flu metrics from the CDC response.
-// The CDC endpoint may return different formats; this handles a basic JSON or CSV parse.
-func parseILINetData(data []byte) (*CDCFluSummary, error) {
-	// Try JSON first
-	var payload interface{}
-	if err := json.Unmarshal(data, &payload); err == nil {
-		// Successfully parsed JSON; extract relevant fields
-		if m, ok := payload.(map[string]interface{}); ok {
-			summary := &CDCFluSummary{
-				WeekEndDate:        time.Now().UTC(),
-				UnweightedILI:      extractFloat(m, "unweighted_ili", 0),
-				FluCases:           extractInt(m, "ilitotal", 0),
-				HospitalAdmissions: extractInt(m, "hospitalization_rate", 0),
-				Region:             "national",
+// parseILINetCSV reads ILINet.csv and summarizes the latest complete week:
+// one whose values are reported ("X" marks a gap), that had reporting
+// providers, and whose week has ended by now. When region is non-empty only
+// that REGION's rows are considered.
+//
+// The file opens with a title line, then a header with REGION TYPE, REGION,
+// YEAR, WEEK, %UNWEIGHTED ILI, ILITOTAL, NUM. OF PROVIDERS among others.
+func parseILINetCSV(r io.Reader, region string, now time.Time) (*CDCFluSummary, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read ILINet CSV: %w", err)
+	}
+
+	// Find the header rather than assuming the title line is there.
+	col := map[string]int{}
+	headerAt := -1
+	for i, row := range rows {
+		for j, name := range row {
+			col[strings.ToUpper(strings.TrimSpace(name))] = j
+		}
+		if _, ok := col["%UNWEIGHTED ILI"]; ok {
+			headerAt = i
+			break
+		}
+		clear(col)
+	}
+	if headerAt < 0 {
+		return nil, fmt.Errorf("ILINet CSV has no %%UNWEIGHTED ILI header")
+	}
+	for _, name := range []string{"REGION", "YEAR", "WEEK", "ILITOTAL", "NUM. OF PROVIDERS"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("ILINet CSV is missing column %s", name)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i := col[name]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var best *CDCFluSummary
+	for _, row := range rows[headerAt+1:] {
+		if region != "" && !strings.EqualFold(field(row, "REGION"), region) {
+			continue
+		}
+		year, errY := strconv.Atoi(field(row, "YEAR"))
+		week, errW := strconv.Atoi(field(row, "WEEK"))
+		ili, errI := strconv.ParseFloat(field(row, "%UNWEIGHTED ILI"), 64)
+		total, errT := strconv.Atoi(field(row, "ILITOTAL"))
+		providers, errP := strconv.Atoi(field(row, "NUM. OF PROVIDERS"))
+		if errY != nil || errW != nil || errI != nil || errT != nil || errP != nil || providers == 0 {
+			continue // "X" or blank: not reported that week
+		}
+
+		weekEnd := mmwrWeekEnd(year, week)
+		if weekEnd.After(now) {
+			continue
+		}
+		if best == nil || weekEnd.After(best.WeekEndDate) {
+			best = &CDCFluSummary{
+				WeekEndDate:   weekEnd,
+				UnweightedILI: ili,
+				FluCases:      total,
 			}
-			return summary, nil
 		}
 	}
+	if best == nil {
+		if region != "" {
+			return nil, fmt.Errorf("%w for region %q", errNoILINetWeek, region)
+		}
+		return nil, errNoILINetWeek
+	}
+	return best, nil
+}
 
-	// Fallback: create a stub summary with current timestamp
-	// In production, parse CSV or other formats the CDC returns
-	return &CDCFluSummary{
-		WeekEndDate: time.Now().UTC(),
-		Region:      "national",
-	}, nil
+// errNoILINetWeek is parseILINetCSV's error when no row qualifies.
+var errNoILINetWeek = errors.New("no complete ILINet week")
+
+// mmwrWeekEnd returns the Saturday ending MMWR week `week` of `year`. MMWR
+// weeks run Sunday to Saturday, and week 1 is the first that has at least
+// four days in January.
+func mmwrWeekEnd(year, week int) time.Time {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := -int(jan1.Weekday()) // back to the Sunday on or before Jan 1
+	if jan1.Weekday() > time.Wednesday {
+		offset += 7 // fewer than four January days in that week
+	}
+	return jan1.AddDate(0, 0, offset+7*(week-1)+6)
 }
 
-// extractFloat safely extracts a float value from a map.
-func extractFloat(m map[string]interface{}, key string, defaultVal float64) float64 {
-	if val, ok := m[key]; ok {
-		if f, ok := val.(float64); ok {
-			return f
-		}
+// currentFluSeasons returns the service's season IDs (start year - 1960)
+// for the season containing now and the one before, so the first weeks of a
+// season still have the previous weeks to fall back on. Seasons start in
+// MMWR week 40, early October.
+func currentFluSeasons(now time.Time) []int {
+	start := now.Year()
+	if now.Month() < time.October {
+		start--
 	}
-	return defaultVal
+	return []int{start - 1961, start - 1960}
 }
 
-// extractInt safely extracts an int value from a map.
-func extractInt(m map[string]interface{}, key string, defaultVal int) int {
-	if val, ok := m[key]; ok {
-		if f, ok := val.(float64); ok {
-			return int(f)
-		}
-		if i, ok := val.(int); ok {
-			return i
+// NREVSS RSV laboratory data on data.cdc.gov (Socrata): one row per week,
+// reporting level and test type.
+const (
+	nrevssDataset    = "3cxc-4k8q"
+	nrevssLevel      = "National"
+	nrevssLookback   = 8 * 7 * 24 * time.Hour
+	nrevssPageLimit  = 5000
+	socrataTimestamp = "2006-01-02T15:04:05.000"
+)
+
+// nrevssRow is the subset of an NREVSS row we use. Socrata returns numbers
+// as strings.
+type nrevssRow struct {
+	WeekEnd    string `json:"mmwrweek_end"`
+	Detections string `json:"pcr_detections"`
+	Tests      string `json:"pcr_tests"`
+}
+
+// rsvWeek accumulates one week's detections and tests across rows.
+type rsvWeek struct {
+	detections int
+	tests      int
+}
+
+// GetNREVSSSummary fetches national RSV detections and tests from
+// data.cdc.gov and summarizes the latest reported week. Use
+// GetNREVSSSummaryFromCSV for a downloaded file when offline.
+func (c *CDCFluViewClient) GetNREVSSSummary() (*CDCFluSummary, error) {
+	return c.GetNREVSSSummaryContext(context.Background())
+}
+
+// GetNREVSSSummaryContext is GetNREVSSSummary with a caller-supplied context.
+func (c *CDCFluViewClient) GetNREVSSSummaryContext(ctx context.Context) (*CDCFluSummary, error) {
+	cutoff := time.Now().UTC().Add(-nrevssLookback).Truncate(24 * time.Hour)
+	q := url.Values{}
+	q.Set("$select", "mmwrweek_end,pcr_detections,pcr_tests")
+	q.Set("$where", fmt.Sprintf("level = '%s' AND mmwrweek_end >= '%s'", nrevssLevel, cutoff.Format(socrataTimestamp)))
+	q.Set("$order", "mmwrweek_end DESC")
+	q.Set("$limit", strconv.Itoa(nrevssPageLimit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.nrevssURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build NREVSS request: %w", err)
+	}
+	resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch NREVSS data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var rows []nrevssRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("decode NREVSS data: %w", err)
+	}
+
+	byDate := make(map[time.Time]*rsvWeek)
+	for _, row := range rows {
+		weekDate, err := time.Parse(socrataTimestamp, row.WeekEnd)
+		if err != nil {
+			continue
 		}
+		det, _ := strconv.ParseFloat(row.Detections, 64)
+		tests, _ := strconv.ParseFloat(row.Tests, 64)
+		addRSVWeek(byDate, weekDate, int(det), int(tests))
+	}
+	if len(byDate) == 0 {
+		return nil, fmt.Errorf("NREVSS returned no rows since %s", cutoff.Format("2006-01-02"))
 	}
-	return defaultVal
+	return summarizeRSV(byDate), nil
 }
 
 // GetNREVSSSummaryFromCSV parses a locally downloaded NREVSS CSV and returns the most recent week's detections/tests.
@@ -141,12 +319,7 @@ func (c *CDCFluViewClient) GetNREVSSSummaryFromCSV(path string) (*CDCFluSummary,
 		return nil, fmt.Errorf("NREVSS CSV has no data rows")
 	}
 
-	type agg struct {
-		detections int
-		tests      int
-	}
-
-	byDate := make(map[time.Time]*agg)
+	byDate := make(map[time.Time]*rsvWeek)
 
 	for i, row := range rows {
 		if i == 0 {
@@ -162,22 +335,31 @@ func (c *CDCFluViewClient) GetNREVSSSummaryFromCSV(path string) (*CDCFluSummary,
 			continue
 		}
 
-		det, _ := strconv.Atoi(strings.TrimSpace(row[5])) // RSV Detections
+		det, _ := strconv.Atoi(strings.TrimSpace(row[5]))   // RSV Detections
 		tests, _ := strconv.Atoi(strings.TrimSpace(row[6])) // RSV Tests
-
-		a := byDate[weekDate]
-		if a == nil {
-			a = &agg{}
-			byDate[weekDate] = a
-		}
-		a.detections += det
-		a.tests += tests
+		addRSVWeek(byDate, weekDate, det, tests)
 	}
 
 	if len(byDate) == 0 {
 		return nil, fmt.Errorf("NREVSS CSV had no parseable rows")
 	}
+	return summarizeRSV(byDate), nil
+}
+
+// addRSVWeek adds one row's counts to its week.
+func addRSVWeek(byDate map[time.Time]*rsvWeek, week time.Time, detections, tests int) {
+	a := byDate[week]
+	if a == nil {
+		a = &rsvWeek{}
+		byDate[week] = a
+	}
+	a.detections += detections
+	a.tests += tests
+}
 
+// summarizeRSV reports the latest week's totals and percent positive; a week
+// without tests has no percentage.
+func summarizeRSV(byDate map[time.Time]*rsvWeek) *CDCFluSummary {
 	var latest time.Time
 	for d := range byDate {
 		if d.After(latest) {
@@ -185,37 +367,59 @@ func (c *CDCFluViewClient) GetNREVSSSummaryFromCSV(path string) (*CDCFluSummary,
 		}
 	}
 
-	latestAgg := byDate[latest]
-	if latestAgg == nil || latestAgg.tests == 0 {
-		return &CDCFluSummary{WeekEndDate: latest, Region: "national"}, nil
+	week := byDate[latest]
+	summary := &CDCFluSummary{
+		WeekEndDate:   latest,
+		Region:        "national",
+		RSVDetections: week.detections,
+		RSVTests:      week.tests,
 	}
+	if week.tests > 0 {
+		summary.RSVPercentPositive = float64(week.detections) / float64(week.tests) * 100.0
+	}
+	return summary
+}
 
-	positivity := float64(latestAgg.detections) / float64(latestAgg.tests) * 100.0
-
-	return &CDCFluSummary{
-		WeekEndDate:        latest,
-		UnweightedILI:      positivity,            // reuse field for RSV percent positive
-		FluCases:           latestAgg.detections,   // reuse field for RSV detections
-		HospitalAdmissions: latestAgg.tests,       // reuse field for RSV total tests
-		Region:             "national",
-	}, nil
+// iliIDName is the {ID, Name} pair the download service expects in its
+// lists.
+type iliIDName struct {
+	ID   int    `json:"ID"`
+	Name string `json:"Name"`
 }
 
-// fetchILINetData issues the POST form request expected by the CDC endpoint.
-func (c *CDCFluViewClient) fetchILINetData(activityID, seasonID, regionID, groupID string) ([]byte, error) {
-	form := url.Values{}
-	form.Set("llILIActivityID", activityID)
-	form.Set("llSeasonID", seasonID)
-	form.Set("llRegionID", regionID)
-	form.Set("llGroupID", groupID)
+// fetchILINetData requests the ILINet download for the given region type and
+// sub-region IDs over the current and previous seasons, and returns the
+// ILINet.csv from the zip it answers with.
+func (c *CDCFluViewClient) fetchILINetData(ctx context.Context, regionType int, subRegions []int) ([]byte, error) {
+	payload := struct {
+		AppVersion   string      `json:"AppVersion"`
+		Datasources  []iliIDName `json:"DatasourceDT"`
+		RegionTypeID int         `json:"RegionTypeId"`
+		SubRegions   []iliIDName `json:"SubRegionsDT"`
+		Seasons      []iliIDName `json:"SeasonsDT"`
+	}{
+		AppVersion:   "Public",
+		Datasources:  []iliIDName{{ID: 1, Name: "ILINet"}},
+		RegionTypeID: regionType,
+	}
+	for _, id := range subRegions {
+		payload.SubRegions = append(payload.SubRegions, iliIDName{ID: id, Name: strconv.Itoa(id)})
+	}
+	for _, id := range currentFluSeasons(time.Now().UTC()) {
+		payload.Seasons = append(payload.Seasons, iliIDName{ID: id, Name: strconv.Itoa(id)})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode CDC request: %w", err)
+	}
 
 	endpoint := fmt.Sprintf("%s/PostPhase02DataDownload", c.baseURL)
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("build CDC request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Referer", "https://gis.cdc.gov/grasp/flu/")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", "https://gis.cdc.gov/grasp/fluview/fluportaldashboard.html")
 	req.Header.Set("User-Agent", "EdgeSight/1.0")
 
 	resp, err := c.httpCli.Do(req)
@@ -225,14 +429,32 @@ func (c *CDCFluViewClient) fetchILINetData(activityID, seasonID, regionID, group
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("CDC ILINet API returned %d: %s", resp.StatusCode, string(body))
+		return nil, readStatusError(resp)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	archive, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read CDC response: %w", err)
 	}
+	return unzipILINet(archive)
+}
 
-	return body, nil
+// unzipILINet returns the contents of ILINet.csv from a download archive.
+func unzipILINet(archive []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("open CDC download archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if !strings.EqualFold(path.Base(f.Name), "ILINet.csv") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("CDC download archive has no ILINet.csv")
 }
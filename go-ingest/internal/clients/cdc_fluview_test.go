@@ -0,0 +1,143 @@
+package clients
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ilinetServer answers PostPhase02DataDownload with a zip holding the
+// testdata CSV for the requested RegionTypeId, as the FluView download
+// service does, and records the sub-region IDs of each request.
+func ilinetServer(t *testing.T, byRegionType map[int]string) (*httptest.Server, *[]int) {
+	t.Helper()
+	archives := make(map[int][]byte, len(byRegionType))
+	for regionType, name := range byRegionType {
+		var archive bytes.Buffer
+		zw := zip.NewWriter(&archive)
+		w, err := zw.Create("FluViewPhase2Data/ILINet.csv")
+		if err != nil {
+			t.Fatalf("zip: %v", err)
+		}
+		w.Write(readFixture(t, name))
+		if err := zw.Close(); err != nil {
+			t.Fatalf("zip: %v", err)
+		}
+		archives[regionType] = archive.Bytes()
+	}
+
+	var subRegions []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RegionTypeID int         `json:"RegionTypeId"`
+			SubRegions   []iliIDName `json:"SubRegionsDT"`
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/PostPhase02DataDownload" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		for _, s := range req.SubRegions {
+			subRegions = append(subRegions, s.ID)
+		}
+		archive, ok := archives[req.RegionTypeID]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(archive)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &subRegions
+}
+
+func TestCDCGetNationalILIData(t *testing.T) {
+	srv, _ := ilinetServer(t, map[int]string{iliRegionNational: "ILINet.csv"})
+	c := NewCDCFluViewClient()
+	c.baseURL = srv.URL
+
+	got, err := c.GetNationalILIData()
+	if err != nil {
+		t.Fatalf("GetNationalILIData: %v", err)
+	}
+	// Week 53 is unreported, so week 52 of 2025 is the latest complete one.
+	want := time.Date(2025, 12, 27, 0, 0, 0, 0, time.UTC)
+	if !got.WeekEndDate.Equal(want) || got.UnweightedILI != 3.75019 || got.FluCases != 98734 || got.Region != "national" {
+		t.Errorf("summary = %+v, want week ending %s with 3.75019%% ILI over 98734 visits", *got, want.Format(time.DateOnly))
+	}
+}
+
+func TestCDCGetStateILIData(t *testing.T) {
+	srv, subRegions := ilinetServer(t, map[int]string{
+		iliRegionState: "ilinet_states.csv",
+		iliRegionHHS:   "ilinet_hhs.csv",
+	})
+	c := NewCDCFluViewClient()
+	c.baseURL = srv.URL
+
+	co, err := c.GetStateILIData("co")
+	if err != nil {
+		t.Fatalf("GetStateILIData(co): %v", err)
+	}
+	if co.Region != "state" || co.UnweightedILI != 2.47561 || co.FluCases != 1502 {
+		t.Errorf("Colorado = %+v, want its own week 52 figures", *co)
+	}
+	if !slices.Equal(*subRegions, []int{6}) {
+		t.Errorf("requested sub-regions %v, want Colorado's ID 6", *subRegions)
+	}
+
+	// California reports nothing, so HHS region 9 stands in.
+	*subRegions = nil
+	ca, err := c.GetStateILIData("California")
+	if err != nil {
+		t.Fatalf("GetStateILIData(California): %v", err)
+	}
+	if ca.Region != "hhs-9 (fallback)" || ca.UnweightedILI != 3.21015 || ca.FluCases != 13420 {
+		t.Errorf("California = %+v, want HHS region 9 week 52 flagged as a fallback", *ca)
+	}
+	if want := []int{5, 9}; !slices.Equal(*subRegions, want) {
+		t.Errorf("requested sub-regions %v, want %v", *subRegions, want)
+	}
+
+	for _, state := range []string{"ZZ", "Atlantis", ""} {
+		if _, err := c.GetStateILIData(state); err == nil {
+			t.Errorf("GetStateILIData(%q): want an error", state)
+		}
+	}
+	if _, err := c.GetStateILIData("ZZ"); !errors.Is(err, ErrUnknownState) {
+		t.Errorf("GetStateILIData(ZZ) = %v, want ErrUnknownState", err)
+	}
+}
+
+func TestMMWRWeekEnd(t *testing.T) {
+	tests := []struct {
+		year, week int
+		want       string
+	}{
+		{2025, 1, "2025-01-04"}, // Jan 1 is a Wednesday: that week is week 1
+		{2025, 52, "2025-12-27"},
+		{2026, 1, "2026-01-10"}, // Jan 1 is a Thursday: week 1 starts Jan 4
+		{2026, 40, "2026-10-10"},
+	}
+	for _, tt := range tests {
+		if got := mmwrWeekEnd(tt.year, tt.week).Format(time.DateOnly); got != tt.want {
+			t.Errorf("mmwrWeekEnd(%d, %d) = %s, want %s", tt.year, tt.week, got, tt.want)
+		}
+	}
+}
+
+func TestUnzipILINetMissingFile(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	zw.Create("README.txt")
+	zw.Close()
+	if _, err := unzipILINet(archive.Bytes()); err == nil {
+		t.Error("archive without ILINet.csv: want an error")
+	}
+}
@@ -0,0 +1,102 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownState is returned for a state the ILINet service has no
+// jurisdiction for.
+var ErrUnknownState = errors.New("cdc: unknown state")
+
+// iliJurisdiction is one ILINet state-level jurisdiction: its sub-region ID
+// in the download form (RegionTypeId 5), the REGION name in ILINet.csv, and
+// the HHS region it belongs to.
+type iliJurisdiction struct {
+	ID   int
+	Name string
+	HHS  int
+}
+
+// iliStates maps USPS codes to ILINet jurisdictions. New York City reports
+// separately from the rest of New York and is keyed "NYC".
+var iliStates = map[string]iliJurisdiction{
+	"AL":  {1, "Alabama", 4},
+	"AK":  {2, "Alaska", 10},
+	"AZ":  {3, "Arizona", 9},
+	"AR":  {4, "Arkansas", 6},
+	"CA":  {5, "California", 9},
+	"CO":  {6, "Colorado", 8},
+	"CT":  {7, "Connecticut", 1},
+	"DE":  {8, "Delaware", 3},
+	"DC":  {9, "District of Columbia", 3},
+	"FL":  {10, "Florida", 4},
+	"GA":  {11, "Georgia", 4},
+	"HI":  {12, "Hawaii", 9},
+	"ID":  {13, "Idaho", 10},
+	"IL":  {14, "Illinois", 5},
+	"IN":  {15, "Indiana", 5},
+	"IA":  {16, "Iowa", 7},
+	"KS":  {17, "Kansas", 7},
+	"KY":  {18, "Kentucky", 4},
+	"LA":  {19, "Louisiana", 6},
+	"ME":  {20, "Maine", 1},
+	"MD":  {21, "Maryland", 3},
+	"MA":  {22, "Massachusetts", 1},
+	"MI":  {23, "Michigan", 5},
+	"MN":  {24, "Minnesota", 5},
+	"MS":  {25, "Mississippi", 4},
+	"MO":  {26, "Missouri", 7},
+	"MT":  {27, "Montana", 8},
+	"NE":  {28, "Nebraska", 7},
+	"NV":  {29, "Nevada", 9},
+	"NH":  {30, "New Hampshire", 1},
+	"NJ":  {31, "New Jersey", 2},
+	"NM":  {32, "New Mexico", 6},
+	"NY":  {33, "New York", 2},
+	"NC":  {34, "North Carolina", 4},
+	"ND":  {35, "North Dakota", 8},
+	"OH":  {36, "Ohio", 5},
+	"OK":  {37, "Oklahoma", 6},
+	"OR":  {38, "Oregon", 10},
+	"PA":  {39, "Pennsylvania", 3},
+	"RI":  {40, "Rhode Island", 1},
+	"SC":  {41, "South Carolina", 4},
+	"SD":  {42, "South Dakota", 8},
+	"TN":  {43, "Tennessee", 4},
+	"TX":  {44, "Texas", 6},
+	"UT":  {45, "Utah", 8},
+	"VT":  {46, "Vermont", 1},
+	"VA":  {47, "Virginia", 3},
+	"WA":  {48, "Washington", 10},
+	"WV":  {49, "West Virginia", 3},
+	"WI":  {50, "Wisconsin", 5},
+	"WY":  {51, "Wyoming", 8},
+	"NYC": {52, "New York City", 2},
+	"PR":  {53, "Puerto Rico", 2},
+	"VI":  {54, "Virgin Islands", 2},
+}
+
+// lookupILIState resolves a USPS code (or "NYC"), or an ILINet REGION name
+// such as "California", to its jurisdiction, ignoring case.
+func lookupILIState(state string) (iliJurisdiction, error) {
+	key := strings.ToUpper(strings.TrimSpace(state))
+	if key == "" {
+		return iliJurisdiction{}, fmt.Errorf("state required")
+	}
+	if j, ok := iliStates[key]; ok {
+		return j, nil
+	}
+	for _, j := range iliStates {
+		if strings.EqualFold(j.Name, key) {
+			return j, nil
+		}
+	}
+	return iliJurisdiction{}, fmt.Errorf("%q: %w", state, ErrUnknownState)
+}
+
+// hhsRegionName is how ILINet.csv names HHS region n in its REGION column.
+func hhsRegionName(n int) string {
+	return fmt.Sprintf("Region %d", n)
+}
@@ -1,9 +1,13 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -11,59 +15,180 @@ import (
 type CityBikesClient struct {
 	baseURL string
 	httpCli *http.Client
+	retry   retryPolicy
 }
 
 // NewCityBikesClient creates a new CityBikes API client
-func NewCityBikesClient() *CityBikesClient {
+func NewCityBikesClient(opts ...ClientOption) *CityBikesClient {
 	return &CityBikesClient{
 		baseURL: "http://api.citybik.es/v2",
-		httpCli: &http.Client{Timeout: 10 * time.Second},
+		httpCli: applyOptions(NewHTTPClient(envTimeout("CITYBIKES_TIMEOUT", 10*time.Second)), opts),
+		retry:   defaultRetryPolicy,
 	}
 }
 
 // NetworksResponse is a subset of the /v2/networks response.
 type NetworksResponse struct {
-    Networks []Network `json:"networks"`
+	Networks []Network `json:"networks"`
 }
 
 // Network holds brief network metadata.
 type Network struct {
-    ID       string   `json:"id"`
-    Name     string   `json:"name"`
-    Location BikeLocation `json:"location"`
-    Href     string   `json:"href"`
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Location BikeLocation `json:"location"`
+	Href     string       `json:"href"`
 }
 
 // Location holds geographical information about a network.
 type BikeLocation struct {
-    City      string  `json:"city"`
-    Country   string  `json:"country"`
-    Latitude  float64 `json:"latitude"`
-    Longitude float64 `json:"longitude"`
+	City      string  `json:"city"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// NetworkDetail is a network with its stations, from /v2/networks/{id}.
+type NetworkDetail struct {
+	Network
+	Stations []BikeStation `json:"stations"`
+}
+
+// BikeStation is one dock and its current availability.
+type BikeStation struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	FreeBikes  int     `json:"free_bikes"`
+	EmptySlots *int    `json:"empty_slots"` // null for dockless stations
+	Timestamp  string  `json:"timestamp"`
+}
+
+// BikeShareSummary totals a network's stations.
+type BikeShareSummary struct {
+	Stations           int
+	BikesAvailable     int       // bikes parked at stations
+	EmptyDocks         int       // docks without a bike
+	TotalDocks         int       // BikesAvailable + EmptyDocks, over docked stations
+	UtilizationPercent float64   // share of docks without a bike, i.e. bikes out in use
+	ObservedAt         time.Time // newest station update; zero when none is dated
 }
 
 // ListNetworks fetches the bike networks catalogue.
 func (c *CityBikesClient) ListNetworks() (*NetworksResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/networks", c.baseURL), nil)
-	if err != nil {
-		return nil, fmt.Errorf("build request: %w", err)
+	return c.ListNetworksContext(context.Background())
+}
+
+// ListNetworksContext is ListNetworks with a caller-supplied context.
+func (c *CityBikesClient) ListNetworksContext(ctx context.Context) (*NetworksResponse, error) {
+	var parsed NetworksResponse
+	if err := c.get(ctx, "/networks", &parsed); err != nil {
+		return nil, err
 	}
+	return &parsed, nil
+}
+
+// GetNetwork fetches one network with the availability of every station.
+func (c *CityBikesClient) GetNetwork(id string) (*NetworkDetail, error) {
+	return c.GetNetworkContext(context.Background(), id)
+}
+
+// GetNetworkContext is GetNetwork with a caller-supplied context.
+func (c *CityBikesClient) GetNetworkContext(ctx context.Context, id string) (*NetworkDetail, error) {
+	if id == "" {
+		return nil, errors.New("CityBikes network ID is empty")
+	}
+	var parsed struct {
+		Network NetworkDetail `json:"network"`
+	}
+	if err := c.get(ctx, "/networks/"+url.PathEscape(id), &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed.Network, nil
+}
 
-	resp, err := c.httpCli.Do(req)
+// FindNearestNetwork returns the network whose location is closest to
+// lat/lon and its distance in km.
+func (c *CityBikesClient) FindNearestNetwork(lat, lon float64) (*Network, float64, error) {
+	return c.FindNearestNetworkContext(context.Background(), lat, lon)
+}
+
+// FindNearestNetworkContext is FindNearestNetwork with a caller-supplied context.
+func (c *CityBikesClient) FindNearestNetworkContext(ctx context.Context, lat, lon float64) (*Network, float64, error) {
+	networks, err := c.ListNetworksContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, 0, err
 	}
-	defer resp.Body.Close()
+	nearest, dist := nearestNetwork(networks.Networks, lat, lon)
+	if nearest == nil {
+		return nil, 0, errors.New("CityBikes lists no networks")
+	}
+	return nearest, dist, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+// nearestNetwork picks the network closest to lat/lon, skipping networks
+// without coordinates.
+func nearestNetwork(networks []Network, lat, lon float64) (*Network, float64) {
+	var nearest *Network
+	best := math.Inf(1)
+	for i, n := range networks {
+		if n.Location.Latitude == 0 && n.Location.Longitude == 0 {
+			continue
+		}
+		if d := haversineKM(lat, lon, n.Location.Latitude, n.Location.Longitude); d < best {
+			nearest, best = &networks[i], d
+		}
 	}
+	return nearest, best
+}
 
-	var parsed NetworksResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+// Summary totals the network's stations. Dockless stations, which report
+// no empty slots, count toward the bikes available but not the docks.
+func (n *NetworkDetail) Summary() *BikeShareSummary {
+	s := &BikeShareSummary{Stations: len(n.Stations)}
+	var dockedBikes int
+	for _, st := range n.Stations {
+		s.BikesAvailable += st.FreeBikes
+		if st.EmptySlots != nil {
+			s.EmptyDocks += *st.EmptySlots
+			dockedBikes += st.FreeBikes
+		}
+		if ts, ok := parseCityBikesTime(st.Timestamp); ok && ts.After(s.ObservedAt) {
+			s.ObservedAt = ts
+		}
 	}
+	s.TotalDocks = dockedBikes + s.EmptyDocks
+	if s.TotalDocks > 0 {
+		s.UtilizationPercent = float64(s.EmptyDocks) / float64(s.TotalDocks) * 100
+	}
+	return s
+}
 
-	return &parsed, nil
+// parseCityBikesTime reads a station timestamp, which is ISO 8601 with or
+// without a zone (UTC when absent).
+func parseCityBikesTime(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
 }
 
+// get fetches path under the API root and decodes the JSON body into out.
+func (c *CityBikesClient) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
+	if err != nil {
+		return fmt.Errorf("CityBikes %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("CityBikes %s: decode response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCityBikesFindNearestNetwork(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/networks": "citybikes_networks.json"})
+	c := NewCityBikesClient()
+	c.baseURL = srv.URL
+
+	// From downtown Denver; the network listed at 0,0 has no coordinates and
+	// is never a candidate.
+	got, dist, err := c.FindNearestNetwork(39.7392, -104.9903)
+	if err != nil {
+		t.Fatalf("FindNearestNetwork: %v", err)
+	}
+	if got.ID != "denver" || dist > 1 {
+		t.Errorf("nearest = %s at %.1f km, want denver within 1 km", got.ID, dist)
+	}
+
+	got, dist, err = c.FindNearestNetwork(40.02, -105.25)
+	if err != nil {
+		t.Fatalf("FindNearestNetwork: %v", err)
+	}
+	if got.ID != "boulder" || dist > 5 {
+		t.Errorf("nearest to Boulder = %s at %.1f km, want boulder", got.ID, dist)
+	}
+}
+
+func TestCityBikesGetNetworkSummary(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/networks/denver": "citybikes_network.json"})
+	c := NewCityBikesClient()
+	c.baseURL = srv.URL
+
+	network, err := c.GetNetwork("denver")
+	if err != nil {
+		t.Fatalf("GetNetwork: %v", err)
+	}
+	if network.Name != "Denver B-cycle" || len(network.Stations) != 4 {
+		t.Fatalf("network = %s with %d stations, want Denver B-cycle with 4", network.Name, len(network.Stations))
+	}
+	if st := network.Stations[2]; st.FreeBikes != 2 || st.EmptySlots != nil {
+		t.Errorf("dockless station = %+v, want 2 bikes and no empty slots", st)
+	}
+
+	s := network.Summary()
+	// The dockless station's bikes are available but add no docks: 22
+	// empty of 30 docks.
+	if s.Stations != 4 || s.BikesAvailable != 10 || s.EmptyDocks != 22 || s.TotalDocks != 30 {
+		t.Errorf("summary = %+v, want 4 stations, 10 bikes, 22 of 30 docks empty", *s)
+	}
+	if math.Abs(s.UtilizationPercent-73.333) > 0.001 {
+		t.Errorf("UtilizationPercent = %.3f, want 73.333", s.UtilizationPercent)
+	}
+	// Union Station's zoneless timestamp is the newest
+	if want := time.Date(2026, 10, 17, 9, 1, 2, 500000000, time.UTC); !s.ObservedAt.Equal(want) {
+		t.Errorf("ObservedAt = %v, want %v", s.ObservedAt, want)
+	}
+
+	if _, err := c.GetNetwork(""); err == nil {
+		t.Error("GetNetwork with an empty ID: want an error")
+	}
+}
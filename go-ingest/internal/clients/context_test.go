@@ -0,0 +1,57 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCancelledContextAbortsSlowRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	meteo := NewOpenMeteoClient()
+	meteo.baseURL = srv.URL
+	fred := NewFREDClient("test-key")
+	fred.baseURL = srv.URL
+	aq := NewOpenAQClient("test-key")
+	aq.baseURL = srv.URL
+
+	calls := map[string]func(ctx context.Context) error{
+		"OpenMeteo": func(ctx context.Context) error {
+			_, err := meteo.GetCurrentWeatherContext(ctx, 34.05, -118.24)
+			return err
+		},
+		"FRED": func(ctx context.Context) error {
+			_, err := fred.GetLatestObservationContext(ctx, "CPIAUCSL")
+			return err
+		},
+		"OpenAQ": func(ctx context.Context) error {
+			_, err := aq.GetLatestByLocationIDContext(ctx, 42)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			err := call(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("call returned after %s, want it aborted promptly", elapsed)
+			}
+		})
+	}
+}
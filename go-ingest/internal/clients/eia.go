@@ -1,9 +1,13 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,22 +20,64 @@ type EIAClient struct {
 	Client  *http.Client
 }
 
-// EIAEnergySummary represents aggregated energy generation and price data
+// EIAEnergySummary represents aggregated energy generation and price data.
+// A field whose query failed is left zero, with a zero SourcedAt time.
 type EIAEnergySummary struct {
-	ElectricityGenerationMWh float64 // Total electricity generation
-	NaturalGasPriceMmbtu     float64 // Natural gas spot price ($/MMBtu)
-	CoalPriceTon             float64 // Coal price ($/short ton)
-	RenewableGenerationMWh   float64 // Renewable electricity generation
-	TotalDemandMWh           float64 // Total electricity demand
+	ElectricityGenerationMWh float64   // Total electricity generation
+	NaturalGasPriceMmbtu     float64   // Natural gas spot price ($/MMBtu)
+	CoalPriceTon             float64   // Coal price ($/short ton)
+	RenewableGenerationMWh   float64   // Solar, wind, hydro, geothermal and biomass generation
+	TotalDemandMWh           float64   // Lower-48 electricity demand in the latest hour
+	RetailPriceKWh           float64   // Average retail electricity price ($/kWh)
+	CrudeOilPriceBbl         float64   // WTI crude spot price ($/barrel)
+	Period                   time.Time // Start of the month the generation figure covers
+
+	SourcedAt EIASourcedAt
+}
+
+// EIASourcedAt is the start of the period each EIAEnergySummary value
+// covers: a month for generation, renewables and retail price, a day for the
+// spot prices, a year for coal and an hour (UTC) for demand.
+type EIASourcedAt struct {
+	Generation  time.Time
+	Renewables  time.Time
+	NaturalGas  time.Time
+	Coal        time.Time
+	Demand      time.Time
+	RetailPrice time.Time
+	CrudeOil    time.Time
 }
 
-// EIAResponse represents the API response structure
+// eiaRenewableFuels are the fueltypeid facets summed into renewable
+// generation: solar, wind, conventional hydro, geothermal and biomass.
+var eiaRenewableFuels = []string{"SUN", "WND", "HYC", "GEO", "BIO"}
+
+// EIA v2 routes read by the client. Each is filtered down to the single
+// series named alongside it (the v1 series ID it replaces).
+const (
+	// ELEC.GEN.ALL-US-99.M: monthly net generation, all fuels and sectors,
+	// in thousand MWh.
+	eiaGenerationRoute = "/electricity/electric-power-operational-data"
+	// NG.RNGWHHD.D: daily Henry Hub natural gas spot price, $/MMBtu.
+	eiaGasPriceRoute = "/natural-gas/pri/spt"
+	// COAL.PRICE_BY_RANK.US-TOT.A: annual average sales price of all coal
+	// ranks, $/short ton.
+	eiaCoalPriceRoute = "/coal/price-by-rank"
+	// EBA.US48-ALL.D.H: hourly Lower-48 demand, MWh. Also serves per
+	// balancing authority demand (type D) and day-ahead forecasts (DF).
+	eiaDemandRoute = "/electricity/rto/region-data"
+	// ELEC.PRICE.US-ALL.M: monthly average retail price, all sectors, cents/kWh.
+	eiaRetailPriceRoute = "/electricity/retail-sales"
+	// PET.RWTC.D: daily WTI Cushing crude spot price, $/barrel.
+	eiaCrudePriceRoute = "/petroleum/pri/spt"
+)
+
+// EIAResponse represents the API response structure. Each row holds
+// "period" plus the requested data column (and facet columns), and values
+// may be numbers or numeric strings depending on the dataset.
 type EIAResponse struct {
 	Response struct {
-		Data []struct {
-			Period string  `json:"period"`
-			Value  float64 `json:"value"`
-		} `json:"data"`
+		Data []map[string]json.RawMessage `json:"data"`
 	} `json:"response"`
 }
 
@@ -40,100 +86,383 @@ func NewEIAClient(apiKey string) *EIAClient {
 	return &EIAClient{
 		APIKey:  apiKey,
 		BaseURL: "https://api.eia.gov/v2",
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		Client:  NewHTTPClient(envTimeout("EIA_TIMEOUT", 30*time.Second)),
 	}
 }
 
 // GetElectricityGeneration fetches total US electricity generation data
 func (c *EIAClient) GetElectricityGeneration() (*EIAEnergySummary, error) {
-	if c.APIKey == "" {
-		return nil, fmt.Errorf("EIA API key required")
-	}
-
-	// Query electricity net generation (thousand megawatthours)
-	// Series ID: ELEC.GEN.ALL-US-99.M (monthly total generation)
-	endpoint := fmt.Sprintf("/electricity/electric-power-operational-data/data/?api_key=%s&frequency=monthly&data[0]=generation&facets[location][]=US&sort[0][column]=period&sort[0][direction]=desc&offset=0&length=1", c.APIKey)
+	return c.GetElectricityGenerationContext(context.Background())
+}
 
-	data, err := c.makeRequest(endpoint)
+// GetElectricityGenerationContext is GetElectricityGeneration with a caller-supplied context.
+// Renewable generation is best-effort and stays zero when its query fails.
+func (c *EIAClient) GetElectricityGenerationContext(ctx context.Context) (*EIAEnergySummary, error) {
+	thousandMWh, period, err := c.latestObservation(ctx, eiaGenerationRoute, "monthly", "generation", map[string][]string{
+		"location":   {"US"},
+		"fueltypeid": {"ALL"},
+		"sectorid":   {"99"},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var resp EIAResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	// Convert thousand MWh to MWh
+	summary := &EIAEnergySummary{
+		ElectricityGenerationMWh: thousandMWh * 1000,
+		Period:                   period,
 	}
+	summary.SourcedAt.Generation = period
 
-	if len(resp.Response.Data) == 0 {
-		return nil, fmt.Errorf("no data returned from EIA")
+	if mwh, at, err := c.renewableGeneration(ctx); err == nil {
+		summary.RenewableGenerationMWh = mwh
+		summary.SourcedAt.Renewables = at
 	}
+	return summary, nil
+}
 
-	// Convert thousand MWh to MWh
-	generationMWh := resp.Response.Data[0].Value * 1000
+// GetRenewableGeneration fetches the latest monthly US renewable generation
+// (solar, wind, hydro, geothermal and biomass) in MWh.
+func (c *EIAClient) GetRenewableGeneration() (float64, error) {
+	return c.GetRenewableGenerationContext(context.Background())
+}
 
-	// For demo purposes, return mock data for other fields
-	// In production, you'd make additional API calls for each metric
-	return &EIAEnergySummary{
-		ElectricityGenerationMWh: generationMWh,
-		NaturalGasPriceMmbtu:     2.85,
-		CoalPriceTon:             52.30,
-		RenewableGenerationMWh:   generationMWh * 0.21, // ~21% renewable
-		TotalDemandMWh:           generationMWh * 0.98, // Assume 2% losses
-	}, nil
+// GetRenewableGenerationContext is GetRenewableGeneration with a caller-supplied context.
+func (c *EIAClient) GetRenewableGenerationContext(ctx context.Context) (float64, error) {
+	mwh, _, err := c.renewableGeneration(ctx)
+	return mwh, err
+}
+
+// renewableGeneration sums the renewable fuels for the newest month any of
+// them reports, returning MWh and that month.
+func (c *EIAClient) renewableGeneration(ctx context.Context) (float64, time.Time, error) {
+	// A few months of rows for each fuel, so the newest month is complete
+	// even when the fuels are returned interleaved
+	rows, err := c.latestRows(ctx, eiaGenerationRoute, "monthly", "generation", map[string][]string{
+		"location":   {"US"},
+		"fueltypeid": eiaRenewableFuels,
+		"sectorid":   {"99"},
+	}, 3*len(eiaRenewableFuels))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	thousandMWh, period, err := sumLatestPeriod(rows, eiaGenerationRoute, "generation")
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return thousandMWh * 1000, period, nil
 }
 
 // GetNaturalGasPrice fetches current natural gas spot prices
 func (c *EIAClient) GetNaturalGasPrice() (float64, error) {
-	if c.APIKey == "" {
-		return 0, fmt.Errorf("EIA API key required")
-	}
+	return c.GetNaturalGasPriceContext(context.Background())
+}
+
+// GetNaturalGasPriceContext is GetNaturalGasPrice with a caller-supplied context.
+func (c *EIAClient) GetNaturalGasPriceContext(ctx context.Context) (float64, error) {
+	v, _, err := c.naturalGasPrice(ctx)
+	return v, err
+}
 
-	// Query Henry Hub Natural Gas Spot Price
-	// Series ID: NG.RNGWHHD.D
-	endpoint := fmt.Sprintf("/natural-gas/pri/spt/data/?api_key=%s&frequency=daily&data[0]=value&facets[series][]=RNGWHHD&sort[0][column]=period&sort[0][direction]=desc&offset=0&length=1", c.APIKey)
+func (c *EIAClient) naturalGasPrice(ctx context.Context) (float64, time.Time, error) {
+	return c.latestObservation(ctx, eiaGasPriceRoute, "daily", "value", map[string][]string{"series": {"RNGWHHD"}})
+}
+
+// GetCoalPrice fetches the latest annual US average coal sales price in
+// $/short ton.
+func (c *EIAClient) GetCoalPrice() (float64, error) {
+	return c.GetCoalPriceContext(context.Background())
+}
+
+// GetCoalPriceContext is GetCoalPrice with a caller-supplied context.
+func (c *EIAClient) GetCoalPriceContext(ctx context.Context) (float64, error) {
+	v, _, err := c.coalPrice(ctx)
+	return v, err
+}
+
+func (c *EIAClient) coalPrice(ctx context.Context) (float64, time.Time, error) {
+	return c.latestObservation(ctx, eiaCoalPriceRoute, "annual", "price", map[string][]string{
+		"stateRegionId": {"US"},
+		"coalRankId":    {"TOT"},
+	})
+}
+
+// GetElectricityDemand fetches Lower-48 electricity demand for the latest
+// reported hour, in MWh.
+func (c *EIAClient) GetElectricityDemand() (float64, error) {
+	return c.GetElectricityDemandContext(context.Background())
+}
+
+// GetElectricityDemandContext is GetElectricityDemand with a caller-supplied context.
+func (c *EIAClient) GetElectricityDemandContext(ctx context.Context) (float64, error) {
+	v, _, err := c.electricityDemand(ctx)
+	return v, err
+}
+
+func (c *EIAClient) electricityDemand(ctx context.Context) (float64, time.Time, error) {
+	return c.latestObservation(ctx, eiaDemandRoute, "hourly", "value", map[string][]string{
+		"respondent": {"US48"},
+		"type":       {"D"},
+	})
+}
+
+// BalancingAuthorityDemand is the latest hourly demand of one balancing
+// authority from EIA's Form 930 data.
+type BalancingAuthorityDemand struct {
+	Respondent  string    // EIA balancing authority code, e.g. CISO, ERCO, PJM
+	Hour        time.Time // Start of the hour DemandMWh covers (UTC)
+	DemandMWh   float64   // Reported demand for Hour
+	ForecastMWh float64   // Day-ahead demand forecast for Hour, 0 when not published
+	PeakMWh     float64   // Highest day-ahead forecast within a day either side of Hour
+}
+
+// eiaForecastWindow is how many hourly day-ahead forecast rows are read:
+// the forecasts run about a day past the latest reported hour, so two days
+// of rows cover a day either side of it.
+const eiaForecastWindow = 48
 
-	data, err := c.makeRequest(endpoint)
+// GetHourlyDemand fetches the latest reported hour of demand for a balancing
+// authority (e.g. "CISO", "ERCO", "PJM") along with its day-ahead forecast.
+func (c *EIAClient) GetHourlyDemand(ba string) (*BalancingAuthorityDemand, error) {
+	return c.GetHourlyDemandContext(context.Background(), ba)
+}
+
+// GetHourlyDemandContext is GetHourlyDemand with a caller-supplied context.
+// The forecast is best-effort: ForecastMWh and PeakMWh stay zero when its
+// query fails.
+func (c *EIAClient) GetHourlyDemandContext(ctx context.Context, ba string) (*BalancingAuthorityDemand, error) {
+	ba = strings.ToUpper(strings.TrimSpace(ba))
+	if ba == "" {
+		return nil, fmt.Errorf("EIA balancing authority required")
+	}
+
+	demand, hour, err := c.latestObservation(ctx, eiaDemandRoute, "hourly", "value", map[string][]string{
+		"respondent": {ba},
+		"type":       {"D"},
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	out := &BalancingAuthorityDemand{Respondent: ba, Hour: hour, DemandMWh: demand}
 
-	var resp EIAResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
-		return 0, fmt.Errorf("decode response: %w", err)
+	rows, err := c.latestRows(ctx, eiaDemandRoute, "hourly", "value", map[string][]string{
+		"respondent": {ba},
+		"type":       {"DF"},
+	}, eiaForecastWindow)
+	if err != nil {
+		return out, nil
 	}
+	for _, row := range rows {
+		v, period, err := decodeEIARow(row, eiaDemandRoute, "value")
+		if err != nil {
+			continue
+		}
+		if period.Equal(hour) {
+			out.ForecastMWh = v
+		}
+		if d := period.Sub(hour); d > -24*time.Hour && d < 24*time.Hour && v > out.PeakMWh {
+			out.PeakMWh = v
+		}
+	}
+	return out, nil
+}
 
-	if len(resp.Response.Data) == 0 {
-		return 0, fmt.Errorf("no gas price data returned")
+// GetRetailElectricityPrice fetches the latest monthly US average retail
+// electricity price across all sectors, in $/kWh.
+func (c *EIAClient) GetRetailElectricityPrice() (float64, error) {
+	return c.GetRetailElectricityPriceContext(context.Background())
+}
+
+// GetRetailElectricityPriceContext is GetRetailElectricityPrice with a caller-supplied context.
+func (c *EIAClient) GetRetailElectricityPriceContext(ctx context.Context) (float64, error) {
+	v, _, err := c.retailElectricityPrice(ctx)
+	return v, err
+}
+
+func (c *EIAClient) retailElectricityPrice(ctx context.Context) (float64, time.Time, error) {
+	cents, period, err := c.latestObservation(ctx, eiaRetailPriceRoute, "monthly", "price", map[string][]string{
+		"stateid":  {"US"},
+		"sectorid": {"ALL"},
+	})
+	if err != nil {
+		return 0, time.Time{}, err
 	}
+	return cents / 100, period, nil
+}
+
+// GetCrudeOilPrice fetches the latest WTI crude spot price in $/barrel.
+func (c *EIAClient) GetCrudeOilPrice() (float64, error) {
+	return c.GetCrudeOilPriceContext(context.Background())
+}
+
+// GetCrudeOilPriceContext is GetCrudeOilPrice with a caller-supplied context.
+func (c *EIAClient) GetCrudeOilPriceContext(ctx context.Context) (float64, error) {
+	v, _, err := c.crudeOilPrice(ctx)
+	return v, err
+}
 
-	return resp.Response.Data[0].Value, nil
+func (c *EIAClient) crudeOilPrice(ctx context.Context) (float64, time.Time, error) {
+	return c.latestObservation(ctx, eiaCrudePriceRoute, "daily", "value", map[string][]string{"series": {"RWTC"}})
 }
 
 // GetEnergySummary fetches comprehensive energy data
 func (c *EIAClient) GetEnergySummary() (*EIAEnergySummary, error) {
-	summary, err := c.GetElectricityGeneration()
+	return c.GetEnergySummaryContext(context.Background())
+}
+
+// GetEnergySummaryContext is GetEnergySummary with a caller-supplied context.
+// Generation is required; the other series are best-effort and stay zero
+// when their query fails.
+func (c *EIAClient) GetEnergySummaryContext(ctx context.Context) (*EIAEnergySummary, error) {
+	summary, err := c.GetElectricityGenerationContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Try to get natural gas price, but don't fail if it errors
-	gasPrice, err := c.GetNaturalGasPrice()
-	if err == nil {
-		summary.NaturalGasPriceMmbtu = gasPrice
+	if v, at, err := c.naturalGasPrice(ctx); err == nil {
+		summary.NaturalGasPriceMmbtu = v
+		summary.SourcedAt.NaturalGas = at
+	}
+	if v, at, err := c.coalPrice(ctx); err == nil {
+		summary.CoalPriceTon = v
+		summary.SourcedAt.Coal = at
+	}
+	if v, at, err := c.electricityDemand(ctx); err == nil {
+		summary.TotalDemandMWh = v
+		summary.SourcedAt.Demand = at
+	}
+	if v, at, err := c.retailElectricityPrice(ctx); err == nil {
+		summary.RetailPriceKWh = v
+		summary.SourcedAt.RetailPrice = at
+	}
+	if v, at, err := c.crudeOilPrice(ctx); err == nil {
+		summary.CrudeOilPriceBbl = v
+		summary.SourcedAt.CrudeOil = at
 	}
 
 	return summary, nil
 }
 
+// latestObservation reads column from the newest row of an EIA v2 data
+// route narrowed by facets, along with the start of the period it covers.
+func (c *EIAClient) latestObservation(ctx context.Context, route, frequency, column string, facets map[string][]string) (float64, time.Time, error) {
+	rows, err := c.latestRows(ctx, route, frequency, column, facets, 1)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return decodeEIARow(rows[0], route, column)
+}
+
+// latestRows fetches up to length rows of an EIA v2 data route, newest
+// period first. A facet with several values matches any of them.
+func (c *EIAClient) latestRows(ctx context.Context, route, frequency, column string, facets map[string][]string, length int) ([]map[string]json.RawMessage, error) {
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("EIA API key required")
+	}
+
+	q := url.Values{}
+	q.Set("api_key", c.APIKey)
+	q.Set("frequency", frequency)
+	q.Set("data[0]", column)
+	for facet, vals := range facets {
+		for _, v := range vals {
+			q.Add("facets["+facet+"][]", v)
+		}
+	}
+	q.Set("sort[0][column]", "period")
+	q.Set("sort[0][direction]", "desc")
+	q.Set("offset", "0")
+	q.Set("length", strconv.Itoa(length))
+
+	data, err := c.makeRequest(ctx, route+"/data/?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var resp EIAResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if len(resp.Response.Data) == 0 {
+		return nil, fmt.Errorf("no data returned from EIA %s", route)
+	}
+	return resp.Response.Data, nil
+}
+
+// decodeEIARow extracts column and the period from one row.
+func decodeEIARow(row map[string]json.RawMessage, route, column string) (float64, time.Time, error) {
+	period := eiaRowPeriod(row)
+
+	raw := row[column]
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, time.Time{}, fmt.Errorf("EIA %s %s: no value in latest period", route, column)
+	}
+	var v float64
+	if err := json.Unmarshal(raw, &v); err == nil {
+		return v, period, nil
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		if v, err := strconv.ParseFloat(str, 64); err == nil {
+			return v, period, nil
+		}
+	}
+	return 0, time.Time{}, fmt.Errorf("EIA %s %s: unusable value %s", route, column, raw)
+}
+
+// sumLatestPeriod adds up column over the rows that share the newest period,
+// skipping rows without a value. Rows must be sorted newest first.
+func sumLatestPeriod(rows []map[string]json.RawMessage, route, column string) (float64, time.Time, error) {
+	latest := eiaRowPeriod(rows[0])
+	var sum float64
+	var n int
+	for _, row := range rows {
+		if !eiaRowPeriod(row).Equal(latest) {
+			break
+		}
+		v, _, err := decodeEIARow(row, route, column)
+		if err != nil {
+			continue
+		}
+		sum += v
+		n++
+	}
+	if n == 0 {
+		return 0, time.Time{}, fmt.Errorf("EIA %s %s: no values in latest period", route, column)
+	}
+	return sum, latest, nil
+}
+
+// eiaRowPeriod parses a row's period, or returns the zero time.
+func eiaRowPeriod(row map[string]json.RawMessage) time.Time {
+	var s string
+	if json.Unmarshal(row["period"], &s) != nil {
+		return time.Time{}
+	}
+	return parseEIAPeriod(s)
+}
+
+// eiaPeriodLayouts are the period formats EIA uses for hourly (UTC), daily,
+// monthly and annual data.
+var eiaPeriodLayouts = []string{"2006-01-02T15", "2006-01-02", "2006-01", "2006"}
+
+// parseEIAPeriod parses an EIA period as UTC, returning the zero time when
+// it matches no known layout.
+func parseEIAPeriod(s string) time.Time {
+	for _, layout := range eiaPeriodLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
 // makeRequest makes an HTTP request to the EIA API
-func (c *EIAClient) makeRequest(endpoint string) ([]byte, error) {
-	url := c.BaseURL + endpoint
+func (c *EIAClient) makeRequest(ctx context.Context, endpoint string) ([]byte, error) {
+	reqURL := c.BaseURL + endpoint
 
 	var lastErr error
 	for i := 0; i < 2; i++ {
-		req, err := http.NewRequest("GET", url, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 		if err != nil {
 			return nil, fmt.Errorf("create request: %w", err)
 		}
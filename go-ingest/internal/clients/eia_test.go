@@ -0,0 +1,140 @@
+package clients
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestEIA(srv *fixtureServer) *EIAClient {
+	c := NewEIAClient("test-key")
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestEIAGetElectricityDemand(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/electricity/rto/region-data/data/": "eia_demand.json"})
+	c := newTestEIA(srv)
+
+	// The demand value is a numeric string in the API response.
+	mwh, err := c.GetElectricityDemand()
+	if err != nil {
+		t.Fatalf("GetElectricityDemand: %v", err)
+	}
+	if mwh != 452103 {
+		t.Errorf("demand = %v MWh, want 452103", mwh)
+	}
+
+	q := srv.lastQuery()
+	if q.Get("facets[respondent][]") != "US48" || q.Get("facets[type][]") != "D" || q.Get("frequency") != "hourly" {
+		t.Errorf("query = %v, want hourly US48 demand (type D)", q)
+	}
+}
+
+func TestEIAGetCoalPrice(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/coal/price-by-rank/data/": "eia_coal.json"})
+	c := newTestEIA(srv)
+
+	price, err := c.GetCoalPrice()
+	if err != nil {
+		t.Fatalf("GetCoalPrice: %v", err)
+	}
+	if price != 58.97 {
+		t.Errorf("coal price = %v, want 58.97", price)
+	}
+
+	q := srv.lastQuery()
+	if q.Get("facets[coalRankId][]") != "TOT" || q.Get("data[0]") != "price" || q.Get("api_key") != "test-key" {
+		t.Errorf("query = %v, want the all-ranks price column", q)
+	}
+}
+
+func TestEIARequiresKey(t *testing.T) {
+	if _, err := NewEIAClient("").GetCoalPrice(); err == nil {
+		t.Error("GetCoalPrice without an API key: want an error")
+	}
+}
+
+// eiaDatasetServer serves one recorded response per EIA dataset. The
+// generation route answers with total or renewable rows depending on the
+// fueltypeid facets asked for.
+func eiaDatasetServer(t *testing.T) *EIAClient {
+	t.Helper()
+	files := map[string]string{
+		eiaGasPriceRoute:    "eia_gas.json",
+		eiaCoalPriceRoute:   "eia_coal_empty.json",
+		eiaDemandRoute:      "eia_demand.json",
+		eiaRetailPriceRoute: "eia_retail.json",
+		eiaCrudePriceRoute:  "eia_crude.json",
+	}
+	bodies := make(map[string][]byte, len(files))
+	for route, name := range files {
+		bodies[route+"/data/"] = readFixture(t, name)
+	}
+	generation := readFixture(t, "eia_generation.json")
+	renewables := readFixture(t, "eia_renewables.json")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == eiaGenerationRoute+"/data/" {
+			fuels := r.URL.Query()["facets[fueltypeid][]"]
+			switch {
+			case slices.Equal(fuels, []string{"ALL"}):
+				w.Write(generation)
+			case slices.Equal(fuels, eiaRenewableFuels):
+				w.Write(renewables)
+			default:
+				http.Error(w, "unexpected fuels", http.StatusBadRequest)
+			}
+			return
+		}
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewEIAClient("test-key")
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestEIAGetEnergySummary(t *testing.T) {
+	s, err := eiaDatasetServer(t).GetEnergySummary()
+	if err != nil {
+		t.Fatalf("GetEnergySummary: %v", err)
+	}
+
+	july := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	oct14 := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		got   float64
+		want  float64
+		at    time.Time
+		wantT time.Time
+	}{
+		{"generation", s.ElectricityGenerationMWh, 385112500, s.SourcedAt.Generation, july},
+		// July only, with the null biomass row skipped.
+		{"renewables", s.RenewableGenerationMWh, 81400750, s.SourcedAt.Renewables, july},
+		{"natural gas", s.NaturalGasPriceMmbtu, 3.12, s.SourcedAt.NaturalGas, oct14},
+		// No coal rows: left zero rather than filled in.
+		{"coal", s.CoalPriceTon, 0, s.SourcedAt.Coal, time.Time{}},
+		{"demand", s.TotalDemandMWh, 452103, s.SourcedAt.Demand, time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)},
+		{"retail price", s.RetailPriceKWh, 0.1342, s.SourcedAt.RetailPrice, july},
+		{"crude oil", s.CrudeOilPriceBbl, 71.35, s.SourcedAt.CrudeOil, oct14},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-6 || !tt.at.Equal(tt.wantT) {
+			t.Errorf("%s = %v sourced at %s, want %v at %s", tt.name, tt.got, tt.at, tt.want, tt.wantT)
+		}
+	}
+	if !s.Period.Equal(july) {
+		t.Errorf("Period = %s, want %s", s.Period, july)
+	}
+}
@@ -1,18 +1,29 @@
 package clients
 
 import (
-	"encoding/json"
+	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// EmberClient queries the Ember Climate API for carbon intensity and electricity generation data
-// Ember provides global electricity data including carbon intensity, generation mix, and renewable percentages
-// API Docs: https://ember-climate.org/data-catalogue/
+// EmberClient reads carbon intensity and generation mix from Ember's yearly
+// electricity data release, a long-format CSV with one row per area, year
+// and variable. The file is cached on disk and revalidated with its ETag
+// once CacheTTL has passed.
+// Data: https://ember-climate.org/data-catalogue/yearly-electricity-data/
 type EmberClient struct {
-	BaseURL string
-	Client  *http.Client
+	BaseURL  string // CSV download URL
+	CacheDir string // where the CSV and its ETag are kept
+	CacheTTL time.Duration
+	Client   *http.Client
 }
 
 // EmberElectricitySummary represents aggregated electricity generation and carbon intensity data
@@ -23,100 +34,286 @@ type EmberElectricitySummary struct {
 	CoalPercent            float64 // Percentage from coal
 	GasPercent             float64 // Percentage from gas
 	NuclearPercent         float64 // Percentage from nuclear
+	Area                   string  // Ember area name, e.g. "World", "United States of America"
+	Year                   int     // Year the figures cover
+
+	// Fallback is set when the CSV could not be downloaded and no cached copy
+	// exists, so the figures are built-in approximations rather than Ember
+	// data.
+	Fallback bool
+}
+
+// emberCSVFile is the cached CSV's file name; its ETag is kept alongside
+// with an ".etag" suffix.
+const emberCSVFile = "yearly_full_release_long_format.csv"
+
+// emberWorld is the area Ember aggregates all countries under.
+const emberWorld = "World"
+
+// emberAreaAliases maps the short codes callers used with the old client to
+// Ember's ISO 3166 alpha-3 country codes.
+var emberAreaAliases = map[string]string{
+	"US": "USA",
+	"DE": "DEU",
 }
 
-// EmberDataPoint represents a single data point from the Ember API
-type EmberDataPoint struct {
-	Year              int     `json:"year"`
-	Country           string  `json:"country"`
-	CarbonIntensity   float64 `json:"carbon_intensity_gco2_per_kwh"`
-	RenewablePercent  float64 `json:"renewable_percent"`
-	GenerationTWh     float64 `json:"generation_twh"`
-	CoalPercent       float64 `json:"coal_percent"`
-	GasPercent        float64 `json:"gas_percent"`
-	NuclearPercent    float64 `json:"nuclear_percent"`
+// emberFallback are approximate figures used only when no CSV is available.
+var emberFallback = map[string]EmberElectricitySummary{
+	"USA":      {CarbonIntensityGCO2KWh: 386.5, RenewablePercent: 21.3, GenerationTWh: 4178.0, CoalPercent: 19.5, GasPercent: 38.4, NuclearPercent: 18.9, Area: "United States of America"},
+	"DEU":      {CarbonIntensityGCO2KWh: 348.2, RenewablePercent: 44.6, GenerationTWh: 574.5, CoalPercent: 29.8, GasPercent: 12.6, NuclearPercent: 11.4, Area: "Germany"},
+	emberWorld: {CarbonIntensityGCO2KWh: 436.0, RenewablePercent: 28.7, GenerationTWh: 28466.0, CoalPercent: 35.1, GasPercent: 23.5, NuclearPercent: 9.8, Area: emberWorld},
 }
 
-// NewEmberClient creates a new Ember API client
-func NewEmberClient() *EmberClient {
+// NewEmberClient creates a new Ember client. The cache directory is
+// EMBER_CACHE_DIR, defaulting to an "edgesight/ember" directory under the
+// user cache directory.
+func NewEmberClient(opts ...ClientOption) *EmberClient {
+	dir := os.Getenv("EMBER_CACHE_DIR")
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			base = os.TempDir()
+		}
+		dir = filepath.Join(base, "edgesight", "ember")
+	}
 	return &EmberClient{
-		BaseURL: "https://ember-climate.org/app/uploads/2022/07/yearly_full_release.csv",
-		Client: &http.Client{
-			Timeout: 15 * time.Second,
-		},
+		BaseURL:  "https://storage.googleapis.com/emb-prod-bkt-publicdata/public-downloads/" + emberCSVFile,
+		CacheDir: dir,
+		CacheTTL: 24 * time.Hour,
+		Client:   applyOptions(NewHTTPClient(envTimeout("EMBER_TIMEOUT", 2*time.Minute)), opts),
 	}
 }
 
-// GetCountrySummary fetches the latest electricity data for a specific country
-// Note: Ember provides CSV data files; this is a simplified mock implementation
-// In production, you would download and parse the CSV file or use their data API
+// GetCountrySummary returns the latest year of Ember data for a country,
+// given as an ISO alpha-3 code ("USA"), an Ember area name ("Germany") or
+// one of the short codes US and DE.
 func (c *EmberClient) GetCountrySummary(countryCode string) (*EmberElectricitySummary, error) {
-	// Mock implementation returning realistic data for demonstration
-	// In production, this would parse actual Ember CSV data or call their API
-	
-	// Example mock data for USA
-	if countryCode == "USA" || countryCode == "US" {
-		return &EmberElectricitySummary{
-			CarbonIntensityGCO2KWh: 386.5,
-			RenewablePercent:       21.3,
-			GenerationTWh:          4178.0,
-			CoalPercent:            19.5,
-			GasPercent:             38.4,
-			NuclearPercent:        18.9,
-		}, nil
-	}
-
-	// Example mock data for Germany
-	if countryCode == "DEU" || countryCode == "DE" || countryCode == "Germany" {
-		return &EmberElectricitySummary{
-			CarbonIntensityGCO2KWh: 348.2,
-			RenewablePercent:       44.6,
-			GenerationTWh:          574.5,
-			CoalPercent:            29.8,
-			GasPercent:             12.6,
-			NuclearPercent:        11.4,
-		}, nil
-	}
-
-	return nil, fmt.Errorf("country data not available for: %s", countryCode)
+	return c.GetCountrySummaryContext(context.Background(), countryCode)
 }
 
-// GetGlobalAverage calculates global average carbon intensity and generation mix
+// GetCountrySummaryContext is GetCountrySummary with a caller-supplied context.
+func (c *EmberClient) GetCountrySummaryContext(ctx context.Context, countryCode string) (*EmberElectricitySummary, error) {
+	area := strings.TrimSpace(countryCode)
+	if alias, ok := emberAreaAliases[strings.ToUpper(area)]; ok {
+		area = alias
+	}
+	return c.summary(ctx, area)
+}
+
+// GetGlobalAverage returns the latest year of Ember's "World" aggregate.
 func (c *EmberClient) GetGlobalAverage() (*EmberElectricitySummary, error) {
-	// Mock global average data
-	return &EmberElectricitySummary{
-		CarbonIntensityGCO2KWh: 436.0,
-		RenewablePercent:       28.7,
-		GenerationTWh:          28466.0,
-		CoalPercent:            35.1,
-		GasPercent:             23.5,
-		NuclearPercent:        9.8,
-	}, nil
+	return c.GetGlobalAverageContext(context.Background())
+}
+
+// GetGlobalAverageContext is GetGlobalAverage with a caller-supplied context.
+func (c *EmberClient) GetGlobalAverageContext(ctx context.Context) (*EmberElectricitySummary, error) {
+	return c.summary(ctx, emberWorld)
 }
 
-// Internal helper for making HTTP requests (for future real API integration)
-func (c *EmberClient) makeRequest(endpoint string) ([]byte, error) {
-	url := c.BaseURL + endpoint
+// summary parses the cached CSV for area. When no CSV can be had it returns
+// the built-in fallback for area, flagged as such, or an error when there is
+// none.
+func (c *EmberClient) summary(ctx context.Context, area string) (*EmberElectricitySummary, error) {
+	path, fetchErr := c.ensureCSV(ctx)
+	if path == "" {
+		for code, fb := range emberFallback {
+			if strings.EqualFold(area, code) || strings.EqualFold(area, fb.Area) {
+				fb.Fallback = true
+				return &fb, nil
+			}
+		}
+		return nil, fmt.Errorf("Ember data unavailable: %w", fetchErr)
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("open Ember CSV: %w", err)
+	}
+	defer f.Close()
+	return ParseEmberCSV(f, area)
+}
+
+// ensureCSV returns the path of a usable cached CSV, downloading or
+// revalidating it when it is missing or older than CacheTTL. A stale copy
+// is returned (with the error) when the download fails; the path is empty
+// only when there is no copy at all.
+func (c *EmberClient) ensureCSV(ctx context.Context) (string, error) {
+	path := filepath.Join(c.CacheDir, emberCSVFile)
+	info, statErr := os.Stat(path)
+	cached := statErr == nil
+	if cached && time.Since(info.ModTime()) < c.CacheTTL {
+		return path, nil
+	}
+
+	err := c.download(ctx, path, cached)
+	switch {
+	case err == nil:
+		return path, nil
+	case cached:
+		return path, err
+	default:
+		return "", err
+	}
+}
+
+// download fetches the CSV into path, sending the stored ETag and the cached
+// file's mtime as validators when a copy exists. A 304 just refreshes the
+// mtime. The file is replaced atomically so a failed download keeps the old
+// copy.
+func (c *EmberClient) download(ctx context.Context, path string, cached bool) error {
+	if err := os.MkdirAll(c.CacheDir, 0o755); err != nil {
+		return fmt.Errorf("create Ember cache dir: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	etagPath := path + ".etag"
+	if cached {
+		if etag, err := os.ReadFile(etagPath); err == nil && len(etag) > 0 {
+			req.Header.Set("If-None-Match", strings.TrimSpace(string(etag)))
+		}
+		if info, err := os.Stat(path); err == nil {
+			req.Header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+		}
 	}
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		now := time.Now()
+		return os.Chtimes(path, now, now)
+	case resp.StatusCode != http.StatusOK:
+		return readStatusError(resp)
 	}
 
-	var result []byte
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	tmp, err := os.CreateTemp(c.CacheDir, emberCSVFile+".*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	_, copyErr := io.Copy(tmp, resp.Body)
+	closeErr := tmp.Close()
+	if err := errors.Join(copyErr, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("download Ember CSV: %w", err)
 	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("store Ember CSV: %w", err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		os.WriteFile(etagPath, []byte(etag), 0o644)
+	} else {
+		os.Remove(etagPath)
+	}
+	return nil
+}
 
-	return result, nil
+// emberYear collects the variables of one area and year that a summary
+// needs, as TWh generation and gCO2/kWh intensity.
+type emberYear struct {
+	total, renewables, coal, gas, nuclear float64
+	intensity                             float64
+	hasTotal                              bool
+}
+
+// ParseEmberCSV reads Ember's long-format yearly CSV and summarizes the
+// latest year with total generation for area, matched against the Area
+// column or the Country code column, ignoring case. Shares are computed from
+// TWh generation so they are consistent with GenerationTWh.
+func ParseEmberCSV(r io.Reader, area string) (*EmberElectricitySummary, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read Ember CSV header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")] = i
+	}
+	for _, name := range []string{"Area", "Country code", "Year", "Category", "Subcategory", "Variable", "Unit", "Value"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("Ember CSV has no %q column", name)
+		}
+	}
+
+	years := make(map[int]*emberYear)
+	var areaName string
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read Ember CSV: %w", err)
+		}
+		if !strings.EqualFold(rec[col["Area"]], area) && !strings.EqualFold(rec[col["Country code"]], area) {
+			continue
+		}
+		year, err := strconv.Atoi(rec[col["Year"]])
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(rec[col["Value"]], 64)
+		if err != nil {
+			continue // blank for years Ember has no estimate
+		}
+		y := years[year]
+		if y == nil {
+			y = &emberYear{}
+			years[year] = y
+		}
+		areaName = rec[col["Area"]]
+		applyEmberRow(y, rec[col["Category"]], rec[col["Subcategory"]], rec[col["Variable"]], rec[col["Unit"]], value)
+	}
+
+	latest := 0
+	for year, y := range years {
+		if y.hasTotal && y.total > 0 && year > latest {
+			latest = year
+		}
+	}
+	if latest == 0 {
+		return nil, fmt.Errorf("no Ember generation data for %q", area)
+	}
+
+	y := years[latest]
+	share := func(twh float64) float64 { return twh / y.total * 100 }
+	return &EmberElectricitySummary{
+		CarbonIntensityGCO2KWh: y.intensity,
+		RenewablePercent:       share(y.renewables),
+		GenerationTWh:          y.total,
+		CoalPercent:            share(y.coal),
+		GasPercent:             share(y.gas),
+		NuclearPercent:         share(y.nuclear),
+		Area:                   areaName,
+		Year:                   latest,
+	}, nil
+}
+
+// applyEmberRow records one CSV row in y if it is a variable the summary
+// uses.
+func applyEmberRow(y *emberYear, category, subcategory, variable, unit string, value float64) {
+	switch {
+	case category == "Power sector emissions" && variable == "CO2 intensity" && unit == "gCO2/kWh":
+		y.intensity = value
+	case category != "Electricity generation" || unit != "TWh":
+		return
+	case subcategory == "Total" && variable == "Total Generation":
+		y.total, y.hasTotal = value, true
+	case subcategory == "Aggregate fuel" && variable == "Renewables":
+		y.renewables = value
+	case subcategory == "Fuel" && variable == "Coal":
+		y.coal = value
+	case subcategory == "Fuel" && variable == "Gas":
+		y.gas = value
+	case subcategory == "Fuel" && variable == "Nuclear":
+		y.nuclear = value
+	}
 }
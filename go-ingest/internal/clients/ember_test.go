@@ -0,0 +1,108 @@
+package clients
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseEmberCSV(t *testing.T) {
+	csv := readFixture(t, "ember_yearly.csv")
+	tests := []struct {
+		area string
+		want EmberElectricitySummary
+	}{
+		{"Germany", EmberElectricitySummary{CarbonIntensityGCO2KWh: 344, RenewablePercent: 50, GenerationTWh: 500, CoalPercent: 20, GasPercent: 15, Area: "Germany", Year: 2024}},
+		// Matched by country code; 2025 has no estimates yet.
+		{"usa", EmberElectricitySummary{CarbonIntensityGCO2KWh: 384, RenewablePercent: 1000.0 / 43, GenerationTWh: 4300, CoalPercent: 16, GasPercent: 42, NuclearPercent: 18, Area: "United States of America", Year: 2024}},
+		{"World", EmberElectricitySummary{CarbonIntensityGCO2KWh: 473, RenewablePercent: 30, GenerationTWh: 30000, CoalPercent: 35, GasPercent: 22, NuclearPercent: 9, Area: "World", Year: 2024}},
+	}
+	for _, tt := range tests {
+		got, err := ParseEmberCSV(bytes.NewReader(csv), tt.area)
+		if err != nil {
+			t.Fatalf("ParseEmberCSV(%q): %v", tt.area, err)
+		}
+		if !emberClose(*got, tt.want) {
+			t.Errorf("ParseEmberCSV(%q) = %+v, want %+v", tt.area, *got, tt.want)
+		}
+	}
+
+	if _, err := ParseEmberCSV(bytes.NewReader(csv), "Atlantis"); err == nil {
+		t.Error("unknown area: want an error")
+	}
+	if _, err := ParseEmberCSV(strings.NewReader("Area,Year,Value\nWorld,2024,1\n"), "World"); err == nil {
+		t.Error("missing columns: want an error")
+	}
+}
+
+// emberClose compares summaries, allowing rounding in the shares.
+func emberClose(a, b EmberElectricitySummary) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	return near(a.CarbonIntensityGCO2KWh, b.CarbonIntensityGCO2KWh) && near(a.RenewablePercent, b.RenewablePercent) &&
+		near(a.GenerationTWh, b.GenerationTWh) && near(a.CoalPercent, b.CoalPercent) && near(a.GasPercent, b.GasPercent) &&
+		near(a.NuclearPercent, b.NuclearPercent) && a.Area == b.Area && a.Year == b.Year && a.Fallback == b.Fallback
+}
+
+func TestEmberClientCachesCSV(t *testing.T) {
+	csv := readFixture(t, "ember_yearly.csv")
+	var hits atomic.Int32
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch {
+		case down.Load():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write(csv)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewEmberClient()
+	c.BaseURL = srv.URL
+	c.CacheDir = t.TempDir()
+
+	get := func(code string) *EmberElectricitySummary {
+		t.Helper()
+		s, err := c.GetCountrySummary(code)
+		if err != nil {
+			t.Fatalf("GetCountrySummary(%s): %v", code, err)
+		}
+		return s
+	}
+
+	if s := get("DE"); s.Area != "Germany" || s.Year != 2024 || s.Fallback {
+		t.Errorf("downloaded summary = %+v", *s)
+	}
+	if s, err := c.GetGlobalAverage(); err != nil || s.Area != "World" || hits.Load() != 1 {
+		t.Errorf("GetGlobalAverage = %+v, %v after %d downloads; want World from the cached file", s, err, hits.Load())
+	}
+
+	// Past the TTL the copy is revalidated with its ETag, then kept on 304.
+	c.CacheTTL = 0
+	if s := get("US"); s.Year != 2024 || hits.Load() != 2 {
+		t.Errorf("revalidated summary = %+v after %d requests", *s, hits.Load())
+	}
+
+	// A failed refresh still serves the stale copy, not the fallback.
+	down.Store(true)
+	if s, err := c.GetCountrySummary("US"); s == nil || s.Fallback || s.GenerationTWh != 4300 {
+		t.Errorf("stale copy = %+v, %v; want the cached Ember figures", s, err)
+	}
+
+	// With no copy at all only the flagged fallback is left.
+	c.CacheDir = t.TempDir()
+	if s, err := c.GetCountrySummary("US"); err != nil || !s.Fallback {
+		t.Errorf("no CSV = %+v, %v; want the flagged fallback", s, err)
+	}
+	if _, err := c.GetCountrySummary("FRA"); err == nil {
+		t.Error("no CSV and no fallback: want an error")
+	}
+}
@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ercotTimeLayout is how ERCOT's dashboard feeds write timestamps.
+const ercotTimeLayout = "2006-01-02 15:04:05-0700"
+
+// ercotRenewables are the fuel-mix entries counted as renewable generation.
+var ercotRenewables = []string{"Solar", "Wind", "Hydro"}
+
+// ercotProvider is the GridProvider for ERCOT, reading the real-time system
+// conditions published for ercot.com's dashboards.
+type ercotProvider struct {
+	baseURL string // dashboards API directory
+	httpCli *http.Client
+	retry   retryPolicy
+}
+
+func newERCOTProvider(cli *http.Client) GridProvider {
+	return &ercotProvider{
+		baseURL: "https://www.ercot.com/api/1/services/read/dashboards",
+		httpCli: cli,
+		retry:   defaultRetryPolicy,
+	}
+}
+
+// ercotSupplyDemand is supply-demand.json: five-minute demand and available
+// capacity for today, with forecast intervals flagged.
+type ercotSupplyDemand struct {
+	Data []struct {
+		Timestamp string  `json:"timestamp"`
+		Demand    float64 `json:"demand"`
+		Capacity  float64 `json:"capacity"`
+		Forecast  int     `json:"forecast"`
+	} `json:"data"`
+}
+
+// ercotFuelMix is fuel-mix.json: generation by fuel per interval, grouped
+// by day.
+type ercotFuelMix struct {
+	Data map[string]map[string]map[string]struct {
+		Gen float64 `json:"gen"`
+	} `json:"data"`
+}
+
+// GetGridStatus builds a GridStatus from the latest actual (non-forecast)
+// interval of demand against available capacity, and the solar, wind and
+// hydro generation of the latest fuel-mix interval. Frequency is not
+// published there and stays 0.
+func (c *ercotProvider) GetGridStatus(ctx context.Context) (*GridStatus, error) {
+	var sd ercotSupplyDemand
+	if err := c.fetch(ctx, "supply-demand.json", &sd); err != nil {
+		return nil, err
+	}
+	var mix ercotFuelMix
+	if err := c.fetch(ctx, "fuel-mix.json", &mix); err != nil {
+		return nil, err
+	}
+	return ercotStatus(&sd, &mix)
+}
+
+// ercotStatus combines the two dashboard payloads into a GridStatus.
+func ercotStatus(sd *ercotSupplyDemand, mix *ercotFuelMix) (*GridStatus, error) {
+	var latest time.Time
+	status := &GridStatus{Status: "Normal", Source: GridSourceERCOT}
+	for _, d := range sd.Data {
+		if d.Forecast != 0 {
+			continue
+		}
+		ts, err := time.Parse(ercotTimeLayout, d.Timestamp)
+		if err != nil || ts.Before(latest) {
+			continue
+		}
+		latest = ts
+		status.LoadMW, status.CapacityMW = d.Demand, d.Capacity
+	}
+	if latest.IsZero() {
+		return nil, fmt.Errorf("ERCOT supply-demand: no actual demand reported")
+	}
+	if status.CapacityMW > 0 {
+		status.UtilizationPercent = status.LoadMW / status.CapacityMW * 100
+		status.Status = gridStatusLevel(status.UtilizationPercent)
+	}
+
+	// The latest interval of the latest day; keys sort chronologically
+	days := make([]string, 0, len(mix.Data))
+	for day := range mix.Data {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	if len(days) > 0 {
+		intervals := mix.Data[days[len(days)-1]]
+		stamps := make([]string, 0, len(intervals))
+		for ts := range intervals {
+			stamps = append(stamps, ts)
+		}
+		sort.Strings(stamps)
+		if len(stamps) > 0 {
+			fuels := intervals[stamps[len(stamps)-1]]
+			for _, fuel := range ercotRenewables {
+				status.RenewablesMW += fuels[fuel].Gen
+			}
+		}
+	}
+	return status, nil
+}
+
+// fetch downloads one dashboard feed and decodes it into out.
+func (c *ercotProvider) fetch(ctx context.Context, file string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+file, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
+	if err != nil {
+		return fmt.Errorf("ERCOT %s: %w", file, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ERCOT %s: decode response: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package clients
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// newTestERCOT returns an ERCOT GridClient reading the dashboard feeds
+// from srv.
+func newTestERCOT(srv *fixtureServer) *GridClient {
+	c := NewGridClient("ERCOT")
+	p := c.provider.(*ercotProvider)
+	p.baseURL = srv.URL
+	p.retry = fastRetry
+	return c
+}
+
+func TestERCOTGetGridStatus(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/supply-demand.json": "ercot_supply_demand.json",
+		"/fuel-mix.json":      "ercot_fuel_mix.json",
+	})
+
+	got, err := newTestERCOT(srv).GetGridStatusContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetGridStatusContext: %v", err)
+	}
+	// 09:10 is the latest actual interval even though it is listed before
+	// 09:05; the later rows are forecasts.
+	if got.LoadMW != 54000 || got.CapacityMW != 60000 || got.UtilizationPercent != 90 || got.Status != "Alert" {
+		t.Errorf("load = %+v, want 54000 of 60000 MW (90%%, Alert)", *got)
+	}
+	// Solar, wind and hydro of the 09:10 fuel mix
+	if got.RenewablesMW != 21000 {
+		t.Errorf("RenewablesMW = %v, want 21000", got.RenewablesMW)
+	}
+	if got.Source != GridSourceERCOT || got.FrequencyHz != 0 {
+		t.Errorf("Source %q FrequencyHz %v, want ercot with no frequency", got.Source, got.FrequencyHz)
+	}
+}
+
+func TestERCOTForecastOnly(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/supply-demand.json": "ercot_forecast_only.json",
+		"/fuel-mix.json":      "ercot_fuel_mix.json",
+	})
+
+	_, err := newTestERCOT(srv).GetGridStatusContext(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no actual demand") {
+		t.Errorf("err = %v, want no actual demand reported", err)
+	}
+}
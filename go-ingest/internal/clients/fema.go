@@ -1,31 +1,71 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// FEMAClient reads FEMA disaster summaries from a static JSON file.
+// FEMAClient summarizes FEMA disaster declarations, either from a static
+// JSON export or from the live OpenFEMA API with the export as an offline
+// fallback.
 type FEMAClient struct {
 	dataPath string
+
+	// Set by NewFEMAClientAPI; nil means file-only.
+	httpCli  *http.Client
+	baseURL  string
+	pageSize int
+	retry    retryPolicy
 }
 
-// FEMASummary aggregates key disaster metrics for a state.
+// FEMASummary aggregates key disaster metrics for a state or county.
 type FEMASummary struct {
 	ActiveDisasters  int
 	TopIncidentType  string
 	Severity         int
 	AffectedCounties int
+
+	IncidentTypeCounts    map[string]int    // declaration records per incident type
+	DeclarationTypeCounts map[string]int    // declaration records per type (DR, EM, FM, ...)
+	LatestIncidentBegin   time.Time         // newest incident begin date; zero when none is active
+	Recent                []FEMADeclaration // newest declarations first, at most femaRecentDeclarations
 }
 
+// FEMADeclaration is one disaster declaration, gathered from the per-county
+// records that share its disaster number.
+type FEMADeclaration struct {
+	DisasterNumber  int
+	Title           string
+	IncidentType    string
+	DeclarationType string
+	DeclarationDate time.Time
+	Open            bool     // not yet closed out
+	Counties        []string // designated areas, e.g. "Los Angeles (County)"
+}
+
+// femaRecentDeclarations is how many declarations FEMASummary.Recent keeps.
+const femaRecentDeclarations = 5
+
 type femaPayload struct {
 	DisasterDeclarationsSummaries []femaRecord `json:"DisasterDeclarationsSummaries"`
 }
 
 type femaRecord struct {
+	DisasterNumber    int     `json:"disasterNumber"`
+	Title             string  `json:"declarationTitle"`
+	DeclarationDate   string  `json:"declarationDate"`
+	DesignatedArea    string  `json:"designatedArea"`
 	State             string  `json:"state"`
 	IncidentType      string  `json:"incidentType"`
 	DeclarationType   string  `json:"declarationType"`
@@ -34,6 +74,9 @@ type femaRecord struct {
 	FIPSCountyCode    string  `json:"fipsCountyCode"`
 }
 
+// femaSelect is the $select list for API queries: just the femaRecord fields.
+const femaSelect = "disasterNumber,declarationTitle,declarationDate,designatedArea,state,incidentType,declarationType,incidentBeginDate,disasterCloseoutDate,fipsCountyCode"
+
 // NewFEMAClient creates a client pointing at a FEMA JSON export; defaults to the repo root file when path is empty.
 func NewFEMAClient(path string) *FEMAClient {
 	if path == "" {
@@ -42,9 +85,48 @@ func NewFEMAClient(path string) *FEMAClient {
 	return &FEMAClient{dataPath: path}
 }
 
+// NewFEMAClientAPI creates a client that queries the OpenFEMA
+// DisasterDeclarationsSummaries API, reading the export at fallbackPath (the
+// NewFEMAClient default when empty) if the API cannot be reached.
+// Docs: https://www.fema.gov/about/openfema/api
+func NewFEMAClientAPI(fallbackPath string, opts ...ClientOption) *FEMAClient {
+	c := NewFEMAClient(fallbackPath)
+	c.httpCli = applyOptions(NewHTTPClient(envTimeout("FEMA_TIMEOUT", 30*time.Second)), opts)
+	c.baseURL = "https://www.fema.gov/api/open/v2/DisasterDeclarationsSummaries"
+	c.pageSize = 1000
+	c.retry = defaultRetryPolicy
+	return c
+}
+
 // GetStateSummary returns a lightweight summary for the requested state.
 // lookbackDays scopes how far back we consider events; default is 180 days when <= 0.
 func (c *FEMAClient) GetStateSummary(state string, lookbackDays int) (*FEMASummary, error) {
+	return c.GetStateSummaryContext(context.Background(), state, lookbackDays)
+}
+
+// GetStateSummaryContext is GetStateSummary with a caller-supplied context.
+func (c *FEMAClient) GetStateSummaryContext(ctx context.Context, state string, lookbackDays int) (*FEMASummary, error) {
+	return c.summary(ctx, state, "", lookbackDays)
+}
+
+// GetCountySummary is GetStateSummary narrowed to one county, given by its
+// three-digit FIPS code (e.g. "037" for Los Angeles County, CA). Statewide
+// declarations count toward every county.
+func (c *FEMAClient) GetCountySummary(state, fipsCounty string, lookbackDays int) (*FEMASummary, error) {
+	return c.GetCountySummaryContext(context.Background(), state, fipsCounty, lookbackDays)
+}
+
+// GetCountySummaryContext is GetCountySummary with a caller-supplied context.
+func (c *FEMAClient) GetCountySummaryContext(ctx context.Context, state, fipsCounty string, lookbackDays int) (*FEMASummary, error) {
+	fipsCounty = strings.TrimSpace(fipsCounty)
+	if len(fipsCounty) != 3 || strings.Trim(fipsCounty, "0123456789") != "" {
+		return nil, fmt.Errorf("county FIPS code %q must be three digits", fipsCounty)
+	}
+	return c.summary(ctx, state, fipsCounty, lookbackDays)
+}
+
+// summary serves both summaries; county is empty for the whole state.
+func (c *FEMAClient) summary(ctx context.Context, state, county string, lookbackDays int) (*FEMASummary, error) {
 	state = strings.ToUpper(strings.TrimSpace(state))
 	if state == "" {
 		return nil, fmt.Errorf("state code required")
@@ -52,7 +134,31 @@ func (c *FEMAClient) GetStateSummary(state string, lookbackDays int) (*FEMASumma
 	if lookbackDays <= 0 {
 		lookbackDays = 180
 	}
+	now := time.Now().UTC()
+
+	if c.httpCli == nil {
+		records, err := c.readFile()
+		if err != nil {
+			return nil, err
+		}
+		return summarizeFEMA(records, state, county, lookbackDays, now), nil
+	}
+
+	records, apiErr := c.fetchAPI(ctx, state, county, now.AddDate(0, 0, -lookbackDays), now)
+	if apiErr != nil {
+		if ctx.Err() != nil {
+			return nil, apiErr
+		}
+		var fileErr error
+		if records, fileErr = c.readFile(); fileErr != nil {
+			return nil, errors.Join(apiErr, fileErr)
+		}
+	}
+	return summarizeFEMA(records, state, county, lookbackDays, now), nil
+}
 
+// readFile decodes the whole JSON export.
+func (c *FEMAClient) readFile() ([]femaRecord, error) {
 	f, err := os.Open(c.dataPath)
 	if err != nil {
 		return nil, fmt.Errorf("open FEMA file: %w", err)
@@ -63,18 +169,69 @@ func (c *FEMAClient) GetStateSummary(state string, lookbackDays int) (*FEMASumma
 	if err := json.NewDecoder(f).Decode(&payload); err != nil {
 		return nil, fmt.Errorf("decode FEMA payload: %w", err)
 	}
+	return payload.DisasterDeclarationsSummaries, nil
+}
 
-	now := time.Now().UTC()
+// fetchAPI pages through the declarations for state (and county, when set)
+// that were declared since cutoff or are still open at now. Declaration follows the incident start,
+// so this is a superset of what summarizeFEMA keeps.
+func (c *FEMAClient) fetchAPI(ctx context.Context, state, county string, cutoff, now time.Time) ([]femaRecord, error) {
+	filter := fmt.Sprintf("state eq '%s' and (declarationDate ge '%s' or disasterCloseoutDate eq null or disasterCloseoutDate gt '%s')",
+		strings.ReplaceAll(state, "'", "''"), cutoff.Format(time.RFC3339), now.Format(time.RFC3339))
+	if county != "" {
+		filter += fmt.Sprintf(" and (fipsCountyCode eq '%s' or fipsCountyCode eq '000')", county)
+	}
+
+	var records []femaRecord
+	for skip := 0; ; skip += c.pageSize {
+		q := url.Values{}
+		q.Set("$filter", filter)
+		q.Set("$select", femaSelect)
+		q.Set("$orderby", "declarationDate desc")
+		q.Set("$top", strconv.Itoa(c.pageSize))
+		q.Set("$skip", strconv.Itoa(skip))
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("build OpenFEMA request: %w", err)
+		}
+		resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch OpenFEMA declarations: %w", err)
+		}
+		var page femaPayload
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode OpenFEMA declarations: %w", err)
+		}
+
+		records = append(records, page.DisasterDeclarationsSummaries...)
+		if len(page.DisasterDeclarationsSummaries) < c.pageSize {
+			return records, nil
+		}
+	}
+}
+
+// summarizeFEMA counts the state's (or county's) declarations that began
+// after the lookback cutoff or are still open at now.
+func summarizeFEMA(records []femaRecord, state, county string, lookbackDays int, now time.Time) *FEMASummary {
 	cutoff := now.AddDate(0, 0, -lookbackDays)
 	typeCounts := make(map[string]int)
+	declTypeCounts := make(map[string]int)
+	var latestBegin time.Time
 	counties := make(map[string]struct{})
-	maxSeverity := 0
+	declarations := make(map[int]*FEMADeclaration)
+	impacts := make(map[int]*declarationImpact)
 	active := 0
 
-	for _, rec := range payload.DisasterDeclarationsSummaries {
+	for i, rec := range records {
 		if strings.ToUpper(rec.State) != state {
 			continue
 		}
+		if county != "" && rec.FIPSCountyCode != county && rec.FIPSCountyCode != "000" {
+			continue
+		}
 
 		begin := parseFEMATime(rec.IncidentBeginDate)
 		closeout := parseFEMATimePtr(rec.DisasterCloseout)
@@ -92,23 +249,78 @@ func (c *FEMAClient) GetStateSummary(state string, lookbackDays int) (*FEMASumma
 
 		active++
 		typeCounts[rec.IncidentType]++
+		declTypeCounts[rec.DeclarationType]++
+		if begin.After(latestBegin) {
+			latestBegin = begin
+		}
 
 		if rec.FIPSCountyCode != "" && rec.FIPSCountyCode != "000" {
 			counties[rec.FIPSCountyCode] = struct{}{}
 		}
 
-		sev := severityFromDeclaration(rec.DeclarationType)
-		if sev > maxSeverity {
-			maxSeverity = sev
+		// Records are per county; group them by disaster number, keeping any
+		// record without one on its own.
+		key := rec.DisasterNumber
+		if key == 0 {
+			key = -(i + 1)
+		}
+		imp, ok := impacts[key]
+		if !ok {
+			imp = &declarationImpact{DeclarationType: rec.DeclarationType, IncidentBegin: begin, counties: make(map[string]struct{})}
+			impacts[key] = imp
+		}
+		imp.counties[rec.FIPSCountyCode] = struct{}{}
+		if begin.After(imp.IncidentBegin) {
+			imp.IncidentBegin = begin
+		}
+
+		d, ok := declarations[key]
+		if !ok {
+			d = &FEMADeclaration{
+				DisasterNumber:  rec.DisasterNumber,
+				Title:           rec.Title,
+				IncidentType:    rec.IncidentType,
+				DeclarationType: rec.DeclarationType,
+				DeclarationDate: parseFEMATime(rec.DeclarationDate),
+			}
+			declarations[key] = d
+		}
+		d.Open = d.Open || isOpen
+		if rec.DesignatedArea != "" && !slices.Contains(d.Counties, rec.DesignatedArea) {
+			d.Counties = append(d.Counties, rec.DesignatedArea)
 		}
 	}
 
 	return &FEMASummary{
-		ActiveDisasters:  active,
-		TopIncidentType:  selectTopIncident(typeCounts),
-		Severity:         maxSeverity,
-		AffectedCounties: len(counties),
-	}, nil
+		ActiveDisasters:       active,
+		TopIncidentType:       selectTopIncident(typeCounts),
+		Severity:              severityLevel(severityScore(impacts, lookbackDays, now)),
+		AffectedCounties:      len(counties),
+		IncidentTypeCounts:    typeCounts,
+		DeclarationTypeCounts: declTypeCounts,
+		LatestIncidentBegin:   latestBegin,
+		Recent:                recentDeclarations(declarations, femaRecentDeclarations),
+	}
+}
+
+// recentDeclarations returns up to n declarations, newest first (ties by
+// disaster number), each with its counties sorted.
+func recentDeclarations(byNumber map[int]*FEMADeclaration, n int) []FEMADeclaration {
+	out := make([]FEMADeclaration, 0, len(byNumber))
+	for _, d := range byNumber {
+		sort.Strings(d.Counties)
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DeclarationDate.Equal(out[j].DeclarationDate) {
+			return out[i].DeclarationDate.After(out[j].DeclarationDate)
+		}
+		return out[i].DisasterNumber > out[j].DisasterNumber
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
 }
 
 func parseFEMATime(val string) time.Time {
@@ -129,20 +341,64 @@ func parseFEMATimePtr(val *string) time.Time {
 	return parseFEMATime(*val)
 }
 
-// severityFromDeclaration maps FEMA declaration types into a coarse 1-5 scale.
-func severityFromDeclaration(declType string) int {
+// declarationImpact is what one declaration contributes to severity.
+type declarationImpact struct {
+	DeclarationType string
+	IncidentBegin   time.Time // latest begin date among its county records
+	counties        map[string]struct{}
+}
+
+// declarationWeight is the severity weight of a FEMA declaration type.
+func declarationWeight(declType string) float64 {
 	switch strings.ToUpper(declType) {
 	case "DR": // Major Disaster Declaration
-		return 5
+		return 1
 	case "EM": // Emergency Declaration
-		return 4
+		return 0.6
 	case "FM": // Fire Management Assistance
-		return 3
+		return 0.4
 	case "FS": // Fire Suppression (legacy)
-		return 2
+		return 0.2
 	default:
-		return 1
+		return 0.1
+	}
+}
+
+// severityScore sums, over declarations, type weight × recency × spread.
+// Recency halves every half lookback window since the incident began, so an
+// old declaration that is merely still open fades out; spread is
+// 1 + log2(counties), so a declaration covering 8 counties counts 4 times
+// one covering a single county.
+func severityScore(impacts map[int]*declarationImpact, lookbackDays int, now time.Time) float64 {
+	halfLife := float64(lookbackDays) / 2
+	var score float64
+	for _, imp := range impacts {
+		ageDays := max(now.Sub(imp.IncidentBegin).Hours()/24, 0)
+		recency := math.Pow(0.5, ageDays/halfLife)
+		spread := 1 + math.Log2(float64(max(len(imp.counties), 1)))
+		score += declarationWeight(imp.DeclarationType) * recency * spread
+	}
+	return score
+}
+
+// severityThresholds are the minimum scores for severities 2 through 5. A
+// fresh single-county DR scores about 1 (severity 3); ten fresh single-county
+// FMs score about 4 (severity 5).
+var severityThresholds = [4]float64{0.25, 0.75, 1.5, 3}
+
+// severityLevel maps a severity score onto the 1-5 scale, or 0 when nothing
+// contributed.
+func severityLevel(score float64) int {
+	if score <= 0 {
+		return 0
+	}
+	level := 1
+	for _, t := range severityThresholds {
+		if score >= t {
+			level++
+		}
 	}
+	return level
 }
 
 func selectTopIncident(counts map[string]int) string {
@@ -0,0 +1,212 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// femaAPIServer serves the records in testdata/fema_api.json a page at a
+// time, honoring $skip and $top like OpenFEMA.
+func femaAPIServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var payload femaPayload
+	if err := json.Unmarshal(readFixture(t, "fema_api.json"), &payload); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	records := payload.DisasterDeclarationsSummaries
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		q := r.URL.Query()
+		if !strings.HasPrefix(q.Get("$filter"), "state eq 'CO'") {
+			t.Errorf("$filter = %q, want it scoped to CO", q.Get("$filter"))
+		}
+		skip, _ := strconv.Atoi(q.Get("$skip"))
+		top, _ := strconv.Atoi(q.Get("$top"))
+		page := records[min(skip, len(records)):min(skip+top, len(records))]
+		json.NewEncoder(w).Encode(femaPayload{DisasterDeclarationsSummaries: page})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestFEMAGetStateSummaryAPI(t *testing.T) {
+	srv, requests := femaAPIServer(t)
+	c := NewFEMAClientAPI(filepath.Join("testdata", "missing.json"))
+	c.baseURL = srv.URL
+	c.pageSize = 2
+
+	got, err := c.GetStateSummary("co", 180)
+	if err != nil {
+		t.Fatalf("GetStateSummary: %v", err)
+	}
+	// Five records over pages of two.
+	if n := requests.Load(); n != 3 {
+		t.Errorf("made %d requests, want 3 pages", n)
+	}
+	// The two open flood records and the open fire count; the closed 2013
+	// flood and the Wyoming fire do not.
+	if got.ActiveDisasters != 3 || got.TopIncidentType != "Flood" || got.AffectedCounties != 3 {
+		t.Errorf("summary = %+v, want 3 active, top Flood, 3 counties", *got)
+	}
+
+	file, err := NewFEMAClient(filepath.Join("testdata", "fema_api.json")).GetStateSummary("CO", 180)
+	if err != nil {
+		t.Fatalf("file GetStateSummary: %v", err)
+	}
+	if !reflect.DeepEqual(got, file) {
+		t.Errorf("API summary %+v differs from file summary %+v", *got, *file)
+	}
+}
+
+func TestFEMAFallsBackToFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewFEMAClientAPI(filepath.Join("testdata", "fema_api.json"))
+	c.baseURL = srv.URL
+	c.retry = fastRetry
+
+	got, err := c.GetStateSummary("CO", 180)
+	if err != nil {
+		t.Fatalf("GetStateSummary: %v", err)
+	}
+	if got.ActiveDisasters != 3 {
+		t.Errorf("fallback ActiveDisasters = %d, want 3 from the file", got.ActiveDisasters)
+	}
+
+	c.dataPath = filepath.Join("testdata", "missing.json")
+	if _, err := c.GetStateSummary("CO", 180); err == nil {
+		t.Error("API and file both unavailable: want an error")
+	}
+}
+
+func TestFEMAGetCountySummary(t *testing.T) {
+	c := NewFEMAClient(filepath.Join("testdata", "fema_county.json"))
+
+	county, err := c.GetCountySummary("CA", "037", 180)
+	if err != nil {
+		t.Fatalf("GetCountySummary: %v", err)
+	}
+	// The open wildfire in the county and the open statewide storm count;
+	// the closed 2019 flood does not.
+	if county.ActiveDisasters != 2 || county.AffectedCounties != 1 {
+		t.Errorf("county summary = %+v, want 2 active in 1 county", *county)
+	}
+	if want := map[string]int{"Fire": 1, "Severe Storm": 1}; !reflect.DeepEqual(county.IncidentTypeCounts, want) {
+		t.Errorf("IncidentTypeCounts = %v, want %v", county.IncidentTypeCounts, want)
+	}
+	if len(county.Recent) != 2 || county.Recent[0].DisasterNumber != 4856 || county.Recent[1].DisasterNumber != 3604 {
+		t.Fatalf("Recent = %+v, want 4856 then 3604", county.Recent)
+	}
+	if got := county.Recent[0].Counties; !reflect.DeepEqual(got, []string{"Los Angeles (County)"}) {
+		t.Errorf("county Recent[0].Counties = %v, want only Los Angeles", got)
+	}
+
+	state, err := c.GetStateSummary("CA", 180)
+	if err != nil {
+		t.Fatalf("GetStateSummary: %v", err)
+	}
+	if state.ActiveDisasters != 4 || state.AffectedCounties != 3 || state.TopIncidentType != "Fire" {
+		t.Errorf("state summary = %+v, want 4 active in 3 counties, top Fire", *state)
+	}
+	if got := state.Recent[0]; got.DisasterNumber != 4856 || !got.Open ||
+		!reflect.DeepEqual(got.Counties, []string{"Los Angeles (County)", "Ventura (County)"}) {
+		t.Errorf("state Recent[0] = %+v, want open 4856 over Los Angeles and Ventura", got)
+	}
+
+	if _, err := c.GetCountySummary("CA", "37", 180); err == nil {
+		t.Error("two-digit county FIPS: want an error")
+	}
+}
+
+func TestFEMASeverityScenarios(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	impact := func(declType string, begin time.Time, counties ...string) *declarationImpact {
+		imp := &declarationImpact{DeclarationType: declType, IncidentBegin: begin, counties: make(map[string]struct{})}
+		for _, c := range counties {
+			imp.counties[c] = struct{}{}
+		}
+		return imp
+	}
+
+	manyFMs := make(map[int]*declarationImpact)
+	for i := range 10 {
+		manyFMs[5400+i] = impact("FM", daysAgo(2), fmt.Sprintf("%03d", i+1))
+	}
+
+	tests := []struct {
+		name    string
+		impacts map[int]*declarationImpact
+		want    int
+	}{
+		{"nothing", nil, 0},
+		{"single old still-open DR", map[int]*declarationImpact{4100: impact("DR", daysAgo(3650), "031")}, 1},
+		{"single fresh DR", map[int]*declarationImpact{4800: impact("DR", daysAgo(1), "031")}, 3},
+		{"DR half a lookback old", map[int]*declarationImpact{4800: impact("DR", daysAgo(90), "031")}, 2},
+		{"ten fresh FMs", manyFMs, 5},
+		{"mixed fresh DR and two FMs", map[int]*declarationImpact{
+			4800: impact("DR", daysAgo(1), "031"),
+			5401: impact("FM", daysAgo(3), "013"),
+			5402: impact("FM", daysAgo(5), "059"),
+		}, 4},
+		{"fresh DR over eight counties", map[int]*declarationImpact{
+			4800: impact("DR", daysAgo(1), "001", "005", "013", "014", "031", "035", "059", "069"),
+		}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := severityScore(tt.impacts, 180, now)
+			if got := severityLevel(score); got != tt.want {
+				t.Errorf("severity = %d (score %.3f), want %d", got, score, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeFEMABreakdown(t *testing.T) {
+	var payload femaPayload
+	if err := json.Unmarshal(readFixture(t, "fema_mixed.json"), &payload); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	records := payload.DisasterDeclarationsSummaries
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	got := summarizeFEMA(records, "FL", "", 180, now)
+	// The closed 2025 storm and the Georgia fire are left out; the closed
+	// but recent flood and fire and the old but open tornado count.
+	wantIncidents := map[string]int{"Hurricane": 3, "Flood": 1, "Fire": 2, "Tornado": 1}
+	if !reflect.DeepEqual(got.IncidentTypeCounts, wantIncidents) {
+		t.Errorf("IncidentTypeCounts = %v, want %v", got.IncidentTypeCounts, wantIncidents)
+	}
+	wantDeclarations := map[string]int{"DR": 4, "EM": 1, "FM": 2}
+	if !reflect.DeepEqual(got.DeclarationTypeCounts, wantDeclarations) {
+		t.Errorf("DeclarationTypeCounts = %v, want %v", got.DeclarationTypeCounts, wantDeclarations)
+	}
+	if want := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC); !got.LatestIncidentBegin.Equal(want) {
+		t.Errorf("LatestIncidentBegin = %v, want the Picayune fire's %v", got.LatestIncidentBegin, want)
+	}
+	// The scalar fields still summarize the same records
+	if got.ActiveDisasters != 7 || got.TopIncidentType != "Hurricane" || got.AffectedCounties != 6 {
+		t.Errorf("summary = %d active, top %q, %d counties; want 7, Hurricane, 6",
+			got.ActiveDisasters, got.TopIncidentType, got.AffectedCounties)
+	}
+
+	none := summarizeFEMA(records, "TX", "", 180, now)
+	if len(none.IncidentTypeCounts) != 0 || len(none.DeclarationTypeCounts) != 0 || !none.LatestIncidentBegin.IsZero() {
+		t.Errorf("state without records = %+v, want empty breakdowns and no begin date", *none)
+	}
+}
@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fixtureServer serves recorded API responses from testdata, keyed by
+// request path, and 404s anything else.
+type fixtureServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+func newFixtureServer(t *testing.T, files map[string]string) *fixtureServer {
+	t.Helper()
+	bodies := make(map[string][]byte, len(files))
+	for path, name := range files {
+		bodies[path] = readFixture(t, name)
+	}
+
+	fs := &fixtureServer{}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.mu.Lock()
+		fs.queries = append(fs.queries, r.URL.Query())
+		fs.mu.Unlock()
+
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+// lastQuery returns the query string of the most recent request.
+func (fs *fixtureServer) lastQuery() url.Values {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if len(fs.queries) == 0 {
+		return nil
+	}
+	return fs.queries[len(fs.queries)-1]
+}
+
+// readFixture returns the contents of testdata/name.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return b
+}
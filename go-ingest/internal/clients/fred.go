@@ -1,67 +1,251 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// FRED series used by the convenience wrappers.
+const (
+	FREDSeriesNasdaq       = "NASDAQCOM" // NASDAQ Composite close (daily)
+	FREDSeriesCPI          = "CPIAUCSL"  // CPI for all urban consumers, index 1982-84=100 (monthly)
+	FREDSeriesUnemployment = "UNRATE"    // civilian unemployment rate, percent (monthly)
+	FREDSeriesTreasury10Y  = "DGS10"     // 10-year Treasury constant maturity yield, percent (daily)
+)
+
+// fredLatestWindow is how many recent observations GetLatestObservation asks
+// for, so a run of "." placeholders (market holidays) still leaves a value.
+const fredLatestWindow = 10
+
+// ErrFREDNoData is returned when a series has no usable observation in the
+// requested window.
+var ErrFREDNoData = errors.New("fred: no observations")
+
 // FREDClient fetches economic time series from the St. Louis Fed (FRED).
 // Docs: https://fred.stlouisfed.org/docs/api/fred/series_observations.html
 // Free tier requires API key via env.
 type FREDClient struct {
 	apiKey  string
+	baseURL string
 	httpCli *http.Client
+	retry   retryPolicy
+}
+
+// FREDObservation is one dated value of a FRED series.
+type FREDObservation struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
 }
 
 // NewFREDClient creates a new FRED client.
-func NewFREDClient(apiKey string) *FREDClient {
+func NewFREDClient(apiKey string, opts ...ClientOption) *FREDClient {
 	return &FREDClient{
 		apiKey:  apiKey,
-		httpCli: &http.Client{Timeout: 15 * time.Second},
+		baseURL: "https://api.stlouisfed.org/fred/series/observations",
+		httpCli: applyOptions(NewHTTPClient(envTimeout("FRED_TIMEOUT", 15*time.Second)), opts),
+		retry:   defaultRetryPolicy,
 	}
 }
 
 // GetNasdaqComposite returns the latest NASDAQ Composite close via FRED series NASDAQCOM.
 func (c *FREDClient) GetNasdaqComposite() (*NASDAQMarketSummary, error) {
+	return c.GetNasdaqCompositeContext(context.Background())
+}
+
+// GetNasdaqCompositeContext is GetNasdaqComposite with a caller-supplied context.
+func (c *FREDClient) GetNasdaqCompositeContext(ctx context.Context) (*NASDAQMarketSummary, error) {
+	obs, err := c.GetLatestObservationContext(ctx, FREDSeriesNasdaq)
+	if err != nil {
+		return nil, err
+	}
+	return &NASDAQMarketSummary{IndexValue: obs.Value, VolumeTraded: 0}, nil
+}
+
+// GetCPI returns the latest CPI-U index level (CPIAUCSL).
+func (c *FREDClient) GetCPI() (*FREDObservation, error) {
+	return c.GetCPIContext(context.Background())
+}
+
+// GetCPIContext is GetCPI with a caller-supplied context.
+func (c *FREDClient) GetCPIContext(ctx context.Context) (*FREDObservation, error) {
+	return c.GetLatestObservationContext(ctx, FREDSeriesCPI)
+}
+
+// GetUnemploymentRate returns the latest national unemployment rate in
+// percent (UNRATE).
+func (c *FREDClient) GetUnemploymentRate() (*FREDObservation, error) {
+	return c.GetUnemploymentRateContext(context.Background())
+}
+
+// GetUnemploymentRateContext is GetUnemploymentRate with a caller-supplied context.
+func (c *FREDClient) GetUnemploymentRateContext(ctx context.Context) (*FREDObservation, error) {
+	return c.GetLatestObservationContext(ctx, FREDSeriesUnemployment)
+}
+
+// GetTreasury10Y returns the latest 10-year Treasury yield in percent (DGS10).
+func (c *FREDClient) GetTreasury10Y() (*FREDObservation, error) {
+	return c.GetTreasury10YContext(context.Background())
+}
+
+// GetTreasury10YContext is GetTreasury10Y with a caller-supplied context.
+func (c *FREDClient) GetTreasury10YContext(ctx context.Context) (*FREDObservation, error) {
+	return c.GetLatestObservationContext(ctx, FREDSeriesTreasury10Y)
+}
+
+// MacroIndicators are the latest values of the FRED macro series. A field is
+// left at 0 when its series could not be fetched; ObservedAt is the newest
+// observation date among those that were.
+type MacroIndicators struct {
+	CPI              float64
+	UnemploymentRate float64
+	Treasury10Y      float64
+	ObservedAt       time.Time
+}
+
+// GetMacroIndicators fetches CPIAUCSL, UNRATE and DGS10. It returns whatever
+// series succeeded together with the joined errors of the rest, and a nil
+// result only when all of them failed.
+func (c *FREDClient) GetMacroIndicators() (*MacroIndicators, error) {
+	return c.GetMacroIndicatorsContext(context.Background())
+}
+
+// GetMacroIndicatorsContext is GetMacroIndicators with a caller-supplied context.
+func (c *FREDClient) GetMacroIndicatorsContext(ctx context.Context) (*MacroIndicators, error) {
+	var m MacroIndicators
+	series := []struct {
+		id  string
+		dst *float64
+	}{
+		{FREDSeriesCPI, &m.CPI},
+		{FREDSeriesUnemployment, &m.UnemploymentRate},
+		{FREDSeriesTreasury10Y, &m.Treasury10Y},
+	}
+
+	var errs []error
+	for _, s := range series {
+		obs, err := c.GetLatestObservationContext(ctx, s.id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		*s.dst = obs.Value
+		if obs.Date.After(m.ObservedAt) {
+			m.ObservedAt = obs.Date
+		}
+	}
+	if len(errs) == len(series) {
+		return nil, errors.Join(errs...)
+	}
+	return &m, errors.Join(errs...)
+}
+
+// GetLatestObservation returns the newest non-missing observation of a series.
+func (c *FREDClient) GetLatestObservation(seriesID string) (*FREDObservation, error) {
+	return c.GetLatestObservationContext(context.Background(), seriesID)
+}
+
+// GetLatestObservationContext is GetLatestObservation with a caller-supplied context.
+func (c *FREDClient) GetLatestObservationContext(ctx context.Context, seriesID string) (*FREDObservation, error) {
+	q := url.Values{}
+	q.Set("sort_order", "desc")
+	q.Set("limit", strconv.Itoa(fredLatestWindow))
+	obs, err := c.observations(ctx, seriesID, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(obs) == 0 {
+		return nil, fmt.Errorf("FRED %s: %w", seriesID, ErrFREDNoData)
+	}
+	return &obs[0], nil
+}
+
+// GetObservations returns the observations of a series dated between start
+// and end (inclusive), oldest first. Missing values are left out.
+func (c *FREDClient) GetObservations(seriesID string, start, end time.Time) ([]FREDObservation, error) {
+	return c.GetObservationsContext(context.Background(), seriesID, start, end)
+}
+
+// GetObservationsContext is GetObservations with a caller-supplied context.
+func (c *FREDClient) GetObservationsContext(ctx context.Context, seriesID string, start, end time.Time) ([]FREDObservation, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("FRED %s: end is before start", seriesID)
+	}
+	q := url.Values{}
+	q.Set("observation_start", start.Format("2006-01-02"))
+	q.Set("observation_end", end.Format("2006-01-02"))
+	q.Set("sort_order", "asc")
+	return c.observations(ctx, seriesID, q)
+}
+
+// observations fetches series/observations with the given extra query
+// parameters and decodes the usable rows in response order.
+func (c *FREDClient) observations(ctx context.Context, seriesID string, q url.Values) ([]FREDObservation, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("FRED API key required")
 	}
+	seriesID = strings.TrimSpace(seriesID)
+	if seriesID == "" {
+		return nil, fmt.Errorf("FRED series ID required")
+	}
 
-	// NASDAQ Composite series_id: NASDAQCOM (daily)
-	url := fmt.Sprintf("https://api.stlouisfed.org/fred/series/observations?series_id=NASDAQCOM&api_key=%s&file_type=json&sort_order=desc&limit=1", c.apiKey)
+	q.Set("series_id", seriesID)
+	q.Set("api_key", c.apiKey)
+	q.Set("file_type", "json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build FRED request: %w", err)
+	}
 
-	resp, err := c.httpCli.Get(url)
+	resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
 	if err != nil {
-		return nil, fmt.Errorf("fetch FRED NASDAQ: %w", err)
+		return nil, fmt.Errorf("fetch FRED %s: %w", seriesID, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("FRED NASDAQ returned %d", resp.StatusCode)
+	obs, err := decodeFREDObservations(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decode FRED %s: %w", seriesID, err)
 	}
+	return obs, nil
+}
 
+// decodeFREDObservations parses a series/observations body. FRED sends
+// values as strings and marks missing ones (holidays, unreleased months)
+// with "."; those rows are skipped.
+func decodeFREDObservations(r io.Reader) ([]FREDObservation, error) {
 	var payload struct {
 		Observations []struct {
+			Date  string `json:"date"`
 			Value string `json:"value"`
 		} `json:"observations"`
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return nil, fmt.Errorf("decode FRED NASDAQ: %w", err)
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, err
 	}
 
-	if len(payload.Observations) == 0 {
-		return nil, fmt.Errorf("no observations from FRED NASDAQ")
+	out := make([]FREDObservation, 0, len(payload.Observations))
+	for _, o := range payload.Observations {
+		v := strings.TrimSpace(o.Value)
+		if v == "" || v == "." {
+			continue
+		}
+		val, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse value %q for %s: %w", o.Value, o.Date, err)
+		}
+		date, err := time.Parse("2006-01-02", o.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", o.Date, err)
+		}
+		out = append(out, FREDObservation{Date: date, Value: val})
 	}
-
-	valStr := payload.Observations[0].Value
-	val, err := strconv.ParseFloat(valStr, 64)
-	if err != nil {
-		return nil, fmt.Errorf("parse NASDAQ value: %w", err)
-	}
-
-	return &NASDAQMarketSummary{IndexValue: val, VolumeTraded: 0}, nil
+	return out, nil
 }
@@ -0,0 +1,114 @@
+package clients
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fredServer answers series/observations from testdata, keyed by series_id,
+// and 404s unknown series the way FRED does.
+func fredServer(t *testing.T, series map[string]string) *FREDClient {
+	t.Helper()
+	bodies := make(map[string][]byte, len(series))
+	for id, name := range series {
+		bodies[id] = readFixture(t, name)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("api_key") != "test-key" || q.Get("file_type") != "json" {
+			http.Error(w, `{"error_code":400,"error_message":"Bad Request."}`, http.StatusBadRequest)
+			return
+		}
+		body, ok := bodies[q.Get("series_id")]
+		if !ok {
+			http.Error(w, `{"error_code":404,"error_message":"Not Found. The series does not exist."}`, http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewFREDClient("test-key")
+	c.baseURL = srv.URL
+	c.retry = fastRetry
+	return c
+}
+
+func TestFREDGetLatestObservationSkipsMissing(t *testing.T) {
+	c := fredServer(t, map[string]string{
+		FREDSeriesUnemployment: "fred_unrate_latest.json",
+		FREDSeriesCPI:          "fred_missing_only.json",
+	})
+
+	obs, err := c.GetUnemploymentRate()
+	if err != nil {
+		t.Fatalf("GetUnemploymentRate: %v", err)
+	}
+	if want := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC); obs.Value != 4.3 || !obs.Date.Equal(want) {
+		t.Errorf("UNRATE = %+v, want 4.3 for September (October is \".\")", *obs)
+	}
+
+	if _, err := c.GetCPI(); !errors.Is(err, ErrFREDNoData) {
+		t.Errorf("series of only \".\" values: err = %v, want ErrFREDNoData", err)
+	}
+}
+
+func TestFREDGetObservations(t *testing.T) {
+	c := fredServer(t, map[string]string{FREDSeriesTreasury10Y: "fred_dgs10_range.json"})
+	start := time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	obs, err := c.GetObservations(" DGS10 ", start, end)
+	if err != nil {
+		t.Fatalf("GetObservations: %v", err)
+	}
+	want := []FREDObservation{
+		{time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC), 4.12},
+		{time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC), 4.08},
+		{time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), 4.05},
+	}
+	if len(obs) != len(want) {
+		t.Fatalf("got %d observations %+v, want %d with the holiday left out", len(obs), obs, len(want))
+	}
+	for i := range want {
+		if !obs[i].Date.Equal(want[i].Date) || obs[i].Value != want[i].Value {
+			t.Errorf("observation %d = %+v, want %+v", i, obs[i], want[i])
+		}
+	}
+
+	if _, err := c.GetObservations("DGS10", end, start); err == nil {
+		t.Error("reversed range: want an error")
+	}
+}
+
+func TestFREDGetMacroIndicatorsPartial(t *testing.T) {
+	c := fredServer(t, map[string]string{
+		FREDSeriesUnemployment: "fred_unrate_latest.json",
+		FREDSeriesTreasury10Y:  "fred_dgs10_latest.json",
+	})
+
+	m, err := c.GetMacroIndicators()
+	if m == nil {
+		t.Fatalf("GetMacroIndicators = nil, %v; want the series that succeeded", err)
+	}
+	if err == nil {
+		t.Error("missing CPIAUCSL: want its error joined into the result")
+	}
+	if m.CPI != 0 || m.UnemploymentRate != 4.3 || m.Treasury10Y != 4.07 {
+		t.Errorf("indicators = %+v, want CPI 0, UNRATE 4.3, DGS10 4.07", *m)
+	}
+	if want := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC); !m.ObservedAt.Equal(want) {
+		t.Errorf("ObservedAt = %s, want %s", m.ObservedAt, want)
+	}
+
+	empty := fredServer(t, nil)
+	if m, err := empty.GetMacroIndicators(); m != nil || err == nil {
+		t.Errorf("all series failing = %+v, %v; want nil and an error", m, err)
+	}
+	if _, err := NewFREDClient("").GetCPI(); err == nil {
+		t.Error("no API key: want an error")
+	}
+}
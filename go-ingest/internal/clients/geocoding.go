@@ -0,0 +1,86 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GeocodingClient resolves place names with the Open-Meteo geocoding API.
+type GeocodingClient struct {
+	baseURL string
+	httpCli *http.Client
+}
+
+// NewGeocodingClient creates a new Open-Meteo geocoding client.
+func NewGeocodingClient(opts ...ClientOption) *GeocodingClient {
+	return &GeocodingClient{
+		baseURL: "https://geocoding-api.open-meteo.com/v1",
+		httpCli: applyOptions(NewHTTPClient(envTimeout("GEOCODING_TIMEOUT", 10*time.Second)), opts),
+	}
+}
+
+// GeocodeResult is one candidate place from a geocoding search.
+type GeocodeResult struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Timezone    string  `json:"timezone"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Admin1      string  `json:"admin1"`
+	Admin2      string  `json:"admin2"`
+	FeatureCode string  `json:"feature_code"`
+	Population  int64   `json:"population"`
+}
+
+// GeocodeResponse is the body of a geocoding search. Results is omitted
+// entirely when nothing matched.
+type GeocodeResponse struct {
+	Results []GeocodeResult `json:"results"`
+}
+
+// Search looks up places matching name, returning at most count results
+// ordered by relevance.
+func (c *GeocodingClient) Search(name string, count int) ([]GeocodeResult, error) {
+	return c.SearchContext(context.Background(), name, count)
+}
+
+// SearchContext is Search with a caller-supplied context.
+func (c *GeocodingClient) SearchContext(ctx context.Context, name string, count int) ([]GeocodeResult, error) {
+	if count < 1 || count > 100 {
+		return nil, fmt.Errorf("count must be between 1 and 100, got %d", count)
+	}
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("count", strconv.Itoa(count))
+	q.Set("language", "en")
+	q.Set("format", "json")
+
+	reqURL := fmt.Sprintf("%s/search?%s", c.baseURL, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed GeocodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return parsed.Results, nil
+}
@@ -0,0 +1,48 @@
+package clients
+
+import "testing"
+
+func TestGeocodingSearch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/search": "geocoding_portland.json"})
+	c := NewGeocodingClient()
+	c.baseURL = srv.URL
+
+	results, err := c.Search("Portland", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	q := srv.lastQuery()
+	if q.Get("name") != "Portland" || q.Get("count") != "10" {
+		t.Errorf("query = %v", q)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	want := GeocodeResult{
+		ID:          5746545,
+		Name:        "Portland",
+		Latitude:    45.52345,
+		Longitude:   -122.67621,
+		Timezone:    "America/Los_Angeles",
+		Country:     "United States",
+		CountryCode: "US",
+		Admin1:      "Oregon",
+		Admin2:      "Multnomah",
+		FeatureCode: "PPLA2",
+		Population:  652503,
+	}
+	if results[0] != want {
+		t.Errorf("results[0] = %+v, want %+v", results[0], want)
+	}
+}
+
+func TestGeocodingSearchNoResults(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/search": "geocoding_empty.json"})
+	c := NewGeocodingClient()
+	c.baseURL = srv.URL
+
+	results, err := c.Search("Xyzzyville", 10)
+	if err != nil || len(results) != 0 {
+		t.Errorf("Search = %v, %v; want no results and no error", results, err)
+	}
+}
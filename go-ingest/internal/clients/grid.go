@@ -1,16 +1,41 @@
 package clients
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"strings"
 	"time"
 )
 
-// GridClient queries grid status and load data
-// This is a mock client that simulates grid monitoring data
-// In production, this would integrate with ISOs like CAISO, PJM, ERCOT, etc.
+// GridProvider reports current conditions on one ISO's grid. Each ISO with
+// a public feed gets an implementation, registered in gridProviders under
+// its region names.
+type GridProvider interface {
+	GetGridStatus(ctx context.Context) (*GridStatus, error)
+}
+
+// gridProviders builds the live provider for each implemented region, keyed
+// by ISO name and by the EIA balancing authority code the location registry
+// uses as grid_region.
+var gridProviders = map[string]func(cli *http.Client) GridProvider{
+	"CAISO": newCAISOProvider,
+	"CISO":  newCAISOProvider,
+	"ERCOT": newERCOTProvider,
+	"ERCO":  newERCOTProvider,
+}
+
+// GridClient queries current grid load for an ISO region through that
+// region's GridProvider. The synthetic generator used for demos runs only on
+// a client from NewMockGridClient (or with Simulate set), so simulated
+// figures are never mistaken for real ones.
 type GridClient struct {
-	Region string
+	Region   string
+	Simulate bool // generate synthetic data instead of querying the ISO
+
+	provider GridProvider // nil when the region is not implemented
 }
 
 // GridStatus represents current power grid conditions
@@ -18,28 +43,74 @@ type GridStatus struct {
 	LoadMW             float64 // Current grid load in megawatts
 	CapacityMW         float64 // Total available capacity in megawatts
 	UtilizationPercent float64 // Load as percentage of capacity
-	FrequencyHz        float64 // Grid frequency (should be ~60Hz in US, ~50Hz in Europe)
+	FrequencyHz        float64 // Grid frequency (~60Hz in US, ~50Hz in Europe); 0 when the source does not report it
 	Status             string  // "Normal", "Alert", "Emergency"
 	RenewablesMW       float64 // Current renewable generation in MW
+	Source             string  // Backend that produced the figures: "caiso", "ercot", "eia" or "mock"
 }
 
-// NewGridClient creates a new grid monitoring client
-func NewGridClient(region string) *GridClient {
-	return &GridClient{
-		Region: region,
+// Grid data backends recorded in GridStatus.Source.
+const (
+	GridSourceCAISO = "caiso"
+	GridSourceERCOT = "ercot"
+	GridSourceEIA   = "eia"
+	GridSourceMock  = "mock"
+)
+
+// ErrGridRegionNotImplemented is returned for a region with no GridProvider.
+var ErrGridRegionNotImplemented = errors.New("grid region not implemented")
+
+// GridRegionImplemented reports whether region has a live GridProvider.
+func GridRegionImplemented(region string) bool {
+	_, ok := gridProviders[strings.ToUpper(strings.TrimSpace(region))]
+	return ok
+}
+
+// NewGridClient creates a grid client for region, an ISO name ("CAISO",
+// "ERCOT") or EIA balancing authority code ("CISO", "ERCO"). A region
+// without a provider yields a client whose calls return
+// ErrGridRegionNotImplemented.
+func NewGridClient(region string, opts ...ClientOption) *GridClient {
+	c := &GridClient{Region: region}
+	if newProvider, ok := gridProviders[strings.ToUpper(strings.TrimSpace(region))]; ok {
+		c.provider = newProvider(applyOptions(NewHTTPClient(envTimeout("GRID_TIMEOUT", 15*time.Second)), opts))
 	}
+	return c
+}
+
+// NewMockGridClient creates a grid client that returns synthetic,
+// time-of-day shaped figures marked with Source "mock". It never makes
+// network requests.
+func NewMockGridClient(region string) *GridClient {
+	return &GridClient{Region: region, Simulate: true}
 }
 
-// GetGridStatus fetches current grid status and load
-// This is a mock implementation that generates realistic data
+// GetGridStatus fetches current grid status and load for the client's
+// region.
 func (c *GridClient) GetGridStatus() (*GridStatus, error) {
+	return c.GetGridStatusContext(context.Background())
+}
+
+// GetGridStatusContext is GetGridStatus with a caller-supplied context.
+func (c *GridClient) GetGridStatusContext(ctx context.Context) (*GridStatus, error) {
+	if c.Simulate {
+		return simulateGridStatus(), nil
+	}
+	if c.provider == nil {
+		return nil, fmt.Errorf("%w: %q", ErrGridRegionNotImplemented, c.Region)
+	}
+	return c.provider.GetGridStatus(ctx)
+}
+
+// simulateGridStatus generates synthetic but plausible grid figures.
+func simulateGridStatus() *GridStatus {
 	// Seed randomizer for realistic variation
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	// Base load varies by time of day (mock implementation)
 	hour := time.Now().Hour()
 	baseLoad := 25000.0 // MW
-	
+
 	// Peak load during afternoon/evening (2pm - 8pm)
 	if hour >= 14 && hour <= 20 {
 		baseLoad = 35000.0
@@ -67,43 +138,41 @@ func (c *GridClient) GetGridStatus() (*GridStatus, error) {
 		renewablesMW = 3000.0 + r.Float64()*1000.0 // Mostly wind at night
 	}
 
-	// Determine status based on utilization
-	status := "Normal"
-	if utilizationPercent > 90 {
-		status = "Emergency"
-	} else if utilizationPercent > 80 {
-		status = "Alert"
-	}
-
 	return &GridStatus{
 		LoadMW:             loadMW,
 		CapacityMW:         capacityMW,
 		UtilizationPercent: utilizationPercent,
 		FrequencyHz:        frequencyHz,
-		Status:             status,
+		Status:             gridStatusLevel(utilizationPercent),
 		RenewablesMW:       renewablesMW,
-	}, nil
+		Source:             GridSourceMock,
+	}
 }
 
-// GetRegionalLoad fetches load data for a specific region
-// In production, this would query ISO-specific APIs (CAISO, ERCOT, PJM, etc.)
-func (c *GridClient) GetRegionalLoad(region string) (float64, error) {
-	// Mock regional load data
-	regionalLoads := map[string]float64{
-		"CAISO":    25000.0, // California
-		"ERCOT":    45000.0, // Texas
-		"PJM":      85000.0, // Mid-Atlantic
-		"NYISO":    20000.0, // New York
-		"ISO-NE":   15000.0, // New England
-		"MISO":     75000.0, // Midwest
-		"SPP":      35000.0, // Southwest Power Pool
+// GridStatusFromDemand builds a GridStatus from a balancing authority's
+// reported demand, using the day-ahead forecast peak as capacity. Frequency
+// and renewables are not part of that data and stay zero, as does
+// utilization when no forecast was published.
+func GridStatusFromDemand(d *BalancingAuthorityDemand) *GridStatus {
+	status := &GridStatus{
+		LoadMW:     d.DemandMWh,
+		CapacityMW: d.PeakMWh,
+		Status:     "Normal",
+		Source:     GridSourceEIA,
 	}
-
-	if load, ok := regionalLoads[region]; ok {
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-		// Add ±5% variation
-		return load * (0.95 + r.Float64()*0.1), nil
+	if d.PeakMWh > 0 {
+		status.UtilizationPercent = d.DemandMWh / d.PeakMWh * 100
+		status.Status = gridStatusLevel(status.UtilizationPercent)
 	}
+	return status
+}
 
-	return 0, fmt.Errorf("region not supported: %s", region)
+// gridStatusLevel determines status based on utilization
+func gridStatusLevel(utilizationPercent float64) string {
+	if utilizationPercent > 90 {
+		return "Emergency"
+	} else if utilizationPercent > 80 {
+		return "Alert"
+	}
+	return "Normal"
 }
@@ -0,0 +1,39 @@
+package clients
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewGridClientSelectsProvider(t *testing.T) {
+	for region, want := range map[string]string{
+		"CAISO":  "caiso",
+		"CISO":   "caiso",
+		"ercot":  "ercot",
+		" ERCO ": "ercot",
+		"PJM":    "",
+		"":       "",
+	} {
+		c := NewGridClient(region)
+		var got string
+		switch c.provider.(type) {
+		case *caisoProvider:
+			got = "caiso"
+		case *ercotProvider:
+			got = "ercot"
+		}
+		if got != want {
+			t.Errorf("NewGridClient(%q) provider = %q, want %q", region, got, want)
+		}
+		if implemented := GridRegionImplemented(region); implemented != (want != "") {
+			t.Errorf("GridRegionImplemented(%q) = %v", region, implemented)
+		}
+	}
+}
+
+func TestGridRegionNotImplemented(t *testing.T) {
+	_, err := NewGridClient("PJM").GetGridStatus()
+	if !errors.Is(err, ErrGridRegionNotImplemented) {
+		t.Errorf("PJM err = %v, want ErrGridRegionNotImplemented", err)
+	}
+}
@@ -0,0 +1,54 @@
+package clients
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sharedTransport pools connections across every client, so the concurrent
+// ingest pass reuses sockets instead of opening a fresh pool per source.
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
+// SharedTransport returns the pooled transport used by NewHTTPClient, for
+// wrapping transports (such as httpcache.Transport) to forward to.
+func SharedTransport() http.RoundTripper {
+	return sharedTransport
+}
+
+// NewHTTPClient returns an http.Client with the given overall request
+// timeout that uses the shared transport.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: sharedTransport}
+}
+
+// envTimeout reads a client timeout from the environment variable key,
+// accepting a Go duration ("45s") or whole seconds ("45"). It falls back to
+// def when the variable is unset or invalid.
+func envTimeout(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d
+	}
+	if n, err := strconv.Atoi(v); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return def
+}
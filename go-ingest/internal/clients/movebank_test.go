@@ -0,0 +1,176 @@
+package clients
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHaversineKM(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", 39.74, -104.99, 39.74, -104.99, 0},
+		{"one degree of latitude", 0, 0, 1, 0, 111.195},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.195},
+		{"Los Angeles to New York", 34.0522, -118.2437, 40.7128, -74.0060, 3935.7},
+		{"antipodes", 0, 0, 0, 180, math.Pi * 6371},
+	}
+	for _, tt := range tests {
+		if got := haversineKM(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(got-tt.want) > 0.5 {
+			t.Errorf("%s: haversineKM = %.3f, want %.3f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMedianDailyPace(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2026, 10, d, hour, 0, 0, 0, time.UTC) }
+	degree := haversineKM(0, 0, 1, 0)
+	events := []MovebankEvent{
+		// One degree a day for two days.
+		{1, day(1, 12), 0, 0}, {1, day(2, 12), 1, 0}, {1, day(3, 12), 2, 0},
+		// One degree over two days; the earlier fix on day 1 is ignored.
+		{2, day(1, 6), 5, 5}, {2, day(1, 12), 0, 0}, {2, day(3, 12), 0, 1},
+		// Fixes either side of midnight are too close to count as a day.
+		{3, day(1, 23), 0, 0}, {3, day(2, 1), 3, 0},
+		// A single day of fixes has no pace.
+		{4, day(1, 8), 0, 0}, {4, day(1, 20), 0, 4},
+	}
+
+	got, ok := medianDailyPace(events)
+	// The median of 1 and 0.5 degrees per day.
+	if want := 0.75 * degree; !ok || math.Abs(got-want) > 1e-6 {
+		t.Errorf("medianDailyPace = %.3f, %v; want %.3f, true", got, ok, want)
+	}
+
+	if _, ok := medianDailyPace(events[6:]); ok {
+		t.Error("no individual with fixes on two days: want no pace")
+	}
+	if _, ok := medianDailyPace(nil); ok {
+		t.Error("no events: want no pace")
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := median([]float64{3, 1, 2}); got != 2 {
+		t.Errorf("median of 3 values = %v, want 2", got)
+	}
+	if got := median([]float64{4, 1, 3, 2}); got != 2.5 {
+		t.Errorf("median of 4 values = %v, want 2.5", got)
+	}
+}
+
+func TestMovebankGetStudyEvents(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/": "movebank_events.csv"})
+	c := NewMovebankClient("", "")
+	c.baseURL = srv.URL + "/"
+
+	events, err := c.GetStudyEvents(2911040, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetStudyEvents: %v", err)
+	}
+	// Rows without a position, with an impossible latitude or with an
+	// unparseable time are dropped; the rest come back by individual and time.
+	want := []MovebankEvent{
+		{2911058, time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC), 52.1723, 5.2231},
+		{2911059, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), 0, 0},
+		{2911059, time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC), 1, 0},
+		{2911059, time.Date(2026, 10, 3, 12, 0, 0, 0, time.UTC), 0, 0},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("events[%d] = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+
+	q := srv.lastQuery()
+	if q.Get("entity_type") != "event" || q.Get("study_id") != "2911040" || q.Get("timestamp_start") != "20261001000000000" {
+		t.Errorf("query = %v", q)
+	}
+}
+
+// movebankServer lists testdata/movebank_studies.csv, with {{recent}}
+// replaced by a time inside the activity window, and refuses event reads.
+func movebankServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	recent := time.Now().UTC().Add(-24 * time.Hour).Format(movebankTimeLayout)
+	studies := strings.ReplaceAll(string(readFixture(t, "movebank_studies.csv")), "{{recent}}", recent)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("entity_type") != "study" {
+			http.Error(w, "no access", http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, studies)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMovebankGetAnimalsByRegion(t *testing.T) {
+	c := NewMovebankClient("", "")
+	c.baseURL = movebankServer(t).URL
+
+	tests := []struct {
+		region                     string
+		species, animals, location int
+	}{
+		// The 2019 study is inactive and the one without a location cannot
+		// be placed.
+		{"Europe", 2, 20, 7000},
+		{" north america ", 2, 30, 9000},
+		{"-5,30,5,40", 1, 5, 700},
+		{"60,-10,70,0", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		got, err := c.GetAnimalsByRegion(tt.region)
+		if err != nil {
+			t.Fatalf("GetAnimalsByRegion(%q): %v", tt.region, err)
+		}
+		if got.ActiveSpecies != tt.species || got.TotalAnimalsTracked != tt.animals || got.LocationCount != tt.location {
+			t.Errorf("GetAnimalsByRegion(%q) = %+v, want %d species, %d animals, %d locations",
+				tt.region, *got, tt.species, tt.animals, tt.location)
+		}
+		if got.PaceAvailable || got.AvgMigrationPace != 0 {
+			t.Errorf("GetAnimalsByRegion(%q) pace = %v (available %v), want none without events", tt.region, got.AvgMigrationPace, got.PaceAvailable)
+		}
+	}
+
+	denver, err := c.GetAnimalsInBox(BoundingBoxAround(39.74, -104.99, 100))
+	if err != nil {
+		t.Fatalf("GetAnimalsInBox: %v", err)
+	}
+	if denver.TotalAnimalsTracked != 30 {
+		t.Errorf("around Denver = %+v, want only the Colorado study", *denver)
+	}
+
+	global, err := c.GetGlobalMovementTrends()
+	if err != nil {
+		t.Fatalf("GetGlobalMovementTrends: %v", err)
+	}
+	if global.TotalAnimalsTracked != 58 || global.ActiveSpecies != 6 {
+		t.Errorf("global = %+v, want 58 animals of 6 species", *global)
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	if box, err := ParseRegion("Oceania"); err != nil || !box.Contains(-33.9, 151.2) || !box.Contains(-17.7, -168) {
+		t.Errorf("Oceania = %+v (%v), want Sydney and Fiji-side longitudes inside", box, err)
+	}
+	if box, err := ParseRegion("10, -20, 30, 40"); err != nil || box != (BoundingBox{10, -20, 30, 40}) {
+		t.Errorf("explicit box = %+v (%v)", box, err)
+	}
+	for _, bad := range []string{"narnia", "1,2,3", "a,b,c,d", "30,0,10,10", "0,0,95,10"} {
+		if _, err := ParseRegion(bad); err == nil {
+			t.Errorf("ParseRegion(%q): want an error", bad)
+		}
+	}
+}
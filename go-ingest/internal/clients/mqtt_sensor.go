@@ -1,104 +1,642 @@
 package clients
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.mqtt.golang/packets"
 )
 
-// MQTTSensorReading holds last values seen on subscribed topics.
+// MQTTSensorReading holds last values seen on subscribed topics. When
+// several devices report a field, it holds their average.
 type MQTTSensorReading struct {
 	Temperature float64
 	Humidity    float64
 	PM25        float64
 	Power       float64
+
+	// ReceivedAt is when each field's newest value arrived. A field that
+	// has never been received is absent, so its zero value can be told
+	// apart from a reported zero.
+	ReceivedAt map[MQTTField]time.Time
+	// Devices is how many devices each field's value averages.
+	Devices map[MQTTField]int
+}
+
+// MQTTField names the MQTTSensorReading field a topic's values are stored in.
+type MQTTField string
+
+// Fields a topic can be mapped to.
+const (
+	MQTTFieldTemperature MQTTField = "temperature"
+	MQTTFieldHumidity    MQTTField = "humidity"
+	MQTTFieldPM25        MQTTField = "pm25"
+	MQTTFieldPower       MQTTField = "power"
+)
+
+// set stores v in the reading field named by f.
+func (f MQTTField) set(r *MQTTSensorReading, v float64) {
+	switch f {
+	case MQTTFieldTemperature:
+		r.Temperature = v
+	case MQTTFieldHumidity:
+		r.Humidity = v
+	case MQTTFieldPM25:
+		r.PM25 = v
+	case MQTTFieldPower:
+		r.Power = v
+	}
+}
+
+// ParseMQTTField validates a field name, e.g. from configuration.
+func ParseMQTTField(s string) (MQTTField, error) {
+	switch f := MQTTField(s); f {
+	case MQTTFieldTemperature, MQTTFieldHumidity, MQTTFieldPM25, MQTTFieldPower:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown MQTT field %q (want temperature, humidity, pm25 or power)", s)
+}
+
+// ParseMQTTTopics reads a topic→field mapping written as comma-separated
+// topic=field pairs, e.g. "edgesight/+/temperature=temperature".
+func ParseMQTTTopics(spec string) (map[string]MQTTField, error) {
+	topics := make(map[string]MQTTField)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		topic, name, ok := strings.Cut(pair, "=")
+		topic = strings.TrimSpace(topic)
+		if !ok || topic == "" {
+			return nil, fmt.Errorf("MQTT topic mapping %q: want topic=field", pair)
+		}
+		field, err := ParseMQTTField(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		topics[topic] = field
+	}
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("no MQTT topics in %q", spec)
+	}
+	return topics, nil
+}
+
+// DefaultMQTTTopics is the topic mapping published by cmd/mqtt-sim.
+var DefaultMQTTTopics = map[string]MQTTField{
+	"sensors/temperature": MQTTFieldTemperature,
+	"sensors/humidity":    MQTTFieldHumidity,
+	"sensors/pm25":        MQTTFieldPM25,
+	"sensors/power":       MQTTFieldPower,
 }
 
 // MQTTSensorClient subscribes to sensor topics and returns the latest readings.
 type MQTTSensorClient struct {
-	broker   string
-	clientID string
-	topics   []string
-	timeout  time.Duration
+	broker    string
+	clientID  string
+	topics    map[string]MQTTField
+	qos       byte
+	timeout   time.Duration
+	maxAge    time.Duration
+	username  string
+	password  string
+	tlsConfig *tls.Config
+	clean     bool
+
+	// newClient builds the paho client; tests replace it with a fake.
+	newClient func(*mqtt.ClientOptions) mqtt.Client
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[mqttKey]mqttValue // last value per topic and device
+	conn  mqtt.Client           // set between Start and Close
+	stop  chan struct{}         // closed by Close to end the Start goroutine
+}
+
+// mqttKey identifies one device's values on one concrete topic. The
+// device is the payload's "device" when it has one, else the topic.
+type mqttKey struct {
+	topic  string
+	device string
+}
+
+// mqttValue is the last message received from one device on one topic.
+type mqttValue struct {
+	field MQTTField
+	value float64
+	at    time.Time
+}
+
+// mqttPayload is the JSON form of a sensor message, e.g.
+// {"value": 23.1, "unit": "C", "device": "esp32-3"}. The unit is not
+// checked; devices must publish in the unit of the mapped field.
+type mqttPayload struct {
+	Value  *float64 `json:"value"`
+	Unit   string   `json:"unit"`
+	Device string   `json:"device"`
 }
 
-// NewMQTTSensorClient creates a new client.
-func NewMQTTSensorClient(broker string) *MQTTSensorClient {
-	return &MQTTSensorClient{
-		broker:   broker,
-		clientID: "edgesight-ingest",
-		topics: []string{
-			"sensors/temperature",
-			"sensors/humidity",
-			"sensors/pm25",
-			"sensors/power",
-		},
-		timeout: 3 * time.Second,
+// parseMQTTPayload reads a message body, either a bare number or a JSON
+// object with a "value" key, returning the value and the device named in
+// the payload, if any.
+func parseMQTTPayload(b []byte) (float64, string, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		var p mqttPayload
+		if err := json.Unmarshal(b, &p); err != nil {
+			return 0, "", fmt.Errorf("decode JSON payload: %w", err)
+		}
+		if p.Value == nil {
+			return 0, "", errors.New("JSON payload has no value")
+		}
+		return *p.Value, p.Device, nil
 	}
+	v, err := strconv.ParseFloat(string(b), 64)
+	return v, "", err
 }
 
-// FetchReadings connects, subscribes, waits briefly for messages, and returns the latest values.
-func (c *MQTTSensorClient) FetchReadings() (*MQTTSensorReading, error) {
-	if c.broker == "" {
-		return nil, fmt.Errorf("mqtt broker not configured")
+// MQTTOption customizes an MQTTSensorClient.
+type MQTTOption func(*MQTTSensorClient)
+
+// WithMQTTTopics replaces the topic→field mapping. Topics may be MQTT
+// filters with + or # wildcards.
+func WithMQTTTopics(topics map[string]MQTTField) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.topics = make(map[string]MQTTField, len(topics))
+		for t, f := range topics {
+			c.topics[t] = f
+		}
 	}
+}
 
-	opts := mqtt.NewClientOptions().AddBroker(c.broker).SetClientID(c.clientID)
-	mc := mqtt.NewClient(opts)
+// WithMQTTQoS sets the subscription QoS level (0, 1 or 2).
+func WithMQTTQoS(qos byte) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.qos = qos
+	}
+}
+
+// WithMQTTTimeout sets how long FetchReadings waits for messages.
+func WithMQTTTimeout(d time.Duration) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.timeout = d
+	}
+}
+
+// WithMQTTMaxAge leaves values older than d out of readings, so a device
+// that stopped publishing drops out of its field's average. 0 keeps every
+// value.
+func WithMQTTMaxAge(d time.Duration) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.maxAge = d
+	}
+}
+
+// WithMQTTClientID sets the client ID the broker knows this subscriber by.
+// Clients connected at the same time need distinct IDs.
+func WithMQTTClientID(id string) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.clientID = id
+	}
+}
+
+// WithMQTTCleanSession sets the clean-session flag (true by default). With
+// false, the broker keeps the subscriptions, and queues QoS 1 and 2
+// messages, for the client ID while it is disconnected.
+func WithMQTTCleanSession(clean bool) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.clean = clean
+	}
+}
+
+// WithMQTTCredentials sets the broker username and password.
+func WithMQTTCredentials(username, password string) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithMQTTTLS sets the TLS configuration used for ssl:// (and tls://)
+// brokers.
+func WithMQTTTLS(cfg *tls.Config) MQTTOption {
+	return func(c *MQTTSensorClient) {
+		c.tlsConfig = cfg
+	}
+}
+
+// NewMQTTSensorClient creates a new client. Without options it subscribes to
+// DefaultMQTTTopics at QoS 1 and waits up to 3s for readings.
+func NewMQTTSensorClient(broker string, opts ...MQTTOption) *MQTTSensorClient {
+	c := &MQTTSensorClient{
+		broker:    broker,
+		clientID:  "edgesight-ingest",
+		clean:     true,
+		qos:       1,
+		timeout:   3 * time.Second,
+		newClient: mqtt.NewClient,
+		now:       time.Now,
+		cache:     make(map[mqttKey]mqttValue),
+	}
+	WithMQTTTopics(DefaultMQTTTopics)(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// clientOptions builds the paho options for the configured broker.
+func (c *MQTTSensorClient) clientOptions() *mqtt.ClientOptions {
+	opts := mqtt.NewClientOptions().AddBroker(c.broker).SetClientID(c.clientID).SetCleanSession(c.clean)
+	if c.username != "" {
+		opts.SetUsername(c.username)
+		opts.SetPassword(c.password)
+	}
+	if c.tlsConfig != nil {
+		opts.SetTLSConfig(c.tlsConfig)
+	}
+	return opts
+}
+
+// Start connects to the broker and subscribes once, keeping the last value
+// and arrival time of every topic in a cache that FetchReadings and Snapshot
+// read. paho reconnects after a broker drop and the topics are resubscribed
+// on each connect, so retained values are picked up again. The connection
+// stays up until ctx is done or Close is called.
+func (c *MQTTSensorClient) Start(ctx context.Context) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	running := c.conn != nil
+	c.mu.Unlock()
+	if running {
+		return errors.New("mqtt client already started")
+	}
+
+	// The first connect reports its subscribe result; reconnects resubscribe
+	// and log failures.
+	subscribed := make(chan error, 1)
+	opts := c.clientOptions().
+		SetAutoReconnect(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("MQTT connection to %s lost, reconnecting: %v", c.broker, err)
+		}).
+		SetOnConnectHandler(func(mc mqtt.Client) {
+			err := c.subscribeAll(mc, c.cacheHandler)
+			select {
+			case subscribed <- err:
+			default:
+				if err != nil {
+					log.Printf("MQTT resubscribe after reconnect: %v", err)
+				}
+			}
+		})
+	mc := c.newClient(opts)
 
 	if token := mc.Connect(); token.Wait() && token.Error() != nil {
-		return nil, fmt.Errorf("mqtt connect: %w", token.Error())
+		return MQTTConnectError(c.broker, token.Error())
+	}
+	select {
+	case err := <-subscribed:
+		if err != nil {
+			mc.Disconnect(50)
+			return err
+		}
+	case <-ctx.Done():
+		mc.Disconnect(50)
+		return ctx.Err()
 	}
-	defer mc.Disconnect(50)
 
-	reading := &MQTTSensorReading{}
-	mu := sync.Mutex{}
-	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	c.mu.Lock()
+	c.conn, c.stop = mc, stop
+	c.mu.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-stop:
+		}
+	}()
+	return nil
+}
 
-	handler := func(_ mqtt.Client, msg mqtt.Message) {
-		mu.Lock()
-		switch msg.Topic() {
-		case "sensors/temperature":
-			reading.Temperature = parseFloatBytes(msg.Payload())
-		case "sensors/humidity":
-			reading.Humidity = parseFloatBytes(msg.Payload())
-		case "sensors/pm25":
-			reading.PM25 = parseFloatBytes(msg.Payload())
-		case "sensors/power":
-			reading.Power = parseFloatBytes(msg.Payload())
+// Close disconnects a client started with Start. The cache is kept, so
+// Snapshot still returns the last values until they pass the max age.
+func (c *MQTTSensorClient) Close() {
+	c.mu.Lock()
+	mc, stop := c.conn, c.stop
+	c.conn, c.stop = nil, nil
+	c.mu.Unlock()
+	if mc == nil {
+		return
+	}
+	close(stop)
+	mc.Disconnect(250)
+}
+
+// Snapshot returns the cached values: for each field, the average of the
+// devices reporting it within the max age.
+func (c *MQTTSensorClient) Snapshot() *MQTTSensorReading {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.aggregate(c.cache)
+}
+
+// aggregate averages values per field, skipping those older than maxAge,
+// and records each field's newest arrival and device count.
+func (c *MQTTSensorClient) aggregate(values map[mqttKey]mqttValue) *MQTTSensorReading {
+	now := c.now()
+	reading := &MQTTSensorReading{
+		ReceivedAt: make(map[MQTTField]time.Time),
+		Devices:    make(map[MQTTField]int),
+	}
+	sums := make(map[MQTTField]float64)
+	for _, v := range values {
+		if c.maxAge > 0 && now.Sub(v.at) > c.maxAge {
+			continue
 		}
-		mu.Unlock()
+		sums[v.field] += v.value
+		reading.Devices[v.field]++
+		if v.at.After(reading.ReceivedAt[v.field]) {
+			reading.ReceivedAt[v.field] = v.at
+		}
+	}
+	for f, sum := range sums {
+		f.set(reading, sum/float64(reading.Devices[f]))
+	}
+	return reading
+}
+
+// record stores a message on a topic mapped to field in values, reporting
+// false for a payload that is not a number or a JSON value.
+func (c *MQTTSensorClient) record(values map[mqttKey]mqttValue, field MQTTField, m mqtt.Message) bool {
+	v, device, err := parseMQTTPayload(m.Payload())
+	if err != nil {
+		return false
+	}
+	if device == "" {
+		device = m.Topic()
+	}
+	values[mqttKey{topic: m.Topic(), device: device}] = mqttValue{field: field, value: v, at: c.now()}
+	return true
+}
+
+// cacheHandler records each message on a topic mapped to field in the cache.
+func (c *MQTTSensorClient) cacheHandler(field MQTTField) mqtt.MessageHandler {
+	return func(_ mqtt.Client, m mqtt.Message) {
+		c.mu.Lock()
+		c.record(c.cache, field, m)
+		c.mu.Unlock()
+	}
+}
+
+// FetchReadings returns the latest values. On a started client it reads the
+// cache, failing only when nothing has been received yet. Otherwise it
+// connects, subscribes, waits until every topic has delivered or the timeout
+// passes, and disconnects.
+func (c *MQTTSensorClient) FetchReadings() (*MQTTSensorReading, error) {
+	c.mu.Lock()
+	running := c.conn != nil
+	c.mu.Unlock()
+	if !running {
+		return c.fetchOnce()
+	}
+	reading := c.Snapshot()
+	if len(reading.ReceivedAt) == 0 {
+		return nil, fmt.Errorf("no MQTT readings received yet from %s", c.broker)
+	}
+	return reading, nil
+}
+
+// fetchOnce is FetchReadings for a client that was not started.
+func (c *MQTTSensorClient) fetchOnce() (*MQTTSensorReading, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
 	}
 
-	for _, t := range c.topics {
-		wg.Add(1)
-		if token := mc.Subscribe(t, 1, func(cl mqtt.Client, m mqtt.Message) {
-			handler(cl, m)
-			wg.Done()
+	mc := c.newClient(c.clientOptions())
+
+	if token := mc.Connect(); token.Wait() && token.Error() != nil {
+		return nil, MQTTConnectError(c.broker, token.Error())
+	}
+	defer mc.Disconnect(50)
+
+	// Collect until every subscription has delivered at least once or the
+	// timeout passes. A device may publish any number of times; later
+	// values overwrite earlier ones.
+	var mu sync.Mutex
+	seen := make(map[string]bool, len(c.topics))
+	allSeen := make(chan struct{})
+
+	for _, t := range c.sortedTopics() {
+		record := c.cacheHandler(c.topics[t])
+		if token := mc.Subscribe(t, c.qos, func(mc mqtt.Client, m mqtt.Message) {
+			record(mc, m)
+			mu.Lock()
+			defer mu.Unlock()
+			if !seen[t] {
+				seen[t] = true
+				if len(seen) == len(c.topics) {
+					close(allSeen)
+				}
+			}
 		}); token.Wait() && token.Error() != nil {
 			return nil, fmt.Errorf("mqtt subscribe %s: %w", t, token.Error())
 		}
 	}
 
-	// Wait up to timeout for first messages; then return whatever was received.
-	waitCh := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(waitCh)
-	}()
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case <-allSeen:
+	case <-timer.C:
+	}
+	return c.Snapshot(), nil
+}
 
+// MQTTConnectError labels a failed connect to broker as an authentication,
+// TLS, broker refusal or network problem.
+func MQTTConnectError(broker string, err error) error {
+	kind := "network error"
+	switch {
+	case errors.Is(err, packets.ErrorRefusedBadUsernameOrPassword), errors.Is(err, packets.ErrorRefusedNotAuthorised):
+		kind = "authentication failed"
+	case errors.Is(err, packets.ErrorRefusedBadProtocolVersion), errors.Is(err, packets.ErrorRefusedIDRejected),
+		errors.Is(err, packets.ErrorRefusedServerUnavailable):
+		kind = "refused by broker"
+	case isTLSError(err):
+		kind = "TLS handshake failed"
+	}
+	return fmt.Errorf("mqtt connect to %s: %s: %w", broker, kind, err)
+}
+
+// isTLSError reports whether err came from the TLS handshake. paho
+// flattens network errors to text, so the crypto/tls and x509 messages are
+// matched as well as their types.
+func isTLSError(err error) bool {
+	var (
+		verify    *tls.CertificateVerificationError
+		header    tls.RecordHeaderError
+		alert     tls.AlertError
+		authority x509.UnknownAuthorityError
+		hostname  x509.HostnameError
+	)
+	if errors.As(err, &verify) || errors.As(err, &header) || errors.As(err, &alert) ||
+		errors.As(err, &authority) || errors.As(err, &hostname) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "tls: ") || strings.Contains(msg, "x509: ")
+}
+
+// LoadMQTTTLSConfig builds the TLS configuration for an ssl:// broker from
+// PEM files: caFile, when set, replaces the system roots, and certFile and
+// keyFile, set together, supply a client certificate. With every path
+// empty it returns nil, so the system roots are used.
+func LoadMQTTTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read MQTT CA certificate: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in MQTT CA file %s", caFile)
+		}
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("MQTT client certificate and key must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load MQTT client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// validate checks the settings every connection needs.
+func (c *MQTTSensorClient) validate() error {
+	if c.broker == "" {
+		return fmt.Errorf("mqtt broker not configured")
+	}
+	if c.qos > 2 {
+		return fmt.Errorf("mqtt qos %d out of range 0-2", c.qos)
+	}
+	return nil
+}
+
+// Subscribe stays connected and sends the rolling latest reading, averaged
+// across devices as in Snapshot, after every message until ctx is done, when
+// it disconnects and closes the channel. paho
+// reconnects after a broker drop and the topics are resubscribed on each
+// connect. The channel holds one reading; a slow consumer gets the newest
+// one rather than a backlog.
+func (c *MQTTSensorClient) Subscribe(ctx context.Context) (<-chan MQTTSensorReading, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	out := make(chan MQTTSensorReading, 1)
+	var (
+		mu     sync.Mutex
+		values = make(map[mqttKey]mqttValue)
+		closed bool
+	)
+	handlerFor := func(field MQTTField) mqtt.MessageHandler {
+		return func(_ mqtt.Client, m mqtt.Message) {
+			mu.Lock()
+			defer mu.Unlock()
+			if closed || !c.record(values, field, m) {
+				return
+			}
+			select {
+			case <-out: // replace the unread reading
+			default:
+			}
+			out <- *c.aggregate(values)
+		}
+	}
+
+	// The first connect reports its subscribe result; reconnects resubscribe
+	// silently and keep streaming.
+	subscribed := make(chan error, 1)
+	opts := c.clientOptions().
+		SetAutoReconnect(true).
+		SetOnConnectHandler(func(mc mqtt.Client) {
+			err := c.subscribeAll(mc, handlerFor)
+			select {
+			case subscribed <- err:
+			default:
+			}
+		})
+	mc := c.newClient(opts)
+
+	if token := mc.Connect(); token.Wait() && token.Error() != nil {
+		return nil, MQTTConnectError(c.broker, token.Error())
+	}
 	select {
-	case <-waitCh:
-	case <-time.After(c.timeout):
+	case err := <-subscribed:
+		if err != nil {
+			mc.Disconnect(50)
+			return nil, err
+		}
+	case <-ctx.Done():
+		mc.Disconnect(50)
+		return nil, ctx.Err()
 	}
 
-	return reading, nil
+	go func() {
+		<-ctx.Done()
+		mu.Lock()
+		closed = true
+		close(out)
+		mu.Unlock()
+		mc.Disconnect(250)
+	}()
+	return out, nil
 }
 
-func parseFloatBytes(b []byte) float64 {
-	v, _ := strconv.ParseFloat(string(b), 64)
-	return v
+// subscribeAll subscribes to every configured topic with the handler for its
+// field.
+func (c *MQTTSensorClient) subscribeAll(mc mqtt.Client, handlerFor func(MQTTField) mqtt.MessageHandler) error {
+	for _, t := range c.sortedTopics() {
+		if token := mc.Subscribe(t, c.qos, handlerFor(c.topics[t])); token.Wait() && token.Error() != nil {
+			return fmt.Errorf("mqtt subscribe %s: %w", t, token.Error())
+		}
+	}
+	return nil
+}
+
+// sortedTopics returns the configured topics in a stable order so broker
+// logs and errors are predictable.
+func (c *MQTTSensorClient) sortedTopics() []string {
+	topics := make([]string, 0, len(c.topics))
+	for t := range c.topics {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
 }
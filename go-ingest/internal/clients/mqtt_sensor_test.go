@@ -0,0 +1,498 @@
+package clients
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// fakeBroker stands in for a paho client connected to a broker. Messages
+// queued with retain are delivered to each matching subscription as it is
+// made, like retained messages; publish delivers to current subscribers.
+// Handlers run synchronously on the caller's goroutine.
+type fakeBroker struct {
+	mqtt.Client // methods the sensor client never calls panic
+
+	mu        sync.Mutex
+	opts      *mqtt.ClientOptions
+	subs      map[string]fakeSub
+	retained  []fakeMessage
+	connected bool
+
+	connectErr error // returned by Connect instead of connecting
+}
+
+type fakeSub struct {
+	qos     byte
+	handler mqtt.MessageHandler
+}
+
+// fakeMessage is a received message; only the topic and payload are set.
+type fakeMessage struct {
+	mqtt.Message
+	topic   string
+	payload []byte
+}
+
+func (m fakeMessage) Topic() string   { return m.topic }
+func (m fakeMessage) Payload() []byte { return m.payload }
+
+// doneToken is a completed paho token.
+type doneToken struct{ err error }
+
+func (t doneToken) Wait() bool {
+	return true
+}
+
+func (t doneToken) WaitTimeout(time.Duration) bool {
+	return true
+}
+
+func (t doneToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+func (t doneToken) Error() error { return t.err }
+
+// newFakeBroker returns a broker and wires c to connect to it.
+func newFakeBroker(c *MQTTSensorClient) *fakeBroker {
+	b := &fakeBroker{subs: make(map[string]fakeSub)}
+	c.newClient = func(opts *mqtt.ClientOptions) mqtt.Client {
+		b.mu.Lock()
+		b.opts = opts
+		b.mu.Unlock()
+		return b
+	}
+	return b
+}
+
+func (b *fakeBroker) retain(topic, payload string) {
+	b.retained = append(b.retained, fakeMessage{topic: topic, payload: []byte(payload)})
+}
+
+func (b *fakeBroker) Connect() mqtt.Token {
+	if b.connectErr != nil {
+		return doneToken{err: b.connectErr}
+	}
+	b.mu.Lock()
+	b.connected = true
+	onConnect := b.opts.OnConnect
+	b.mu.Unlock()
+	if onConnect != nil {
+		onConnect(b)
+	}
+	return doneToken{}
+}
+
+func (b *fakeBroker) Disconnect(uint) {
+	b.mu.Lock()
+	b.connected = false
+	b.mu.Unlock()
+}
+
+func (b *fakeBroker) IsConnected() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected
+}
+
+func (b *fakeBroker) Subscribe(filter string, qos byte, h mqtt.MessageHandler) mqtt.Token {
+	b.mu.Lock()
+	b.subs[filter] = fakeSub{qos: qos, handler: h}
+	retained := append([]fakeMessage(nil), b.retained...)
+	b.mu.Unlock()
+	for _, m := range retained {
+		if topicMatches(filter, m.topic) {
+			h(b, m)
+		}
+	}
+	return doneToken{}
+}
+
+// publish delivers a message to every matching subscription.
+func (b *fakeBroker) publish(topic, payload string) {
+	b.mu.Lock()
+	var handlers []mqtt.MessageHandler
+	for filter, s := range b.subs {
+		if topicMatches(filter, topic) {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	b.mu.Unlock()
+	for _, h := range handlers {
+		h(b, fakeMessage{topic: topic, payload: []byte(payload)})
+	}
+}
+
+// topicMatches reports whether topic matches an MQTT filter with + and #
+// wildcards.
+func topicMatches(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, level := range f {
+		switch {
+		case level == "#":
+			return true
+		case i >= len(t):
+			return false
+		case level != "+" && level != t[i]:
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+func TestMQTTCustomTopicRouting(t *testing.T) {
+	tlsCfg := &tls.Config{ServerName: "broker.example.com"}
+	c := NewMQTTSensorClient("ssl://broker.example.com:8883",
+		WithMQTTTopics(map[string]MQTTField{
+			"edgesight/+/temperature": MQTTFieldTemperature,
+			"lab/air/pm25":            MQTTFieldPM25,
+			"lab/power":               MQTTFieldPower,
+		}),
+		WithMQTTQoS(2),
+		WithMQTTCredentials("edge", "secret"),
+		WithMQTTTLS(tlsCfg),
+	)
+	b := newFakeBroker(c)
+	b.retain("edgesight/kitchen/temperature", `{"value": 21, "unit": "C", "device": "esp32-1"}`)
+	b.retain("edgesight/attic/temperature", "25")
+	b.retain("lab/air/pm25", "7.5")
+	b.retain("lab/power", "120")
+	b.retain("sensors/temperature", "99") // a default topic that is not configured
+
+	reading, err := c.FetchReadings()
+	if err != nil {
+		t.Fatalf("FetchReadings: %v", err)
+	}
+	if reading.Temperature != 23 || reading.Devices[MQTTFieldTemperature] != 2 {
+		t.Errorf("temperature = %v from %d devices, want the average 23 from 2", reading.Temperature, reading.Devices[MQTTFieldTemperature])
+	}
+	if reading.PM25 != 7.5 || reading.Power != 120 {
+		t.Errorf("pm25 = %v, power = %v; want 7.5 and 120", reading.PM25, reading.Power)
+	}
+	if _, ok := reading.ReceivedAt[MQTTFieldHumidity]; ok || reading.Humidity != 0 {
+		t.Errorf("humidity = %v, want no value from an unmapped field", reading.Humidity)
+	}
+
+	if len(b.subs) != 3 {
+		t.Errorf("subscribed to %d filters, want the 3 configured", len(b.subs))
+	}
+	for filter, s := range b.subs {
+		if s.qos != 2 {
+			t.Errorf("%s subscribed at QoS %d, want 2", filter, s.qos)
+		}
+	}
+	if b.opts.Username != "edge" || b.opts.Password != "secret" || b.opts.TLSConfig != tlsCfg {
+		t.Errorf("connect options missing credentials or TLS config")
+	}
+	if b.IsConnected() {
+		t.Error("FetchReadings left the connection open")
+	}
+}
+
+func TestMQTTFetchReadingsWithRepeatedAndSilentTopics(t *testing.T) {
+	c := NewMQTTSensorClient("tcp://localhost:1883",
+		WithMQTTTopics(map[string]MQTTField{
+			"sensors/temperature": MQTTFieldTemperature,
+			"sensors/humidity":    MQTTFieldHumidity,
+		}),
+		WithMQTTTimeout(50*time.Millisecond),
+	)
+	b := newFakeBroker(c)
+	// Temperature publishes twice; humidity never does.
+	b.retain("sensors/temperature", "20")
+	b.retain("sensors/temperature", "22")
+
+	start := time.Now()
+	reading, err := c.FetchReadings()
+	if err != nil {
+		t.Fatalf("FetchReadings: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("returned after %s, before the timeout, with a topic still silent", elapsed)
+	}
+	if reading.Temperature != 22 || reading.Devices[MQTTFieldTemperature] != 1 {
+		t.Errorf("temperature = %v from %d devices, want the later value 22 from 1", reading.Temperature, reading.Devices[MQTTFieldTemperature])
+	}
+	if _, ok := reading.ReceivedAt[MQTTFieldHumidity]; ok {
+		t.Error("humidity marked as received though it never published")
+	}
+}
+
+func TestMQTTFetchReadingsReturnsOnceEveryTopicDelivers(t *testing.T) {
+	c := NewMQTTSensorClient("tcp://localhost:1883", WithMQTTTimeout(time.Minute))
+	b := newFakeBroker(c)
+	for topic := range DefaultMQTTTopics {
+		b.retain(topic, "1")
+		b.retain(topic, "2")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := c.FetchReadings(); err != nil {
+			t.Errorf("FetchReadings: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("FetchReadings waited for the timeout although every topic delivered")
+	}
+}
+
+func TestMQTTSubscribeStreamsReadings(t *testing.T) {
+	c := NewMQTTSensorClient("tcp://localhost:1883")
+	b := newFakeBroker(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	readings, err := c.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	next := func() MQTTSensorReading {
+		t.Helper()
+		select {
+		case r := <-readings:
+			return r
+		case <-time.After(time.Second):
+			t.Fatal("no reading emitted")
+			return MQTTSensorReading{}
+		}
+	}
+
+	b.publish("sensors/temperature", "20")
+	if r := next(); r.Temperature != 20 || len(r.ReceivedAt) != 1 {
+		t.Errorf("after temperature: %+v", r)
+	}
+	b.publish("sensors/humidity", `{"value": 40, "unit": "%"}`)
+	if r := next(); r.Temperature != 20 || r.Humidity != 40 {
+		t.Errorf("after humidity: temperature %v, humidity %v; want 20 and 40", r.Temperature, r.Humidity)
+	}
+	b.publish("sensors/temperature", `{"value": 22, "device": "esp32-2"}`)
+	if r := next(); r.Temperature != 21 || r.Devices[MQTTFieldTemperature] != 2 {
+		t.Errorf("second device: temperature %v from %d devices, want 21 from 2", r.Temperature, r.Devices[MQTTFieldTemperature])
+	}
+
+	b.publish("sensors/pm25", "not a number")
+	select {
+	case r := <-readings:
+		t.Errorf("malformed payload emitted %+v", r)
+	default:
+	}
+
+	// After a broker drop paho reconnects and the topics are resubscribed.
+	b.Disconnect(0)
+	b.subs = make(map[string]fakeSub)
+	b.Connect()
+	b.publish("sensors/power", "130")
+	if r := next(); r.Power != 130 || r.Temperature != 21 {
+		t.Errorf("after reconnect: power %v, temperature %v; want 130 and the earlier 21", r.Power, r.Temperature)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-readings:
+		if ok {
+			t.Error("reading emitted after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestMQTTStartCachesReadings(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	c := NewMQTTSensorClient("tcp://localhost:1883", WithMQTTMaxAge(time.Minute))
+	c.now = func() time.Time { return now }
+	b := newFakeBroker(c)
+	b.retain("sensors/temperature", "18")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := c.Start(ctx); err == nil {
+		t.Error("second Start succeeded, want an error")
+	}
+
+	// The retained value is cached on subscribe; FetchReadings reads it
+	// without reconnecting.
+	reading, err := c.FetchReadings()
+	if err != nil {
+		t.Fatalf("FetchReadings: %v", err)
+	}
+	if reading.Temperature != 18 || !reading.ReceivedAt[MQTTFieldTemperature].Equal(now) {
+		t.Errorf("retained temperature = %v at %v, want 18 at %v", reading.Temperature, reading.ReceivedAt[MQTTFieldTemperature], now)
+	}
+	if !b.IsConnected() {
+		t.Error("FetchReadings closed the started connection")
+	}
+
+	now = now.Add(30 * time.Second)
+	b.publish("sensors/humidity", "40")
+	if r := c.Snapshot(); r.Humidity != 40 || r.Temperature != 18 || !r.ReceivedAt[MQTTFieldHumidity].Equal(now) {
+		t.Errorf("after humidity: %+v, want temperature 18 and humidity 40 received now", *r)
+	}
+
+	// Past the max age the temperature drops out; the humidity is still fresh
+	now = now.Add(45 * time.Second)
+	if r := c.Snapshot(); r.Temperature != 0 || r.Humidity != 40 {
+		t.Errorf("after 75s: temperature %v, humidity %v; want only humidity 40", r.Temperature, r.Humidity)
+	}
+	now = now.Add(time.Minute)
+	if _, err := c.FetchReadings(); err == nil {
+		t.Error("FetchReadings with only stale values succeeded, want an error")
+	}
+
+	// Cancelling the context disconnects; the cache survives
+	b.publish("sensors/pm25", "9")
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for b.IsConnected() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if b.IsConnected() {
+		t.Fatal("still connected after cancel")
+	}
+	if r := c.Snapshot(); r.PM25 != 9 {
+		t.Errorf("PM25 after disconnect = %v, want the cached 9", r.PM25)
+	}
+}
+
+func TestMQTTClientOptions(t *testing.T) {
+	tlsCfg := &tls.Config{ServerName: "broker.example.com"}
+	c := NewMQTTSensorClient("ssl://broker.example.com:8883",
+		WithMQTTClientID("edge-1"),
+		WithMQTTCleanSession(false),
+		WithMQTTCredentials("edge", "secret"),
+		WithMQTTTLS(tlsCfg),
+	)
+	opts := c.clientOptions()
+	if len(opts.Servers) != 1 || opts.Servers[0].String() != "ssl://broker.example.com:8883" {
+		t.Errorf("servers = %v, want the ssl:// broker", opts.Servers)
+	}
+	if opts.ClientID != "edge-1" || opts.CleanSession || opts.Username != "edge" || opts.Password != "secret" || opts.TLSConfig != tlsCfg {
+		t.Errorf("options = client %q clean %v user %q/%q TLS %v; want edge-1, persistent session, edge/secret and the TLS config",
+			opts.ClientID, opts.CleanSession, opts.Username, opts.Password, opts.TLSConfig)
+	}
+
+	plain := NewMQTTSensorClient("tcp://localhost:1883").clientOptions()
+	if !plain.CleanSession || plain.Username != "" {
+		t.Errorf("default options = clean %v user %q; want a clean session without credentials", plain.CleanSession, plain.Username)
+	}
+
+	if err := NewMQTTSensorClient("tcp://localhost:1883", WithMQTTQoS(3)).Start(context.Background()); err == nil {
+		t.Error("Start with QoS 3 succeeded, want an error")
+	}
+}
+
+func TestMQTTConnectErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{packets.ErrorRefusedBadUsernameOrPassword, "authentication failed"},
+		{packets.ErrorRefusedNotAuthorised, "authentication failed"},
+		{packets.ErrorRefusedServerUnavailable, "refused by broker"},
+		{x509.UnknownAuthorityError{}, "TLS handshake failed"},
+		{errors.New("network Error : tls: failed to verify certificate: x509: certificate signed by unknown authority"), "TLS handshake failed"},
+		{errors.New("network Error : dial tcp 127.0.0.1:8883: connect: connection refused"), "network error"},
+	}
+	for _, tt := range tests {
+		err := MQTTConnectError("ssl://broker:8883", tt.err)
+		if !strings.Contains(err.Error(), tt.want) || !errors.Is(err, tt.err) {
+			t.Errorf("MQTTConnectError(%v) = %v, want %q wrapping the cause", tt.err, err, tt.want)
+		}
+	}
+
+	// The label reaches callers of FetchReadings and Start
+	c := NewMQTTSensorClient("ssl://broker:8883", WithMQTTCredentials("edge", "wrong"))
+	b := newFakeBroker(c)
+	b.connectErr = packets.ErrorRefusedBadUsernameOrPassword
+	if _, err := c.FetchReadings(); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("FetchReadings = %v, want an authentication failure", err)
+	}
+	if err := c.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("Start = %v, want an authentication failure", err)
+	}
+}
+
+// writeTestCert writes a self-signed certificate and its key as PEM files
+// and returns their paths.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "broker.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestLoadMQTTTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	if cfg, err := LoadMQTTTLSConfig("", "", ""); cfg != nil || err != nil {
+		t.Errorf("no files = %v, %v; want nil for the system roots", cfg, err)
+	}
+	cfg, err := LoadMQTTTLSConfig(certFile, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadMQTTTLSConfig: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 || cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("config = roots %v, %d certificates, min version %x; want the CA, one client certificate and TLS 1.2",
+			cfg.RootCAs != nil, len(cfg.Certificates), cfg.MinVersion)
+	}
+
+	for name, files := range map[string][3]string{
+		"cert without key": {"", certFile, ""},
+		"missing CA":       {filepath.Join(t.TempDir(), "ca.pem"), "", ""},
+		"CA without certs": {keyFile, "", ""},
+		"key as cert":      {"", keyFile, keyFile},
+	} {
+		if _, err := LoadMQTTTLSConfig(files[0], files[1], files[2]); err == nil {
+			t.Errorf("%s: LoadMQTTTLSConfig succeeded, want an error", name)
+		}
+	}
+}
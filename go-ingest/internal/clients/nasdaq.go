@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,60 +19,135 @@ type NASDAQClient struct {
 
 // NASDAQMarketSummary aggregates current market metrics.
 type NASDAQMarketSummary struct {
-	IndexValue       float64 // NASDAQ Composite index value
-	VolumeTraded     int64   // Total shares traded
-	AdvancingStocks  int     // Number of stocks advancing
-	DecliningStocks  int     // Number of stocks declining
-	MarketCapBillions float64 // Total market cap in billions USD
+	IndexValue      float64 // NASDAQ Composite index value
+	VolumeTraded    int64   // Total shares traded
+	AdvancingStocks int     // Number of stocks advancing
+	DecliningStocks int     // Number of stocks declining
+	BreadthAsOf     string  // Trading day of the breadth counts; empty if they were unavailable
 }
 
+// NASDAQBreadth is the count of advancing and declining NASDAQ issues for one
+// trading day.
+type NASDAQBreadth struct {
+	Date      string
+	Advancing int
+	Declining int
+}
+
+// Breadth datasets (Unicorn Research Corp), each a [date, count] series.
+const (
+	nasdaqAdvancingDataset = "URC/NASDAQ_ADV"
+	nasdaqDecliningDataset = "URC/NASDAQ_DEC"
+)
+
 // NewNASDAQClient creates a NASDAQ Data Link client.
 func NewNASDAQClient(apiKey string) *NASDAQClient {
 	return &NASDAQClient{
 		baseURL: "https://data.nasdaq.com/api/v3",
 		apiKey:  apiKey,
-		httpCli: &http.Client{Timeout: 20 * time.Second},
+		httpCli: NewHTTPClient(envTimeout("NASDAQ_TIMEOUT", 20*time.Second)),
 	}
 }
 
 // GetMarketSummary fetches current NASDAQ composite index and market metrics.
 func (c *NASDAQClient) GetMarketSummary() (*NASDAQMarketSummary, error) {
+	return c.GetMarketSummaryContext(context.Background())
+}
+
+// GetMarketSummaryContext is GetMarketSummary with a caller-supplied context.
+// Breadth is best effort: if it cannot be fetched the index is still
+// returned, with BreadthAsOf empty.
+func (c *NASDAQClient) GetMarketSummaryContext(ctx context.Context) (*NASDAQMarketSummary, error) {
 	// NASDAQ Data Link endpoint for composite index
 	// Example: /datasets/NASDAQOMX/COMP.json?api_key=XXX&limit=1
-	url := fmt.Sprintf("%s/datasets/NASDAQOMX/COMP.json?api_key=%s&limit=1", c.baseURL, c.apiKey)
+	body, err := c.fetchDataset(ctx, "NASDAQOMX/COMP")
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := parseMarketData(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse NASDAQ data: %w", err)
+	}
+
+	if breadth, err := c.GetBreadthContext(ctx); err == nil {
+		summary.AdvancingStocks = breadth.Advancing
+		summary.DecliningStocks = breadth.Declining
+		summary.BreadthAsOf = breadth.Date
+	}
+
+	return summary, nil
+}
+
+// GetBreadth fetches the latest advancing/declining issue counts.
+func (c *NASDAQClient) GetBreadth() (*NASDAQBreadth, error) {
+	return c.GetBreadthContext(context.Background())
+}
+
+// GetBreadthContext is GetBreadth with a caller-supplied context.
+func (c *NASDAQClient) GetBreadthContext(ctx context.Context) (*NASDAQBreadth, error) {
+	advBody, err := c.fetchDataset(ctx, nasdaqAdvancingDataset)
+	if err != nil {
+		return nil, err
+	}
+	decBody, err := c.fetchDataset(ctx, nasdaqDecliningDataset)
+	if err != nil {
+		return nil, err
+	}
+
+	advDate, advancing, err := parseBreadthCount(advBody)
+	if err != nil {
+		return nil, fmt.Errorf("parse NASDAQ advancing issues: %w", err)
+	}
+	decDate, declining, err := parseBreadthCount(decBody)
+	if err != nil {
+		return nil, fmt.Errorf("parse NASDAQ declining issues: %w", err)
+	}
+	if advDate != decDate {
+		return nil, fmt.Errorf("NASDAQ breadth dates differ: advancing %s, declining %s", advDate, decDate)
+	}
+
+	return &NASDAQBreadth{Date: advDate, Advancing: advancing, Declining: declining}, nil
+}
+
+// fetchDataset returns the latest row of a Data Link dataset as raw JSON.
+func (c *NASDAQClient) fetchDataset(ctx context.Context, code string) ([]byte, error) {
+	url := fmt.Sprintf("%s/datasets/%s.json?api_key=%s&limit=1", c.baseURL, code, c.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build NASDAQ request: %w", err)
+	}
 
-	resp, err := c.httpCli.Get(url)
+	resp, err := c.httpCli.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("fetch NASDAQ data: %w", err)
+		return nil, fmt.Errorf("fetch NASDAQ %s: %w", code, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("NASDAQ API returned %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("NASDAQ API returned %d for %s: %s", resp.StatusCode, code, string(body))
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read NASDAQ response: %w", err)
 	}
+	return body, nil
+}
 
-	summary, err := parseMarketData(body)
-	if err != nil {
-		return nil, fmt.Errorf("parse NASDAQ data: %w", err)
-	}
-
-	return summary, nil
+// nasdaqDataset is the Data Link dataset envelope; each row starts with the
+// date.
+type nasdaqDataset struct {
+	Dataset struct {
+		Data [][]interface{} `json:"data"`
+	} `json:"dataset"`
 }
 
 // parseMarketData extracts market metrics from NASDAQ Data Link response.
 func parseMarketData(data []byte) (*NASDAQMarketSummary, error) {
-	var payload struct {
-		Dataset struct {
-			Data [][]interface{} `json:"data"`
-		} `json:"dataset"`
-	}
-
+	var payload nasdaqDataset
 	if err := json.Unmarshal(data, &payload); err != nil {
 		return nil, err
 	}
@@ -101,6 +177,32 @@ func parseMarketData(data []byte) (*NASDAQMarketSummary, error) {
 	return &NASDAQMarketSummary{
 		IndexValue:   indexValue,
 		VolumeTraded: volume,
-		// Other metrics would require additional API calls or datasets
 	}, nil
 }
+
+// parseBreadthCount reads the date and issue count from the latest row of a
+// breadth dataset, e.g. {"dataset": {"data": [["2024-03-01", 2311.0]]}}.
+func parseBreadthCount(data []byte) (string, int, error) {
+	var payload nasdaqDataset
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", 0, err
+	}
+
+	if len(payload.Dataset.Data) == 0 {
+		return "", 0, fmt.Errorf("no data returned from NASDAQ")
+	}
+
+	row := payload.Dataset.Data[0]
+	if len(row) < 2 {
+		return "", 0, fmt.Errorf("invalid data format")
+	}
+	date, ok := row[0].(string)
+	if !ok {
+		return "", 0, fmt.Errorf("invalid date %v", row[0])
+	}
+	count, ok := row[1].(float64)
+	if !ok || count < 0 {
+		return "", 0, fmt.Errorf("invalid count %v", row[1])
+	}
+	return date, int(count), nil
+}
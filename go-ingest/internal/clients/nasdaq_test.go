@@ -0,0 +1,68 @@
+package clients
+
+import "testing"
+
+func TestNASDAQGetBreadth(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/datasets/URC/NASDAQ_ADV.json": "nasdaq_adv.json",
+		"/datasets/URC/NASDAQ_DEC.json": "nasdaq_dec.json",
+	})
+	c := NewNASDAQClient("test-key")
+	c.baseURL = srv.URL
+
+	got, err := c.GetBreadth()
+	if err != nil {
+		t.Fatalf("GetBreadth: %v", err)
+	}
+	want := NASDAQBreadth{Date: "2024-03-01", Advancing: 2311, Declining: 1872}
+	if *got != want {
+		t.Errorf("GetBreadth = %+v, want %+v", *got, want)
+	}
+	if q := srv.lastQuery(); q.Get("api_key") != "test-key" || q.Get("limit") != "1" {
+		t.Errorf("query = %v, want api_key and limit=1", q)
+	}
+}
+
+func TestNASDAQGetBreadthDateMismatch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/datasets/URC/NASDAQ_ADV.json": "nasdaq_adv.json",
+		"/datasets/URC/NASDAQ_DEC.json": "nasdaq_dec_stale.json",
+	})
+	c := NewNASDAQClient("test-key")
+	c.baseURL = srv.URL
+
+	if _, err := c.GetBreadth(); err == nil {
+		t.Error("GetBreadth accepted counts from different trading days")
+	}
+}
+
+func TestNASDAQGetMarketSummary(t *testing.T) {
+	files := map[string]string{
+		"/datasets/NASDAQOMX/COMP.json": "nasdaq_comp.json",
+		"/datasets/URC/NASDAQ_ADV.json": "nasdaq_adv.json",
+		"/datasets/URC/NASDAQ_DEC.json": "nasdaq_dec.json",
+	}
+	srv := newFixtureServer(t, files)
+	c := NewNASDAQClient("test-key")
+	c.baseURL = srv.URL
+
+	got, err := c.GetMarketSummary()
+	if err != nil {
+		t.Fatalf("GetMarketSummary: %v", err)
+	}
+	if got.IndexValue != 16274.94 || got.AdvancingStocks != 2311 || got.DecliningStocks != 1872 || got.BreadthAsOf != "2024-03-01" {
+		t.Errorf("GetMarketSummary = %+v, want index 16274.94 with 2311/1872 breadth as of 2024-03-01", *got)
+	}
+
+	// Breadth is best effort: without it the index is still returned.
+	delete(files, "/datasets/URC/NASDAQ_DEC.json")
+	srv = newFixtureServer(t, files)
+	c.baseURL = srv.URL
+	got, err = c.GetMarketSummary()
+	if err != nil {
+		t.Fatalf("GetMarketSummary without breadth: %v", err)
+	}
+	if got.IndexValue != 16274.94 || got.AdvancingStocks != 0 || got.BreadthAsOf != "" {
+		t.Errorf("GetMarketSummary without breadth = %+v, want the index with empty breadth", *got)
+	}
+}
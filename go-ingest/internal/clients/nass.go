@@ -1,11 +1,15 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +20,29 @@ type NASSClient struct {
 	APIKey  string
 	BaseURL string
 	Client  *http.Client
+	retry   retryPolicy
+}
+
+// NASSError is an error payload from QuickStats, which reports problems as
+// {"error": ["..."]} - with status 200 for a bad key and 400/413 for bad or
+// oversized queries.
+type NASSError struct {
+	StatusCode int
+	Messages   []string
+}
+
+func (e *NASSError) Error() string {
+	return fmt.Sprintf("NASS API error (status %d): %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// Unauthorized reports whether NASS rejected the API key.
+func (e *NASSError) Unauthorized() bool {
+	for _, m := range e.Messages {
+		if strings.Contains(strings.ToLower(m), "unauthorized") {
+			return true
+		}
+	}
+	return false
 }
 
 // NASSCropSummary represents aggregated crop statistics
@@ -31,14 +58,38 @@ type NASSCropSummary struct {
 
 // NASSResponse represents the NASS API response
 type NASSResponse struct {
-	Data []struct {
-		CommodityDesc string      `json:"commodity_desc"`
-		Year          json.Number `json:"year"`
-		State         string      `json:"state_alpha"`
-		Value         string      `json:"Value"`
-		Unit          string      `json:"unit_desc"`
-		StatisticCat  string      `json:"statisticcat_desc"`
-	} `json:"data"`
+	Data []nassRow `json:"data"`
+}
+
+// nassRow is one QuickStats record.
+type nassRow struct {
+	CommodityDesc string      `json:"commodity_desc"`
+	Year          json.Number `json:"year"`
+	State         string      `json:"state_alpha"`
+	Value         string      `json:"Value"`
+	Unit          string      `json:"unit_desc"`
+	StatisticCat  string      `json:"statisticcat_desc"`
+}
+
+// NASS statistic categories merged into a NASSCropSummary.
+const (
+	nassStatProduction = "PRODUCTION"
+	nassStatYield      = "YIELD"
+	nassStatHarvested  = "AREA HARVESTED"
+	nassStatPrice      = "PRICE RECEIVED"
+)
+
+// nassPoundsPerBushel are the standard test weights used to convert cwt,
+// ton, and pound figures to bushels.
+var nassPoundsPerBushel = map[string]float64{
+	"CORN":     56,
+	"SORGHUM":  56,
+	"SOYBEANS": 60,
+	"WHEAT":    60,
+	"BARLEY":   48,
+	"OATS":     32,
+	"RYE":      56,
+	"RICE":     45,
 }
 
 // NewNASSClient creates a new NASS API client
@@ -46,30 +97,92 @@ func NewNASSClient(apiKey string) *NASSClient {
 	return &NASSClient{
 		APIKey:  apiKey,
 		BaseURL: "https://quickstats.nass.usda.gov/api",
-		Client: &http.Client{
-			Timeout: 20 * time.Second,
-		},
+		Client: NewHTTPClient(envTimeout("NASS_TIMEOUT", 20*time.Second)),
+		retry:   defaultRetryPolicy,
 	}
 }
 
-// GetCropProduction fetches production data for a specific crop and state
+// nassStats are the statistic categories GetCropProduction queries.
+var nassStats = []string{nassStatProduction, nassStatYield, nassStatHarvested, nassStatPrice}
+
+// GetCropProduction fetches production, yield, harvested area, and price
+// received for a crop and state and merges them into one summary. Categories
+// NASS has no (usable) data for are left at zero; an error is returned only
+// when none of them could be read.
 func (c *NASSClient) GetCropProduction(crop, state string, year int) (*NASSCropSummary, error) {
+	return c.GetCropProductionContext(context.Background(), crop, state, year)
+}
+
+// GetCropProductionContext is GetCropProduction with a caller-supplied
+// context. The categories are queried concurrently.
+func (c *NASSClient) GetCropProductionContext(ctx context.Context, crop, state string, year int) (*NASSCropSummary, error) {
 	if c.APIKey == "" {
 		return nil, fmt.Errorf("NASS API key required")
 	}
 
-	// Build query parameters
+	summary := &NASSCropSummary{
+		CropType: crop,
+		State:    state,
+		Year:     year,
+	}
+
+	rows := make([][]nassRow, len(nassStats))
+	errs := make([]error, len(nassStats))
+	var wg sync.WaitGroup
+	for i, cat := range nassStats {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rows[i], errs[i] = c.queryStatistic(ctx, crop, state, year, cat)
+		}()
+	}
+	wg.Wait()
+
+	var firstErr error
+	found := 0
+	for i, cat := range nassStats {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", cat, errs[i])
+			}
+			continue
+		}
+		if applyNASSRows(summary, cat, rows[i]) {
+			found++
+		}
+	}
+
+	if found == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("no data found for %s in %s (%d)", crop, state, year)
+	}
+
+	return summary, nil
+}
+
+// queryStatistic fetches the rows for one statistic category. When NASS
+// rejects the query as too large (413, over its 50,000 record limit) it is
+// retried once narrowed to annual, whole-year figures at the state or
+// national level.
+func (c *NASSClient) queryStatistic(ctx context.Context, crop, state string, year int, statCat string) ([]nassRow, error) {
 	params := url.Values{}
 	params.Set("key", c.APIKey)
 	params.Set("commodity_desc", crop)
 	params.Set("year", fmt.Sprintf("%d", year))
 	params.Set("state_alpha", state)
-	params.Set("statisticcat_desc", "PRODUCTION")
+	params.Set("statisticcat_desc", statCat)
 	params.Set("format", "JSON")
 
-	endpoint := fmt.Sprintf("/api_GET/?%s", params.Encode())
-
-	data, err := c.makeRequest(endpoint)
+	data, err := c.makeRequest(ctx, fmt.Sprintf("/api_GET/?%s", params.Encode()))
+	var nassErr *NASSError
+	var statusErr *StatusError
+	if errors.As(err, &nassErr) && nassErr.StatusCode == http.StatusRequestEntityTooLarge ||
+		errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestEntityTooLarge {
+		narrowNASSQuery(params, state)
+		data, err = c.makeRequest(ctx, fmt.Sprintf("/api_GET/?%s", params.Encode()))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -78,70 +191,194 @@ func (c *NASSClient) GetCropProduction(crop, state string, year int) (*NASSCropS
 	if err := json.Unmarshal(data, &resp); err != nil {
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
+	return resp.Data, nil
+}
 
-	if len(resp.Data) == 0 {
-		return nil, fmt.Errorf("no data found for %s in %s (%d)", crop, state, year)
+// narrowNASSQuery restricts params to the rows applyNASSRows can use:
+// annual survey figures for the whole marketing year at the aggregation
+// level of state.
+func narrowNASSQuery(params url.Values, state string) {
+	level := "STATE"
+	if strings.EqualFold(state, "US") {
+		level = "NATIONAL"
 	}
+	params.Set("agg_level_desc", level)
+	params.Set("freq_desc", "ANNUAL")
+	params.Set("reference_period_desc", "YEAR")
+	params.Set("source_desc", "SURVEY")
+}
 
-	row := resp.Data[0]
-	prodVal, _ := parseNumber(row.Value)
-	parsedYear, _ := row.Year.Int64()
+// applyNASSRows sets the summary field for statCat from the first row whose
+// value parses and whose unit can be expressed in bushels/acres/dollars.
+// It reports whether a value was applied.
+func applyNASSRows(summary *NASSCropSummary, statCat string, rows []nassRow) bool {
+	for _, row := range rows {
+		val, err := parseNumber(row.Value)
+		if err != nil {
+			continue // suppressed values like "(D)" or "(NA)"
+		}
+		converted, ok := convertNASSValue(summary.CropType, statCat, row.Unit, val)
+		if !ok {
+			continue
+		}
 
-	summary := &NASSCropSummary{
-		CropType:          crop,
-		State:             state,
-		Year:              int(parsedYear),
-		ProductionBushels: prodVal,
-		YieldPerAcre:      0,
-		HarvestedAcres:    0,
-		PricePerBushel:    0,
+		switch statCat {
+		case nassStatProduction:
+			summary.ProductionBushels = converted
+		case nassStatYield:
+			summary.YieldPerAcre = converted
+		case nassStatHarvested:
+			summary.HarvestedAcres = converted
+		case nassStatPrice:
+			summary.PricePerBushel = converted
+		}
+		if y, err := row.Year.Int64(); err == nil && y > 0 {
+			summary.Year = int(y)
+		}
+		return true
 	}
+	return false
+}
 
-	return summary, nil
+// convertNASSValue normalizes a value to bushels, bushels/acre, acres, or
+// $/bushel based on unit_desc. Weight-based units (cwt, tons, lb) are
+// converted using the crop's standard bushel weight when known.
+func convertNASSValue(crop, statCat, unit string, val float64) (float64, bool) {
+	unit = strings.ToUpper(strings.Join(strings.Fields(unit), " "))
+	lbPerBu := nassPoundsPerBushel[strings.ToUpper(crop)]
+
+	// pounds in one unit of the weight measure, 0 when not a weight unit
+	weightLbs := func(u string) float64 {
+		switch u {
+		case "CWT":
+			return 100
+		case "TONS", "TON":
+			return 2000
+		case "LB":
+			return 1
+		}
+		return 0
+	}
+
+	switch statCat {
+	case nassStatHarvested:
+		return val, unit == "ACRES"
+
+	case nassStatProduction:
+		if unit == "BU" {
+			return val, true
+		}
+		if w := weightLbs(unit); w > 0 && lbPerBu > 0 {
+			return val * w / lbPerBu, true
+		}
+
+	case nassStatYield:
+		base, ok := strings.CutSuffix(unit, " / ACRE")
+		if !ok {
+			return 0, false
+		}
+		if base == "BU" {
+			return val, true
+		}
+		if w := weightLbs(base); w > 0 && lbPerBu > 0 {
+			return val * w / lbPerBu, true
+		}
+
+	case nassStatPrice:
+		base, ok := strings.CutPrefix(unit, "$ / ")
+		if !ok {
+			return 0, false
+		}
+		if base == "BU" {
+			return val, true
+		}
+		if w := weightLbs(base); w > 0 && lbPerBu > 0 {
+			return val * lbPerBu / w, true
+		}
+	}
+	return 0, false
 }
 
 // GetNationalCropSummary fetches aggregated national crop data
 func (c *NASSClient) GetNationalCropSummary(crop string) (*NASSCropSummary, error) {
+	return c.GetNationalCropSummaryContext(context.Background(), crop)
+}
+
+// GetNationalCropSummaryContext is GetNationalCropSummary with a caller-supplied context.
+func (c *NASSClient) GetNationalCropSummaryContext(ctx context.Context, crop string) (*NASSCropSummary, error) {
 	currentYear := time.Now().Year() - 1 // Use previous year for complete data
 
 	// Get national (US-level) data
-	return c.GetCropProduction(crop, "US", currentYear)
+	return c.GetCropProductionContext(ctx, crop, "US", currentYear)
 }
 
 // GetStateCropSummary fetches state-level crop data
 func (c *NASSClient) GetStateCropSummary(crop, state string) (*NASSCropSummary, error) {
+	return c.GetStateCropSummaryContext(context.Background(), crop, state)
+}
+
+// GetStateCropSummaryContext is GetStateCropSummary with a caller-supplied context.
+func (c *NASSClient) GetStateCropSummaryContext(ctx context.Context, crop, state string) (*NASSCropSummary, error) {
 	currentYear := time.Now().Year() - 1
 
-	return c.GetCropProduction(crop, state, currentYear)
+	return c.GetCropProductionContext(ctx, crop, state, currentYear)
 }
 
-// makeRequest makes an HTTP request to the NASS API
-func (c *NASSClient) makeRequest(endpoint string) ([]byte, error) {
+// makeRequest makes an HTTP request to the NASS API, retrying 429, 5xx and
+// network errors. An error payload is returned as a *NASSError whatever the
+// status; any other non-200 response as a *StatusError.
+func (c *NASSClient) makeRequest(ctx context.Context, endpoint string) ([]byte, error) {
 	url := c.BaseURL + endpoint
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := doWithRetry(ctx, c.Client, c.retry, req)
 	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			if nassErr := parseNASSError(statusErr.StatusCode, []byte(statusErr.Body)); nassErr != nil {
+				return nil, nassErr
+			}
+		}
 		return nil, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("NASS API returned status %d", resp.StatusCode)
-	}
-
 	var result json.RawMessage
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
+	if nassErr := parseNASSError(resp.StatusCode, result); nassErr != nil {
+		return nil, nassErr
+	}
 
 	return result, nil
 }
 
+// parseNASSError returns the NASSError in body, or nil when body is not an
+// error payload.
+func parseNASSError(status int, body []byte) *NASSError {
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) != nil || len(payload.Error) == 0 || string(payload.Error) == "null" {
+		return nil
+	}
+	// Usually an array of messages, occasionally a bare string
+	var msgs []string
+	if json.Unmarshal(payload.Error, &msgs) != nil {
+		var msg string
+		if json.Unmarshal(payload.Error, &msg) != nil {
+			msg = string(payload.Error)
+		}
+		msgs = []string{msg}
+	}
+	return &NASSError{StatusCode: status, Messages: msgs}
+}
+
 // parseNumber converts string with optional commas into float64.
 func parseNumber(val string) (float64, error) {
 	clean := ""
@@ -0,0 +1,220 @@
+package clients
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// nassServer answers QuickStats queries with the fixture for each statistic
+// category, and with QuickStats' "invalid query" error for the rest.
+func nassServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	t.Helper()
+	bodies := make(map[string][]byte, len(fixtures))
+	for cat, name := range fixtures {
+		bodies[cat] = readFixture(t, name)
+	}
+	noData := readFixture(t, "nass_no_data.json")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api_GET/" {
+			http.NotFound(w, r)
+			return
+		}
+		body, ok := bodies[r.URL.Query().Get("statisticcat_desc")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(noData)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNASSGetCropProductionMergesCategories(t *testing.T) {
+	srv := nassServer(t, map[string]string{
+		nassStatProduction: "nass_corn_production.json",
+		nassStatYield:      "nass_corn_yield.json",
+		nassStatHarvested:  "nass_corn_harvested.json",
+	})
+	c := NewNASSClient("test-key")
+	c.BaseURL = srv.URL
+
+	got, err := c.GetCropProduction("CORN", "IA", 2025)
+	if err != nil {
+		t.Fatalf("GetCropProduction: %v", err)
+	}
+	// The suppressed "(D)" silage row is skipped in favor of the grain row,
+	// and the missing price category is left at zero.
+	want := NASSCropSummary{
+		CropType:          "CORN",
+		ProductionBushels: 2640540000,
+		YieldPerAcre:      211,
+		HarvestedAcres:    12510000,
+		State:             "IA",
+		Year:              2025,
+	}
+	if *got != want {
+		t.Errorf("GetCropProduction = %+v, want %+v", *got, want)
+	}
+}
+
+func TestNASSGetCropProductionConvertsCWTPrice(t *testing.T) {
+	srv := nassServer(t, map[string]string{nassStatPrice: "nass_rice_price.json"})
+	c := NewNASSClient("test-key")
+	c.BaseURL = srv.URL
+
+	got, err := c.GetCropProduction("RICE", "AR", 2025)
+	if err != nil {
+		t.Fatalf("GetCropProduction: %v", err)
+	}
+	// $16.00/cwt at 45 lb/bu is $7.20/bu.
+	if math.Abs(got.PricePerBushel-7.2) > 1e-9 {
+		t.Errorf("PricePerBushel = %v, want 7.2", got.PricePerBushel)
+	}
+	if got.ProductionBushels != 0 || got.YieldPerAcre != 0 || got.HarvestedAcres != 0 {
+		t.Errorf("categories without data were filled: %+v", *got)
+	}
+}
+
+func TestNASSGetCropProductionNoData(t *testing.T) {
+	srv := nassServer(t, nil)
+	c := NewNASSClient("test-key")
+	c.BaseURL = srv.URL
+
+	if _, err := c.GetCropProduction("CORN", "IA", 2025); err == nil {
+		t.Error("GetCropProduction succeeded with no categories available")
+	}
+}
+
+func TestConvertNASSValue(t *testing.T) {
+	tests := []struct {
+		crop, stat, unit string
+		in, want         float64
+		ok               bool
+	}{
+		{"CORN", nassStatProduction, "BU", 100, 100, true},
+		{"RICE", nassStatProduction, "CWT", 45, 100, true},
+		{"CORN", nassStatYield, "BU / ACRE", 180, 180, true},
+		{"RICE", nassStatYield, "LB / ACRE", 7560, 168, true},
+		{"CORN", nassStatHarvested, "ACRES", 10, 10, true},
+		{"CORN", nassStatPrice, "$ / BU", 4.5, 4.5, true},
+		{"WHEAT", nassStatPrice, "$ / CWT", 10, 6, true},
+		{"COTTON", nassStatProduction, "480 LB BALES", 10, 0, false},
+		{"CORN", nassStatPrice, "$", 10, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := convertNASSValue(tt.crop, tt.stat, tt.unit, tt.in)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("convertNASSValue(%s, %s, %q, %v) = %v, %v; want %v, %v",
+				tt.crop, tt.stat, tt.unit, tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNASSGetCropProductionAllCategories(t *testing.T) {
+	bodies := map[string][]byte{
+		nassStatProduction: readFixture(t, "nass_corn_production.json"),
+		nassStatYield:      readFixture(t, "nass_corn_yield.json"),
+		nassStatHarvested:  readFixture(t, "nass_corn_harvested.json"),
+		nassStatPrice:      readFixture(t, "nass_corn_price.json"),
+	}
+	tooLarge := readFixture(t, "nass_too_large.json")
+
+	var (
+		mu       sync.Mutex
+		requests []url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		requests = append(requests, q)
+		mu.Unlock()
+		// Harvested area is over the record limit until narrowed.
+		if q.Get("statisticcat_desc") == nassStatHarvested && q.Get("agg_level_desc") == "" {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write(tooLarge)
+			return
+		}
+		w.Write(bodies[q.Get("statisticcat_desc")])
+	}))
+	defer srv.Close()
+	c := NewNASSClient("test-key")
+	c.BaseURL = srv.URL
+	c.retry = fastRetry
+
+	got, err := c.GetCropProduction("CORN", "IA", 2025)
+	if err != nil {
+		t.Fatalf("GetCropProduction: %v", err)
+	}
+	// The "(NA)" annual price is skipped for the next usable row.
+	want := NASSCropSummary{
+		CropType:          "CORN",
+		ProductionBushels: 2640540000,
+		YieldPerAcre:      211,
+		HarvestedAcres:    12510000,
+		PricePerBushel:    4.08,
+		State:             "IA",
+		Year:              2025,
+	}
+	if *got != want {
+		t.Errorf("GetCropProduction = %+v, want %+v", *got, want)
+	}
+
+	counts := make(map[string]int)
+	for _, q := range requests {
+		counts[q.Get("statisticcat_desc")]++
+		if q.Get("statisticcat_desc") == nassStatHarvested && q.Get("agg_level_desc") != "" &&
+			(q.Get("agg_level_desc") != "STATE" || q.Get("freq_desc") != "ANNUAL") {
+			t.Errorf("narrowed query = %v, want annual state-level rows", q)
+		}
+	}
+	wantCounts := map[string]int{nassStatProduction: 1, nassStatYield: 1, nassStatPrice: 1, nassStatHarvested: 2}
+	if !maps.Equal(counts, wantCounts) {
+		t.Errorf("requests per category = %v, want %v", counts, wantCounts)
+	}
+}
+
+func TestNASSUnauthorized(t *testing.T) {
+	// QuickStats rejects a bad key with status 200 and an error payload.
+	srv := newFixtureServer(t, map[string]string{"/api_GET/": "nass_unauthorized.json"})
+	c := NewNASSClient("bad-key")
+	c.BaseURL = srv.URL
+	c.retry = fastRetry
+
+	_, err := c.GetCropProduction("CORN", "IA", 2025)
+	var nassErr *NASSError
+	if !errors.As(err, &nassErr) || !nassErr.Unauthorized() || nassErr.StatusCode != http.StatusOK {
+		t.Fatalf("err = %v, want an unauthorized NASSError", err)
+	}
+	if !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("error %q does not carry the API's message", err)
+	}
+}
+
+func TestNASSRetriesTransientFailure(t *testing.T) {
+	srv, attempts := flakyServer(t, "nass_corn_yield.json", http.StatusInternalServerError)
+	c := NewNASSClient("test-key")
+	c.BaseURL = srv.URL
+	c.retry = fastRetry
+
+	got, err := c.GetCropProduction("CORN", "IA", 2025)
+	if err != nil {
+		t.Fatalf("GetCropProduction after one 500: %v", err)
+	}
+	if got.YieldPerAcre != 211 {
+		t.Errorf("YieldPerAcre = %v, want 211", got.YieldPerAcre)
+	}
+	// One request per category plus the retry of the one that hit the 500.
+	if n := attempts.Load(); n != int32(len(nassStats))+1 {
+		t.Errorf("%d requests, want %d", n, len(nassStats)+1)
+	}
+}
@@ -2,28 +2,66 @@ package clients
 
 import (
 	"bytes"
-	"io"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// OpenAQClient handles interactions with the OpenAQ API
+// OpenAQClient handles interactions with the OpenAQ API. Requests are
+// retried on 429, 5xx and network errors, honoring Retry-After.
 type OpenAQClient struct {
 	baseURL string
 	apiKey  string
 	httpCli *http.Client
+	retry   retryPolicy
+
+	// pageSize is the largest limit paginated calls request per page.
+	pageSize int
+
+	// debug, when set, logs each response's status, URL and a truncated body.
+	debug *log.Logger
+}
+
+// OpenAQOption customizes an OpenAQClient.
+type OpenAQOption func(*OpenAQClient)
+
+// WithOpenAQDebug logs every response's status, URL and first
+// openAQDebugBody bytes of body to logger (log.Default() if nil). The API key
+// is sent as a header, so it never appears in the logged URL.
+func WithOpenAQDebug(logger *log.Logger) OpenAQOption {
+	return func(c *OpenAQClient) {
+		if logger == nil {
+			logger = log.Default()
+		}
+		c.debug = logger
+	}
 }
 
+// openAQDebugBody caps how much of a response body debug logging prints.
+const openAQDebugBody = 512
+
 // NewOpenAQClient creates a new OpenAQ API client
-func NewOpenAQClient(apiKey string) *OpenAQClient {
-    return &OpenAQClient{
-        baseURL: "https://api.openaq.org/v3",
-        apiKey:  apiKey,
-        httpCli: &http.Client{Timeout: 15 * time.Second},
-    }
+func NewOpenAQClient(apiKey string, opts ...OpenAQOption) *OpenAQClient {
+	c := &OpenAQClient{
+		baseURL: "https://api.openaq.org/v3",
+		apiKey:  apiKey,
+		httpCli: NewHTTPClient(envTimeout("OPENAQ_TIMEOUT", 15*time.Second)),
+		retry:   defaultRetryPolicy,
+
+		pageSize: openAQMaxLimit,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // LocationsResponse represents the response from /v3/locations
@@ -108,7 +146,36 @@ type ResponseMeta struct {
     Website    string `json:"website"`
     Page       int    `json:"page"`
     Limit      int    `json:"limit"`
-    Found      interface{} `json:"found"`
+    Found      OpenAQFound `json:"found"`
+}
+
+// OpenAQFound is meta.found, which OpenAQ sends as a number or, for large
+// result sets, as a lower bound string such as ">100".
+type OpenAQFound struct {
+	Count   int  // exact total, or the lower bound when AtLeast is set
+	AtLeast bool // Count is a lower bound
+	Known   bool // false when found was null or unparseable
+}
+
+// UnmarshalJSON accepts a number, a numeric string, a ">N" string or null.
+// Anything else decodes as unknown rather than failing the whole response.
+func (f *OpenAQFound) UnmarshalJSON(data []byte) error {
+	*f = OpenAQFound{}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	switch v := v.(type) {
+	case float64:
+		*f = OpenAQFound{Count: int(v), Known: true}
+	case string:
+		v = strings.TrimSpace(v)
+		atLeast := strings.HasPrefix(v, ">")
+		if n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(v, ">"))); err == nil {
+			*f = OpenAQFound{Count: n, AtLeast: atLeast, Known: true}
+		}
+	}
+	return nil
 }
 
 // SensorsResponse represents the response from /v3/sensors
@@ -131,145 +198,268 @@ type SensorReading struct {
     Datetime DatetimeInfo `json:"datetime"`
 }
 
+// SelectActiveLocation returns the most recently updated location whose
+// datetimeLast is within window of now, or nil when none is. Locations with
+// a missing or unparseable datetimeLast are skipped.
+func SelectActiveLocation(locations []OpenAQLocation, window time.Duration) *OpenAQLocation {
+	var (
+		best       *OpenAQLocation
+		bestUpdate time.Time
+	)
+	for i := range locations {
+		loc := &locations[i]
+		if loc.DatetimeLast == nil {
+			continue
+		}
+		lastUpdate, err := time.Parse(time.RFC3339, loc.DatetimeLast.UTC)
+		if err != nil || time.Since(lastUpdate) >= window {
+			continue
+		}
+		if best == nil || lastUpdate.After(bestUpdate) {
+			best, bestUpdate = loc, lastUpdate
+		}
+	}
+	return best
+}
+
+// GetSensorsByLocationID fetches sensors (with latest readings) for a location.
 func (c *OpenAQClient) GetSensorsByLocationID(locationID int) (*SensorsResponse, error) {
-    // Correct endpoint: /v3/locations/{id}/sensors (not /v3/sensors)
-    reqURL := fmt.Sprintf("%s/locations/%d/sensors", c.baseURL, locationID)
-    
-    req, err := http.NewRequest(http.MethodGet, reqURL, nil)
-    if err != nil {
-        return nil, fmt.Errorf("build request: %w", err)
-    }
-    if c.apiKey != "" {
-        req.Header.Set("X-API-Key", c.apiKey)
-    }
-
-    resp, err := c.httpCli.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("request failed: %w", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
-    }
-
-    var parsed SensorsResponse
-    if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-        return nil, fmt.Errorf("decode response: %w", err)
-    }
-
-    return &parsed, nil
-}
-
-// GetLocationsByCity fetches locations in a city
+	return c.GetSensorsByLocationIDContext(context.Background(), locationID)
+}
+
+// GetSensorsByLocationIDContext is GetSensorsByLocationID with a caller-supplied context.
+func (c *OpenAQClient) GetSensorsByLocationIDContext(ctx context.Context, locationID int) (*SensorsResponse, error) {
+	// Correct endpoint: /v3/locations/{id}/sensors (not /v3/sensors)
+	var parsed SensorsResponse
+	if err := c.doGET(ctx, fmt.Sprintf("/locations/%d/sensors", locationID), nil, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// GetLocationsByCity fetches a single page of locations in a city; see
+// GetAllLocationsByCity to follow pages.
 func (c *OpenAQClient) GetLocationsByCity(city string, limit int) (*LocationsResponse, error) {
-    if c.apiKey == "" {
-        return nil, fmt.Errorf("openaq api key is required")
-    }
+	return c.GetLocationsByCityContext(context.Background(), city, limit)
+}
+
+// GetLocationsByCityContext is GetLocationsByCity with a caller-supplied context.
+func (c *OpenAQClient) GetLocationsByCityContext(ctx context.Context, city string, limit int) (*LocationsResponse, error) {
+	q := url.Values{}
+	q.Set("city", city)
+	q.Set("limit", fmt.Sprintf("%d", limit))
+
+	var parsed LocationsResponse
+	if err := c.doGET(ctx, "/locations", q, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// GetLatestByLocationID fetches latest measurements for a specific location
+func (c *OpenAQClient) GetLatestByLocationID(locationID int) (*LatestResponse, error) {
+	return c.GetLatestByLocationIDContext(context.Background(), locationID)
+}
 
-    q := url.Values{}
-    q.Set("city", city)
-    q.Set("limit", fmt.Sprintf("%d", limit))
+// GetLatestByLocationIDContext is GetLatestByLocationID with a caller-supplied context.
+func (c *OpenAQClient) GetLatestByLocationIDContext(ctx context.Context, locationID int) (*LatestResponse, error) {
+	var parsed LatestResponse
+	if err := c.doGET(ctx, fmt.Sprintf("/locations/%d/latest", locationID), nil, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
 
-    reqURL := fmt.Sprintf("%s/locations?%s", c.baseURL, q.Encode())
-    req, err := http.NewRequest(http.MethodGet, reqURL, nil)
-    if err != nil {
-        return nil, fmt.Errorf("build request: %w", err)
-    }
-    req.Header.Set("X-API-Key", c.apiKey)
+// GetLocationsByCoordinates fetches a single page of locations near a
+// coordinate point; see GetAllLocationsByCoordinates to follow pages.
+func (c *OpenAQClient) GetLocationsByCoordinates(lat, lon float64, radius int, limit int) (*LocationsResponse, error) {
+	return c.GetLocationsByCoordinatesContext(context.Background(), lat, lon, radius, limit)
+}
 
-    resp, err := c.httpCli.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("request failed: %w", err)
-    }
-    defer resp.Body.Close()
+// GetLocationsByCoordinatesContext is GetLocationsByCoordinates with a caller-supplied context.
+func (c *OpenAQClient) GetLocationsByCoordinatesContext(ctx context.Context, lat, lon float64, radius int, limit int) (*LocationsResponse, error) {
+	q := url.Values{}
+	q.Set("coordinates", fmt.Sprintf("%f,%f", lat, lon))
+	q.Set("radius", fmt.Sprintf("%d", radius)) // radius in meters
+	q.Set("limit", fmt.Sprintf("%d", limit))
+
+	var parsed LocationsResponse
+	if err := c.doGET(ctx, "/locations", q, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
 
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
-    }
+// openAQMaxPages caps how many pages a paginated call follows, whatever
+// maxResults asks for.
+const openAQMaxPages = 10
 
-    var parsed LocationsResponse
-    if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-        return nil, fmt.Errorf("decode response: %w", err)
-    }
+// openAQMaxLimit is the largest page size OpenAQ accepts.
+const openAQMaxLimit = 1000
 
-    return &parsed, nil
+// GetAllLocationsByCoordinates is GetLocationsByCoordinates following pages
+// until results run out or maxResults locations have been collected.
+func (c *OpenAQClient) GetAllLocationsByCoordinates(lat, lon float64, radius int, maxResults int) (*LocationsResponse, error) {
+	return c.GetAllLocationsByCoordinatesContext(context.Background(), lat, lon, radius, maxResults)
 }
 
-// GetLatestByLocationID fetches latest measurements for a specific location
-func (c *OpenAQClient) GetLatestByLocationID(locationID int) (*LatestResponse, error) {
-    if c.apiKey == "" {
-        return nil, fmt.Errorf("openaq api key is required")
-    }
-
-    reqURL := fmt.Sprintf("%s/locations/%d/latest", c.baseURL, locationID)
-    req, err := http.NewRequest(http.MethodGet, reqURL, nil)
-    if err != nil {
-        return nil, fmt.Errorf("build request: %w", err)
-    }
-    req.Header.Set("X-API-Key", c.apiKey)
-
-    resp, err := c.httpCli.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("request failed: %w", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
-    }
-
-    // --- START X-RAY CODE ---
-    // Read the raw body into a byte array
-    bodyBytes, _ := io.ReadAll(resp.Body)
-    
-    // Print it to the console so we can see the TRUTH
-    fmt.Println("DEBUG RAW JSON RESPONSE:", string(bodyBytes))
-
-    // Restore the body so the JSON decoder can read it again
-    resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-    // --- END X-RAY CODE ---
-
-    var parsed LatestResponse
-    if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-        return nil, fmt.Errorf("decode response: %w", err)
-    }
-
-    return &parsed, nil
-}
-
-// GetLocationsByCoordinates fetches locations near a coordinate point
-func (c *OpenAQClient) GetLocationsByCoordinates(lat, lon float64, radius int, limit int) (*LocationsResponse, error) {
-    if c.apiKey == "" {
-        return nil, fmt.Errorf("openaq api key is required")
-    }
-
-    q := url.Values{}
-    q.Set("coordinates", fmt.Sprintf("%f,%f", lat, lon))
-    q.Set("radius", fmt.Sprintf("%d", radius)) // radius in meters
-    q.Set("limit", fmt.Sprintf("%d", limit))
-
-    reqURL := fmt.Sprintf("%s/locations?%s", c.baseURL, q.Encode())
-    req, err := http.NewRequest(http.MethodGet, reqURL, nil)
-    if err != nil {
-        return nil, fmt.Errorf("build request: %w", err)
-    }
-    req.Header.Set("X-API-Key", c.apiKey)
-
-    resp, err := c.httpCli.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("request failed: %w", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
-    }
-
-    var parsed LocationsResponse
-    if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-        return nil, fmt.Errorf("decode response: %w", err)
-    }
-
-    return &parsed, nil
-}
\ No newline at end of file
+// GetAllLocationsByCoordinatesContext is GetAllLocationsByCoordinates with a caller-supplied context.
+func (c *OpenAQClient) GetAllLocationsByCoordinatesContext(ctx context.Context, lat, lon float64, radius int, maxResults int) (*LocationsResponse, error) {
+	q := url.Values{}
+	q.Set("coordinates", fmt.Sprintf("%f,%f", lat, lon))
+	q.Set("radius", fmt.Sprintf("%d", radius)) // radius in meters
+
+	results, meta, err := paginateOpenAQ(ctx, maxResults, c.pageSize, func(page, limit int) ([]OpenAQLocation, ResponseMeta, error) {
+		var parsed LocationsResponse
+		err := c.doGET(ctx, "/locations", withPage(q, page, limit), &parsed)
+		return parsed.Results, parsed.Meta, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LocationsResponse{Meta: meta, Results: results}, nil
+}
+
+// GetAllLocationsByCity is GetLocationsByCity following pages until results
+// run out or maxResults locations have been collected.
+func (c *OpenAQClient) GetAllLocationsByCity(city string, maxResults int) (*LocationsResponse, error) {
+	return c.GetAllLocationsByCityContext(context.Background(), city, maxResults)
+}
+
+// GetAllLocationsByCityContext is GetAllLocationsByCity with a caller-supplied context.
+func (c *OpenAQClient) GetAllLocationsByCityContext(ctx context.Context, city string, maxResults int) (*LocationsResponse, error) {
+	q := url.Values{}
+	q.Set("city", city)
+
+	results, meta, err := paginateOpenAQ(ctx, maxResults, c.pageSize, func(page, limit int) ([]OpenAQLocation, ResponseMeta, error) {
+		var parsed LocationsResponse
+		err := c.doGET(ctx, "/locations", withPage(q, page, limit), &parsed)
+		return parsed.Results, parsed.Meta, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LocationsResponse{Meta: meta, Results: results}, nil
+}
+
+// GetAllSensorsByLocationID is GetSensorsByLocationID following pages until
+// results run out or maxResults sensors have been collected.
+func (c *OpenAQClient) GetAllSensorsByLocationID(locationID int, maxResults int) (*SensorsResponse, error) {
+	return c.GetAllSensorsByLocationIDContext(context.Background(), locationID, maxResults)
+}
+
+// GetAllSensorsByLocationIDContext is GetAllSensorsByLocationID with a caller-supplied context.
+func (c *OpenAQClient) GetAllSensorsByLocationIDContext(ctx context.Context, locationID int, maxResults int) (*SensorsResponse, error) {
+	path := fmt.Sprintf("/locations/%d/sensors", locationID)
+	results, meta, err := paginateOpenAQ(ctx, maxResults, c.pageSize, func(page, limit int) ([]Sensor, ResponseMeta, error) {
+		var parsed SensorsResponse
+		err := c.doGET(ctx, path, withPage(nil, page, limit), &parsed)
+		return parsed.Results, parsed.Meta, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SensorsResponse{Meta: meta, Results: results}, nil
+}
+
+// withPage returns a copy of q with page and limit set.
+func withPage(q url.Values, page, limit int) url.Values {
+	out := url.Values{}
+	for k, v := range q {
+		out[k] = append([]string(nil), v...)
+	}
+	out.Set("page", strconv.Itoa(page))
+	out.Set("limit", strconv.Itoa(limit))
+	return out
+}
+
+// paginateOpenAQ calls fetch for pages 1, 2, ... of at most pageSize items
+// until a short page, an exact meta.found total is reached, maxResults items
+// are collected or openAQMaxPages pages have been read. The returned meta is
+// the first page's.
+func paginateOpenAQ[T any](ctx context.Context, maxResults, pageSize int, fetch func(page, limit int) ([]T, ResponseMeta, error)) ([]T, ResponseMeta, error) {
+	if maxResults < 1 {
+		return nil, ResponseMeta{}, fmt.Errorf("maxResults must be positive")
+	}
+	limit := min(maxResults, pageSize)
+
+	var (
+		all   []T
+		first ResponseMeta
+	)
+	for page := 1; page <= openAQMaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, ResponseMeta{}, err
+		}
+		results, meta, err := fetch(page, limit)
+		if err != nil {
+			return nil, ResponseMeta{}, fmt.Errorf("page %d: %w", page, err)
+		}
+		if page == 1 {
+			first = meta
+		}
+		all = append(all, results...)
+
+		if len(results) < limit || len(all) >= maxResults {
+			break
+		}
+		if f := meta.Found; f.Known && !f.AtLeast && len(all) >= f.Count {
+			break
+		}
+	}
+	if len(all) > maxResults {
+		all = all[:maxResults]
+	}
+	return all, first, nil
+}
+
+// doGET requests path (relative to baseURL) with retries and decodes the
+// JSON response into out. The body is decoded straight off the wire unless
+// debug logging is on.
+func (c *OpenAQClient) doGET(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if c.apiKey == "" {
+		return fmt.Errorf("openaq api key is required")
+	}
+
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := doWithRetry(ctx, c.httpCli, c.retry, req)
+	if err != nil {
+		if se := (*StatusError)(nil); c.debug != nil && errors.As(err, &se) {
+			c.debug.Printf("openaq: GET %s -> %d: %s", reqURL, se.StatusCode, se.Body)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	body := io.Reader(resp.Body)
+	if c.debug != nil {
+		raw, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		c.debug.Printf("openaq: GET %s -> %d (%d bytes): %s", reqURL, resp.StatusCode, len(raw), truncateBody(raw, openAQDebugBody))
+		body = bytes.NewReader(raw)
+	}
+
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// truncateBody returns at most n bytes of b, marking any cut.
+func truncateBody(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return string(b[:n]) + "...(truncated)"
+}
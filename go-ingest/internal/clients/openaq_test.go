@@ -0,0 +1,155 @@
+package clients
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openAQPageServer serves /locations from the given fixtures by page
+// number, and a full page with an open-ended found count for any page past
+// them. It records every request's query.
+func openAQPageServer(t *testing.T, pages ...string) (*OpenAQClient, func() []url.Values) {
+	t.Helper()
+	bodies := make([][]byte, len(pages))
+	for i, name := range pages {
+		bodies[i] = readFixture(t, name)
+	}
+
+	var (
+		mu      sync.Mutex
+		queries []url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		queries = append(queries, q)
+		mu.Unlock()
+		if r.URL.Path != "/locations" || r.Header.Get("X-API-Key") != "test-key" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var page int
+		fmt.Sscan(q.Get("page"), &page)
+		if page >= 1 && page <= len(bodies) {
+			w.Write(bodies[page-1])
+			return
+		}
+		fmt.Fprintf(w, `{"meta":{"page":%d,"limit":2,"found":">1000"},"results":[{"id":%d},{"id":%d}]}`, page, 10*page, 10*page+1)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewOpenAQClient("test-key")
+	c.baseURL = srv.URL
+	c.retry = fastRetry
+	c.pageSize = 2
+	return c, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return queries
+	}
+}
+
+func TestOpenAQGetAllLocationsByCityFollowsPages(t *testing.T) {
+	c, queries := openAQPageServer(t, "openaq_locations_page1.json", "openaq_locations_page2.json")
+
+	resp, err := c.GetAllLocationsByCity("Denver", 50)
+	if err != nil {
+		t.Fatalf("GetAllLocationsByCity: %v", err)
+	}
+	var ids []int
+	for _, loc := range resp.Results {
+		ids = append(ids, loc.ID)
+	}
+	if fmt.Sprint(ids) != "[2160 2163 2174]" {
+		t.Errorf("locations = %v, want both pages combined", ids)
+	}
+	if resp.Meta.Page != 1 || resp.Meta.Found.Count != 3 {
+		t.Errorf("meta = %+v, want the first page's", resp.Meta)
+	}
+	if resp.Results[0].DatetimeLast == nil || resp.Results[2].DatetimeLast != nil {
+		t.Errorf("datetimeLast not decoded as recorded")
+	}
+
+	qs := queries()
+	if len(qs) != 2 {
+		t.Fatalf("made %d requests, want 2 (page 2 is short)", len(qs))
+	}
+	for i, q := range qs {
+		if q.Get("city") != "Denver" || q.Get("page") != fmt.Sprint(i+1) || q.Get("limit") != "2" {
+			t.Errorf("request %d query = %v", i+1, q)
+		}
+	}
+}
+
+func TestOpenAQPaginationLimits(t *testing.T) {
+	// Stops once maxResults are collected, trimming the last page.
+	c, queries := openAQPageServer(t)
+	resp, err := c.GetAllLocationsByCity("Denver", 5)
+	if err != nil {
+		t.Fatalf("GetAllLocationsByCity: %v", err)
+	}
+	if len(resp.Results) != 5 || len(queries()) != 3 {
+		t.Errorf("got %d locations from %d requests, want 5 from 3", len(resp.Results), len(queries()))
+	}
+
+	// Never reads more than openAQMaxPages, however many are found.
+	c, queries = openAQPageServer(t)
+	resp, err = c.GetAllLocationsByCoordinates(39.74, -104.99, 10000, 1000)
+	if err != nil {
+		t.Fatalf("GetAllLocationsByCoordinates: %v", err)
+	}
+	if n := len(queries()); n != openAQMaxPages || len(resp.Results) != 2*openAQMaxPages {
+		t.Errorf("made %d requests for %d locations, want the %d-page cap", n, len(resp.Results), openAQMaxPages)
+	}
+
+	if _, err := c.GetAllLocationsByCity("Denver", 0); err == nil {
+		t.Error("maxResults 0: want an error")
+	}
+}
+
+func TestSelectActiveLocation(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(ago time.Duration) *DatetimeInfo {
+		return &DatetimeInfo{UTC: now.Add(-ago).Format(time.RFC3339)}
+	}
+	locations := []OpenAQLocation{
+		{ID: 1, DatetimeLast: at(20 * time.Hour)},
+		{ID: 2, DatetimeLast: nil},
+		{ID: 3, DatetimeLast: at(2 * time.Hour)},
+		{ID: 4, DatetimeLast: &DatetimeInfo{UTC: "yesterday"}},
+		{ID: 5, DatetimeLast: at(30 * time.Hour)},
+		{ID: 6, DatetimeLast: at(5 * time.Hour)},
+	}
+
+	tests := []struct {
+		window time.Duration
+		want   int // 0 for none
+	}{
+		{24 * time.Hour, 3}, // freshest, not the first listed
+		{48 * time.Hour, 3},
+		{time.Hour, 0},
+	}
+	for _, tt := range tests {
+		got := SelectActiveLocation(locations, tt.window)
+		switch {
+		case tt.want == 0 && got != nil:
+			t.Errorf("window %s picked %d, want none", tt.window, got.ID)
+		case tt.want != 0 && (got == nil || got.ID != tt.want):
+			t.Errorf("window %s picked %+v, want %d", tt.window, got, tt.want)
+		}
+	}
+
+	if got := SelectActiveLocation(nil, 24*time.Hour); got != nil {
+		t.Errorf("empty list picked %+v", got)
+	}
+	// The result points into the slice.
+	if got := SelectActiveLocation(locations, 24*time.Hour); got != &locations[2] {
+		t.Errorf("result is a copy, not the element")
+	}
+}
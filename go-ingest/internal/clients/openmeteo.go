@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,15 +11,17 @@ import (
 
 // OpenMeteoClient handles interactions with the Open-Meteo API.
 type OpenMeteoClient struct {
-	baseURL string
-	httpCli *http.Client
+	baseURL           string
+	airQualityBaseURL string
+	httpCli           *http.Client
 }
 
 // NewOpenMeteoClient creates a new Open-Meteo API client
-func NewOpenMeteoClient() *OpenMeteoClient {
+func NewOpenMeteoClient(opts ...ClientOption) *OpenMeteoClient {
 	return &OpenMeteoClient{
-		baseURL: "https://api.open-meteo.com/v1",
-		httpCli: &http.Client{Timeout: 10 * time.Second},
+		baseURL:           "https://api.open-meteo.com/v1",
+		airQualityBaseURL: "https://air-quality-api.open-meteo.com/v1",
+		httpCli:           applyOptions(NewHTTPClient(envTimeout("OPENMETEO_TIMEOUT", 10*time.Second)), opts),
 	}
 }
 
@@ -29,23 +32,32 @@ type CurrentWeatherResponse struct {
 	Current   CurrentBlock `json:"current"`
 }
 
-// CurrentBlock holds the current weather metrics requested.
+// CurrentBlock holds the current weather metrics requested, in Open-Meteo's
+// default units.
 type CurrentBlock struct {
 	Time             string  `json:"time"`
 	Temperature2m    float64 `json:"temperature_2m"`
 	WindSpeed10m     float64 `json:"wind_speed_10m"`
 	RelativeHumidity float64 `json:"relative_humidity_2m"`
+	Precipitation    float64 `json:"precipitation"` // mm over the preceding interval
+	CloudCover       float64 `json:"cloud_cover"`   // percent
+	Visibility       float64 `json:"visibility"`    // metres
 }
 
 // GetCurrentWeather fetches current weather for provided coordinates.
 func (c *OpenMeteoClient) GetCurrentWeather(lat, lon float64) (*CurrentWeatherResponse, error) {
+	return c.GetCurrentWeatherContext(context.Background(), lat, lon)
+}
+
+// GetCurrentWeatherContext is GetCurrentWeather with a caller-supplied context.
+func (c *OpenMeteoClient) GetCurrentWeatherContext(ctx context.Context, lat, lon float64) (*CurrentWeatherResponse, error) {
 	q := url.Values{}
 	q.Set("latitude", fmt.Sprintf("%f", lat))
 	q.Set("longitude", fmt.Sprintf("%f", lon))
-	q.Set("current", "temperature_2m,wind_speed_10m,relative_humidity_2m")
+	q.Set("current", "temperature_2m,wind_speed_10m,relative_humidity_2m,precipitation,cloud_cover,visibility")
 
 	reqURL := fmt.Sprintf("%s/forecast?%s", c.baseURL, q.Encode())
-	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("build request: %w", err)
 	}
@@ -67,3 +79,186 @@ func (c *OpenMeteoClient) GetCurrentWeather(lat, lon float64) (*CurrentWeatherRe
 
 	return &parsed, nil
 }
+
+// AirQualityResponse is the current block of the Open-Meteo air-quality API:
+// modeled (CAMS) concentrations available for any coordinate. Values are
+// null where the model has no coverage; CurrentUnits maps each variable to
+// its unit (µg/m³ by default).
+type AirQualityResponse struct {
+	Latitude     float64           `json:"latitude"`
+	Longitude    float64           `json:"longitude"`
+	CurrentUnits map[string]string `json:"current_units"`
+	Current      AirQualityBlock   `json:"current"`
+}
+
+// AirQualityBlock holds the current modeled pollutant concentrations.
+type AirQualityBlock struct {
+	Time  string   `json:"time"`
+	PM25  *float64 `json:"pm2_5"`
+	PM10  *float64 `json:"pm10"`
+	Ozone *float64 `json:"ozone"`
+	NO2   *float64 `json:"nitrogen_dioxide"`
+	SO2   *float64 `json:"sulphur_dioxide"`
+	CO    *float64 `json:"carbon_monoxide"`
+}
+
+// airQualityVariables is the current= list sent to the air-quality API; the
+// names match AirQualityBlock's JSON tags.
+const airQualityVariables = "pm2_5,pm10,ozone,nitrogen_dioxide,sulphur_dioxide,carbon_monoxide"
+
+// GetAirQuality fetches current modeled air quality for the coordinates.
+func (c *OpenMeteoClient) GetAirQuality(lat, lon float64) (*AirQualityResponse, error) {
+	return c.GetAirQualityContext(context.Background(), lat, lon)
+}
+
+// GetAirQualityContext is GetAirQuality with a caller-supplied context.
+func (c *OpenMeteoClient) GetAirQualityContext(ctx context.Context, lat, lon float64) (*AirQualityResponse, error) {
+	q := url.Values{}
+	q.Set("latitude", fmt.Sprintf("%f", lat))
+	q.Set("longitude", fmt.Sprintf("%f", lon))
+	q.Set("current", airQualityVariables)
+
+	reqURL := fmt.Sprintf("%s/air-quality?%s", c.airQualityBaseURL, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed AirQualityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return &parsed, nil
+}
+
+// ForecastPoint is one hour of an Open-Meteo hourly forecast.
+type ForecastPoint struct {
+	Time              time.Time `json:"time"`
+	TemperatureC      float64   `json:"temperature_c"`
+	PrecipProbability float64   `json:"precip_probability"` // percent
+	PrecipMM          float64   `json:"precip_mm"`
+	WindSpeedMS       float64   `json:"wind_speed_ms"`
+	SoilMoisture      float64   `json:"soil_moisture"` // m³/m³ at 9-27 cm, the root zone
+}
+
+// HourlyForecastResponse is the subset of the forecast response carrying
+// the hourly block. Open-Meteo returns parallel arrays indexed by hour;
+// values may be null for hours a model does not cover.
+type HourlyForecastResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Hourly    struct {
+		Time                     []string   `json:"time"`
+		Temperature2m            []*float64 `json:"temperature_2m"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability"`
+		Precipitation            []*float64 `json:"precipitation"`
+		WindSpeed10m             []*float64 `json:"wind_speed_10m"`
+		SoilMoisture9To27cm      []*float64 `json:"soil_moisture_9_to_27cm"`
+	} `json:"hourly"`
+}
+
+// openMeteoHourLayout is the UTC hour format used in hourly time arrays.
+const openMeteoHourLayout = "2006-01-02T15:04"
+
+// GetHourlyForecast fetches the next hours (1-168) of hourly forecast for
+// the provided coordinates, starting at the current hour.
+func (c *OpenMeteoClient) GetHourlyForecast(lat, lon float64, hours int) ([]ForecastPoint, error) {
+	return c.GetHourlyForecastContext(context.Background(), lat, lon, hours)
+}
+
+// GetHourlyForecastContext is GetHourlyForecast with a caller-supplied context.
+func (c *OpenMeteoClient) GetHourlyForecastContext(ctx context.Context, lat, lon float64, hours int) ([]ForecastPoint, error) {
+	if hours < 1 || hours > 168 {
+		return nil, fmt.Errorf("hours must be between 1 and 168")
+	}
+
+	q := url.Values{}
+	q.Set("latitude", fmt.Sprintf("%f", lat))
+	q.Set("longitude", fmt.Sprintf("%f", lon))
+	q.Set("hourly", "temperature_2m,precipitation_probability,precipitation,wind_speed_10m,soil_moisture_9_to_27cm")
+	q.Set("forecast_hours", fmt.Sprintf("%d", hours))
+	q.Set("wind_speed_unit", "ms")
+	q.Set("timezone", "UTC")
+
+	reqURL := fmt.Sprintf("%s/forecast?%s", c.baseURL, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed HourlyForecastResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return parsed.Points()
+}
+
+// Points zips the parallel hourly arrays into ForecastPoints. Null values
+// become zero.
+func (r *HourlyForecastResponse) Points() ([]ForecastPoint, error) {
+	h := r.Hourly
+	points := make([]ForecastPoint, 0, len(h.Time))
+	for i, ts := range h.Time {
+		t, err := time.Parse(openMeteoHourLayout, ts)
+		if err != nil {
+			return nil, fmt.Errorf("parse forecast time %q: %w", ts, err)
+		}
+		points = append(points, ForecastPoint{
+			Time:              t,
+			TemperatureC:      valueAt(h.Temperature2m, i),
+			PrecipProbability: valueAt(h.PrecipitationProbability, i),
+			PrecipMM:          valueAt(h.Precipitation, i),
+			WindSpeedMS:       valueAt(h.WindSpeed10m, i),
+			SoilMoisture:      valueAt(h.SoilMoisture9To27cm, i),
+		})
+	}
+	return points, nil
+}
+
+// SumPrecipitation totals forecast precipitation (mm) over the points that
+// fall within window of the first one, e.g. 72*time.Hour for the next three
+// days.
+func SumPrecipitation(points []ForecastPoint, window time.Duration) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	end := points[0].Time.Add(window)
+	var total float64
+	for _, p := range points {
+		if !p.Time.Before(end) {
+			break
+		}
+		total += p.PrecipMM
+	}
+	return total
+}
+
+// valueAt returns vals[i], or 0 when it is missing or null.
+func valueAt(vals []*float64, i int) float64 {
+	if i >= len(vals) || vals[i] == nil {
+		return 0
+	}
+	return *vals[i]
+}
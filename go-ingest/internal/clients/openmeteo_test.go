@@ -0,0 +1,79 @@
+package clients
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenMeteoGetHourlyForecast(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/forecast": "openmeteo_hourly.json"})
+	c := NewOpenMeteoClient()
+	c.baseURL = srv.URL
+
+	points, err := c.GetHourlyForecast(34.05, -118.24, 4)
+	if err != nil {
+		t.Fatalf("GetHourlyForecast: %v", err)
+	}
+	if got := srv.lastQuery().Get("forecast_hours"); got != "4" {
+		t.Errorf("requested forecast_hours %q, want 4", got)
+	}
+	if len(points) != 4 {
+		t.Fatalf("got %d points, want 4", len(points))
+	}
+
+	want := ForecastPoint{
+		Time:              time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC),
+		TemperatureC:      20.1,
+		PrecipProbability: 35,
+		PrecipMM:          1.2,
+		WindSpeedMS:       4.4,
+		SoilMoisture:      0.143,
+	}
+	if points[2] != want {
+		t.Errorf("points[2] = %+v, want %+v", points[2], want)
+	}
+	// Nulls decode as zero.
+	if points[3].PrecipProbability != 0 || points[3].SoilMoisture != 0 {
+		t.Errorf("points[3] = %+v, want null fields zeroed", points[3])
+	}
+	if got := SumPrecipitation(points, 3*time.Hour); math.Abs(got-1.2) > 1e-9 {
+		t.Errorf("SumPrecipitation over 3h = %v, want 1.2", got)
+	}
+}
+
+func TestOpenMeteoGetHourlyForecastRejectsHours(t *testing.T) {
+	c := NewOpenMeteoClient()
+	for _, hours := range []int{0, 169} {
+		if _, err := c.GetHourlyForecast(0, 0, hours); err == nil {
+			t.Errorf("GetHourlyForecast(hours=%d) succeeded", hours)
+		}
+	}
+}
+
+func TestOpenMeteoGetCurrentWeather(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/forecast": "openmeteo_current.json"})
+	c := NewOpenMeteoClient()
+	c.baseURL = srv.URL
+
+	got, err := c.GetCurrentWeather(39.7392, -104.9903)
+	if err != nil {
+		t.Fatalf("GetCurrentWeather: %v", err)
+	}
+	want := CurrentBlock{
+		Time:             "2026-10-17T15:00",
+		Temperature2m:    14.3,
+		WindSpeed10m:     11.2,
+		RelativeHumidity: 41,
+		Precipitation:    0.4,
+		CloudCover:       87,
+		Visibility:       24140,
+	}
+	if got.Current != want {
+		t.Errorf("Current = %+v, want %+v", got.Current, want)
+	}
+	if q := srv.lastQuery().Get("current"); !strings.Contains(q, "precipitation,cloud_cover,visibility") {
+		t.Errorf("current = %q, want precipitation, cloud_cover and visibility requested", q)
+	}
+}
@@ -0,0 +1,140 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// OpenSkyClient counts aircraft from the OpenSky Network live state vectors.
+// Anonymous access works with a low daily credit allowance; a username and
+// password raise it.
+// Docs: https://openskynetwork.github.io/opensky-api/rest.html
+type OpenSkyClient struct {
+	baseURL  string
+	username string
+	password string
+	httpCli  *http.Client
+}
+
+// FlightSummary describes the aircraft currently inside a bounding box.
+type FlightSummary struct {
+	Aircraft     int       // every state vector in the box, airborne or not
+	Airborne     int       // aircraft not on the ground
+	AvgAltitudeM float64   // mean barometric altitude of airborne aircraft (geometric when barometric is missing)
+	ObservedAt   time.Time // time of the state vectors; zero when OpenSky omits it
+}
+
+// Indices into an OpenSky state vector, which is a JSON array of mixed
+// types rather than an object.
+const (
+	openSkyBaroAltitude = 7
+	openSkyOnGround     = 8
+	openSkyGeoAltitude  = 13
+)
+
+// openSkyStates is the /states/all body; states is null when the box is
+// empty.
+type openSkyStates struct {
+	Time   int64           `json:"time"`
+	States [][]interface{} `json:"states"`
+}
+
+// NewOpenSkyClient creates an OpenSky client. Empty credentials use
+// anonymous access.
+func NewOpenSkyClient(username, password string, opts ...ClientOption) *OpenSkyClient {
+	return &OpenSkyClient{
+		baseURL:  "https://opensky-network.org/api",
+		username: username,
+		password: password,
+		httpCli:  applyOptions(NewHTTPClient(envTimeout("OPENSKY_TIMEOUT", 20*time.Second)), opts),
+	}
+}
+
+// GetFlightsInBox summarizes the aircraft within radiusKm of lat/lon.
+func (c *OpenSkyClient) GetFlightsInBox(lat, lon, radiusKm float64) (*FlightSummary, error) {
+	return c.GetFlightsInBoxContext(context.Background(), lat, lon, radiusKm)
+}
+
+// GetFlightsInBoxContext is GetFlightsInBox with a caller-supplied context.
+func (c *OpenSkyClient) GetFlightsInBoxContext(ctx context.Context, lat, lon, radiusKm float64) (*FlightSummary, error) {
+	if radiusKm <= 0 {
+		return nil, fmt.Errorf("radius must be positive")
+	}
+	south, west, north, east := boundingBox(lat, lon, radiusKm)
+
+	q := url.Values{}
+	q.Set("lamin", strconv.FormatFloat(south, 'f', 4, 64))
+	q.Set("lomin", strconv.FormatFloat(west, 'f', 4, 64))
+	q.Set("lamax", strconv.FormatFloat(north, 'f', 4, 64))
+	q.Set("lomax", strconv.FormatFloat(east, 'f', 4, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/states/all?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build OpenSky request: %w", err)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch OpenSky states: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readStatusError(resp)
+	}
+
+	var payload openSkyStates
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode OpenSky states: %w", err)
+	}
+	s := summarizeFlights(payload.States)
+	if payload.Time > 0 {
+		s.ObservedAt = time.Unix(payload.Time, 0).UTC()
+	}
+	return s, nil
+}
+
+// summarizeFlights counts state vectors and averages airborne altitude.
+// Short or malformed vectors still count as aircraft but are not treated as
+// airborne.
+func summarizeFlights(states [][]interface{}) *FlightSummary {
+	s := &FlightSummary{Aircraft: len(states)}
+	var altSum float64
+	var altCount int
+	for _, sv := range states {
+		if onGround, ok := stateField(sv, openSkyOnGround).(bool); !ok || onGround {
+			continue
+		}
+		s.Airborne++
+
+		alt, ok := stateField(sv, openSkyBaroAltitude).(float64)
+		if !ok {
+			alt, ok = stateField(sv, openSkyGeoAltitude).(float64)
+		}
+		if ok {
+			altSum += alt
+			altCount++
+		}
+	}
+	if altCount > 0 {
+		s.AvgAltitudeM = altSum / float64(altCount)
+	}
+	return s
+}
+
+// stateField returns element i of a state vector, or nil when the vector is
+// too short.
+func stateField(sv []interface{}, i int) interface{} {
+	if i < len(sv) {
+		return sv[i]
+	}
+	return nil
+}
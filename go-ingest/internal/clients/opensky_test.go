@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenSkyGetFlightsInBox(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/states/all": "opensky_states.json"})
+	c := NewOpenSkyClient("", "")
+	c.baseURL = srv.URL
+
+	got, err := c.GetFlightsInBox(39.7392, -104.9903, 50)
+	if err != nil {
+		t.Fatalf("GetFlightsInBox: %v", err)
+	}
+	// Four vectors, one on the ground; the second airborne one has no
+	// barometric altitude and falls back to its geometric 5000 m.
+	if got.Aircraft != 4 || got.Airborne != 3 || got.AvgAltitudeM != 6000 {
+		t.Errorf("GetFlightsInBox = %+v, want 4 aircraft, 3 airborne at 6000 m", *got)
+	}
+
+	q := srv.lastQuery()
+	for _, k := range []string{"lamin", "lomin", "lamax", "lomax"} {
+		if q.Get(k) == "" {
+			t.Errorf("query missing %s: %v", k, q)
+		}
+	}
+	if q.Get("lamin") >= q.Get("lamax") {
+		t.Errorf("lamin %s not below lamax %s", q.Get("lamin"), q.Get("lamax"))
+	}
+}
+
+func TestOpenSkyEmptyBox(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/states/all": "opensky_empty.json"})
+	c := NewOpenSkyClient("", "")
+	c.baseURL = srv.URL
+
+	got, err := c.GetFlightsInBox(39.7392, -104.9903, 50)
+	if err != nil {
+		t.Fatalf("GetFlightsInBox: %v", err)
+	}
+	if got.Aircraft != 0 || got.Airborne != 0 || got.AvgAltitudeM != 0 {
+		t.Errorf("null states = %+v, want zero", *got)
+	}
+}
+
+func TestOpenSkyBasicAuth(t *testing.T) {
+	var user, pass string
+	var ok bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok = r.BasicAuth()
+		w.Write([]byte(`{"time":0,"states":[]}`))
+	}))
+	defer srv.Close()
+
+	c := NewOpenSkyClient("alice", "s3cret")
+	c.baseURL = srv.URL
+	if _, err := c.GetFlightsInBox(0, 0, 10); err != nil {
+		t.Fatalf("GetFlightsInBox: %v", err)
+	}
+	if !ok || user != "alice" || pass != "s3cret" {
+		t.Errorf("basic auth = %q/%q (%v), want alice/s3cret", user, pass, ok)
+	}
+
+	anon := NewOpenSkyClient("", "")
+	anon.baseURL = srv.URL
+	if _, err := anon.GetFlightsInBox(0, 0, 10); err != nil {
+		t.Fatalf("GetFlightsInBox: %v", err)
+	}
+	if ok {
+		t.Error("anonymous client sent basic auth")
+	}
+}
+
+func TestSummarizeFlightsShortVector(t *testing.T) {
+	s := summarizeFlights([][]interface{}{{"abc123", "SHORT"}})
+	if s.Aircraft != 1 || s.Airborne != 0 {
+		t.Errorf("short vector = %+v, want counted but not airborne", *s)
+	}
+}
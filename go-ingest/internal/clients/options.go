@@ -0,0 +1,23 @@
+package clients
+
+import "net/http"
+
+// ClientOption customizes the http.Client a client is constructed with.
+type ClientOption func(*http.Client)
+
+// WithTransport routes the client's requests through rt, e.g. an
+// httpcache.Transport so slowly changing sources are not re-fetched on every
+// ingest run.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *http.Client) {
+		c.Transport = rt
+	}
+}
+
+// applyOptions applies opts to c and returns it.
+func applyOptions(c *http.Client, opts []ClientOption) *http.Client {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,132 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusError is a non-2xx response, carrying the start of the body so logs
+// say why the API refused.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// statusBodySnippet is how much of an error body StatusError keeps.
+const statusBodySnippet = 256
+
+// retryPolicy controls doWithRetry. Retries is the number of extra attempts
+// after the first.
+type retryPolicy struct {
+	Retries       int
+	Backoff       time.Duration // first delay; doubles per attempt
+	MaxBackoff    time.Duration
+	MaxRetryAfter time.Duration // longest Retry-After honored
+}
+
+var defaultRetryPolicy = retryPolicy{
+	Retries:       3,
+	Backoff:       500 * time.Millisecond,
+	MaxBackoff:    10 * time.Second,
+	MaxRetryAfter: time.Minute,
+}
+
+// doWithRetry sends a bodiless request, retrying network errors, 429 and 5xx
+// with exponential backoff plus jitter; a Retry-After header replaces the
+// computed delay. It returns the first 2xx response, or a *StatusError for
+// any other status once retries are used up or the status is not retryable.
+func doWithRetry(ctx context.Context, cli *http.Client, policy retryPolicy, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := cli.Do(req.Clone(ctx))
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			err = fmt.Errorf("request failed: %w", err)
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return resp, nil
+		default:
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			err = readStatusError(resp)
+			if !retryableStatus(resp.StatusCode) {
+				return nil, err
+			}
+		}
+
+		if attempt >= policy.Retries {
+			return nil, err
+		}
+
+		delay := policy.delay(attempt)
+		if retryAfter > 0 {
+			delay = min(retryAfter, policy.MaxRetryAfter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// delay is the backoff before retry attempt+1: Backoff·2^attempt capped at
+// MaxBackoff, plus up to half again in random jitter so clients that failed
+// together do not retry together.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff << attempt
+	if d <= 0 || d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d > 1 {
+		d += rand.N(d / 2)
+	}
+	return d
+}
+
+// retryableStatus reports whether a status is worth retrying.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// readStatusError drains and closes resp, keeping a snippet of its body.
+func readStatusError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, statusBodySnippet))
+	io.Copy(io.Discard, resp.Body)
+	return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// parseRetryAfter reads a Retry-After header in either delta-seconds or
+// HTTP-date form, returning 0 when absent or invalid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package clients
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry keeps retry tests quick while still exercising backoff.
+var fastRetry = retryPolicy{Retries: 3, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, MaxRetryAfter: 20 * time.Millisecond}
+
+// flakyServer answers the first len(failures) requests with those statuses
+// and every later one with the fixture body.
+func flakyServer(t *testing.T, fixture string, failures ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	body := readFixture(t, fixture)
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(attempts.Add(1))
+		if n <= len(failures) {
+			if failures[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			http.Error(w, `{"detail":"try again"}`, failures[n-1])
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &attempts
+}
+
+func newTestOpenAQ(srv *httptest.Server) *OpenAQClient {
+	c := NewOpenAQClient("test-key")
+	c.baseURL = srv.URL
+	c.retry = fastRetry
+	return c
+}
+
+func TestOpenAQRetriesTransientFailures(t *testing.T) {
+	srv, attempts := flakyServer(t, "openaq_latest.json", http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	latest, err := newTestOpenAQ(srv).GetLatestByLocationID(2160)
+	if err != nil {
+		t.Fatalf("GetLatestByLocationID: %v", err)
+	}
+	if len(latest.Results) != 2 || latest.Results[0].Value != 8.4 {
+		t.Errorf("results = %+v, want the two fixture measurements", latest.Results)
+	}
+	if n := attempts.Load(); n != 4 {
+		t.Errorf("made %d attempts, want 4", n)
+	}
+}
+
+func TestOpenAQGivesUpAfterRetries(t *testing.T) {
+	srv, attempts := flakyServer(t, "openaq_latest.json", 500, 500, 500, 500, 500)
+
+	_, err := newTestOpenAQ(srv).GetLatestByLocationID(2160)
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want a 500 StatusError", err)
+	}
+	if n := attempts.Load(); n != 4 {
+		t.Errorf("made %d attempts, want 1 plus 3 retries", n)
+	}
+}
+
+func TestOpenAQFailsFastOnPermanentErrors(t *testing.T) {
+	for _, code := range []int{http.StatusUnauthorized, http.StatusNotFound} {
+		srv, attempts := flakyServer(t, "openaq_latest.json", code)
+
+		_, err := newTestOpenAQ(srv).GetLatestByLocationID(2160)
+		var se *StatusError
+		if !errors.As(err, &se) || se.StatusCode != code || !strings.Contains(se.Body, "try again") {
+			t.Errorf("%d: err = %v, want a StatusError with the body snippet", code, err)
+		}
+		if n := attempts.Load(); n != 1 {
+			t.Errorf("%d: made %d attempts, want 1", code, n)
+		}
+	}
+}
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	arrivals := make(chan time.Time, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrivals <- time.Now()
+		if len(arrivals) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	// Retry-After: 1 asks for a second, but MaxRetryAfter caps the wait.
+	policy := fastRetry
+	policy.MaxRetryAfter = 50 * time.Millisecond
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := doWithRetry(req.Context(), srv.Client(), policy, req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	resp.Body.Close()
+	if len(arrivals) != 2 {
+		t.Fatalf("made %d attempts, want 2", len(arrivals))
+	}
+	first, second := <-arrivals, <-arrivals
+	if gap := second.Sub(first); gap < 50*time.Millisecond || gap > 500*time.Millisecond {
+		t.Errorf("waited %s before retrying, want the 50ms cap on Retry-After", gap)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{now.Add(2 * time.Minute).Format(http.TimeFormat), 2 * time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
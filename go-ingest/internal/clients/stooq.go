@@ -1,70 +1,261 @@
 package clients
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// ErrStooqNoData is returned when Stooq has no data for a symbol or range
+// (it answers with "N/D" placeholders or an empty file rather than an error).
+var ErrStooqNoData = errors.New("stooq: no data")
+
 // StooqClient fetches free market data from stooq.pl as a lightweight fallback.
 type StooqClient struct {
-	baseURL string
-	httpCli *http.Client
+	baseURL    string
+	historyURL string
+	httpCli    *http.Client
+}
+
+// OHLCV is one daily bar (or the latest quote) for a symbol.
+type OHLCV struct {
+	Symbol string    `json:"symbol"`
+	Date   time.Time `json:"date"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume int64     `json:"volume"`
 }
 
 // NewStooqClient creates a Stooq client with sensible defaults.
-func NewStooqClient() *StooqClient {
+func NewStooqClient(opts ...ClientOption) *StooqClient {
 	return &StooqClient{
-		baseURL: "https://stooq.pl/q/l/",
-		httpCli: &http.Client{Timeout: 15 * time.Second},
+		baseURL:    "https://stooq.pl/q/l/",
+		historyURL: "https://stooq.pl/q/d/l/",
+		httpCli:    applyOptions(NewHTTPClient(envTimeout("STOOQ_TIMEOUT", 15*time.Second)), opts),
 	}
 }
 
 // GetNasdaqComposite returns NASDAQ composite via Stooq (^ndq), mapped into NASDAQMarketSummary.
 // Stooq CSV format: Symbol,Date,Time,Open,High,Low,Close,Volume
 func (c *StooqClient) GetNasdaqComposite() (*NASDAQMarketSummary, error) {
-	// i=d gives daily; f=sd2t2ohlcv includes symbol/date/time/ohlcv; h&e=csv ensures headers and CSV
-	url := fmt.Sprintf("%s?s=^ndq&f=sd2t2ohlcv&h&e=csv", c.baseURL)
+	return c.GetNasdaqCompositeContext(context.Background())
+}
+
+// GetNasdaqCompositeContext is GetNasdaqComposite with a caller-supplied context.
+func (c *StooqClient) GetNasdaqCompositeContext(ctx context.Context) (*NASDAQMarketSummary, error) {
+	bar, err := c.GetQuoteContext(ctx, "^ndq")
+	if err != nil {
+		return nil, err
+	}
+	return &NASDAQMarketSummary{
+		IndexValue:   bar.Close,
+		VolumeTraded: bar.Volume,
+	}, nil
+}
+
+// GetQuote returns the latest bar for any symbol Stooq knows. Symbols are
+// normalized with NormalizeStooqSymbol, so "AAPL", "aapl.us" and "^NDQ" all
+// work.
+func (c *StooqClient) GetQuote(symbol string) (*OHLCV, error) {
+	return c.GetQuoteContext(context.Background(), symbol)
+}
+
+// GetQuoteContext is GetQuote with a caller-supplied context.
+func (c *StooqClient) GetQuoteContext(ctx context.Context, symbol string) (*OHLCV, error) {
+	stooqSymbol := NormalizeStooqSymbol(symbol)
+	if stooqSymbol == "" {
+		return nil, fmt.Errorf("stooq quote: empty symbol")
+	}
+
+	// f=sd2t2ohlcv includes symbol/date/time/ohlcv; h&e=csv ensures headers and CSV
+	q := url.Values{}
+	q.Set("s", stooqSymbol)
+	q.Set("f", "sd2t2ohlcv")
+	q.Set("e", "csv")
+	reqURL := fmt.Sprintf("%s?%s&h", c.baseURL, q.Encode())
+
+	body, err := c.fetchCSV(ctx, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch Stooq quote %s: %w", stooqSymbol, err)
+	}
+	defer body.Close()
+
+	bar, err := parseStooqQuote(body)
+	if err != nil {
+		return nil, fmt.Errorf("Stooq quote %s: %w", stooqSymbol, err)
+	}
+	return bar, nil
+}
+
+// GetDailyHistory returns daily bars for symbol between start and end
+// (inclusive), oldest first, using Stooq's d/l/ CSV download.
+func (c *StooqClient) GetDailyHistory(symbol string, start, end time.Time) ([]OHLCV, error) {
+	return c.GetDailyHistoryContext(context.Background(), symbol, start, end)
+}
+
+// GetDailyHistoryContext is GetDailyHistory with a caller-supplied context.
+func (c *StooqClient) GetDailyHistoryContext(ctx context.Context, symbol string, start, end time.Time) ([]OHLCV, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("stooq history: end is before start")
+	}
+	stooqSymbol := NormalizeStooqSymbol(symbol)
+	if stooqSymbol == "" {
+		return nil, fmt.Errorf("stooq history: empty symbol")
+	}
+
+	q := url.Values{}
+	q.Set("s", stooqSymbol)
+	q.Set("d1", start.Format("20060102"))
+	q.Set("d2", end.Format("20060102"))
+	q.Set("i", "d")
+	reqURL := fmt.Sprintf("%s?%s", c.historyURL, q.Encode())
+
+	body, err := c.fetchCSV(ctx, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch Stooq history %s: %w", stooqSymbol, err)
+	}
+	defer body.Close()
 
-	resp, err := c.httpCli.Get(url)
+	bars, err := parseStooqHistory(stooqSymbol, body)
 	if err != nil {
-		return nil, fmt.Errorf("fetch Stooq NASDAQ: %w", err)
+		return nil, fmt.Errorf("Stooq history %s: %w", stooqSymbol, err)
+	}
+	return bars, nil
+}
+
+// stooqMarkets are the exchange suffixes Stooq uses; a symbol without one is
+// taken to be a US listing.
+var stooqMarkets = map[string]bool{
+	"us": true, "uk": true, "de": true, "jp": true, "hk": true,
+	"pl": true, "hu": true, "f": true,
+}
+
+// NormalizeStooqSymbol maps a ticker as users and Alpha Vantage write it onto
+// Stooq's lowercase form: indices keep their "^" prefix ("^NDQ" -> "^ndq"),
+// bare tickers get the ".us" suffix ("AAPL" -> "aapl.us"), and share-class
+// dots become dashes ("BRK.B" -> "brk-b.us").
+func NormalizeStooqSymbol(symbol string) string {
+	s := strings.ToLower(strings.TrimSpace(symbol))
+	if s == "" || strings.HasPrefix(s, "^") {
+		return s
 	}
-	defer resp.Body.Close()
+	if i := strings.LastIndexByte(s, '.'); i > 0 && stooqMarkets[s[i+1:]] {
+		return s
+	}
+	return strings.ReplaceAll(s, ".", "-") + ".us"
+}
 
+// fetchCSV issues a GET and returns the body for a 200 response.
+func (c *StooqClient) fetchCSV(ctx context.Context, reqURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build Stooq request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Stooq NASDAQ returned %d: %s", resp.StatusCode, string(body))
+		resp.Body.Close()
+		return nil, fmt.Errorf("Stooq returned %d: %s", resp.StatusCode, string(body))
 	}
+	return resp.Body, nil
+}
 
-	reader := csv.NewReader(resp.Body)
+// parseStooqQuote parses the single-row quote CSV
+// (Symbol,Date,Time,Open,High,Low,Close,Volume).
+func parseStooqQuote(r io.Reader) (*OHLCV, error) {
+	reader := csv.NewReader(r)
 	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
 	rows, err := reader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("parse Stooq CSV: %w", err)
 	}
 	if len(rows) < 2 {
-		return nil, fmt.Errorf("Stooq NASDAQ CSV missing data rows")
+		return nil, fmt.Errorf("Stooq CSV missing data rows")
 	}
 
 	row := rows[1]
 	if len(row) < 8 {
-		return nil, fmt.Errorf("Stooq NASDAQ CSV malformed")
+		return nil, fmt.Errorf("Stooq CSV malformed")
+	}
+	if isStooqND(row[6]) {
+		return nil, ErrStooqNoData
 	}
 
-	closeVal := parseFloatSafe(row[6])
-	vol := parseInt64Safe(row[7])
-
-	return &NASDAQMarketSummary{
-		IndexValue:   closeVal,
-		VolumeTraded: vol,
+	date, _ := time.Parse("2006-01-02", strings.TrimSpace(row[1]))
+	return &OHLCV{
+		Symbol: strings.TrimSpace(row[0]),
+		Date:   date,
+		Open:   parseFloatSafe(row[3]),
+		High:   parseFloatSafe(row[4]),
+		Low:    parseFloatSafe(row[5]),
+		Close:  parseFloatSafe(row[6]),
+		Volume: parseInt64Safe(row[7]),
 	}, nil
 }
 
+// parseStooqHistory parses the daily download CSV
+// (Date,Open,High,Low,Close[,Volume]). Rows with "N/D" closes are skipped;
+// Stooq answers unknown symbols with a non-CSV "No data" body.
+func parseStooqHistory(symbol string, r io.Reader) ([]OHLCV, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse Stooq CSV: %w", err)
+	}
+	if len(rows) == 0 || len(rows[0]) < 5 || !strings.EqualFold(strings.TrimSpace(rows[0][0]), "Date") {
+		return nil, ErrStooqNoData
+	}
+
+	bars := make([]OHLCV, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		if len(row) < 5 || isStooqND(row[4]) {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(row[0]))
+		if err != nil {
+			continue
+		}
+		bar := OHLCV{
+			Symbol: symbol,
+			Date:   date,
+			Open:   parseFloatSafe(row[1]),
+			High:   parseFloatSafe(row[2]),
+			Low:    parseFloatSafe(row[3]),
+			Close:  parseFloatSafe(row[4]),
+		}
+		if len(row) > 5 {
+			bar.Volume = parseInt64Safe(row[5])
+		}
+		bars = append(bars, bar)
+	}
+	if len(bars) == 0 {
+		return nil, ErrStooqNoData
+	}
+	return bars, nil
+}
+
+// isStooqND reports whether a CSV cell is Stooq's missing-data placeholder.
+func isStooqND(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.EqualFold(s, "N/D")
+}
+
 func parseFloatSafe(s string) float64 {
 	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
 	return f
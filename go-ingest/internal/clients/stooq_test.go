@@ -0,0 +1,77 @@
+package clients
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStooqGetQuote(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/q/l/": "stooq_quote.csv"})
+	c := NewStooqClient()
+	c.baseURL = srv.URL + "/q/l/"
+
+	bar, err := c.GetQuote("AAPL")
+	if err != nil {
+		t.Fatalf("GetQuote: %v", err)
+	}
+	if got := srv.lastQuery().Get("s"); got != "aapl.us" {
+		t.Errorf("requested symbol %q, want aapl.us", got)
+	}
+	want := OHLCV{
+		Symbol: "AAPL.US",
+		Date:   time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		Open:   247.25, High: 249.69, Low: 245.56, Close: 247.45,
+		Volume: 39698527,
+	}
+	if *bar != want {
+		t.Errorf("GetQuote = %+v, want %+v", *bar, want)
+	}
+}
+
+func TestStooqGetQuoteNoData(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/q/l/": "stooq_quote_nd.csv"})
+	c := NewStooqClient()
+	c.baseURL = srv.URL + "/q/l/"
+
+	if _, err := c.GetQuote("ZZZZ"); !errors.Is(err, ErrStooqNoData) {
+		t.Errorf("GetQuote err = %v, want ErrStooqNoData", err)
+	}
+}
+
+func TestStooqGetDailyHistory(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/q/d/l/": "stooq_history.csv"})
+	c := NewStooqClient()
+	c.historyURL = srv.URL + "/q/d/l/"
+
+	start := time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	bars, err := c.GetDailyHistory("aapl", start, end)
+	if err != nil {
+		t.Fatalf("GetDailyHistory: %v", err)
+	}
+	q := srv.lastQuery()
+	if q.Get("d1") != "20261013" || q.Get("d2") != "20261016" {
+		t.Errorf("requested range d1=%s d2=%s", q.Get("d1"), q.Get("d2"))
+	}
+
+	// The N/D row for 2026-10-14 is skipped.
+	if len(bars) != 3 {
+		t.Fatalf("got %d bars, want 3", len(bars))
+	}
+	last := bars[2]
+	if !last.Date.Equal(end) || last.Close != 247.45 || last.Volume != 39698527 || last.Symbol != "aapl.us" {
+		t.Errorf("last bar = %+v", last)
+	}
+}
+
+func TestStooqGetDailyHistoryNoData(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/q/d/l/": "stooq_history_nodata.csv"})
+	c := NewStooqClient()
+	c.historyURL = srv.URL + "/q/d/l/"
+
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	if _, err := c.GetDailyHistory("ZZZZ", day, day); !errors.Is(err, ErrStooqNoData) {
+		t.Errorf("GetDailyHistory err = %v, want ErrStooqNoData", err)
+	}
+}
@@ -0,0 +1,149 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TrafficClient fetches real-time traffic flow from the HERE Traffic API v7.
+// Requires an API key from https://platform.here.com
+// Docs: https://www.here.com/docs/bundle/traffic-api-v7-api-reference
+type TrafficClient struct {
+	baseURL  string
+	apiKey   string
+	radiusKM float64
+	httpCli  *http.Client
+}
+
+// TrafficSummary aggregates flow over the road segments in the query box.
+// Speeds and the jam factor are averaged weighted by segment length.
+type TrafficSummary struct {
+	AvgSpeedKmH      float64
+	FreeFlowSpeedKmH float64
+	JamFactor        float64 // 0 (free flow) to 10 (road closed)
+	Segments         int
+	ObservedAt       time.Time // HERE's sourceUpdated; zero when absent
+}
+
+// hereFlowResponse is the subset of a /v7/flow response we use. Speeds are
+// in m/s and segment lengths in metres.
+type hereFlowResponse struct {
+	SourceUpdated string `json:"sourceUpdated"`
+	Results       []struct {
+		Location struct {
+			Description string  `json:"description"`
+			Length      float64 `json:"length"`
+		} `json:"location"`
+		CurrentFlow struct {
+			Speed          float64 `json:"speed"`
+			SpeedUncapped  float64 `json:"speedUncapped"`
+			FreeFlow       float64 `json:"freeFlow"`
+			JamFactor      float64 `json:"jamFactor"`
+			Confidence     float64 `json:"confidence"`
+			Traversability string  `json:"traversability"`
+		} `json:"currentFlow"`
+	} `json:"results"`
+}
+
+// NewTrafficClient creates a HERE traffic client querying a box of 5 km
+// around each point.
+func NewTrafficClient(apiKey string, opts ...ClientOption) *TrafficClient {
+	return &TrafficClient{
+		baseURL:  "https://data.traffic.hereapi.com/v7",
+		apiKey:   apiKey,
+		radiusKM: 5,
+		httpCli:  applyOptions(NewHTTPClient(envTimeout("HERE_TIMEOUT", 15*time.Second)), opts),
+	}
+}
+
+// GetTrafficFlow returns the traffic flow summary around lat/lon.
+func (c *TrafficClient) GetTrafficFlow(lat, lon float64) (*TrafficSummary, error) {
+	return c.GetTrafficFlowContext(context.Background(), lat, lon)
+}
+
+// GetTrafficFlowContext is GetTrafficFlow with a caller-supplied context.
+func (c *TrafficClient) GetTrafficFlowContext(ctx context.Context, lat, lon float64) (*TrafficSummary, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("HERE API key required")
+	}
+
+	q := url.Values{}
+	q.Set("in", bboxAround(lat, lon, c.radiusKM))
+	q.Set("locationReferencing", "none")
+	q.Set("apiKey", c.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/flow?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build HERE request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch HERE traffic flow: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readStatusError(resp)
+	}
+
+	var payload hereFlowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode HERE traffic flow: %w", err)
+	}
+	return summarizeTrafficFlow(&payload)
+}
+
+// summarizeTrafficFlow length-weights the per-segment flow. Segments without
+// a length count as 1 m so they still contribute.
+func summarizeTrafficFlow(payload *hereFlowResponse) (*TrafficSummary, error) {
+	if len(payload.Results) == 0 {
+		return nil, fmt.Errorf("no traffic flow segments returned")
+	}
+
+	var speed, freeFlow, jam, total float64
+	for _, r := range payload.Results {
+		w := r.Location.Length
+		if w <= 0 {
+			w = 1
+		}
+		speed += r.CurrentFlow.Speed * w
+		freeFlow += r.CurrentFlow.FreeFlow * w
+		jam += r.CurrentFlow.JamFactor * w
+		total += w
+	}
+
+	// sourceUpdated is informational; a missing or odd value is not fatal.
+	observed, _ := time.Parse(time.RFC3339, payload.SourceUpdated)
+
+	const msToKmH = 3.6
+	return &TrafficSummary{
+		AvgSpeedKmH:      speed / total * msToKmH,
+		FreeFlowSpeedKmH: freeFlow / total * msToKmH,
+		JamFactor:        jam / total,
+		Segments:         len(payload.Results),
+		ObservedAt:       observed,
+	}, nil
+}
+
+// bboxAround formats a HERE "bbox:west,south,east,north" box extending
+// radiusKM from lat/lon in each direction.
+func bboxAround(lat, lon, radiusKM float64) string {
+	south, west, north, east := boundingBox(lat, lon, radiusKM)
+	return fmt.Sprintf("bbox:%.5f,%.5f,%.5f,%.5f", west, south, east, north)
+}
+
+// boundingBox returns the box extending radiusKM from lat/lon in each
+// direction, clamped to valid coordinates.
+func boundingBox(lat, lon, radiusKM float64) (south, west, north, east float64) {
+	const kmPerDegree = 111.32
+	dLat := radiusKM / kmPerDegree
+	dLon := radiusKM / (kmPerDegree * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	return math.Max(lat-dLat, -90), math.Max(lon-dLon, -180),
+		math.Min(lat+dLat, 90), math.Min(lon+dLon, 180)
+}
@@ -0,0 +1,59 @@
+package clients
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrafficGetTrafficFlow(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/flow": "here_flow.json"})
+	c := NewTrafficClient("test-key")
+	c.baseURL = srv.URL
+
+	got, err := c.GetTrafficFlow(39.7392, -104.9903)
+	if err != nil {
+		t.Fatalf("GetTrafficFlow: %v", err)
+	}
+	// Length-weighted: (10·1000 + 20·3000) / 4000 m/s, and so on.
+	want := TrafficSummary{
+		AvgSpeedKmH:      63,
+		FreeFlowSpeedKmH: 81,
+		JamFactor:        1.75,
+		Segments:         2,
+		ObservedAt:       time.Date(2026, 10, 17, 15, 4, 12, 0, time.UTC),
+	}
+	if math.Abs(got.AvgSpeedKmH-want.AvgSpeedKmH) > 1e-9 || math.Abs(got.FreeFlowSpeedKmH-want.FreeFlowSpeedKmH) > 1e-9 ||
+		got.JamFactor != want.JamFactor || got.Segments != want.Segments || !got.ObservedAt.Equal(want.ObservedAt) {
+		t.Errorf("GetTrafficFlow = %+v, want %+v", *got, want)
+	}
+
+	q := srv.lastQuery()
+	if q.Get("apiKey") != "test-key" || !strings.HasPrefix(q.Get("in"), "bbox:-105.0") {
+		t.Errorf("query = %v, want the API key and a bbox around the point", q)
+	}
+}
+
+func TestTrafficGetTrafficFlowErrors(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/flow": "here_flow_empty.json"})
+	c := NewTrafficClient("test-key")
+	c.baseURL = srv.URL
+	if _, err := c.GetTrafficFlow(39.7392, -104.9903); err == nil {
+		t.Error("no segments: want an error")
+	}
+
+	if _, err := NewTrafficClient("").GetTrafficFlow(39.7392, -104.9903); err == nil {
+		t.Error("missing API key: want an error")
+	}
+}
+
+func TestBBoxAround(t *testing.T) {
+	got := bboxAround(0, 0, 111.32)
+	if got != "bbox:-1.00000,-1.00000,1.00000,1.00000" {
+		t.Errorf("bboxAround(0, 0, 111.32) = %s", got)
+	}
+	if got := bboxAround(89.9, 179.9, 50); !strings.HasSuffix(got, ",180.00000,90.00000") {
+		t.Errorf("bboxAround near the pole = %s, want clamped to 180,90", got)
+	}
+}
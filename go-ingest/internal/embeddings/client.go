@@ -2,24 +2,200 @@ package embeddings
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 )
 
+var (
+	// ErrSidecarUnavailable means the sidecar could not be reached at all
+	// (connection refused, DNS failure, timeout).
+	ErrSidecarUnavailable = errors.New("embedding sidecar unavailable")
+
+	// ErrEmbedFailed means the sidecar answered with a non-200 status.
+	ErrEmbedFailed = errors.New("embedding request failed")
+)
+
+// Defaults for retrying transient sidecar failures.
+const (
+	defaultRetries    = 3
+	defaultBackoff    = 500 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+	readyPollInterval = time.Second
+)
+
+// defaultTimeout bounds one request for a single text; EMBEDDING_TIMEOUT or
+// WithTimeout overrides it.
+const defaultTimeout = 10 * time.Second
+
+// batchTextsPerTimeout is how many texts an EmbedBatch request may take per
+// timeout: a batch of up to this many gets one timeout, each further group
+// another.
+const batchTextsPerTimeout = 16
+
 // Client talks to the Python embedding sidecar.
 type Client struct {
 	endpoint string
 	httpCli  *http.Client
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithRetries sets how many times a request is retried after a connection
+// failure or a 429/5xx response, and the initial backoff, which doubles per
+// attempt (capped at 5s). Zero retries disables retrying.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
 }
 
-// NewClient creates a new embeddings client.
-func NewClient(endpoint string) *Client {
-	return &Client{
+// WithTimeout sets how long one request for a single text may take;
+// EmbedBatch scales it with the batch size. It replaces EMBEDDING_TIMEOUT.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// NewClient creates a new embeddings client. The per-request timeout is
+// EMBEDDING_TIMEOUT, a Go duration ("30s") or whole seconds, defaulting to
+// 10s.
+func NewClient(endpoint string, opts ...Option) *Client {
+	c := &Client{
 		endpoint: endpoint,
-		httpCli:  &http.Client{Timeout: 10 * time.Second},
+		httpCli:  &http.Client{},
+		timeout:  envTimeout("EMBEDDING_TIMEOUT", defaultTimeout),
+		retries:  defaultRetries,
+		backoff:  defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// statusError is a non-200 sidecar response. It unwraps to ErrEmbedFailed.
+type statusError struct {
+	path string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned %d", e.path, e.code)
+}
+
+func (e *statusError) Unwrap() error { return ErrEmbedFailed }
+
+// retryable reports whether err is worth another attempt.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	return errors.Is(err, ErrSidecarUnavailable)
+}
+
+// envTimeout reads a timeout from the environment variable key, accepting a
+// Go duration or whole seconds, and falls back to def when it is unset or
+// invalid.
+func envTimeout(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d
+	}
+	if n, err := strconv.Atoi(v); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return def
+}
+
+// batchTimeout is the per-attempt timeout for embedding n texts at once.
+func (c *Client) batchTimeout(n int) time.Duration {
+	groups := (n + batchTextsPerTimeout - 1) / batchTextsPerTimeout
+	return c.timeout * time.Duration(max(groups, 1))
+}
+
+// postJSON posts payload to path and decodes the 200 response into out,
+// retrying transient failures with exponential backoff. Each attempt may
+// take up to timeout.
+func (c *Client) postJSON(ctx context.Context, path string, payload, out interface{}, timeout time.Duration) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s request: %w", path, err)
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err = c.postOnce(ctx, path, body, out, timeout)
+		if err == nil || !retryable(err) || attempt >= c.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (c *Client) postOnce(ctx context.Context, path string, body []byte, out interface{}, timeout time.Duration) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return c.callError(ctx, attemptCtx, path, timeout, err)
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{path: path, code: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		if attemptCtx.Err() != nil {
+			return c.callError(ctx, attemptCtx, path, timeout, err)
+		}
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// callError classifies a failed call: the caller's own cancellation is
+// returned as is, and running out the per-request timeout or failing to
+// connect is ErrSidecarUnavailable.
+func (c *Client) callError(ctx, attemptCtx context.Context, path string, timeout time.Duration, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("call %s: %w: no response within %s", path, ErrSidecarUnavailable, timeout)
+	}
+	return fmt.Errorf("call %s: %w: %v", path, ErrSidecarUnavailable, err)
 }
 
 // EmbedRequest represents the payload to the sidecar.
@@ -34,26 +210,106 @@ type EmbedResponse struct {
 
 // Embed sends text to the sidecar and returns the vector.
 func (c *Client) Embed(text string) ([]float64, error) {
-	body, _ := json.Marshal(EmbedRequest{Text: text})
-	req, err := http.NewRequest("POST", c.endpoint+"/embed", bytes.NewReader(body))
+	return c.EmbedContext(context.Background(), text)
+}
+
+// EmbedContext is Embed with a caller-supplied context.
+func (c *Client) EmbedContext(ctx context.Context, text string) ([]float64, error) {
+	var er EmbedResponse
+	if err := c.postJSON(ctx, "/embed", EmbedRequest{Text: text}, &er, c.timeout); err != nil {
+		return nil, err
+	}
+	return er.Embedding, nil
+}
+
+// Ping checks that the sidecar is up via its GET /health route.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/health", nil)
 	if err != nil {
-		return nil, fmt.Errorf("build embed request: %w", err)
+		return fmt.Errorf("build health request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpCli.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("call embed: %w", err)
+		return fmt.Errorf("call health: %w: %v", ErrSidecarUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("embed endpoint returned %d", resp.StatusCode)
+		return &statusError{path: "/health", code: resp.StatusCode}
 	}
+	return nil
+}
 
-	var er EmbedResponse
-	if err := json.NewDecoder(resp.Body).Decode(&er); err != nil {
-		return nil, fmt.Errorf("decode embed response: %w", err)
+// Ready blocks until Ping succeeds or ctx is done, for callers that want to
+// wait out sidecar start-up before the first embed. It returns the last
+// Ping error when ctx expires first.
+func (c *Client) Ready(ctx context.Context) error {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		err := c.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
 	}
-	return er.Embedding, nil
+}
+
+// EmbedBatchRequest is the payload for the sidecar batch route.
+type EmbedBatchRequest struct {
+	Texts []string `json:"texts"`
+}
+
+// EmbedBatchResponse is the sidecar batch response.
+type EmbedBatchResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// EmbedBatch embeds several texts in one round trip via /embed_batch. The
+// request may take one timeout per batchTextsPerTimeout texts. Older
+// sidecars without the batch route (404) are handled by looping Embed.
+func (c *Client) EmbedBatch(texts []string) ([][]float64, error) {
+	return c.EmbedBatchContext(context.Background(), texts)
+}
+
+// EmbedBatchContext is EmbedBatch with a caller-supplied context.
+func (c *Client) EmbedBatchContext(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	var br EmbedBatchResponse
+	err := c.postJSON(ctx, "/embed_batch", EmbedBatchRequest{Texts: texts}, &br, c.batchTimeout(len(texts)))
+	var se *statusError
+	if errors.As(err, &se) && se.code == http.StatusNotFound {
+		return c.embedEach(ctx, texts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(br.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embed batch returned %d vectors for %d texts", len(br.Embeddings), len(texts))
+	}
+	return br.Embeddings, nil
+}
+
+// embedEach is the one-request-per-text fallback for EmbedBatch.
+func (c *Client) embedEach(ctx context.Context, texts []string) ([][]float64, error) {
+	out := make([][]float64, 0, len(texts))
+	for i, t := range texts {
+		vec, err := c.EmbedContext(ctx, t)
+		if err != nil {
+			return nil, fmt.Errorf("embed text %d: %w", i, err)
+		}
+		out = append(out, vec)
+	}
+	return out, nil
 }
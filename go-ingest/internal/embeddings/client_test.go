@@ -0,0 +1,202 @@
+package embeddings
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEmbedBatch(t *testing.T) {
+	var batchCalls, singleCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/embed_batch":
+			batchCalls.Add(1)
+			var req EmbedBatchRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decode batch request: %v", err)
+			}
+			var resp EmbedBatchResponse
+			for _, text := range req.Texts {
+				resp.Embeddings = append(resp.Embeddings, []float64{float64(len(text))})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/embed":
+			singleCalls.Add(1)
+			http.Error(w, "unexpected", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got, err := NewClient(srv.URL, WithRetries(0, 0)).EmbedBatch([]string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("EmbedBatch: %v", err)
+	}
+	want := [][]float64{{1}, {2}, {3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EmbedBatch = %v, want %v", got, want)
+	}
+	if n := batchCalls.Load(); n != 1 {
+		t.Errorf("batch route called %d times, want 1", n)
+	}
+	if n := singleCalls.Load(); n != 0 {
+		t.Errorf("single route called %d times, want 0", n)
+	}
+}
+
+func TestEmbedBatchFallsBackOn404(t *testing.T) {
+	var singleCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/embed":
+			singleCalls.Add(1)
+			var req EmbedRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			json.NewEncoder(w).Encode(EmbedResponse{Embedding: []float64{float64(len(req.Text))}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got, err := NewClient(srv.URL, WithRetries(0, 0)).EmbedBatch([]string{"a", "bb"})
+	if err != nil {
+		t.Fatalf("EmbedBatch: %v", err)
+	}
+	want := [][]float64{{1}, {2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EmbedBatch = %v, want %v", got, want)
+	}
+	if n := singleCalls.Load(); n != 2 {
+		t.Errorf("single route called %d times, want 2", n)
+	}
+}
+
+func TestEmbedRetriesUntilSidecarReady(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "model loading", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(EmbedResponse{Embedding: []float64{0.5, 0.5}})
+	}))
+	defer srv.Close()
+
+	got, err := NewClient(srv.URL, WithRetries(2, time.Millisecond)).Embed("warming up")
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []float64{0.5, 0.5}) || calls.Load() != 2 {
+		t.Errorf("Embed = %v after %d calls, want [0.5 0.5] after 2", got, calls.Load())
+	}
+}
+
+func TestEmbedErrorTypes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad input", http.StatusBadRequest)
+	}))
+	_, err := NewClient(srv.URL, WithRetries(2, time.Millisecond)).Embed("x")
+	srv.Close()
+	if !errors.Is(err, ErrEmbedFailed) || errors.Is(err, ErrSidecarUnavailable) {
+		t.Errorf("HTTP 400 error = %v, want ErrEmbedFailed", err)
+	}
+
+	// The server is closed now, so the connection is refused.
+	_, err = NewClient(srv.URL, WithRetries(0, 0)).Embed("x")
+	if !errors.Is(err, ErrSidecarUnavailable) {
+		t.Errorf("connection error = %v, want ErrSidecarUnavailable", err)
+	}
+}
+
+// slowSidecar answers /embed and /embed_batch after delay.
+func slowSidecar(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reading the body first lets the server notice the client giving up.
+		var req EmbedBatchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		if r.URL.Path == "/embed_batch" {
+			resp := EmbedBatchResponse{Embeddings: make([][]float64, len(req.Texts))}
+			for i := range resp.Embeddings {
+				resp.Embeddings[i] = []float64{1}
+			}
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+		json.NewEncoder(w).Encode(EmbedResponse{Embedding: []float64{1}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEmbedTimeout(t *testing.T) {
+	slow := slowSidecar(t, 50*time.Millisecond)
+	if _, err := NewClient(slow.URL, WithRetries(0, 0), WithTimeout(time.Second)).Embed("x"); err != nil {
+		t.Errorf("slow response within the timeout: %v", err)
+	}
+
+	tooSlow := slowSidecar(t, time.Second)
+	start := time.Now()
+	_, err := NewClient(tooSlow.URL, WithRetries(0, 0), WithTimeout(50*time.Millisecond)).Embed("x")
+	if !errors.Is(err, ErrSidecarUnavailable) {
+		t.Errorf("response over the timeout: err = %v, want ErrSidecarUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timed-out Embed took %s, want it to give up near the 50ms timeout", elapsed)
+	}
+}
+
+func TestEmbedBatchTimeoutScalesWithSize(t *testing.T) {
+	// Two groups of batchTextsPerTimeout texts get twice the timeout, so a
+	// response slower than one timeout but faster than two succeeds.
+	srv := slowSidecar(t, 150*time.Millisecond)
+	c := NewClient(srv.URL, WithRetries(0, 0), WithTimeout(100*time.Millisecond))
+
+	texts := make([]string, batchTextsPerTimeout+1)
+	if _, err := c.EmbedBatch(texts); err != nil {
+		t.Errorf("EmbedBatch of %d texts: %v", len(texts), err)
+	}
+	if _, err := c.EmbedBatch(texts[:2]); !errors.Is(err, ErrSidecarUnavailable) {
+		t.Errorf("EmbedBatch of 2 texts: err = %v, want ErrSidecarUnavailable", err)
+	}
+}
+
+func TestTimeoutConfig(t *testing.T) {
+	t.Setenv("EMBEDDING_TIMEOUT", "")
+	if got := NewClient("http://sidecar").timeout; got != defaultTimeout {
+		t.Errorf("default timeout = %s, want %s", got, defaultTimeout)
+	}
+	t.Setenv("EMBEDDING_TIMEOUT", "45s")
+	c := NewClient("http://sidecar")
+	if c.timeout != 45*time.Second {
+		t.Errorf("EMBEDDING_TIMEOUT=45s gave %s", c.timeout)
+	}
+	t.Setenv("EMBEDDING_TIMEOUT", "30")
+	if got := NewClient("http://sidecar").timeout; got != 30*time.Second {
+		t.Errorf("EMBEDDING_TIMEOUT=30 gave %s, want whole seconds", got)
+	}
+	if got := NewClient("http://sidecar", WithTimeout(time.Minute)).timeout; got != time.Minute {
+		t.Errorf("WithTimeout(1m) gave %s", got)
+	}
+
+	for n, want := range map[int]time.Duration{0: 45 * time.Second, 16: 45 * time.Second, 17: 90 * time.Second, 64: 180 * time.Second} {
+		if got := c.batchTimeout(n); got != want {
+			t.Errorf("batchTimeout(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
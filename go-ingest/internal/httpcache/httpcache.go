@@ -0,0 +1,145 @@
+// Package httpcache provides a caching http.RoundTripper for read-only API
+// clients whose upstream data changes slowly (weather, market closes,
+// yearly energy releases).
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	Response []byte    `json:"response"` // wire-format response (httputil.DumpResponse)
+	Expires  time.Time `json:"expires"`
+}
+
+// Store persists cache entries by key.
+type Store interface {
+	Get(key string) (Entry, bool)
+	Set(key string, e Entry)
+	Delete(key string)
+}
+
+// Transport serves GET responses from a Store while they are fresh and
+// otherwise forwards to Next. Freshness comes from Cache-Control max-age or
+// Expires when the upstream sends them, else from the per-host TTL, else
+// DefaultTTL. Only 200 responses are cached; no-store/no-cache are honored.
+type Transport struct {
+	Next       http.RoundTripper // defaults to http.DefaultTransport
+	Store      Store
+	DefaultTTL time.Duration
+
+	mu      sync.RWMutex
+	hostTTL map[string]time.Duration
+	now     func() time.Time
+}
+
+// NewTransport creates a caching transport backed by store.
+func NewTransport(store Store, defaultTTL time.Duration) *Transport {
+	return &Transport{
+		Store:      store,
+		DefaultTTL: defaultTTL,
+		hostTTL:    make(map[string]time.Duration),
+		now:        time.Now,
+	}
+}
+
+// SetHostTTL overrides the fallback TTL for one host (e.g. "api.open-meteo.com").
+func (t *Transport) SetHostTTL(host string, ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hostTTL[strings.ToLower(host)] = ttl
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	if e, ok := t.Store.Get(key); ok {
+		if t.now().Before(e.Expires) {
+			if resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(e.Response)), req); err == nil {
+				resp.Header.Set("X-From-Cache", "1")
+				return resp, nil
+			}
+		}
+		t.Store.Delete(key)
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	ttl, cacheable := t.freshness(req.URL.Hostname(), resp.Header)
+	if !cacheable || ttl <= 0 {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Dump a copy so the caller's response is untouched.
+	stored := *resp
+	stored.Body = io.NopCloser(bytes.NewReader(body))
+	dump, err := httputil.DumpResponse(&stored, true)
+	if err == nil {
+		t.Store.Set(key, Entry{Response: dump, Expires: t.now().Add(ttl)})
+	}
+	return resp, nil
+}
+
+// freshness returns how long a response may be cached and whether it may
+// be cached at all.
+func (t *Transport) freshness(host string, h http.Header) (time.Duration, bool) {
+	if cc := h.Get("Cache-Control"); cc != "" {
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			switch {
+			case directive == "no-store" || directive == "no-cache" || directive == "private":
+				return 0, false
+			case strings.HasPrefix(directive, "max-age="):
+				if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+					return time.Duration(secs) * time.Second, true
+				}
+			}
+		}
+	}
+	if exp := h.Get("Expires"); exp != "" {
+		expires, err := http.ParseTime(exp)
+		if err != nil {
+			return 0, false // invalid Expires means already expired
+		}
+		base := t.now()
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			base = date
+		}
+		return expires.Sub(base), true
+	}
+
+	t.mu.RLock()
+	ttl, ok := t.hostTTL[strings.ToLower(host)]
+	t.mu.RUnlock()
+	if ok {
+		return ttl, true
+	}
+	return t.DefaultTTL, true
+}
@@ -0,0 +1,88 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer answers every GET with a body naming the hit number and
+// the given Cache-Control header.
+func countingServer(t *testing.T, cacheControl string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func get(t *testing.T, cli *http.Client, url string) (string, bool) {
+	t.Helper()
+	resp, err := cli.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(body), resp.Header.Get("X-From-Cache") == "1"
+}
+
+func TestTransportServesFreshResponsesFromCache(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"disk": func(t *testing.T) Store {
+			d, err := NewDiskStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewDiskStore: %v", err)
+			}
+			return d
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv, hits := countingServer(t, "")
+			tr := NewTransport(newStore(t), time.Minute)
+			now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+			tr.now = func() time.Time { return now }
+			cli := &http.Client{Transport: tr}
+
+			first, cached := get(t, cli, srv.URL+"/forecast")
+			if cached {
+				t.Error("first response marked as cached")
+			}
+			second, cached := get(t, cli, srv.URL+"/forecast")
+			if !cached || second != first || hits.Load() != 1 {
+				t.Errorf("second request within TTL: body %q cached %v after %d upstream hits; want %q from cache after 1",
+					second, cached, hits.Load(), first)
+			}
+
+			now = now.Add(2 * time.Minute)
+			third, cached := get(t, cli, srv.URL+"/forecast")
+			if cached || third != "response 2" {
+				t.Errorf("request after TTL: body %q cached %v, want a fresh upstream response", third, cached)
+			}
+		})
+	}
+}
+
+func TestTransportHonorsCacheControl(t *testing.T) {
+	srv, hits := countingServer(t, "no-store")
+	cli := &http.Client{Transport: NewTransport(NewMemoryStore(), time.Hour)}
+
+	get(t, cli, srv.URL)
+	if _, cached := get(t, cli, srv.URL); cached || hits.Load() != 2 {
+		t.Errorf("no-store response was cached (%d upstream hits)", hits.Load())
+	}
+}
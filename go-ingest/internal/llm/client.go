@@ -1,11 +1,13 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -42,7 +44,7 @@ type chatRequest struct {
 	Model       string        `json:"model"`
 	Messages    []chatMessage `json:"messages"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
-	Temperature float64       `json:"temperature,omitempty"`
+	Temperature float64       `json:"temperature"`
 }
 
 // chatResponse captures a minimal subset of the response.
@@ -52,18 +54,32 @@ type chatResponse struct {
 	} `json:"choices"`
 }
 
+// ChatOptions tunes a single chat completion.
+type ChatOptions struct {
+	MaxTokens   int
+	Temperature float64
+}
+
+// DefaultTemperature is used by Chat; low values keep answers grounded.
+const DefaultTemperature = 0.2
+
 // Chat sends a system + user prompt and returns the assistant reply.
 func (c *Client) Chat(ctx context.Context, system, user string, maxTokens int) (string, error) {
+	return c.ChatWithOptions(ctx, system, user, ChatOptions{MaxTokens: maxTokens, Temperature: DefaultTemperature})
+}
+
+// ChatWithOptions is Chat with explicit sampling options.
+func (c *Client) ChatWithOptions(ctx context.Context, system, user string, opts ChatOptions) (string, error) {
 	payload := chatRequest{
 		Model: c.model,
 		Messages: []chatMessage{
 			{Role: "system", Content: system},
 			{Role: "user", Content: user},
 		},
-		Temperature: 0.2,
+		Temperature: opts.Temperature,
 	}
-	if maxTokens > 0 {
-		payload.MaxTokens = maxTokens
+	if opts.MaxTokens > 0 {
+		payload.MaxTokens = opts.MaxTokens
 	}
 
 	body, _ := json.Marshal(payload)
@@ -92,3 +108,114 @@ func (c *Client) Chat(ctx context.Context, system, user string, maxTokens int) (
 	}
 	return cr.Choices[0].Message.Content, nil
 }
+
+// Ping checks that the LLM server is reachable by listing models on the
+// OpenAI-compatible /models route next to the chat endpoint. Servers that do
+// not implement it still answer, so any non-5xx response counts as up.
+func (c *Client) Ping(ctx context.Context) error {
+	modelsURL := strings.TrimSuffix(c.endpoint, "/chat/completions") + "/models"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL, nil)
+	if err != nil {
+		return fmt.Errorf("build llm ping request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return fmt.Errorf("call llm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("llm status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// chatStreamChunk is one OpenAI-style streaming chunk.
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// ChatStream is ChatWithOptions with "stream": true. Each content delta is
+// passed to onDelta as it arrives; returning an error from onDelta aborts the
+// stream. The full concatenated reply is returned. Cancelling ctx cancels the
+// upstream request.
+func (c *Client) ChatStream(ctx context.Context, system, user string, opts ChatOptions, onDelta func(string) error) (string, error) {
+	payload := struct {
+		chatRequest
+		Stream bool `json:"stream"`
+	}{
+		chatRequest: chatRequest{
+			Model: c.model,
+			Messages: []chatMessage{
+				{Role: "system", Content: system},
+				{Role: "user", Content: user},
+			},
+			Temperature: opts.Temperature,
+		},
+		Stream: true,
+	}
+	if opts.MaxTokens > 0 {
+		payload.MaxTokens = opts.MaxTokens
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("build llm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The client-wide timeout would cut off long generations; the stream is
+	// bounded by ctx instead.
+	streamCli := *c.httpCli
+	streamCli.Timeout = 0
+
+	resp, err := streamCli.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("call llm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("llm status %d", resp.StatusCode)
+	}
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue // blank separators, comments, event names
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return full.String(), nil
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return full.String(), fmt.Errorf("decode llm stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			full.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return full.String(), err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return full.String(), fmt.Errorf("read llm stream: %w", err)
+	}
+	return full.String(), nil
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// streamServer emits each delta as an OpenAI-style SSE chunk, then [DONE].
+func streamServer(t *testing.T, deltas []string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("request stream = %v (err %v), want true", req.Stream, err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"},\"finish_reason\":null}]}\n\n")
+		for _, d := range deltas {
+			b, _ := json.Marshal(d)
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%s},\"finish_reason\":null}]}\n\n", b)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestChatStream(t *testing.T) {
+	deltas := []string{"PM2.5 is ", "12 µg/m³", ", which is ", "moderate."}
+	srv := streamServer(t, deltas)
+
+	var got []string
+	full, err := NewClient(srv.URL, "test").ChatStream(context.Background(), "system", "user", ChatOptions{}, func(d string) error {
+		got = append(got, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if !reflect.DeepEqual(got, deltas) {
+		t.Errorf("deltas = %q, want %q", got, deltas)
+	}
+	if want := "PM2.5 is 12 µg/m³, which is moderate."; full != want {
+		t.Errorf("full reply = %q, want %q", full, want)
+	}
+}
+
+func TestChatStreamStopsOnCallbackError(t *testing.T) {
+	srv := streamServer(t, []string{"one", "two", "three"})
+	errStop := errors.New("client went away")
+
+	calls := 0
+	full, err := NewClient(srv.URL, "test").ChatStream(context.Background(), "system", "user", ChatOptions{}, func(string) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("err = %v, want the callback's error", err)
+	}
+	if calls != 2 || full != "onetwo" {
+		t.Errorf("got %d calls and reply %q, want 2 and %q", calls, full, "onetwo")
+	}
+}
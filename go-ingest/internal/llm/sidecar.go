@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SidecarClient talks to the embedding sidecar's /query route
+// (embedding_sidecar.py), which runs a local model and takes the system and
+// user prompts directly rather than an OpenAI message list.
+type SidecarClient struct {
+	endpoint string
+	httpCli  *http.Client
+}
+
+// NewSidecarClient constructs a client for the full /query URL, e.g.
+// http://localhost:9000/query.
+func NewSidecarClient(endpoint string) *SidecarClient {
+	return &SidecarClient{
+		endpoint: endpoint,
+		httpCli:  &http.Client{Timeout: 45 * time.Second},
+	}
+}
+
+// sidecarQueryRequest mirrors the sidecar's QueryRequest model.
+type sidecarQueryRequest struct {
+	System      string  `json:"system"`
+	User        string  `json:"user"`
+	MaxTokens   int     `json:"max_tokens,omitempty"`
+	Temperature float64 `json:"temperature"`
+}
+
+// ChatWithOptions sends a system + user prompt and returns the answer.
+func (c *SidecarClient) ChatWithOptions(ctx context.Context, system, user string, opts ChatOptions) (string, error) {
+	body, _ := json.Marshal(sidecarQueryRequest{
+		System:      system,
+		User:        user,
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("build query request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("call query sidecar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("query sidecar status %d", resp.StatusCode)
+	}
+
+	var qr struct {
+		Answer string `json:"answer"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&qr); err != nil {
+		return "", fmt.Errorf("decode query response: %w", err)
+	}
+	return qr.Answer, nil
+}
+
+// Ping checks the sidecar's /health route next to the query endpoint.
+func (c *SidecarClient) Ping(ctx context.Context) error {
+	healthURL := strings.TrimSuffix(c.endpoint, "/query") + "/health"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return fmt.Errorf("build query ping request: %w", err)
+	}
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return fmt.Errorf("call query sidecar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("query sidecar status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSidecarClient(t *testing.T) {
+	var got sidecarQueryRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/query":
+			if r.Method != http.MethodPost {
+				t.Errorf("query method = %s, want POST", r.Method)
+			}
+			json.NewDecoder(r.Body).Decode(&got)
+			w.Write([]byte(`{"answer":"Air quality is good."}`))
+		case "/v2/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewSidecarClient(srv.URL + "/v2/query")
+	answer, err := c.ChatWithOptions(context.Background(), "be brief", "how is the air?", ChatOptions{MaxTokens: 64, Temperature: 0.2})
+	if err != nil {
+		t.Fatalf("ChatWithOptions: %v", err)
+	}
+	if answer != "Air quality is good." {
+		t.Errorf("answer = %q", answer)
+	}
+	want := sidecarQueryRequest{System: "be brief", User: "how is the air?", MaxTokens: 64, Temperature: 0.2}
+	if got != want {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
+
+func TestSidecarClientStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewSidecarClient(srv.URL + "/query")
+	if _, err := c.ChatWithOptions(context.Background(), "", "q", ChatOptions{}); err == nil {
+		t.Error("ChatWithOptions on 503: want an error")
+	}
+	if err := c.Ping(context.Background()); err == nil {
+		t.Error("Ping on 503: want an error")
+	}
+}
@@ -0,0 +1,180 @@
+package locations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+)
+
+// geocodeCandidates is how many search results are considered per name.
+const geocodeCandidates = 10
+
+// ErrUnknownLocation is returned when a name matches no place.
+var ErrUnknownLocation = errors.New("unknown location")
+
+// AmbiguousError is returned when a name matches several places. Qualifying
+// the name with a region or country ("Portland, Oregon") narrows it down.
+type AmbiguousError struct {
+	Name       string
+	Candidates []Location
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("ambiguous location %q; did you mean: %s", e.Name, strings.Join(e.Suggestions(), "; "))
+}
+
+// Suggestions returns the candidates as qualified names that resolve
+// unambiguously when passed back in.
+func (e *AmbiguousError) Suggestions() []string {
+	out := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		out[i] = c.Name
+	}
+	return out
+}
+
+// PlaceSearcher finds places by name. It is satisfied by
+// *clients.GeocodingClient.
+type PlaceSearcher interface {
+	SearchContext(ctx context.Context, name string, count int) ([]clients.GeocodeResult, error)
+}
+
+// Geocoder resolves free-text location names to coordinates. Builtin
+// locations are answered without a network call; other names go to the
+// searcher and successful resolutions are cached for the Geocoder's
+// lifetime. It is safe for concurrent use.
+type Geocoder struct {
+	search PlaceSearcher
+
+	mu    sync.Mutex
+	cache map[string]Location
+}
+
+// NewGeocoder creates a Geocoder backed by search. A nil search limits it to
+// the builtin locations.
+func NewGeocoder(search PlaceSearcher) *Geocoder {
+	return &Geocoder{search: search, cache: make(map[string]Location)}
+}
+
+// Resolve maps name to a Location. A name may be qualified with
+// comma-separated region or country parts ("Springfield, Illinois" or
+// "Paris, FR"); each part must match the candidate's region, country or
+// country code. It returns ErrUnknownLocation when nothing matches and an
+// *AmbiguousError when more than one place does.
+func (g *Geocoder) Resolve(ctx context.Context, name string) (Location, error) {
+	if loc, ok := Lookup(name); ok {
+		return loc, nil
+	}
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return Location{}, fmt.Errorf("empty location name: %w", ErrUnknownLocation)
+	}
+
+	g.mu.Lock()
+	loc, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok {
+		return loc, nil
+	}
+	if g.search == nil {
+		return Location{}, fmt.Errorf("%q: %w", name, ErrUnknownLocation)
+	}
+
+	place, qualifiers := splitQualifiers(name)
+	results, err := g.search.SearchContext(ctx, place, geocodeCandidates)
+	if err != nil {
+		return Location{}, fmt.Errorf("geocode %q: %w", name, err)
+	}
+
+	loc, err = pickCandidate(name, place, qualifiers, results)
+	if err != nil {
+		return Location{}, err
+	}
+
+	g.mu.Lock()
+	g.cache[key] = loc
+	g.mu.Unlock()
+	return loc, nil
+}
+
+// splitQualifiers splits "City, Region, Country" into the place name and the
+// lower-cased qualifier parts.
+func splitQualifiers(name string) (string, []string) {
+	parts := strings.Split(name, ",")
+	place := strings.TrimSpace(parts[0])
+	var qualifiers []string
+	for _, p := range parts[1:] {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			qualifiers = append(qualifiers, p)
+		}
+	}
+	return place, qualifiers
+}
+
+// pickCandidate narrows search results to the single place name refers to.
+// Results whose name matches exactly are preferred over partial matches.
+func pickCandidate(name, place string, qualifiers []string, results []clients.GeocodeResult) (Location, error) {
+	var matches []clients.GeocodeResult
+	for _, r := range results {
+		if matchesQualifiers(r, qualifiers) {
+			matches = append(matches, r)
+		}
+	}
+
+	var exact []clients.GeocodeResult
+	for _, r := range matches {
+		if strings.EqualFold(r.Name, place) {
+			exact = append(exact, r)
+		}
+	}
+	if len(exact) > 0 {
+		matches = exact
+	}
+
+	switch len(matches) {
+	case 0:
+		return Location{}, fmt.Errorf("%q: %w", name, ErrUnknownLocation)
+	case 1:
+		return fromGeocode(matches[0]), nil
+	}
+
+	amb := &AmbiguousError{Name: name}
+	for _, r := range matches {
+		amb.Candidates = append(amb.Candidates, fromGeocode(r))
+	}
+	return Location{}, amb
+}
+
+// matchesQualifiers reports whether every qualifier names r's region,
+// county, country or country code.
+func matchesQualifiers(r clients.GeocodeResult, qualifiers []string) bool {
+	for _, q := range qualifiers {
+		if q != strings.ToLower(r.Admin1) && q != strings.ToLower(r.Admin2) &&
+			q != strings.ToLower(r.Country) && q != strings.ToLower(r.CountryCode) {
+			return false
+		}
+	}
+	return true
+}
+
+// fromGeocode converts a search result to a Location whose Name is qualified
+// with its region and country, so it round-trips through Resolve.
+func fromGeocode(r clients.GeocodeResult) Location {
+	parts := []string{r.Name}
+	if r.Admin1 != "" && r.Admin1 != r.Name {
+		parts = append(parts, r.Admin1)
+	}
+	if r.Country != "" {
+		parts = append(parts, r.Country)
+	}
+	return Location{
+		Name:     strings.Join(parts, ", "),
+		Lat:      r.Latitude,
+		Lon:      r.Longitude,
+		Timezone: r.Timezone,
+	}
+}
@@ -0,0 +1,66 @@
+package locations
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+)
+
+// fakeSearcher returns the same places for every query and counts calls.
+type fakeSearcher struct {
+	results []clients.GeocodeResult
+	calls   int
+}
+
+func (f *fakeSearcher) SearchContext(ctx context.Context, name string, count int) ([]clients.GeocodeResult, error) {
+	f.calls++
+	return f.results, nil
+}
+
+var portlands = []clients.GeocodeResult{
+	{Name: "Portland", Latitude: 45.52, Longitude: -122.68, Timezone: "America/Los_Angeles", Country: "United States", CountryCode: "US", Admin1: "Oregon"},
+	{Name: "Portland", Latitude: 43.66, Longitude: -70.26, Timezone: "America/New_York", Country: "United States", CountryCode: "US", Admin1: "Maine"},
+	{Name: "Portland Heights", Latitude: 45.50, Longitude: -122.70, Timezone: "America/Los_Angeles", Country: "United States", CountryCode: "US", Admin1: "Oregon"},
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	g := NewGeocoder(&fakeSearcher{results: portlands})
+
+	_, err := g.Resolve(context.Background(), "Portland")
+	var amb *AmbiguousError
+	if !errors.As(err, &amb) {
+		t.Fatalf("Resolve err = %v, want *AmbiguousError", err)
+	}
+	want := []string{"Portland, Oregon, United States", "Portland, Maine, United States"}
+	if got := amb.Suggestions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggestions = %q, want %q", got, want)
+	}
+}
+
+func TestResolveQualifiedAndCached(t *testing.T) {
+	search := &fakeSearcher{results: portlands}
+	g := NewGeocoder(search)
+
+	for i := 0; i < 2; i++ {
+		loc, err := g.Resolve(context.Background(), "Portland, Maine")
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		if loc.Name != "Portland, Maine, United States" || loc.Timezone != "America/New_York" || loc.Lat != 43.66 {
+			t.Errorf("Resolve = %+v", loc)
+		}
+	}
+	if search.calls != 1 {
+		t.Errorf("searcher called %d times, want 1 (second lookup cached)", search.calls)
+	}
+}
+
+func TestResolveUnknown(t *testing.T) {
+	g := NewGeocoder(&fakeSearcher{})
+	if _, err := g.Resolve(context.Background(), "Xyzzyville"); !errors.Is(err, ErrUnknownLocation) {
+		t.Errorf("Resolve err = %v, want ErrUnknownLocation", err)
+	}
+}
@@ -0,0 +1,50 @@
+// Package locations holds the known EdgeSight locations and their
+// coordinates, so callers can key on a location name instead of repeating
+// lat/lon constants.
+package locations
+
+import (
+	"sort"
+	"strings"
+)
+
+// Location is a named place EdgeSight can fetch data for.
+type Location struct {
+	Name     string  `json:"name"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	State    string  `json:"state,omitempty"`
+	Timezone string  `json:"timezone,omitempty"`
+	// GridRegion is the EIA balancing authority serving the location (e.g.
+	// CISO, ERCO), used for hourly grid demand.
+	GridRegion string `json:"grid_region,omitempty"`
+}
+
+// builtin are the locations known without any configuration.
+var builtin = []Location{
+	{Name: "Los Angeles", Lat: 34.0549, Lon: -118.2426, State: "CA", Timezone: "America/Los_Angeles", GridRegion: "CISO"},
+	{Name: "New York", Lat: 40.7128, Lon: -74.0060, State: "NY", Timezone: "America/New_York", GridRegion: "NYIS"},
+	{Name: "Chicago", Lat: 41.8781, Lon: -87.6298, State: "IL", Timezone: "America/Chicago", GridRegion: "PJM"},
+	{Name: "Houston", Lat: 29.7604, Lon: -95.3698, State: "TX", Timezone: "America/Chicago", GridRegion: "ERCO"},
+	{Name: "Seattle", Lat: 47.6062, Lon: -122.3321, State: "WA", Timezone: "America/Los_Angeles", GridRegion: "SCL"},
+}
+
+// Lookup finds a builtin location by name, ignoring case and surrounding
+// whitespace.
+func Lookup(name string) (Location, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	for _, loc := range builtin {
+		if strings.ToLower(loc.Name) == key {
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+// All returns the builtin locations sorted by name.
+func All() []Location {
+	out := make([]Location, len(builtin))
+	copy(out, builtin)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"strings"
+)
+
+// FieldDelta is the change in one numeric snapshot field. Percent is relative
+// to the magnitude of From, and nil when From is zero.
+type FieldDelta struct {
+	From    float64  `json:"from"`
+	To      float64  `json:"to"`
+	Delta   float64  `json:"delta"`
+	Percent *float64 `json:"percent"`
+}
+
+// Diff returns the change from s to other for every numeric field, keyed
+// "section.field" by JSON name (e.g. "weather.temperature_c"). Text fields
+// such as symbols are skipped.
+func (s Snapshot) Diff(other Snapshot) map[string]FieldDelta {
+	out := make(map[string]FieldDelta)
+	from, to := reflect.ValueOf(s), reflect.ValueOf(other)
+	t := from.Type()
+	for i := 0; i < t.NumField(); i++ {
+		section := t.Field(i)
+		if section.Type.Kind() != reflect.Struct || section.Type == reflect.TypeOf(s.Timestamp) {
+			continue
+		}
+		prefix := jsonName(section)
+		diffStruct(prefix, from.Field(i), to.Field(i), out)
+	}
+	return out
+}
+
+// diffStruct records deltas for the numeric fields of one snapshot section.
+func diffStruct(prefix string, from, to reflect.Value, out map[string]FieldDelta) {
+	t := from.Type()
+	for i := 0; i < t.NumField(); i++ {
+		a, ok := numericValue(from.Field(i))
+		if !ok {
+			continue
+		}
+		b, _ := numericValue(to.Field(i))
+
+		d := FieldDelta{From: a, To: b, Delta: b - a}
+		if a != 0 {
+			pct := (b - a) / math.Abs(a) * 100
+			d.Percent = &pct
+		}
+		out[prefix+"."+jsonName(t.Field(i))] = d
+	}
+}
+
+// numericValue converts int and float fields to float64.
+func numericValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	}
+	return 0, false
+}
+
+// jsonName returns a field's JSON key, falling back to its Go name.
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Tolerance bounds how far a numeric field may move and still count as
+// unchanged: a field is within tolerance when |delta| <= Absolute or
+// |delta| <= Relative·|from|. The zero Tolerance only accepts exact matches.
+type Tolerance struct {
+	Absolute float64
+	Relative float64
+}
+
+// within reports whether d is small enough to ignore.
+func (t Tolerance) within(d FieldDelta) bool {
+	delta := math.Abs(d.Delta)
+	return delta <= t.Absolute || delta <= t.Relative*math.Abs(d.From)
+}
+
+// Unchanged reports whether other matches s up to tol: every numeric field is
+// within tolerance and every text field (symbols, crop, disaster type) is
+// equal. Location and timestamp are not compared.
+func (s Snapshot) Unchanged(other Snapshot, tol Tolerance) bool {
+	for _, d := range s.Diff(other) {
+		if !tol.within(d) {
+			return false
+		}
+	}
+
+	from, to := reflect.ValueOf(s), reflect.ValueOf(other)
+	t := from.Type()
+	for i := 0; i < t.NumField(); i++ {
+		section := t.Field(i)
+		if section.Type.Kind() != reflect.Struct || section.Type == reflect.TypeOf(s.Timestamp) {
+			continue
+		}
+		a, b := from.Field(i), to.Field(i)
+		for j := 0; j < section.Type.NumField(); j++ {
+			if a.Field(j).Kind() == reflect.String && a.Field(j).String() != b.Field(j).String() {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	from := Snapshot{
+		Timestamp:   time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Location:    "Denver",
+		Weather:     Weather{TemperatureC: 20, Humidity: 40},
+		Environment: Environment{PM25: 8},
+		Mobility:    Mobility{FlightCount: 10},
+		Finance:     Finance{StockPrice: 100, StockSymbol: "SPY", Volume: 1000},
+	}
+	to := from
+	to.Timestamp = from.Timestamp.Add(24 * time.Hour)
+	to.Weather.TemperatureC = 15
+	to.Environment.PM25 = 12
+	to.Environment.Ozone = 30
+	to.Mobility.FlightCount = 15
+	to.Finance.StockSymbol = "QQQ"
+
+	changes := from.Diff(to)
+
+	tests := []struct {
+		key                string
+		from, to, delta    float64
+		percent            float64
+		wantPercentPresent bool
+	}{
+		{"weather.temperature_c", 20, 15, -5, -25, true},
+		{"weather.humidity", 40, 40, 0, 0, true},
+		{"environment.pm25", 8, 12, 4, 50, true},
+		{"environment.ozone", 0, 30, 30, 0, false}, // no baseline to compare against
+		{"mobility.flight_count", 10, 15, 5, 50, true},
+		{"finance.volume", 1000, 1000, 0, 0, true},
+	}
+	for _, tt := range tests {
+		d, ok := changes[tt.key]
+		if !ok {
+			t.Errorf("%s missing from diff", tt.key)
+			continue
+		}
+		if d.From != tt.from || d.To != tt.to || d.Delta != tt.delta {
+			t.Errorf("%s = %v -> %v (delta %v), want %v -> %v (delta %v)", tt.key, d.From, d.To, d.Delta, tt.from, tt.to, tt.delta)
+		}
+		switch {
+		case !tt.wantPercentPresent && d.Percent != nil:
+			t.Errorf("%s percent = %v, want nil", tt.key, *d.Percent)
+		case tt.wantPercentPresent && (d.Percent == nil || *d.Percent != tt.percent):
+			t.Errorf("%s percent = %v, want %v", tt.key, d.Percent, tt.percent)
+		}
+	}
+
+	for _, key := range []string{"finance.stock_symbol", "timestamp", "location", "environment.raw_units", "weather.observed_at"} {
+		if _, ok := changes[key]; ok {
+			t.Errorf("diff includes non-numeric field %s", key)
+		}
+	}
+}
+
+func TestSnapshotDiffPercentUsesMagnitude(t *testing.T) {
+	from := Snapshot{Weather: Weather{TemperatureC: -10}}
+	to := Snapshot{Weather: Weather{TemperatureC: -5}}
+
+	d := from.Diff(to)["weather.temperature_c"]
+	if d.Percent == nil || *d.Percent != 50 {
+		t.Errorf("warming from -10 to -5: percent = %v, want +50", d.Percent)
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "encoding/json"
+
+// SeverityLevel names the scores at or above Min on the 1-5 severity scale.
+type SeverityLevel struct {
+	Min   float64
+	Label string
+}
+
+// SeverityLevels is the score-to-label table shared by FEMA disaster
+// severity and detected events, highest threshold first. Replace it to
+// relabel every response.
+var SeverityLevels = []SeverityLevel{
+	{Min: 5, Label: "Catastrophic"},
+	{Min: 4, Label: "Severe"},
+	{Min: 3, Label: "Major"},
+	{Min: 2, Label: "Moderate"},
+	{Min: 1, Label: "Minor"},
+}
+
+// SeverityLabel returns the label of the highest level whose threshold
+// score reaches, or "" when it is below every level (e.g. 0, no severity).
+func SeverityLabel(score float64) string {
+	for _, l := range SeverityLevels {
+		if score >= l.Min {
+			return l.Label
+		}
+	}
+	return ""
+}
+
+// MarshalJSON adds severity_label, derived from Severity, next to the stored
+// fields.
+func (d Disasters) MarshalJSON() ([]byte, error) {
+	type plain Disasters
+	return json.Marshal(struct {
+		plain
+		SeverityLabel string `json:"severity_label,omitempty"`
+	}{plain(d), SeverityLabel(float64(d.Severity))})
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSeverityLabel(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, ""},
+		{0.99, ""},
+		{1, "Minor"},
+		{2, "Moderate"},
+		{2.5, "Moderate"},
+		{3, "Major"},
+		{4, "Severe"},
+		{5, "Catastrophic"},
+		{7, "Catastrophic"},
+	}
+	for _, tt := range tests {
+		if got := SeverityLabel(tt.score); got != tt.want {
+			t.Errorf("SeverityLabel(%v) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestDisastersJSONIncludesSeverityLabel(t *testing.T) {
+	b, err := json.Marshal(Disasters{Severity: 4})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got struct {
+		Severity      int    `json:"severity"`
+		SeverityLabel string `json:"severity_label"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal %s: %v", b, err)
+	}
+	if got.Severity != 4 || got.SeverityLabel != "Severe" {
+		t.Errorf("JSON = %s, want severity 4 labelled Severe", b)
+	}
+
+	b, _ = json.Marshal(Disasters{})
+	var none map[string]any
+	json.Unmarshal(b, &none)
+	if _, ok := none["severity_label"]; ok {
+		t.Errorf("JSON = %s, want no severity_label without a severity", b)
+	}
+}
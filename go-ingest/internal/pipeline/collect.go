@@ -0,0 +1,672 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/canonicalizer"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// OpenAQ pagination caps for the active-monitor search.
+const (
+	openaqMaxCandidates = 200
+	openaqMaxSensors    = 100
+)
+
+// citybikesMaxDistanceKm is how far a CityBikes network's centre may be
+// from a location for its stations to count toward the location.
+const citybikesMaxDistanceKm = 25.0
+
+// openskyRadiusKm is the half-width of the box OpenSky aircraft are counted
+// in, wide enough to take in a metro area's approach paths.
+const openskyRadiusKm = 50.0
+
+// collection is what one pass gathered: the snapshot plus the extras stored
+// alongside it.
+type collection struct {
+	snap           models.Snapshot
+	report         *sourceReport
+	disasterDetail *clients.FEMASummary // county-level when FEMA_COUNTY_FIPS is set
+	femaCounty     string               // cleared when the county lookup failed
+	watchlist      []sourcedQuote       // symbols after the first, archived to the raw table
+}
+
+// pass is the state of one collect call that source runners share.
+type pass struct {
+	p      *Pipeline
+	target locations.Location
+	report *sourceReport
+	in     canonicalizer.Inputs
+	c      *collection
+}
+
+// collect runs every enabled source for target and builds the snapshot.
+// Source failures are logged and recorded in the report.
+func (p *Pipeline) collect(ctx context.Context, target locations.Location) *collection {
+	report := &sourceReport{}
+	r := &pass{
+		p:      p,
+		target: target,
+		report: report,
+		c:      &collection{report: report, femaCounty: p.cfg.femaCounty},
+	}
+	for _, s := range p.sources {
+		if ctx.Err() != nil {
+			break
+		}
+		s.run(ctx, r)
+	}
+
+	// Build unified snapshot from all sources
+	r.c.snap = canonicalizer.BuildSnapshot(target.Name, r.in, r.p.cfg.snapshotOptions())
+	return r.c
+}
+
+func runOpenAQ(ctx context.Context, r *pass) {
+	if r.p.cfg.openaqKey == "" {
+		log.Printf("skipping OpenAQ: set OPENAQ_API_KEY to enable call")
+		r.report.skip("openaq", "OPENAQ_API_KEY not set")
+		return
+	}
+	openaq, target := r.p.openaq, r.target
+
+	// 1. USE COORDINATES INSTEAD OF CITY
+	// Radius: 10000 meters (10km); follow pages so dense cities don't hide
+	// the one active monitor past the first page
+	locations, err := openaq.GetAllLocationsByCoordinatesContext(ctx, target.Lat, target.Lon, 10000, openaqMaxCandidates)
+	if err != nil {
+		log.Printf("OpenAQ error: %v", err)
+		r.report.fail("openaq", err)
+		return
+	}
+	if len(locations.Results) == 0 {
+		log.Printf("OpenAQ: No locations found at these coordinates.")
+		r.report.fail("openaq", fmt.Errorf("no locations found at coordinates"))
+		return
+	}
+
+	// 2. Pick the most recently updated ACTIVE location
+	bestLoc := clients.SelectActiveLocation(locations.Results, r.p.cfg.openaqFreshness)
+	if bestLoc == nil {
+		log.Printf("No active sensors found nearby (checked %d candidates, window %s)", len(locations.Results), r.p.cfg.openaqFreshness)
+		r.report.fail("openaq", fmt.Errorf("no active sensors among %d candidates", len(locations.Results)))
+		return
+	}
+	log.Printf("Found ACTIVE location: %s (Last updated: %s)", bestLoc.Name, bestLoc.DatetimeLast.Local)
+
+	sensors, err := openaq.GetAllSensorsByLocationIDContext(ctx, bestLoc.ID, openaqMaxSensors)
+	if err != nil {
+		log.Printf("Error fetching sensors: %v", err)
+		r.report.fail("openaq", err)
+		return
+	}
+	r.in.Sensors = sensors
+	r.report.ok("openaq")
+	log.Printf("Measurements for %s:", bestLoc.Name)
+
+	for _, s := range sensors.Results {
+		// Skip sensors that have no recent data
+		if s.Latest.Datetime.Local == "" {
+			continue
+		}
+
+		// Now you have access to the Units directly!
+		// s.Parameter.DisplayName handles "PM2.5", "Ozone", etc.
+		// s.Parameter.Units handles "µg/m³", "ppm", etc.
+
+		name := s.Parameter.DisplayName
+		if name == "" {
+			name = s.Parameter.Name
+		} // Fallback
+
+		log.Printf("  - %s: %.2f %s (at %s)",
+			name,
+			s.Latest.Value,
+			s.Parameter.Units,
+			s.Latest.Datetime.Local,
+		)
+	}
+}
+
+// runOpenMeteoAQ fetches modeled air quality; it fills in pollutants OpenAQ
+// had no reading for.
+func runOpenMeteoAQ(ctx context.Context, r *pass) {
+	if aq, err := r.p.meteo.GetAirQualityContext(ctx, r.target.Lat, r.target.Lon); err != nil {
+		log.Printf("OpenMeteo air quality error: %v", err)
+		r.report.fail("openmeteo_aq", err)
+	} else {
+		r.report.ok("openmeteo_aq")
+		r.in.AirQuality = aq
+	}
+}
+
+// runAlphaVantage fetches the watchlist: the first symbol goes into the
+// snapshot, the rest are archived to the raw table after it is stored.
+func runAlphaVantage(ctx context.Context, r *pass) {
+	if r.p.cfg.alphaKey == "" {
+		log.Printf("skipping AlphaVantage: set ALPHAVANTAGE_API_KEY to enable call")
+		r.report.skip("alphavantage", "ALPHAVANTAGE_API_KEY not set")
+		return
+	}
+	symbols := r.p.cfg.stockSymbols
+	quotes, err := r.p.alpha.GetGlobalQuotesContext(ctx, symbols)
+	sources := make(map[string]string, len(symbols))
+	for sym := range quotes {
+		sources[sym] = "alphavantage"
+	}
+	if errors.Is(err, clients.ErrRateLimited) {
+		// Out of quota: Stooq fills in whatever Alpha Vantage did not return
+		log.Printf("AlphaVantage rate limited: %v", err)
+		r.report.fail("alphavantage", err)
+		for _, sym := range stooqWatchlist(ctx, r.p.stooq, symbols, quotes, r.report) {
+			sources[sym] = "stooq"
+		}
+	} else if err != nil {
+		log.Printf("AlphaVantage watchlist error: %v", err)
+		if _, ok := quotes[symbols[0]]; !ok {
+			r.report.fail("alphavantage", err)
+		}
+	}
+
+	if quote, ok := quotes[symbols[0]]; ok {
+		if sources[symbols[0]] == "alphavantage" {
+			r.report.ok("alphavantage")
+		}
+		r.in.Stock = &quote
+		log.Printf("%s %s price %.2f (open %.2f, high %.2f, low %.2f)", sources[symbols[0]], quote.Symbol, quote.Price, quote.Open, quote.High, quote.Low)
+	} else if errors.Is(err, clients.ErrRateLimited) && r.p.store != nil {
+		// Neither source answered: carry the last stored price forward
+		// rather than recording a zero.
+		if prev, at, err := r.p.store.GetLatestMetricValueContext(ctx, "stock_price", r.target.Name); err == nil {
+			r.in.Stock = &clients.GlobalQuote{Symbol: symbols[0], Price: prev, LatestTradingDay: at}
+			log.Printf("AlphaVantage: keeping previous price %.2f from %s", prev, at.Format(time.RFC3339))
+		}
+	}
+	for _, sym := range symbols[1:] {
+		if quote, ok := quotes[sym]; ok {
+			r.c.watchlist = append(r.c.watchlist, sourcedQuote{Source: sources[sym], Quote: quote})
+		}
+	}
+}
+
+// runCommodity fetches one AlphaVantage series named by COMMODITY_SYMBOL
+// (e.g. WTI).
+func runCommodity(ctx context.Context, r *pass) {
+	commoditySymbol := r.p.cfg.commoditySymbol
+	if commoditySymbol == "" || r.p.cfg.alphaKey == "" {
+		log.Printf("skipping commodity: set COMMODITY_SYMBOL and ALPHAVANTAGE_API_KEY to enable call")
+		r.report.skip("commodity", "COMMODITY_SYMBOL or ALPHAVANTAGE_API_KEY not set")
+	} else if price, err := r.p.alpha.GetCommodityPriceContext(ctx, commoditySymbol); err != nil {
+		log.Printf("Commodity %s error: %v", commoditySymbol, err)
+		r.report.fail("commodity", err)
+	} else {
+		r.report.ok("commodity")
+		r.in.Commodity = price
+		log.Printf("Commodity %s: %.2f %s (%s)", price.Symbol, price.Value, price.Unit, price.Date.Format("2006-01-02"))
+	}
+}
+
+func runOpenMeteo(ctx context.Context, r *pass) {
+	if weather, err := r.p.meteo.GetCurrentWeatherContext(ctx, r.target.Lat, r.target.Lon); err != nil {
+		log.Printf("OpenMeteo error: %v", err)
+		r.report.fail("openmeteo", err)
+	} else {
+		r.report.ok("openmeteo")
+		r.in.Meteo = weather
+		log.Printf("OpenMeteo NYC temp %.1f C wind %.1f m/s humidity %.0f%% precip %.1f mm cloud %.0f%% visibility %.1f km",
+			weather.Current.Temperature2m, weather.Current.WindSpeed10m, weather.Current.RelativeHumidity,
+			weather.Current.Precipitation, weather.Current.CloudCover, weather.Current.Visibility/1000)
+	}
+}
+
+// runFEMA fetches the statewide summary for the snapshot and, when
+// FEMA_COUNTY_FIPS is set, a county summary for the stored detail.
+func runFEMA(ctx context.Context, r *pass) {
+	fema, cfg, c := r.p.fema, r.p.cfg, r.c
+	summary, err := fema.GetStateSummaryContext(ctx, cfg.femaState, cfg.femaLookbackDays)
+	if err != nil {
+		log.Printf("FEMA error: %v", err)
+		r.report.fail("fema", err)
+		return
+	}
+	r.report.ok("fema")
+	r.in.Disasters = summary
+	c.disasterDetail = summary
+	log.Printf("FEMA %s: %d active (%s), %d counties", cfg.femaState, summary.ActiveDisasters, summary.TopIncidentType, summary.AffectedCounties)
+
+	if c.femaCounty != "" {
+		if county, err := fema.GetCountySummaryContext(ctx, cfg.femaState, c.femaCounty, cfg.femaLookbackDays); err != nil {
+			log.Printf("FEMA county %s error, keeping statewide detail: %v", c.femaCounty, err)
+			c.femaCounty = ""
+		} else {
+			c.disasterDetail = county
+			log.Printf("FEMA %s county %s: %d active (%s)", cfg.femaState, c.femaCounty, county.ActiveDisasters, county.TopIncidentType)
+		}
+	}
+}
+
+// runFluView fetches state-level ILINet for registry locations; geocoded
+// places carry no state and get national data.
+func runFluView(ctx context.Context, r *pass) {
+	cdc := r.p.cdc
+	fetchFlu := cdc.GetNationalILIDataContext
+	if r.target.State != "" {
+		fetchFlu = func(ctx context.Context) (*clients.CDCFluSummary, error) {
+			return cdc.GetStateILIDataContext(ctx, r.target.State)
+		}
+	}
+	if fluSummary, err := fetchFlu(ctx); err != nil {
+		log.Printf("CDC FluView error: %v", err)
+		r.report.fail("cdc_fluview", err)
+	} else {
+		r.report.ok("cdc_fluview")
+		r.in.Flu = fluSummary
+		log.Printf("CDC ILI (%s): %.2f%% unweighted ILI, %d cases, %d hospitalizations", fluSummary.Region, fluSummary.UnweightedILI, fluSummary.FluCases, fluSummary.HospitalAdmissions)
+	}
+}
+
+// runNREVSS fetches NREVSS RSV from data.cdc.gov, falling back to
+// NREVSS_CSV_PATH when set, and merges it into the FluView summary.
+func runNREVSS(ctx context.Context, r *pass) {
+	cdc, nrevssCSV := r.p.cdc, r.p.cfg.nrevssCSV
+	rsvSummary, err := cdc.GetNREVSSSummaryContext(ctx)
+	if err != nil && nrevssCSV != "" {
+		log.Printf("NREVSS error: %v; reading %s", err, nrevssCSV)
+		if fromCSV, csvErr := cdc.GetNREVSSSummaryFromCSV(nrevssCSV); csvErr != nil {
+			err = errors.Join(err, csvErr)
+		} else {
+			rsvSummary, err = fromCSV, nil
+		}
+	}
+	if err != nil {
+		log.Printf("NREVSS error: %v", err)
+		r.report.fail("nrevss", err)
+		return
+	}
+	r.report.ok("nrevss")
+	log.Printf("NREVSS RSV: %.2f%% positive, %d detections, %d tests (week ending %s)", rsvSummary.RSVPercentPositive, rsvSummary.RSVDetections, rsvSummary.RSVTests, rsvSummary.WeekEndDate.Format("2006-01-02"))
+	if r.in.Flu == nil {
+		r.in.Flu = rsvSummary
+	} else {
+		r.in.Flu.RSVPercentPositive = rsvSummary.RSVPercentPositive
+		r.in.Flu.RSVDetections = rsvSummary.RSVDetections
+		r.in.Flu.RSVTests = rsvSummary.RSVTests
+	}
+}
+
+// runMQTT reads the MQTT sensors, from the subscriber's cache when Start
+// connected it (non-fatal if the broker is unavailable).
+func runMQTT(ctx context.Context, r *pass) {
+	if r.p.mqtt == nil {
+		return
+	}
+	if m, err := r.p.mqtt.FetchReadings(); err != nil {
+		log.Printf("MQTT error: %v", err)
+		r.report.fail("mqtt", err)
+	} else {
+		r.report.ok("mqtt")
+		r.in.MQTT = m
+		log.Printf("MQTT sensors: temp %.1fC, humidity %.0f%%, PM2.5 %.1f, power %.0f (%s)",
+			m.Temperature, m.Humidity, m.PM25, m.Power, mqttSummary(m))
+	}
+}
+
+func runForecast(ctx context.Context, r *pass) {
+	forecastHours := int(canonicalizer.PrecipForecastWindow / time.Hour)
+	if points, err := r.p.meteo.GetHourlyForecastContext(ctx, r.target.Lat, r.target.Lon, forecastHours); err != nil {
+		log.Printf("OpenMeteo forecast error: %v", err)
+		r.report.fail("openmeteo_forecast", err)
+	} else {
+		r.report.ok("openmeteo_forecast")
+		r.in.Forecast = points
+		log.Printf("OpenMeteo forecast: %.1f mm precipitation over the next %dh", clients.SumPrecipitation(points, canonicalizer.PrecipForecastWindow), forecastHours)
+	}
+}
+
+func runTraffic(ctx context.Context, r *pass) {
+	if r.p.traffic == nil {
+		log.Printf("skipping HERE traffic: set HERE_API_KEY to enable call")
+		r.report.skip("here", "HERE_API_KEY not set")
+	} else if flow, err := r.p.traffic.GetTrafficFlowContext(ctx, r.target.Lat, r.target.Lon); err != nil {
+		log.Printf("HERE traffic error: %v", err)
+		r.report.fail("here", err)
+	} else {
+		r.report.ok("here")
+		r.in.Traffic = flow
+		log.Printf("HERE traffic: %.1f km/h avg (free flow %.1f), jam factor %.1f over %d segments", flow.AvgSpeedKmH, flow.FreeFlowSpeedKmH, flow.JamFactor, flow.Segments)
+	}
+}
+
+func runOpenSky(ctx context.Context, r *pass) {
+	if flights, err := r.p.opensky.GetFlightsInBoxContext(ctx, r.target.Lat, r.target.Lon, openskyRadiusKm); err != nil {
+		log.Printf("OpenSky error: %v", err)
+		r.report.fail("opensky", err)
+	} else {
+		r.report.ok("opensky")
+		r.in.Flights = flights
+		log.Printf("OpenSky: %d aircraft within %.0f km (%d airborne, %.0f m avg altitude)", flights.Aircraft, openskyRadiusKm, flights.Airborne, flights.AvgAltitudeM)
+	}
+}
+
+// runCityBikes totals the stations of the bike-share network nearest the
+// location. The network is looked up once per location and cached.
+func runCityBikes(ctx context.Context, r *pass) {
+	id, ok := r.p.bikeNetworks[r.target.Name]
+	if !ok {
+		network, dist, err := r.p.bikes.FindNearestNetworkContext(ctx, r.target.Lat, r.target.Lon)
+		if err != nil {
+			log.Printf("CityBikes error: %v", err)
+			r.report.fail("citybikes", err)
+			return
+		}
+		if dist <= citybikesMaxDistanceKm {
+			id = network.ID
+			log.Printf("CityBikes: using %s (%s), %.1f km away", network.Name, network.ID, dist)
+		}
+		r.p.bikeNetworks[r.target.Name] = id
+	}
+	if id == "" {
+		r.report.skip("citybikes", fmt.Sprintf("no network within %.0f km", citybikesMaxDistanceKm))
+		return
+	}
+	network, err := r.p.bikes.GetNetworkContext(ctx, id)
+	if err != nil {
+		log.Printf("CityBikes error: %v", err)
+		r.report.fail("citybikes", err)
+		return
+	}
+	r.report.ok("citybikes")
+	r.in.Bikes = network.Summary()
+	log.Printf("CityBikes %s: %d bikes available at %d stations, %.0f%% of docks empty",
+		id, r.in.Bikes.BikesAvailable, r.in.Bikes.Stations, r.in.Bikes.UtilizationPercent)
+}
+
+func runMovebank(ctx context.Context, r *pass) {
+	movebankRadiusKm := r.p.cfg.movebankRadiusKm
+	movebankBox := clients.BoundingBoxAround(r.target.Lat, r.target.Lon, movebankRadiusKm)
+	movement, err := r.p.movebank.GetAnimalsInBoxContext(ctx, movebankBox)
+	if err != nil {
+		log.Printf("Movebank error: %v", err)
+		r.report.fail("movebank", err)
+		return
+	}
+	r.report.ok("movebank")
+	r.in.Movement = movement
+	if movement.PaceAvailable {
+		log.Printf("Movebank within %.0f km: %d species, %d animals tracked, %.1f km/day median migration pace", movebankRadiusKm, movement.ActiveSpecies, movement.TotalAnimalsTracked, movement.AvgMigrationPace)
+	} else {
+		log.Printf("Movebank within %.0f km: %d species, %d animals tracked, no sampled events to measure pace", movebankRadiusKm, movement.ActiveSpecies, movement.TotalAnimalsTracked)
+	}
+}
+
+// runNasdaq fetches the market index: FRED (official) if keyed, with Stooq
+// as the fallback and the default.
+func runNasdaq(ctx context.Context, r *pass) {
+	if fred := r.p.fred; fred != nil {
+		market, err := fred.GetNasdaqCompositeContext(ctx)
+		if err == nil {
+			r.report.ok("fred")
+			r.in.NASDAQ = market
+			log.Printf("FRED NASDAQ: %.2f", market.IndexValue)
+			return
+		}
+		log.Printf("FRED NASDAQ error: %v", err)
+		r.report.fail("fred", err)
+	}
+	if stooqMarket, err := r.p.stooq.GetNasdaqCompositeContext(ctx); err != nil {
+		log.Printf("Stooq NASDAQ error: %v", err)
+		r.report.fail("stooq", err)
+	} else {
+		r.report.ok("stooq")
+		r.in.NASDAQ = stooqMarket
+		log.Printf("Stooq NASDAQ: %.2f, Volume: %d", stooqMarket.IndexValue, stooqMarket.VolumeTraded)
+	}
+}
+
+// runMacro fetches macro indicators, from FRED only.
+func runMacro(ctx context.Context, r *pass) {
+	if r.p.fred == nil {
+		r.report.skip("fred_macro", "FRED_API_KEY not set")
+		return
+	}
+	macro, err := r.p.fred.GetMacroIndicatorsContext(ctx)
+	if err != nil {
+		log.Printf("FRED macro error: %v", err)
+		r.report.fail("fred_macro", err)
+	} else {
+		r.report.ok("fred_macro")
+	}
+	if macro != nil {
+		r.in.Macro = macro
+		log.Printf("FRED macro: CPI %.1f, unemployment %.1f%%, 10y Treasury %.2f%%", macro.CPI, macro.UnemploymentRate, macro.Treasury10Y)
+	}
+}
+
+// runEmber fetches the global generation mix. The offline fallback figures
+// are never stored as measurements.
+func runEmber(ctx context.Context, r *pass) {
+	if summary, err := r.p.ember.GetGlobalAverageContext(ctx); err != nil {
+		log.Printf("Ember error: %v", err)
+		r.report.fail("ember", err)
+	} else if summary.Fallback {
+		log.Printf("Ember CSV unavailable; not using the built-in fallback figures")
+		r.report.fail("ember", fmt.Errorf("Ember CSV unavailable, only offline fallback figures"))
+	} else {
+		r.report.ok("ember")
+		r.in.Ember = summary
+		log.Printf("Ember %s %d: %.1f gCO2/kWh carbon intensity, %.1f%% renewable", summary.Area, summary.Year, summary.CarbonIntensityGCO2KWh, summary.RenewablePercent)
+	}
+}
+
+// runGrid fetches grid load from the GridProvider for the location's
+// grid_region, falling back to the balancing authority's hourly demand from
+// EIA when keyed and no provider is implemented. Synthetic figures are
+// stored only when GRID_SIMULATE asks for them.
+func runGrid(ctx context.Context, r *pass) {
+	grid, eia, region := r.p.grid, r.p.eia, r.target.GridRegion
+	if grid == nil && clients.GridRegionImplemented(region) {
+		grid = clients.NewGridClient(region)
+	}
+	switch {
+	case grid != nil:
+		if status, err := grid.GetGridStatusContext(ctx); err != nil {
+			log.Printf("Grid %s error: %v", grid.Region, err)
+			r.report.fail("grid", err)
+		} else {
+			r.report.ok("grid")
+			r.in.Grid = status
+			log.Printf("Grid Status (%s): %.0f MW load (%.1f%% utilization), %.0f MW renewables, %s",
+				status.Source, status.LoadMW, status.UtilizationPercent, status.RenewablesMW, status.Status)
+		}
+	case eia != nil && region != "":
+		if demand, err := eia.GetHourlyDemandContext(ctx, region); err != nil {
+			log.Printf("EIA %s demand error: %v", region, err)
+			r.report.fail("grid", err)
+		} else {
+			r.report.ok("grid")
+			r.in.Grid = clients.GridStatusFromDemand(demand)
+			log.Printf("EIA %s demand: %.0f MWh at %s (%.1f%% of forecast peak %.0f MWh)",
+				demand.Respondent, demand.DemandMWh, demand.Hour.Format(time.RFC3339), r.in.Grid.UtilizationPercent, demand.PeakMWh)
+		}
+	case region == "":
+		r.report.skip("grid", "location has no grid_region")
+	default:
+		r.report.skip("grid", fmt.Sprintf("no grid provider for %s and EIA_API_KEY not set", region))
+	}
+}
+
+func runEIA(ctx context.Context, r *pass) {
+	if r.p.eia == nil {
+		log.Printf("skipping EIA: set EIA_API_KEY to enable call")
+		r.report.skip("eia", "EIA_API_KEY not set")
+	} else if energySummary, err := r.p.eia.GetEnergySummaryContext(ctx); err != nil {
+		log.Printf("EIA error: %v", err)
+		r.report.fail("eia", err)
+	} else {
+		r.report.ok("eia")
+		r.in.EIA = energySummary
+		log.Printf("EIA: %.0f MWh generation, %.0f MWh hourly demand, $%.4f/kWh retail, $%.2f/MMBtu natural gas, $%.2f/ton coal, $%.2f/bbl WTI",
+			energySummary.ElectricityGenerationMWh, energySummary.TotalDemandMWh, energySummary.RetailPriceKWh,
+			energySummary.NaturalGasPriceMmbtu, energySummary.CoalPriceTon, energySummary.CrudeOilPriceBbl)
+	}
+}
+
+func runNASS(ctx context.Context, r *pass) {
+	if r.p.nass == nil {
+		log.Printf("skipping NASS: set NASS_API_KEY to enable call")
+		r.report.skip("nass", "NASS_API_KEY not set")
+		return
+	}
+	var nassErr *clients.NASSError
+	if cropSummary, err := r.p.nass.GetNationalCropSummaryContext(ctx, "CORN"); errors.As(err, &nassErr) && nassErr.Unauthorized() {
+		log.Printf("NASS unauthorized — check NASS_API_KEY")
+		r.report.fail("nass", err)
+	} else if err != nil {
+		log.Printf("NASS error: %v", err)
+		r.report.fail("nass", err)
+	} else {
+		r.report.ok("nass")
+		r.in.NASS = cropSummary
+		log.Printf("NASS %s: %.0f bushels, %.1f bu/acre yield, $%.2f/bu", cropSummary.CropType, cropSummary.ProductionBushels, cropSummary.YieldPerAcre, cropSummary.PricePerBushel)
+	}
+}
+
+// mqttSummary describes how many devices a reading averages and how old
+// its oldest field is, for logging.
+func mqttSummary(m *clients.MQTTSensorReading) string {
+	devices := 0
+	for _, n := range m.Devices {
+		devices = max(devices, n)
+	}
+	var oldest time.Duration
+	for _, at := range m.ReceivedAt {
+		oldest = max(oldest, time.Since(at))
+	}
+	return fmt.Sprintf("up to %d devices per field, oldest %s", devices, oldest.Round(time.Second))
+}
+
+// disasterReport converts a FEMA summary into the stored detail row for snap.
+func disasterReport(snap models.Snapshot, state, county string, s *clients.FEMASummary) store.DisasterReport {
+	r := store.DisasterReport{
+		Location:              snap.Location,
+		Timestamp:             snap.Timestamp,
+		State:                 strings.ToUpper(state),
+		County:                county,
+		ActiveDisasters:       s.ActiveDisasters,
+		TopIncidentType:       s.TopIncidentType,
+		Severity:              s.Severity,
+		AffectedCounties:      s.AffectedCounties,
+		IncidentTypeCounts:    s.IncidentTypeCounts,
+		DeclarationTypeCounts: s.DeclarationTypeCounts,
+		Recent:                make([]store.DisasterDeclaration, 0, len(s.Recent)),
+	}
+	if !s.LatestIncidentBegin.IsZero() {
+		begin := s.LatestIncidentBegin
+		r.LatestIncidentBegin = &begin
+	}
+	for _, d := range s.Recent {
+		r.Recent = append(r.Recent, store.DisasterDeclaration{
+			DisasterNumber:  d.DisasterNumber,
+			Title:           d.Title,
+			IncidentType:    d.IncidentType,
+			DeclarationType: d.DeclarationType,
+			DeclarationDate: d.DeclarationDate,
+			Open:            d.Open,
+			Counties:        d.Counties,
+		})
+	}
+	return r
+}
+
+// movebankOptions reads MOVEBANK_SAMPLE (how many recently active studies
+// to measure migration pace from) and MOVEBANK_STUDY_IDS (a comma-separated
+// list of studies to use instead).
+func movebankOptions() []clients.MovebankOption {
+	var opts []clients.MovebankOption
+	if n, err := strconv.Atoi(os.Getenv("MOVEBANK_SAMPLE")); err == nil && n > 0 {
+		opts = append(opts, clients.WithMovebankSample(n))
+	}
+	var ids []int64
+	for _, part := range strings.Split(os.Getenv("MOVEBANK_STUDY_IDS"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		} else {
+			log.Printf("Ignoring MOVEBANK_STUDY_IDS entry %q: not a study ID", part)
+		}
+	}
+	if len(ids) > 0 {
+		opts = append(opts, clients.WithMovebankStudies(ids))
+	}
+	return opts
+}
+
+// sourcedQuote is a watchlist quote tagged with the source that supplied it.
+type sourcedQuote struct {
+	Source string
+	Quote  clients.GlobalQuote
+}
+
+// stooqWatchlist fetches from Stooq every symbol missing from quotes, adds
+// them in place, and returns the symbols it filled.
+func stooqWatchlist(ctx context.Context, stooq *clients.StooqClient, symbols []string, quotes map[string]clients.GlobalQuote, report *sourceReport) []string {
+	var filled []string
+	var errs []error
+	for _, sym := range symbols {
+		if _, ok := quotes[sym]; ok {
+			continue
+		}
+		bar, err := stooq.GetQuoteContext(ctx, sym)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		quotes[sym] = clients.GlobalQuote{
+			Symbol:           sym,
+			Open:             bar.Open,
+			High:             bar.High,
+			Low:              bar.Low,
+			Price:            bar.Close,
+			Volume:           bar.Volume,
+			LatestTradingDay: bar.Date,
+		}
+		filled = append(filled, sym)
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("Stooq watchlist error: %v", err)
+		report.fail("stooq_watchlist", err)
+	} else if len(filled) > 0 {
+		report.ok("stooq_watchlist")
+	}
+	return filled
+}
+
+// stockSymbols reads the STOCK_SYMBOLS watchlist (comma-separated, e.g.
+// "IBM,AAPL,MSFT"), defaulting to IBM. The first symbol is the one recorded
+// in the snapshot.
+func stockSymbols() []string {
+	var symbols []string
+	for _, part := range strings.Split(os.Getenv("STOCK_SYMBOLS"), ",") {
+		if sym := strings.ToUpper(strings.TrimSpace(part)); sym != "" && !slices.Contains(symbols, sym) {
+			symbols = append(symbols, sym)
+		}
+	}
+	if len(symbols) == 0 {
+		symbols = []string{"IBM"}
+	}
+	return symbols
+}
@@ -0,0 +1,477 @@
+// Package pipeline runs one ingest pass: it resolves a location, queries
+// every configured source, builds the canonical snapshot and stores it along
+// with its embedding. cmd/ingest runs a pass per invocation and the API
+// server runs one on POST /api/v1/ingest.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/canonicalizer"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/clients"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/embeddings"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/httpcache"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/semantic"
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/store"
+)
+
+// ErrBusy is returned by RunOnce while another run of the same Pipeline is
+// in progress.
+var ErrBusy = errors.New("an ingest run is already in progress")
+
+// Options are the dependencies a Pipeline shares with its caller.
+type Options struct {
+	// Store receives the snapshot, FEMA detail, watchlist quotes and
+	// embedding. Nil runs without persisting anything (dry run).
+	Store *store.SQLiteStore
+
+	// Embed embeds the stored snapshot's summary. Nil skips embedding.
+	Embed *embeddings.Client
+
+	// Summarizer renders the embedded summary. Nil means the default
+	// sections.
+	Summarizer *semantic.Summarizer
+}
+
+// Result is the outcome of one ingest pass.
+type Result struct {
+	Snapshot models.Snapshot `json:"snapshot"`
+	Summary  string          `json:"summary"`
+	Sources  []SourceResult  `json:"sources"`
+	// Stored is false in a dry run, when the snapshot was unchanged within
+	// INGEST_CHANGE_TOLERANCE, or when the insert failed.
+	Stored bool `json:"stored"`
+}
+
+// config holds the per-source settings read from the environment.
+type config struct {
+	openaqKey        string
+	alphaKey         string
+	femaState        string
+	femaCounty       string // e.g. "037"; empty keeps the detail statewide
+	femaLookbackDays int
+	changeTol        *models.Tolerance
+	openaqFreshness  time.Duration
+	aqAggregate      canonicalizer.Aggregator
+	aqStaleAfter     time.Duration // OPENAQ_MAX_AGE; 0 disables the check
+	mqttStaleAfter   time.Duration // MQTT_MAX_AGE; 0 disables the check
+	nrevssCSV        string
+	movebankRadiusKm float64
+	stockSymbols     []string
+	commoditySymbol  string
+}
+
+// Pipeline holds the source clients for ingest passes. It is safe for
+// concurrent use, but runs one pass at a time.
+type Pipeline struct {
+	store      *store.SQLiteStore
+	embed      *embeddings.Client
+	summarizer *semantic.Summarizer
+	cfg        config
+	sources    []source // enabled by EDGESIGHT_SOURCES, in query order
+
+	geocoder *locations.Geocoder
+	openaq   *clients.OpenAQClient
+	alpha    *clients.AlphaVantageClient
+	meteo    *clients.OpenMeteoClient
+	fema     *clients.FEMAClient
+	cdc      *clients.CDCFluViewClient
+	movebank *clients.MovebankClient
+	stooq    *clients.StooqClient
+	fred     *clients.FREDClient // nil without FRED_API_KEY
+	mqtt     *clients.MQTTSensorClient
+	ember    *clients.EmberClient
+	grid     *clients.GridClient // mock, set only with GRID_SIMULATE
+	eia      *clients.EIAClient  // nil without EIA_API_KEY
+	nass     *clients.NASSClient // nil without NASS_API_KEY
+	opensky  *clients.OpenSkyClient
+	traffic  *clients.TrafficClient // nil without HERE_API_KEY
+	bikes    *clients.CityBikesClient
+
+	// bikeNetworks caches the CityBikes network resolved for each location,
+	// "" when none is close enough. Only passes, which hold mu, use it.
+	bikeNetworks map[string]string
+
+	mu sync.Mutex
+}
+
+// New builds a Pipeline whose source clients and settings come from the
+// environment (API keys, FEMA_*, OPENAQ_*, MOVEBANK_*, MQTT_*, STOCK_SYMBOLS, ...).
+// Only the sources named by EDGESIGHT_SOURCES are queried; see selectSources.
+func New(opts Options) (*Pipeline, error) {
+	cfg := config{
+		openaqKey:        os.Getenv("OPENAQ_API_KEY"),
+		alphaKey:         os.Getenv("ALPHAVANTAGE_API_KEY"),
+		femaState:        os.Getenv("FEMA_STATE_CODE"),
+		femaCounty:       os.Getenv("FEMA_COUNTY_FIPS"),
+		femaLookbackDays: 180,
+		openaqFreshness:  24 * time.Hour,
+		nrevssCSV:        os.Getenv("NREVSS_CSV_PATH"),
+		movebankRadiusKm: 500,
+		stockSymbols:     stockSymbols(),
+		commoditySymbol:  os.Getenv("COMMODITY_SYMBOL"),
+		aqStaleAfter:     canonicalizer.DefaultAQStaleAfter,
+		mqttStaleAfter:   canonicalizer.DefaultMQTTStaleAfter,
+	}
+	if cfg.femaState == "" {
+		cfg.femaState = "CA"
+	}
+	if envDays := os.Getenv("FEMA_LOOKBACK_DAYS"); envDays != "" {
+		if days, err := strconv.Atoi(envDays); err == nil && days > 0 {
+			cfg.femaLookbackDays = days
+		}
+	}
+
+	// INGEST_CHANGE_TOLERANCE (a fraction, e.g. 0.01 for 1%) skips storing a
+	// snapshot whose fields all moved less than that since the last one
+	if envTol := os.Getenv("INGEST_CHANGE_TOLERANCE"); envTol != "" {
+		if tol, err := strconv.ParseFloat(envTol, 64); err == nil && tol >= 0 {
+			cfg.changeTol = &models.Tolerance{Relative: tol}
+		} else {
+			log.Printf("Ignoring INGEST_CHANGE_TOLERANCE=%q: want a non-negative fraction", envTol)
+		}
+	}
+
+	if v := os.Getenv("OPENAQ_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.aqStaleAfter = d
+		}
+	}
+	if v := os.Getenv("MQTT_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.mqttStaleAfter = d
+		}
+	}
+	agg, err := canonicalizer.ParseAggregator(os.Getenv("OPENAQ_AGGREGATE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENAQ_AGGREGATE: %w", err)
+	}
+	cfg.aqAggregate = agg
+	// A monitor counts as active when it reported within the last
+	// OPENAQ_FRESHNESS_HOURS (default 24)
+	if v := os.Getenv("OPENAQ_FRESHNESS_HOURS"); v != "" {
+		if h, err := strconv.ParseFloat(v, 64); err == nil && h > 0 {
+			cfg.openaqFreshness = time.Duration(h * float64(time.Hour))
+		}
+	}
+	// Only studies centred within MOVEBANK_RADIUS_KM (default 500) of the
+	// location count toward its Mobility numbers
+	if v, err := strconv.ParseFloat(os.Getenv("MOVEBANK_RADIUS_KM"), 64); err == nil && v > 0 {
+		cfg.movebankRadiusKm = v
+	}
+
+	sources, err := selectSources(os.Getenv(SourcesEnv))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SourcesEnv, err)
+	}
+	enabled := make([]string, len(sources))
+	for i, s := range sources {
+		enabled[i] = s.name
+	}
+	log.Printf("Ingest sources enabled: %s", strings.Join(enabled, ", "))
+
+	// Optional on-disk response cache for slowly changing read-only sources
+	var cacheOpts []clients.ClientOption
+	if cacheDir := os.Getenv("EDGESIGHT_HTTP_CACHE_DIR"); cacheDir != "" {
+		cacheStore, err := httpcache.NewDiskStore(cacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("initialize HTTP cache: %w", err)
+		}
+		ttl := 15 * time.Minute
+		if v := os.Getenv("EDGESIGHT_HTTP_CACHE_TTL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				ttl = d
+			}
+		}
+		cache := httpcache.NewTransport(cacheStore, ttl)
+		cache.Next = clients.SharedTransport()
+		cache.SetHostTTL("api.stlouisfed.org", 6*time.Hour)
+		cacheOpts = append(cacheOpts, clients.WithTransport(cache))
+	}
+
+	summarizer := opts.Summarizer
+	if summarizer == nil {
+		if summarizer, err = semantic.NewSummarizer(nil); err != nil {
+			return nil, err
+		}
+	}
+	p := &Pipeline{
+		store:      opts.Store,
+		embed:      opts.Embed,
+		summarizer: summarizer,
+		cfg:        cfg,
+		sources:    sources,
+		geocoder:   locations.NewGeocoder(clients.NewGeocodingClient(cacheOpts...)),
+		alpha:      clients.NewAlphaVantageClient(cfg.alphaKey),
+		meteo:      clients.NewOpenMeteoClient(cacheOpts...),
+		cdc:        clients.NewCDCFluViewClient(),
+		movebank:   clients.NewMovebankClient(os.Getenv("MOVEBANK_USERNAME"), os.Getenv("MOVEBANK_PASSWORD"), movebankOptions()...),
+		stooq:      clients.NewStooqClient(cacheOpts...),
+		// Ember keeps its own on-disk copy of the yearly CSV (EMBER_CACHE_DIR)
+		ember:   clients.NewEmberClient(),
+		opensky: clients.NewOpenSkyClient(os.Getenv("OPENSKY_USERNAME"), os.Getenv("OPENSKY_PASSWORD")),
+		bikes:   clients.NewCityBikesClient(),
+
+		bikeNetworks: make(map[string]string),
+	}
+
+	// GRID_SIMULATE=true stores synthetic grid figures (Source "mock") for
+	// demos; otherwise each location's grid_region picks its provider
+	if simulate, _ := strconv.ParseBool(os.Getenv("GRID_SIMULATE")); simulate {
+		p.grid = clients.NewMockGridClient("CAISO")
+	}
+
+	var openaqOpts []clients.OpenAQOption
+	if os.Getenv("OPENAQ_DEBUG") != "" {
+		openaqOpts = append(openaqOpts, clients.WithOpenAQDebug(nil))
+	}
+	p.openaq = clients.NewOpenAQClient(cfg.openaqKey, openaqOpts...)
+
+	// FEMA_SOURCE=file reads only the local export; otherwise query OpenFEMA
+	// and fall back to the export when offline
+	femaJSONPath := os.Getenv("FEMA_JSON_PATH")
+	p.fema = clients.NewFEMAClientAPI(femaJSONPath)
+	if os.Getenv("FEMA_SOURCE") == "file" {
+		p.fema = clients.NewFEMAClient(femaJSONPath)
+	}
+
+	if fredKey := os.Getenv("FRED_API_KEY"); fredKey != "" {
+		p.fred = clients.NewFREDClient(fredKey, cacheOpts...)
+	}
+	mqttBroker := os.Getenv("MQTT_BROKER")
+	if mqttBroker == "" {
+		mqttBroker = "tcp://localhost:1883"
+	}
+	mqttOpts, err := mqttOptions(cfg.mqttStaleAfter)
+	if err != nil {
+		return nil, err
+	}
+	p.mqtt = clients.NewMQTTSensorClient(mqttBroker, mqttOpts...)
+	if eiaKey := os.Getenv("EIA_API_KEY"); eiaKey != "" {
+		p.eia = clients.NewEIAClient(eiaKey)
+	}
+	if nassKey := os.Getenv("NASS_API_KEY"); nassKey != "" {
+		p.nass = clients.NewNASSClient(nassKey)
+	}
+	if hereKey := os.Getenv("HERE_API_KEY"); hereKey != "" {
+		p.traffic = clients.NewTrafficClient(hereKey)
+	}
+	return p, nil
+}
+
+// LocationFromEnv returns the location to ingest when none is given:
+// EDGESIGHT_LOCATION, then EDGESIGHT_DEFAULT_LOCATION, then Los Angeles.
+func LocationFromEnv() string {
+	for _, key := range []string{"EDGESIGHT_LOCATION", "EDGESIGHT_DEFAULT_LOCATION"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return "Los Angeles"
+}
+
+// snapshotOptions returns the canonicalizer settings for this pipeline's
+// snapshots.
+func (c config) snapshotOptions() canonicalizer.Options {
+	return canonicalizer.Options{
+		AQAggregate:    c.aqAggregate,
+		AQStaleAfter:   disabledIfZero(c.aqStaleAfter),
+		MQTTStaleAfter: disabledIfZero(c.mqttStaleAfter),
+	}
+}
+
+// disabledIfZero maps an *_MAX_AGE of 0, which disables the check, to the
+// negative value canonicalizer.Options uses for that.
+func disabledIfZero(d time.Duration) time.Duration {
+	if d == 0 {
+		return -1
+	}
+	return d
+}
+
+// mqttOptions reads the MQTT subscriber settings: MQTT_TOPICS, the broker
+// credentials (MQTT_USERNAME, MQTT_PASSWORD), TLS files (MQTT_TLS_CA,
+// MQTT_TLS_CERT, MQTT_TLS_KEY), MQTT_QOS, MQTT_CLEAN_SESSION and
+// MQTT_CLIENT_ID. The default client ID carries the process ID, so the
+// ingest service and the API can subscribe at the same time. Values older
+// than maxAge are dropped from readings; 0 keeps them all.
+func mqttOptions(maxAge time.Duration) ([]clients.MQTTOption, error) {
+	opts := []clients.MQTTOption{
+		clients.WithMQTTMaxAge(maxAge),
+		clients.WithMQTTClientID(fmt.Sprintf("edgesight-ingest-%d", os.Getpid())),
+	}
+	if spec := os.Getenv("MQTT_TOPICS"); spec != "" {
+		topics, err := clients.ParseMQTTTopics(spec)
+		if err != nil {
+			return nil, fmt.Errorf("MQTT_TOPICS: %w", err)
+		}
+		opts = append(opts, clients.WithMQTTTopics(topics))
+	}
+	if id := os.Getenv("MQTT_CLIENT_ID"); id != "" {
+		opts = append(opts, clients.WithMQTTClientID(id))
+	}
+	if user := os.Getenv("MQTT_USERNAME"); user != "" {
+		opts = append(opts, clients.WithMQTTCredentials(user, os.Getenv("MQTT_PASSWORD")))
+	}
+	tlsCfg, err := clients.LoadMQTTTLSConfig(os.Getenv("MQTT_TLS_CA"), os.Getenv("MQTT_TLS_CERT"), os.Getenv("MQTT_TLS_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts = append(opts, clients.WithMQTTTLS(tlsCfg))
+	}
+	if v := os.Getenv("MQTT_QOS"); v != "" {
+		qos, err := strconv.ParseUint(v, 10, 8)
+		if err != nil || qos > 2 {
+			return nil, fmt.Errorf("invalid MQTT_QOS %q: want 0, 1 or 2", v)
+		}
+		opts = append(opts, clients.WithMQTTQoS(byte(qos)))
+	}
+	if v := os.Getenv("MQTT_CLEAN_SESSION"); v != "" {
+		clean, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MQTT_CLEAN_SESSION %q: %w", v, err)
+		}
+		opts = append(opts, clients.WithMQTTCleanSession(clean))
+	}
+	return opts, nil
+}
+
+// Start connects the long-lived clients, currently the MQTT subscriber, for
+// the lifetime of ctx so passes read cached values instead of listening
+// only while they run. A failed start is logged and passes fall back to a
+// short subscription of their own.
+func (p *Pipeline) Start(ctx context.Context) {
+	if p.mqtt == nil || !slices.ContainsFunc(p.sources, func(s source) bool { return s.name == "mqtt" }) {
+		return
+	}
+	if err := p.mqtt.Start(ctx); err != nil {
+		log.Printf("MQTT subscriber not started, falling back to per-pass fetches: %v", err)
+	}
+}
+
+// Close disconnects the clients connected by Start.
+func (p *Pipeline) Close() {
+	if p.mqtt != nil {
+		p.mqtt.Close()
+	}
+}
+
+// RunOnce ingests one snapshot for locationName: it resolves the name,
+// queries every source, and unless the Pipeline has no store, persists the
+// snapshot and its embedding. Source failures are reported in the Result,
+// not returned; the error is for an unresolvable location or a cancelled
+// context. It returns ErrBusy without doing anything while another pass is
+// running.
+func (p *Pipeline) RunOnce(ctx context.Context, locationName string) (*Result, error) {
+	if !p.mu.TryLock() {
+		return nil, ErrBusy
+	}
+	defer p.mu.Unlock()
+
+	target, err := p.geocoder.Resolve(ctx, locationName)
+	if err != nil {
+		return nil, fmt.Errorf("resolve location %q: %w", locationName, err)
+	}
+	log.Printf("Ingesting for %s (%.4f, %.4f)", target.Name, target.Lat, target.Lon)
+
+	c := p.collect(ctx, target)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res := &Result{
+		Snapshot: c.snap,
+		Summary:  p.summarizer.Summarize(c.snap),
+		Sources:  c.report.results,
+	}
+	if p.store != nil {
+		res.Stored = p.persist(ctx, c, res.Summary)
+	}
+	return res, nil
+}
+
+// persist stores the snapshot (unless it matches the previous one within
+// INGEST_CHANGE_TOLERANCE), the FEMA detail and the watchlist quotes, then
+// embeds the summary. Failures are logged; it reports whether the snapshot
+// was inserted.
+func (p *Pipeline) persist(ctx context.Context, c *collection, summary string) bool {
+	db, snap := p.store, c.snap
+
+	inserted := false
+	if p.cfg.changeTol != nil {
+		var err error
+		if inserted, err = db.InsertSnapshotIfChangedContext(ctx, snap, *p.cfg.changeTol); err != nil {
+			log.Printf("Error inserting snapshot: %v", err)
+		} else if !inserted {
+			log.Printf("Snapshot for %s unchanged since the last one, not stored", snap.Location)
+		}
+	} else if err := db.InsertSnapshotContext(ctx, snap); err != nil {
+		log.Printf("Error inserting snapshot: %v", err)
+	} else {
+		inserted = true
+	}
+	if inserted {
+		log.Printf("Snapshot stored in database for %s at %s", snap.Location, snap.Timestamp.Format(time.RFC3339))
+	}
+	if c.disasterDetail != nil {
+		if err := db.InsertDisasterReportContext(ctx, disasterReport(snap, p.cfg.femaState, c.femaCounty, c.disasterDetail)); err != nil {
+			log.Printf("Error storing FEMA detail: %v", err)
+		}
+	}
+	for _, wq := range c.watchlist {
+		quote := wq.Quote
+		raw := models.RawData{
+			Source:    wq.Source,
+			Timestamp: snap.Timestamp,
+			Data: map[string]interface{}{
+				"symbol":             quote.Symbol,
+				"price":              quote.Price,
+				"open":               quote.Open,
+				"high":               quote.High,
+				"low":                quote.Low,
+				"volume":             quote.Volume,
+				"latest_trading_day": quote.LatestTradingDay.Format("2006-01-02"),
+			},
+		}
+		if err := db.InsertRawContext(ctx, raw); err != nil {
+			log.Printf("Error archiving %s quote: %v", quote.Symbol, err)
+		}
+	}
+
+	// Generate and store embedding (best-effort), giving a sidecar that is
+	// still starting up a chance to come ready
+	if p.embed != nil && inserted {
+		readyCtx, cancelReady := context.WithTimeout(ctx, 30*time.Second)
+		readyErr := p.embed.Ready(readyCtx)
+		cancelReady()
+
+		if readyErr != nil {
+			log.Printf("Embedding sidecar not ready, skipping embedding (run backfill later): %v", readyErr)
+		} else if vec, err := p.embed.EmbedContext(ctx, summary); err != nil {
+			log.Printf("Embedding error: %v", err)
+		} else {
+			e := store.SnapshotEmbedding{
+				SnapshotTS: snap.Timestamp.Format(time.RFC3339),
+				Location:   snap.Location,
+				Summary:    summary,
+				Embedding:  vec,
+				CreatedAt:  time.Now().UTC(),
+			}
+			if err := db.InsertEmbeddingContext(ctx, e); err != nil {
+				log.Printf("Insert embedding error: %v", err)
+			}
+		}
+	}
+	return inserted
+}
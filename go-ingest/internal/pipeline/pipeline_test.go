@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/canonicalizer"
+)
+
+func TestRunOnceBusyWhilePassRunning(t *testing.T) {
+	p := &Pipeline{}
+	p.mu.Lock() // a pass in progress
+
+	if _, err := p.RunOnce(context.Background(), "Denver"); !errors.Is(err, ErrBusy) {
+		t.Errorf("RunOnce during a pass = %v, want ErrBusy", err)
+	}
+}
+
+func TestNewKeepsMaxAgesPerPipeline(t *testing.T) {
+	t.Setenv("OPENAQ_MAX_AGE", "6h")
+	t.Setenv("MQTT_MAX_AGE", "0")
+	first, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Setenv("OPENAQ_MAX_AGE", "")
+	t.Setenv("MQTT_MAX_AGE", "2m")
+	second, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// The second pipeline's settings must not leak into the first
+	if got := first.cfg.snapshotOptions(); got.AQStaleAfter != 6*time.Hour || got.MQTTStaleAfter >= 0 {
+		t.Errorf("first = %v / %v, want 6h and MQTT disabled", got.AQStaleAfter, got.MQTTStaleAfter)
+	}
+	if got := second.cfg.snapshotOptions(); got.AQStaleAfter != canonicalizer.DefaultAQStaleAfter || got.MQTTStaleAfter != 2*time.Minute {
+		t.Errorf("second = %v / %v, want the 3h default and 2m", got.AQStaleAfter, got.MQTTStaleAfter)
+	}
+}
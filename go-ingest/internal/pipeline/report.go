@@ -0,0 +1,25 @@
+package pipeline
+
+// SourceResult records how one data source fared during an ingest run.
+type SourceResult struct {
+	Source string `json:"source"`
+	Status string `json:"status"` // "ok", "error" or "skipped"
+	Detail string `json:"detail,omitempty"`
+}
+
+// sourceReport collects per-source outcomes in call order.
+type sourceReport struct {
+	results []SourceResult
+}
+
+func (r *sourceReport) ok(source string) {
+	r.results = append(r.results, SourceResult{Source: source, Status: "ok"})
+}
+
+func (r *sourceReport) fail(source string, err error) {
+	r.results = append(r.results, SourceResult{Source: source, Status: "error", Detail: err.Error()})
+}
+
+func (r *sourceReport) skip(source, reason string) {
+	r.results = append(r.results, SourceResult{Source: source, Status: "skipped", Detail: reason})
+}
@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SourcesEnv names the environment variable that selects which sources an
+// ingest pass queries.
+const SourcesEnv = "EDGESIGHT_SOURCES"
+
+// source is one named step of an ingest pass. Its runner queries the source
+// for the pass's location, records the outcome in the report and stores
+// what it fetched in the pass's inputs.
+type source struct {
+	name string
+	run  func(ctx context.Context, r *pass)
+}
+
+// registry lists every source in the order a pass queries them. Adding a
+// source means writing its runner and listing it here.
+var registry = []source{
+	{"openaq", runOpenAQ},
+	{"openmeteo_aq", runOpenMeteoAQ},
+	{"alphavantage", runAlphaVantage},
+	{"commodity", runCommodity},
+	{"openmeteo", runOpenMeteo},
+	{"fema", runFEMA},
+	{"cdc_fluview", runFluView},
+	{"nrevss", runNREVSS},
+	{"mqtt", runMQTT},
+	{"openmeteo_forecast", runForecast},
+	{"here", runTraffic},
+	{"opensky", runOpenSky},
+	{"citybikes", runCityBikes},
+	{"movebank", runMovebank},
+	{"nasdaq", runNasdaq},
+	{"fred_macro", runMacro},
+	{"ember", runEmber},
+	{"grid", runGrid},
+	{"eia", runEIA},
+	{"nass", runNASS},
+}
+
+// SourceNames returns the names EDGESIGHT_SOURCES accepts, in query order.
+func SourceNames() []string {
+	names := make([]string, len(registry))
+	for i, s := range registry {
+		names[i] = s.name
+	}
+	return names
+}
+
+// selectSources parses an EDGESIGHT_SOURCES value into the enabled subset
+// of registry, in registry order. The value is a comma-separated list of
+// source names, or "all" followed by "-name" entries to leave out; an empty
+// value, or one with only "-name" entries, starts from all sources.
+func selectSources(spec string) ([]source, error) {
+	var include, exclude []string
+	sawAll := false
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		switch {
+		case name == "":
+		case name == "all":
+			sawAll = true
+		case strings.HasPrefix(name, "-"):
+			exclude = append(exclude, strings.TrimSpace(name[1:]))
+		default:
+			include = append(include, name)
+		}
+	}
+	if sawAll && len(include) > 0 {
+		return nil, fmt.Errorf("list sources by name or start from \"all\", not both")
+	}
+	known := SourceNames()
+	for _, name := range slices.Concat(include, exclude) {
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown source %q (valid: %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	var enabled []source
+	for _, s := range registry {
+		if (len(include) == 0 || slices.Contains(include, s.name)) && !slices.Contains(exclude, s.name) {
+			enabled = append(enabled, s)
+		}
+	}
+	return enabled, nil
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/locations"
+)
+
+// fakeRegistry replaces registry for the test with runners that only record
+// that they ran.
+func fakeRegistry(t *testing.T, names ...string) *[]string {
+	t.Helper()
+	var ran []string
+	saved := registry
+	registry = nil
+	for _, name := range names {
+		registry = append(registry, source{name, func(ctx context.Context, r *pass) {
+			ran = append(ran, name)
+			r.report.ok(name)
+		}})
+	}
+	t.Cleanup(func() { registry = saved })
+	return &ran
+}
+
+func TestDisabledSourceNotRun(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"", []string{"openaq", "movebank", "ember"}},
+		{"all,-movebank", []string{"openaq", "ember"}},
+		{"ember, OpenAQ", []string{"openaq", "ember"}},
+		{"-openaq,-ember", []string{"movebank"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ran := fakeRegistry(t, "openaq", "movebank", "ember")
+			t.Setenv(SourcesEnv, tt.spec)
+			p, err := New(Options{})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			c := p.collect(context.Background(), locations.Location{Name: "Denver"})
+			if !slices.Equal(*ran, tt.want) {
+				t.Errorf("%s=%q ran %v, want %v", SourcesEnv, tt.spec, *ran, tt.want)
+			}
+			if len(c.report.results) != len(tt.want) {
+				t.Errorf("report = %+v, want only the enabled sources", c.report.results)
+			}
+		})
+	}
+}
+
+func TestSelectSourcesRejectsUnknownNames(t *testing.T) {
+	for _, spec := range []string{"openaq,weather", "all,-weather", "all,openaq"} {
+		if _, err := selectSources(spec); err == nil {
+			t.Errorf("selectSources(%q) succeeded, want an error", spec)
+		}
+	}
+}
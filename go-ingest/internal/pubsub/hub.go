@@ -0,0 +1,122 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// Hub fans newly inserted snapshots out to in-process subscribers.
+// Each location remembers the timestamps it published most recently, so the
+// same snapshot reported twice (e.g. by an insert hook and a DB watcher) is
+// only delivered once. A snapshot older than ones already published (a
+// backfill or late data) is still delivered.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	recent map[string]*recentSet
+}
+
+// dedupWindow is how many published timestamps each location remembers.
+// Both reports of a snapshot arrive within one watcher poll, far fewer.
+const dedupWindow = 64
+
+// recentSet holds the last dedupWindow timestamps published for a location.
+type recentSet struct {
+	ts   [dedupWindow]time.Time
+	n    int // slots filled
+	next int // slot the next timestamp overwrites
+}
+
+// add records t and reports whether it was not already present.
+func (r *recentSet) add(t time.Time) bool {
+	for _, seen := range r.ts[:r.n] {
+		if seen.Equal(t) {
+			return false
+		}
+	}
+	r.ts[r.next] = t
+	r.next = (r.next + 1) % dedupWindow
+	if r.n < dedupWindow {
+		r.n++
+	}
+	return true
+}
+
+// Subscription receives snapshots for a single location ("" means all).
+type Subscription struct {
+	C        <-chan models.Snapshot
+	ch       chan models.Snapshot
+	hub      *Hub
+	location string
+}
+
+// NewHub creates an empty hub.
+func NewHub() *Hub {
+	return &Hub{
+		subs:   make(map[*Subscription]struct{}),
+		recent: make(map[string]*recentSet),
+	}
+}
+
+// Subscribe registers a subscriber for location. The channel is buffered;
+// a subscriber that falls behind misses snapshots rather than blocking
+// publishers.
+func (h *Hub) Subscribe(location string) *Subscription {
+	ch := make(chan models.Snapshot, 16)
+	sub := &Subscription{C: ch, ch: ch, hub: h, location: location}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Close unregisters the subscription and closes its channel.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.ch)
+	}
+}
+
+// Location returns the location the subscription is filtered on.
+func (s *Subscription) Location() string {
+	return s.location
+}
+
+// Publish delivers snap to every subscriber of its location. A snapshot
+// whose location and timestamp were just published is ignored.
+func (h *Hub) Publish(snap models.Snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	recent := h.recent[snap.Location]
+	if recent == nil {
+		recent = &recentSet{}
+		h.recent[snap.Location] = recent
+	}
+	if !recent.add(snap.Timestamp) {
+		return
+	}
+
+	for sub := range h.subs {
+		if sub.location != "" && sub.location != snap.Location {
+			continue
+		}
+		select {
+		case sub.ch <- snap:
+		default:
+		}
+	}
+}
+
+// SubscriberCount returns the number of active subscriptions.
+func (h *Hub) SubscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
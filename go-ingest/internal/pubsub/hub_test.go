@@ -0,0 +1,90 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// drain returns the snapshots waiting on sub.
+func drain(sub *Subscription) []models.Snapshot {
+	var out []models.Snapshot
+	for {
+		select {
+		case snap := <-sub.C:
+			out = append(out, snap)
+		default:
+			return out
+		}
+	}
+}
+
+func TestHubDeliversRepeatedTimestampOnce(t *testing.T) {
+	h := NewHub()
+	denver, boston, all := h.Subscribe("Denver"), h.Subscribe("Boston"), h.Subscribe("")
+	defer denver.Close()
+	defer boston.Close()
+	defer all.Close()
+
+	ts := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	snap := models.Snapshot{Location: "Denver", Timestamp: ts}
+	h.Publish(snap)
+	// The insert hook and a DB watcher both report the same snapshot.
+	h.Publish(snap)
+	h.Publish(models.Snapshot{Location: "Denver", Timestamp: ts.Add(time.Hour)})
+	// An older snapshot arriving late (a backfill) is still delivered, once.
+	late := models.Snapshot{Location: "Denver", Timestamp: ts.Add(-time.Hour)}
+	h.Publish(late)
+	h.Publish(late)
+
+	got := drain(denver)
+	want := []time.Time{ts, ts.Add(time.Hour), ts.Add(-time.Hour)}
+	if len(got) != len(want) {
+		t.Fatalf("Denver subscriber got %d snapshots %v, want %v once each", len(got), got, want)
+	}
+	for i := range want {
+		if !got[i].Timestamp.Equal(want[i]) {
+			t.Errorf("Denver snapshot %d at %s, want %s", i, got[i].Timestamp, want[i])
+		}
+	}
+	if got := drain(all); len(got) != 3 {
+		t.Errorf("all-locations subscriber got %d snapshots, want 3", len(got))
+	}
+	if got := drain(boston); len(got) != 0 {
+		t.Errorf("Boston subscriber got %d snapshots though Boston never published", len(got))
+	}
+}
+
+func TestHubForgetsOldestBeyondWindow(t *testing.T) {
+	h := NewHub()
+	sub := h.Subscribe("Denver")
+	defer sub.Close()
+
+	base := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	for i := range dedupWindow + 1 {
+		h.Publish(models.Snapshot{Location: "Denver", Timestamp: base.Add(time.Duration(i) * time.Minute)})
+		drain(sub)
+	}
+	// The first timestamp has left the window; the latest has not
+	h.Publish(models.Snapshot{Location: "Denver", Timestamp: base})
+	h.Publish(models.Snapshot{Location: "Denver", Timestamp: base.Add(dedupWindow * time.Minute)})
+	if got := drain(sub); len(got) != 1 || !got[0].Timestamp.Equal(base) {
+		t.Errorf("got %v, want only the forgotten %s again", got, base)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h := NewHub()
+	sub := h.Subscribe("Denver")
+	sub.Close()
+	sub.Close() // idempotent
+
+	if n := h.SubscriberCount(); n != 0 {
+		t.Errorf("SubscriberCount = %d after Close, want 0", n)
+	}
+	if _, ok := <-sub.C; ok {
+		t.Error("channel still open after Close")
+	}
+	h.Publish(models.Snapshot{Location: "Denver", Timestamp: time.Now()}) // must not panic
+}
@@ -2,52 +2,181 @@ package semantic
 
 import (
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
 )
 
+// SectionsEnv names the env var holding the comma-separated summary sections,
+// in order. Unset means DefaultSections.
+const SectionsEnv = "EDGESIGHT_SUMMARY_SECTIONS"
+
+// section renders one part of a summary, returning no phrases when the
+// snapshot has nothing to say about it.
+type section func(snap models.Snapshot) []string
+
+// sections maps each section name to its renderer.
+var sections = map[string]section{
+	"weather":     weatherSection,
+	"air_quality": airQualitySection,
+	"gases":       gasesSection,
+	"traffic":     trafficSection,
+	"aviation":    aviationSection,
+	"wildlife":    wildlifeSection,
+	"finance":     financeSection,
+	"energy":      energySection,
+	"health":      healthSection,
+	"agriculture": agricultureSection,
+	"disasters":   disastersSection,
+}
+
+// DefaultSections is the order GenerateSummary uses.
+var DefaultSections = []string{
+	"weather", "air_quality", "traffic", "aviation", "wildlife",
+	"finance", "energy", "health", "agriculture", "disasters",
+}
+
+// optionalSections can be enabled but are not in DefaultSections, so
+// existing embeddings stay comparable.
+var optionalSections = []string{"gases"}
+
+// Summarizer renders snapshots using an ordered list of sections. The
+// location/time header always comes first.
+type Summarizer struct {
+	names    []string
+	sections []section
+}
+
+// NewSummarizer builds a Summarizer from section names in the order they
+// should appear. An empty list means DefaultSections.
+func NewSummarizer(names []string) (*Summarizer, error) {
+	if len(names) == 0 {
+		names = DefaultSections
+	}
+	s := &Summarizer{}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		fn, ok := sections[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown summary section %q (valid: %s)", name, strings.Join(SectionNames(), ", "))
+		}
+		seen[name] = true
+		s.names = append(s.names, name)
+		s.sections = append(s.sections, fn)
+	}
+	return s, nil
+}
+
+// SummarizerFromEnv builds a Summarizer from EDGESIGHT_SUMMARY_SECTIONS.
+func SummarizerFromEnv() (*Summarizer, error) {
+	v := strings.TrimSpace(os.Getenv(SectionsEnv))
+	if v == "" {
+		return NewSummarizer(nil)
+	}
+	return NewSummarizer(strings.Split(v, ","))
+}
+
+// SectionNames lists every available section in default order, followed by
+// the optional ones.
+func SectionNames() []string {
+	return append(slices.Clone(DefaultSections), optionalSections...)
+}
+
+// Sections returns the enabled section names in order.
+func (s *Summarizer) Sections() []string {
+	return slices.Clone(s.names)
+}
+
+// Summarize creates a natural language description of a snapshot.
+func (s *Summarizer) Summarize(snap models.Snapshot) string {
+	parts := []string{fmt.Sprintf("Location: %s at %s", snap.Location, snap.Timestamp.Format("Jan 02, 2006 3:04 PM MST"))}
+	for _, fn := range s.sections {
+		parts = append(parts, fn(snap)...)
+	}
+	return strings.Join(parts, ". ")
+}
+
+var defaultSummarizer, _ = NewSummarizer(nil)
+
 // GenerateSummary creates a natural language description of a snapshot
+// using DefaultSections.
 func GenerateSummary(snap models.Snapshot) string {
-	var parts []string
+	return defaultSummarizer.Summarize(snap)
+}
 
-	parts = append(parts, fmt.Sprintf("Location: %s at %s", snap.Location, snap.Timestamp.Format("Jan 02, 2006 3:04 PM MST")))
+func weatherSection(snap models.Snapshot) []string {
+	if snap.Weather.TemperatureC == 0 && snap.Weather.Humidity == 0 {
+		return nil
+	}
+	weather := fmt.Sprintf("Weather: %.1f°C, %.0f%% humidity, wind %.1f m/s",
+		snap.Weather.TemperatureC, snap.Weather.Humidity, snap.Weather.WindSpeedMS)
+	if snap.Weather.PrecipMM > 0 {
+		weather += fmt.Sprintf(", %.1fmm precipitation", snap.Weather.PrecipMM)
+	}
+	return []string{weather}
+}
 
-	// Weather
-	if snap.Weather.TemperatureC != 0 || snap.Weather.Humidity != 0 {
-		weather := fmt.Sprintf("Weather: %.1f°C, %.0f%% humidity, wind %.1f m/s",
-			snap.Weather.TemperatureC, snap.Weather.Humidity, snap.Weather.WindSpeedMS)
-		if snap.Weather.PrecipMM > 0 {
-			weather += fmt.Sprintf(", %.1fmm precipitation", snap.Weather.PrecipMM)
-		}
-		parts = append(parts, weather)
+func airQualitySection(snap models.Snapshot) []string {
+	if snap.Environment.PM25 <= 0 && snap.Environment.PM10 <= 0 {
+		return nil
+	}
+	aq := fmt.Sprintf("Air Quality: PM2.5 %.1f µg/m³ (%s), PM10 %.1f µg/m³",
+		snap.Environment.PM25, interpretAQI(snap.Environment.PM25), snap.Environment.PM10)
+	if snap.Environment.Ozone > 0 {
+		aq += fmt.Sprintf(", O₃ %.1f ppb", snap.Environment.Ozone)
 	}
+	return []string{aq}
+}
 
-	// Air Quality
-	if snap.Environment.PM25 > 0 || snap.Environment.PM10 > 0 {
-		aq := fmt.Sprintf("Air Quality: PM2.5 %.1f µg/m³ (%s), PM10 %.1f µg/m³",
-			snap.Environment.PM25, interpretAQI(snap.Environment.PM25), snap.Environment.PM10)
-		if snap.Environment.Ozone > 0 {
-			aq += fmt.Sprintf(", O₃ %.2f ppm", snap.Environment.Ozone)
-		}
-		parts = append(parts, aq)
+func gasesSection(snap models.Snapshot) []string {
+	var gases []string
+	if snap.Environment.NO2 > 0 {
+		gases = append(gases, fmt.Sprintf("NO₂ %.1f ppb", snap.Environment.NO2))
+	}
+	if snap.Environment.SO2 > 0 {
+		gases = append(gases, fmt.Sprintf("SO₂ %.1f ppb", snap.Environment.SO2))
+	}
+	if snap.Environment.CO > 0 {
+		gases = append(gases, fmt.Sprintf("CO %.0f ppb", snap.Environment.CO))
 	}
+	if len(gases) == 0 {
+		return nil
+	}
+	return []string{"Gases: " + strings.Join(gases, ", ")}
+}
 
-	// Mobility
-	if snap.Mobility.TrafficSpeedKmH > 0 {
-		parts = append(parts, fmt.Sprintf("Traffic: avg speed %.1f km/h, jam factor %.2f",
-			snap.Mobility.TrafficSpeedKmH, snap.Mobility.TrafficJamFactor))
+func trafficSection(snap models.Snapshot) []string {
+	if snap.Mobility.TrafficSpeedKmH <= 0 {
+		return nil
 	}
-	if snap.Mobility.FlightCount > 0 {
-		parts = append(parts, fmt.Sprintf("Aviation: %d flights overhead, avg altitude %.0fm",
-			snap.Mobility.FlightCount, snap.Mobility.AvgAltitudeM))
+	return []string{fmt.Sprintf("Traffic: avg speed %.1f km/h, jam factor %.2f",
+		snap.Mobility.TrafficSpeedKmH, snap.Mobility.TrafficJamFactor)}
+}
+
+func aviationSection(snap models.Snapshot) []string {
+	if snap.Mobility.FlightCount <= 0 {
+		return nil
 	}
-	if snap.Mobility.ActiveSpecies > 0 || snap.Mobility.AnimalsTracked > 0 {
-		parts = append(parts, fmt.Sprintf("Wildlife: %d species, %d animals tracked, %.1f km/day pace",
-			snap.Mobility.ActiveSpecies, snap.Mobility.AnimalsTracked, snap.Mobility.AvgMigrationPaceKMDay))
+	return []string{fmt.Sprintf("Aviation: %d flights overhead, avg altitude %.0fm",
+		snap.Mobility.FlightCount, snap.Mobility.AvgAltitudeM)}
+}
+
+func wildlifeSection(snap models.Snapshot) []string {
+	if snap.Mobility.ActiveSpecies <= 0 && snap.Mobility.AnimalsTracked <= 0 {
+		return nil
 	}
+	return []string{fmt.Sprintf("Wildlife: %d species, %d animals tracked, %.1f km/day pace",
+		snap.Mobility.ActiveSpecies, snap.Mobility.AnimalsTracked, snap.Mobility.AvgMigrationPaceKMDay)}
+}
 
-	// Finance
+func financeSection(snap models.Snapshot) []string {
+	var parts []string
 	if snap.Finance.StockPrice > 0 {
 		parts = append(parts, fmt.Sprintf("Equity: %s at $%.2f",
 			snap.Finance.StockSymbol, snap.Finance.StockPrice))
@@ -59,32 +188,44 @@ func GenerateSummary(snap models.Snapshot) string {
 		parts = append(parts, fmt.Sprintf("Commodity: %s at $%.2f",
 			snap.Finance.CommoditySymbol, snap.Finance.CommodityPrice))
 	}
+	return parts
+}
 
-	// Energy
-	if snap.Energy.ElectricityPriceUSD > 0 || snap.Energy.GenerationMWh > 0 || snap.Energy.RenewablePercent > 0 {
-		parts = append(parts, fmt.Sprintf("Energy: $%.4f/kWh, %.0f MWh gen, %.1f%% renewable, CI %.0f gCO2/kWh",
-			snap.Energy.ElectricityPriceUSD, snap.Energy.GenerationMWh, snap.Energy.RenewablePercent, snap.Energy.CarbonIntensity))
+func energySection(snap models.Snapshot) []string {
+	if snap.Energy.ElectricityPriceUSD <= 0 && snap.Energy.GenerationMWh <= 0 && snap.Energy.RenewablePercent <= 0 {
+		return nil
 	}
+	return []string{fmt.Sprintf("Energy: $%.4f/kWh, %.0f MWh gen, %.1f%% renewable, CI %.0f gCO2/kWh",
+		snap.Energy.ElectricityPriceUSD, snap.Energy.GenerationMWh, snap.Energy.RenewablePercent, snap.Energy.CarbonIntensity)}
+}
 
-	// Health
+func healthSection(snap models.Snapshot) []string {
+	var parts []string
 	if snap.Health.FluCases > 0 || snap.Health.ILIPercent > 0 {
-		parts = append(parts, fmt.Sprintf("Health: %d cases, %.1f%% ILI/RSV",
+		parts = append(parts, fmt.Sprintf("Health: %d flu cases, %.1f%% ILI",
 			snap.Health.FluCases, snap.Health.ILIPercent))
 	}
-
-	// Agriculture
-	if snap.Agriculture.CropYield > 0 {
-		parts = append(parts, fmt.Sprintf("Agriculture: %s yield %.1f, soil moisture %.1f%%",
-			snap.Agriculture.CropType, snap.Agriculture.CropYield, snap.Agriculture.SoilMoisture))
+	if snap.Health.RSVTests > 0 || snap.Health.RSVPercentPositive > 0 {
+		parts = append(parts, fmt.Sprintf("RSV: %.1f%% positive (%d detections in %d tests)",
+			snap.Health.RSVPercentPositive, snap.Health.RSVDetections, snap.Health.RSVTests))
 	}
+	return parts
+}
 
-	// Disasters
-	if snap.Disasters.ActiveDisasters > 0 {
-		parts = append(parts, fmt.Sprintf("⚠️ Disasters: %d active (%s, severity %d), %d counties affected",
-			snap.Disasters.ActiveDisasters, snap.Disasters.DisasterType, snap.Disasters.Severity, snap.Disasters.AffectedCounties))
+func agricultureSection(snap models.Snapshot) []string {
+	if snap.Agriculture.CropYield <= 0 {
+		return nil
 	}
+	return []string{fmt.Sprintf("Agriculture: %s yield %.1f, soil moisture %.1f%%",
+		snap.Agriculture.CropType, snap.Agriculture.CropYield, snap.Agriculture.SoilMoisture)}
+}
 
-	return strings.Join(parts, ". ")
+func disastersSection(snap models.Snapshot) []string {
+	if snap.Disasters.ActiveDisasters <= 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("⚠️ Disasters: %d active (%s, severity %d), %d counties affected",
+		snap.Disasters.ActiveDisasters, snap.Disasters.DisasterType, snap.Disasters.Severity, snap.Disasters.AffectedCounties)}
 }
 
 // interpretAQI converts PM2.5 µg/m³ to qualitative category
@@ -0,0 +1,100 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func testSnapshot() models.Snapshot {
+	snap := models.Snapshot{
+		Location:  "Denver",
+		Timestamp: time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+	}
+	snap.Weather.TemperatureC = 18.5
+	snap.Weather.Humidity = 40
+	snap.Weather.WindSpeedMS = 3.2
+	snap.Environment.PM25 = 8.4
+	snap.Environment.PM10 = 15
+	snap.Environment.NO2 = 21.5
+	snap.Finance.NASDAQIndex = 18250.5
+	snap.Finance.VolumeTraded = 1200
+	snap.Energy.ElectricityPriceUSD = 0.1325
+	snap.Energy.GenerationMWh = 5400
+	snap.Energy.RenewablePercent = 32.5
+	snap.Energy.CarbonIntensity = 410
+	return snap
+}
+
+func TestGenerateSummaryDefault(t *testing.T) {
+	want := "Location: Denver at Oct 17, 2026 9:30 AM UTC" +
+		". Weather: 18.5°C, 40% humidity, wind 3.2 m/s" +
+		". Air Quality: PM2.5 8.4 µg/m³ (Good), PM10 15.0 µg/m³" +
+		". NASDAQ: 18250.50 (vol 1200)" +
+		". Energy: $0.1325/kWh, 5400 MWh gen, 32.5% renewable, CI 410 gCO2/kWh"
+	if got := GenerateSummary(testSnapshot()); got != want {
+		t.Errorf("GenerateSummary =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSummarizerSections(t *testing.T) {
+	snap := testSnapshot()
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string // phrases that must appear, in order
+		missing []string
+	}{
+		{"finance disabled", []string{"weather", "air_quality", "energy"},
+			[]string{"Weather:", "Air Quality:", "Energy:"}, []string{"NASDAQ"}},
+		{"reordered", []string{" Energy ", "weather", "energy"},
+			[]string{"Energy:", "Weather:"}, []string{"Air Quality", "NASDAQ"}},
+		{"optional gases", []string{"air_quality", "gases"},
+			[]string{"Air Quality:", "Gases: NO₂ 21.5 ppb"}, []string{"Weather"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSummarizer(tt.names)
+			if err != nil {
+				t.Fatalf("NewSummarizer: %v", err)
+			}
+			got := s.Summarize(snap)
+			if !strings.HasPrefix(got, "Location: Denver") {
+				t.Errorf("summary %q does not start with the location header", got)
+			}
+			at := 0
+			for _, phrase := range tt.want {
+				i := strings.Index(got[at:], phrase)
+				if i < 0 {
+					t.Fatalf("summary %q lacks %q after offset %d", got, phrase, at)
+				}
+				at += i + len(phrase)
+			}
+			for _, phrase := range tt.missing {
+				if strings.Contains(got, phrase) {
+					t.Errorf("summary %q contains disabled %q", got, phrase)
+				}
+			}
+		})
+	}
+
+	if _, err := NewSummarizer([]string{"weather", "horoscope"}); err == nil {
+		t.Error("unknown section: want an error")
+	}
+}
+
+func TestSummarizerFromEnv(t *testing.T) {
+	t.Setenv(SectionsEnv, "")
+	s, err := SummarizerFromEnv()
+	if err != nil || strings.Join(s.Sections(), ",") != strings.Join(DefaultSections, ",") {
+		t.Errorf("unset %s = %v, %v; want DefaultSections", SectionsEnv, s.Sections(), err)
+	}
+
+	t.Setenv(SectionsEnv, "health, weather,,")
+	s, err = SummarizerFromEnv()
+	if err != nil || strings.Join(s.Sections(), ",") != "health,weather" {
+		t.Errorf("%s=health, weather = %v, %v", SectionsEnv, s.Sections(), err)
+	}
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func TestCancelledContextAbortsScan(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	const total = 300
+	for i := 0; i < total; i++ {
+		mustInsert(t, s, "Denver", base.Add(time.Duration(i)*time.Minute))
+	}
+	end := base.Add(total * time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seen := 0
+	err := s.EachSnapshotInRangeContext(ctx, "Denver", base, end, func(models.Snapshot) error {
+		seen++
+		if seen == 1 {
+			// Simulate the client disconnecting mid-scan; give database/sql
+			// a moment to close the rows.
+			cancel()
+			time.Sleep(20 * time.Millisecond)
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("scan error = %v, want context.Canceled", err)
+	}
+	if seen >= total {
+		t.Errorf("scan read all %d rows after cancellation", seen)
+	}
+
+	if _, err := s.GetSnapshotsByTimeRangeContext(ctx, "Denver", base, end); !errors.Is(err, context.Canceled) {
+		t.Errorf("range query on a cancelled context = %v, want context.Canceled", err)
+	}
+	if _, err := s.GetMetricSeriesContext(ctx, "temp_c", "Denver", base, end); !errors.Is(err, context.Canceled) {
+		t.Errorf("metric series on a cancelled context = %v, want context.Canceled", err)
+	}
+}
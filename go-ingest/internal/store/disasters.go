@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// DisasterReport is the FEMA detail recorded alongside a snapshot: the
+// coarse numbers the snapshot stores plus the per-incident and
+// per-declaration-type breakdown.
+type DisasterReport struct {
+	Location              string                `json:"location"`
+	Timestamp             time.Time             `json:"timestamp"`
+	State                 string                `json:"state"`
+	County                string                `json:"county,omitempty"` // FIPS code; empty for statewide
+	ActiveDisasters       int                   `json:"active_disasters"`
+	TopIncidentType       string                `json:"top_incident_type"`
+	Severity              int                   `json:"severity"`
+	SeverityLabel         string                `json:"severity_label,omitempty"` // from models.SeverityLevels; not stored
+	AffectedCounties      int                   `json:"affected_counties"`
+	IncidentTypeCounts    map[string]int        `json:"incident_type_counts"`
+	DeclarationTypeCounts map[string]int        `json:"declaration_type_counts,omitempty"` // absent in reports stored before it existed
+	LatestIncidentBegin   *time.Time            `json:"latest_incident_begin,omitempty"`
+	Recent                []DisasterDeclaration `json:"recent"`
+}
+
+// DisasterDeclaration is one of a report's most recent declarations.
+type DisasterDeclaration struct {
+	DisasterNumber  int       `json:"disaster_number"`
+	Title           string    `json:"title"`
+	IncidentType    string    `json:"incident_type"`
+	DeclarationType string    `json:"declaration_type"`
+	DeclarationDate time.Time `json:"declaration_date"`
+	Open            bool      `json:"open"`
+	Counties        []string  `json:"counties"`
+}
+
+// InsertDisasterReport stores a FEMA report.
+func (s *SQLiteStore) InsertDisasterReport(r DisasterReport) error {
+	return s.InsertDisasterReportContext(context.Background(), r)
+}
+
+// InsertDisasterReportContext is InsertDisasterReport with a caller-supplied context.
+func (s *SQLiteStore) InsertDisasterReportContext(ctx context.Context, r DisasterReport) error {
+	counts, err := json.Marshal(r.IncidentTypeCounts)
+	if err != nil {
+		return fmt.Errorf("marshal incident counts: %w", err)
+	}
+	declCounts, err := json.Marshal(r.DeclarationTypeCounts)
+	if err != nil {
+		return fmt.Errorf("marshal declaration counts: %w", err)
+	}
+	recent, err := json.Marshal(r.Recent)
+	if err != nil {
+		return fmt.Errorf("marshal recent declarations: %w", err)
+	}
+	var latestBegin string
+	if r.LatestIncidentBegin != nil {
+		latestBegin = r.LatestIncidentBegin.UTC().Format(time.RFC3339)
+	}
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO disasters
+		(location, ts, state, county, active_disasters, top_incident_type, severity, affected_counties, incident_counts, declaration_counts, latest_incident_begin, recent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Location, r.Timestamp.UTC().Format(time.RFC3339), r.State, r.County,
+		r.ActiveDisasters, r.TopIncidentType, r.Severity, r.AffectedCounties, string(counts), string(declCounts), latestBegin, string(recent))
+	if err != nil {
+		return fmt.Errorf("insert disaster report: %w", err)
+	}
+	return nil
+}
+
+// GetLatestDisasterReport returns the newest FEMA report for a location.
+func (s *SQLiteStore) GetLatestDisasterReport(location string) (*DisasterReport, error) {
+	return s.GetLatestDisasterReportContext(context.Background(), location)
+}
+
+// GetLatestDisasterReportContext is GetLatestDisasterReport with a caller-supplied context.
+func (s *SQLiteStore) GetLatestDisasterReportContext(ctx context.Context, location string) (*DisasterReport, error) {
+	row := s.DB.QueryRowContext(ctx, `SELECT location, ts, state, county, active_disasters, top_incident_type, severity, affected_counties,
+		incident_counts, declaration_counts, latest_incident_begin, recent
+		FROM disasters WHERE location = ? ORDER BY ts DESC, id DESC LIMIT 1`, location)
+
+	var r DisasterReport
+	var tsStr, latestBegin string
+	var counts, declCounts, recent sql.NullString
+	err := row.Scan(&r.Location, &tsStr, &r.State, &r.County, &r.ActiveDisasters, &r.TopIncidentType,
+		&r.Severity, &r.AffectedCounties, &counts, &declCounts, &latestBegin, &recent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no disaster report for location %s: %w", location, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Timestamp, err = time.Parse(time.RFC3339, tsStr); err != nil {
+		return nil, err
+	}
+	r.SeverityLabel = models.SeverityLabel(float64(r.Severity))
+	if counts.Valid {
+		if err := json.Unmarshal([]byte(counts.String), &r.IncidentTypeCounts); err != nil {
+			return nil, fmt.Errorf("decode incident counts: %w", err)
+		}
+	}
+	if declCounts.Valid {
+		if err := json.Unmarshal([]byte(declCounts.String), &r.DeclarationTypeCounts); err != nil {
+			return nil, fmt.Errorf("decode declaration counts: %w", err)
+		}
+	}
+	if latestBegin != "" {
+		t, err := time.Parse(time.RFC3339, latestBegin)
+		if err != nil {
+			return nil, fmt.Errorf("decode latest incident begin: %w", err)
+		}
+		r.LatestIncidentBegin = &t
+	}
+	if recent.Valid {
+		if err := json.Unmarshal([]byte(recent.String), &r.Recent); err != nil {
+			return nil, fmt.Errorf("decode recent declarations: %w", err)
+		}
+	}
+	return &r, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPragmas are applied to every connection unless the DSN already sets
+// the same pragma with its own _pragma option. WAL lets the API read while
+// the ingest daemon writes; busy_timeout makes competing writers wait instead
+// of failing with "database is locked".
+var defaultPragmas = []struct{ name, value string }{
+	{"busy_timeout", "5000"},
+	{"journal_mode", "WAL"},
+	{"synchronous", "NORMAL"},
+}
+
+// Connection pool defaults, overridable with the _max_open_conns and
+// _conn_max_lifetime DSN options (the driver ignores options it does not
+// know).
+const (
+	defaultMaxOpenConns    = 8
+	defaultConnMaxLifetime = time.Hour
+)
+
+// buildDSN appends the default _pragma options to dbPath, e.g.
+// "edgesight.db" becomes "edgesight.db?_pragma=busy_timeout(5000)&...".
+func buildDSN(dbPath string) string {
+	path, rawQuery, _ := strings.Cut(dbPath, "?")
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return dbPath // let the driver report the malformed DSN
+	}
+
+	for _, p := range defaultPragmas {
+		if !hasPragma(q["_pragma"], p.name) {
+			q.Add("_pragma", p.name+"("+p.value+")")
+		}
+	}
+	return path + "?" + q.Encode()
+}
+
+// hasPragma reports whether any _pragma value sets name, in either the
+// name(value) or name=value form.
+func hasPragma(pragmas []string, name string) bool {
+	for _, p := range pragmas {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == name || strings.HasPrefix(p, name+"(") || strings.HasPrefix(p, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// configurePool sizes the connection pool. An in-memory database exists per
+// connection, so it is pinned to one.
+func configurePool(db *sql.DB, dbPath string) {
+	path, rawQuery, _ := strings.Cut(dbPath, "?")
+	q, _ := url.ParseQuery(rawQuery)
+
+	maxOpen := defaultMaxOpenConns
+	if path == "" || strings.Contains(path, ":memory:") || q.Get("mode") == "memory" {
+		maxOpen = 1
+	}
+	if n, err := strconv.Atoi(q.Get("_max_open_conns")); err == nil && n > 0 {
+		maxOpen = n
+	}
+	lifetime := defaultConnMaxLifetime
+	if d, err := time.ParseDuration(q.Get("_conn_max_lifetime")); err == nil && d >= 0 {
+		lifetime = d
+	}
+
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxOpen)
+	db.SetConnMaxLifetime(lifetime)
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func TestBuildDSN(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string // expected _pragma values
+	}{
+		{"edgesight.db", []string{"busy_timeout(5000)", "journal_mode(WAL)", "synchronous(NORMAL)"}},
+		{"edgesight.db?_pragma=journal_mode(DELETE)", []string{"journal_mode(DELETE)", "busy_timeout(5000)", "synchronous(NORMAL)"}},
+		{"edgesight.db?_pragma=busy_timeout=100&_max_open_conns=2", []string{"busy_timeout=100", "journal_mode(WAL)", "synchronous(NORMAL)"}},
+	}
+	for _, tt := range tests {
+		path, rawQuery, _ := strings.Cut(buildDSN(tt.path), "?")
+		q, err := url.ParseQuery(rawQuery)
+		if err != nil || path != "edgesight.db" {
+			t.Fatalf("buildDSN(%q) = %q?%s (%v)", tt.path, path, rawQuery, err)
+		}
+		got := q["_pragma"]
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("buildDSN(%q) pragmas = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStoreUsesWAL(t *testing.T) {
+	s := newTestStore(t)
+	var mode string
+	if err := s.DB.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatalf("read journal_mode: %v", err)
+	}
+	if !strings.EqualFold(mode, "wal") {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+}
+
+// TestConcurrentReadDuringWrite opens the same file twice, as the API and the
+// ingest daemon do, and reads while the other handle writes.
+func TestConcurrentReadDuringWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "edgesight.db")
+	open := func() *SQLiteStore {
+		s, err := NewSQLiteStore(path)
+		if err != nil {
+			t.Fatalf("NewSQLiteStore: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+	writer, reader := open(), open()
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	mustInsert(t, writer, "Denver", base)
+
+	// A reader must not wait on an open write transaction.
+	ctx := context.Background()
+	conn, err := writer.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `INSERT INTO snapshot (ts, location) VALUES (?, ?)`,
+		base.Add(time.Hour).Format(time.RFC3339), "Denver"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	readCtx, cancel := context.WithTimeout(ctx, time.Second)
+	snap, err := reader.GetLatestSnapshotContext(readCtx, "Denver")
+	cancel()
+	if err != nil {
+		t.Errorf("read during open write transaction: %v", err)
+	} else if !snap.Timestamp.Equal(base) {
+		t.Errorf("read saw uncommitted snapshot at %s", snap.Timestamp)
+	}
+	conn.ExecContext(ctx, `ROLLBACK`) // the partial row only exists to hold the lock
+	conn.Close()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	errs := make(chan error, 100)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 2; i < 100; i++ {
+			snap := models.Snapshot{Location: "Denver", Timestamp: base.Add(time.Duration(i) * time.Hour)}
+			if err := writer.InsertSnapshot(snap); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := reader.GetSnapshotsByTimeRange("Denver", base, base.Add(100*time.Hour)); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := reader.GetLatestSnapshot("Denver"); err != nil && !errors.Is(err, ErrNotFound) {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent access: %v", err)
+	}
+}
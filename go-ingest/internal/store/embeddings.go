@@ -1,10 +1,13 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -18,30 +21,156 @@ type SnapshotEmbedding struct {
 	CreatedAt  time.Time
 }
 
-// SearchResult includes similarity score for a snapshot embedding.
+// SearchResult includes similarity score for a snapshot embedding. Under
+// MetricEuclidean the score is a distance, so lower is more similar.
 type SearchResult struct {
 	SnapshotEmbedding
 	Score float64
 }
 
+// Metric selects how search scores an embedding against the query vector.
+type Metric string
+
+const (
+	// MetricCosine is the cosine of the angle between the vectors.
+	MetricCosine Metric = "cosine"
+	// MetricDot is the dot product, equal to cosine for normalized vectors
+	// and cheaper to compute.
+	MetricDot Metric = "dot"
+	// MetricEuclidean is the straight-line distance between the vectors.
+	MetricEuclidean Metric = "euclidean"
+)
+
+// Metrics lists the metrics ParseMetric accepts.
+var Metrics = []Metric{MetricCosine, MetricDot, MetricEuclidean}
+
+// ParseMetric returns the Metric named by s (case-insensitive). An empty
+// string is MetricCosine.
+func ParseMetric(s string) (Metric, error) {
+	if s == "" {
+		return MetricCosine, nil
+	}
+	m := Metric(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(Metrics, m) {
+		return "", fmt.Errorf("unknown metric %q", s)
+	}
+	return m, nil
+}
+
+// score compares a query vector with an embedding of the same length.
+func (m Metric) score(a, b []float64) float64 {
+	switch m {
+	case MetricDot:
+		return dot(a, b)
+	case MetricEuclidean:
+		return euclidean(a, b)
+	default:
+		return cosine(a, b)
+	}
+}
+
+// better reports whether score x ranks ahead of y: higher similarity, or
+// lower distance for MetricEuclidean.
+func (m Metric) better(x, y float64) bool {
+	if m == MetricEuclidean {
+		return x < y
+	}
+	return x > y
+}
+
 // InsertEmbedding stores an embedding for a snapshot.
 func (s *SQLiteStore) InsertEmbedding(e SnapshotEmbedding) error {
+	return s.InsertEmbeddingContext(context.Background(), e)
+}
+
+// InsertEmbeddingContext is InsertEmbedding with a caller-supplied context.
+func (s *SQLiteStore) InsertEmbeddingContext(ctx context.Context, e SnapshotEmbedding) error {
 	blob, err := json.Marshal(e.Embedding)
 	if err != nil {
 		return fmt.Errorf("marshal embedding: %w", err)
 	}
-	_, err = s.DB.Exec(`INSERT INTO snapshot_embeddings (snapshot_ts, location, summary, embedding, created_at) VALUES (?, ?, ?, ?, ?)`,
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO snapshot_embeddings (snapshot_ts, location, summary, embedding, created_at) VALUES (?, ?, ?, ?, ?)`,
 		e.SnapshotTS, e.Location, e.Summary, string(blob), e.CreatedAt.Format(time.RFC3339))
 	return err
 }
 
+// ReplaceEmbeddings rewrites the embedding rows for each snapshot in one
+// transaction, dropping any existing rows for the same snapshot.
+func (s *SQLiteStore) ReplaceEmbeddings(embs []SnapshotEmbedding) error {
+	return s.ReplaceEmbeddingsContext(context.Background(), embs)
+}
+
+// ReplaceEmbeddingsContext is ReplaceEmbeddings with a caller-supplied context.
+func (s *SQLiteStore) ReplaceEmbeddingsContext(ctx context.Context, embs []SnapshotEmbedding) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, e := range embs {
+		blob, err := json.Marshal(e.Embedding)
+		if err != nil {
+			return fmt.Errorf("marshal embedding: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM snapshot_embeddings WHERE snapshot_ts = ? AND location = ?`, e.SnapshotTS, e.Location); err != nil {
+			return fmt.Errorf("delete embedding: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO snapshot_embeddings (snapshot_ts, location, summary, embedding, created_at) VALUES (?, ?, ?, ?, ?)`,
+			e.SnapshotTS, e.Location, e.Summary, string(blob), e.CreatedAt.Format(time.RFC3339)); err != nil {
+			return fmt.Errorf("insert embedding: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
 // GetEmbeddingsByLocation fetches embeddings for a location (optionally limit recent).
+// An empty location returns embeddings for every location.
 func (s *SQLiteStore) GetEmbeddingsByLocation(location string, limit int) ([]SnapshotEmbedding, error) {
-	q := `SELECT id, snapshot_ts, location, summary, embedding, created_at FROM snapshot_embeddings WHERE location = ? ORDER BY created_at DESC`
+	return s.GetEmbeddingsByLocationContext(context.Background(), location, limit)
+}
+
+// GetEmbeddingsByLocationContext is GetEmbeddingsByLocation with a caller-supplied context.
+func (s *SQLiteStore) GetEmbeddingsByLocationContext(ctx context.Context, location string, limit int) ([]SnapshotEmbedding, error) {
+	q := `SELECT id, snapshot_ts, location, summary, embedding, created_at FROM snapshot_embeddings`
+	var args []interface{}
+	if location != "" {
+		q += ` WHERE location = ?`
+		args = append(args, location)
+	}
+	q += ` ORDER BY created_at DESC`
 	if limit > 0 {
 		q += fmt.Sprintf(" LIMIT %d", limit)
 	}
-	rows, err := s.DB.Query(q, location)
+	return s.queryEmbeddings(ctx, q, args...)
+}
+
+// GetEmbeddingsForLocations fetches the embeddings of the given locations,
+// newest first. It reads only those locations' rows via the location index.
+func (s *SQLiteStore) GetEmbeddingsForLocations(locations []string) ([]SnapshotEmbedding, error) {
+	return s.GetEmbeddingsForLocationsContext(context.Background(), locations)
+}
+
+// GetEmbeddingsForLocationsContext is GetEmbeddingsForLocations with a caller-supplied context.
+func (s *SQLiteStore) GetEmbeddingsForLocationsContext(ctx context.Context, locations []string) ([]SnapshotEmbedding, error) {
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(locations))
+	for i, loc := range locations {
+		args[i] = loc
+	}
+	q := `SELECT id, snapshot_ts, location, summary, embedding, created_at FROM snapshot_embeddings
+		WHERE location IN (` + strings.TrimSuffix(strings.Repeat("?,", len(locations)), ",") + `)
+		ORDER BY created_at DESC`
+	return s.queryEmbeddings(ctx, q, args...)
+}
+
+// queryEmbeddings runs q, which selects the SnapshotEmbedding columns, and
+// decodes the rows. A row whose embedding is not valid JSON is skipped
+// rather than failing the query; ListEmbeddingHealth reports it.
+func (s *SQLiteStore) queryEmbeddings(ctx context.Context, q string, args ...interface{}) ([]SnapshotEmbedding, error) {
+	rows, err := s.DB.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,42 +185,129 @@ func (s *SQLiteStore) GetEmbeddingsByLocation(location string, limit int) ([]Sna
 			return nil, err
 		}
 		if err := json.Unmarshal([]byte(embText), &rec.Embedding); err != nil {
-			return nil, err
+			continue
 		}
 		if ts, err := time.Parse(time.RFC3339, created); err == nil {
 			rec.CreatedAt = ts
 		}
 		out = append(out, rec)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+// SearchEmbeddings naive similarity search in Go (acceptable for small N),
+// scoring with metric. An empty location searches across all locations.
+func (s *SQLiteStore) SearchEmbeddings(location string, queryVec []float64, topK int, metric Metric) ([]SearchResult, error) {
+	return s.SearchEmbeddingsContext(context.Background(), location, queryVec, topK, metric)
+}
+
+// SearchEmbeddingsContext is SearchEmbeddings with a caller-supplied context.
+func (s *SQLiteStore) SearchEmbeddingsContext(ctx context.Context, location string, queryVec []float64, topK int, metric Metric) ([]SearchResult, error) {
+	return s.SearchEmbeddingsInRangeContext(ctx, location, queryVec, topK, time.Time{}, time.Time{}, metric)
+}
+
+// SearchEmbeddingsInRange is SearchEmbeddings restricted to snapshots whose
+// timestamp falls within [start, end]. A zero start or end leaves that side open.
+func (s *SQLiteStore) SearchEmbeddingsInRange(location string, queryVec []float64, topK int, start, end time.Time, metric Metric) ([]SearchResult, error) {
+	return s.SearchEmbeddingsInRangeContext(context.Background(), location, queryVec, topK, start, end, metric)
+}
+
+// SearchEmbeddingsInRangeContext is SearchEmbeddingsInRange with a caller-supplied context.
+func (s *SQLiteStore) SearchEmbeddingsInRangeContext(ctx context.Context, location string, queryVec []float64, topK int, start, end time.Time, metric Metric) ([]SearchResult, error) {
+	recs, err := s.GetEmbeddingsByLocationContext(ctx, location, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !start.IsZero() || !end.IsZero() {
+		recs = filterEmbeddingsByTime(recs, start, end)
+	}
+	return rankEmbeddings(recs, queryVec, topK, 0, metric), nil
+}
+
+// SearchEmbeddingsMulti scores the embeddings of several locations together
+// and returns the global top K by metric, each result carrying its
+// location.
+func (s *SQLiteStore) SearchEmbeddingsMulti(locations []string, queryVec []float64, topK int, metric Metric) ([]SearchResult, error) {
+	return s.SearchEmbeddingsMultiContext(context.Background(), locations, queryVec, topK, metric)
+}
+
+// SearchEmbeddingsMultiContext is SearchEmbeddingsMulti with a caller-supplied context.
+func (s *SQLiteStore) SearchEmbeddingsMultiContext(ctx context.Context, locations []string, queryVec []float64, topK int, metric Metric) ([]SearchResult, error) {
+	recs, err := s.GetEmbeddingsForLocationsContext(ctx, locations)
+	if err != nil {
+		return nil, err
+	}
+	return rankEmbeddings(recs, queryVec, topK, 0, metric), nil
 }
 
-// SearchEmbeddings naive cosine similarity search in Go (acceptable for small N).
-func (s *SQLiteStore) SearchEmbeddings(location string, queryVec []float64, topK int) ([]SearchResult, error) {
-	recs, err := s.GetEmbeddingsByLocation(location, 0)
+// SearchEmbeddingsAllLocations scores embeddings from every location and
+// returns the global top K. When perLocation > 0, each location contributes
+// at most perLocation results before the merge, so one busy site cannot
+// crowd out the rest.
+func (s *SQLiteStore) SearchEmbeddingsAllLocations(queryVec []float64, topK, perLocation int, start, end time.Time, metric Metric) ([]SearchResult, error) {
+	return s.SearchEmbeddingsAllLocationsContext(context.Background(), queryVec, topK, perLocation, start, end, metric)
+}
+
+// SearchEmbeddingsAllLocationsContext is SearchEmbeddingsAllLocations with a caller-supplied context.
+func (s *SQLiteStore) SearchEmbeddingsAllLocationsContext(ctx context.Context, queryVec []float64, topK, perLocation int, start, end time.Time, metric Metric) ([]SearchResult, error) {
+	recs, err := s.GetEmbeddingsByLocationContext(ctx, "", 0)
 	if err != nil {
 		return nil, err
 	}
-	type scored struct {
-		rec   SnapshotEmbedding
-		score float64
+	if !start.IsZero() || !end.IsZero() {
+		recs = filterEmbeddingsByTime(recs, start, end)
 	}
-	var scoredList []scored
+	return rankEmbeddings(recs, queryVec, topK, perLocation, metric), nil
+}
+
+// rankEmbeddings scores recs against queryVec with metric, optionally caps
+// results per location, and returns the best topK (all when topK <= 0).
+func rankEmbeddings(recs []SnapshotEmbedding, queryVec []float64, topK, perLocation int, metric Metric) []SearchResult {
+	var scored []SearchResult
 	for _, r := range recs {
 		if len(r.Embedding) == 0 || len(r.Embedding) != len(queryVec) {
 			continue
 		}
-		scoredList = append(scoredList, scored{rec: r, score: cosine(queryVec, r.Embedding)})
+		scored = append(scored, SearchResult{SnapshotEmbedding: r, Score: metric.score(queryVec, r.Embedding)})
 	}
-	sort.Slice(scoredList, func(i, j int) bool { return scoredList[i].score > scoredList[j].score })
-	if topK > 0 && len(scoredList) > topK {
-		scoredList = scoredList[:topK]
+	sort.SliceStable(scored, func(i, j int) bool { return metric.better(scored[i].Score, scored[j].Score) })
+
+	if perLocation > 0 {
+		counts := make(map[string]int)
+		kept := scored[:0]
+		for _, r := range scored {
+			if counts[r.Location] >= perLocation {
+				continue
+			}
+			counts[r.Location]++
+			kept = append(kept, r)
+		}
+		scored = kept
 	}
-	out := make([]SearchResult, len(scoredList))
-	for i, s := range scoredList {
-		out[i] = SearchResult{SnapshotEmbedding: s.rec, Score: s.score}
+
+	if topK > 0 && len(scored) > topK {
+		scored = scored[:topK]
 	}
-	return out, nil
+	return scored
+}
+
+// filterEmbeddingsByTime keeps records whose snapshot_ts is within [start, end].
+func filterEmbeddingsByTime(recs []SnapshotEmbedding, start, end time.Time) []SnapshotEmbedding {
+	out := recs[:0]
+	for _, r := range recs {
+		ts, err := time.Parse(time.RFC3339, r.SnapshotTS)
+		if err != nil {
+			continue
+		}
+		if !start.IsZero() && ts.Before(start) {
+			continue
+		}
+		if !end.IsZero() && ts.After(end) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
 }
 
 func cosine(a, b []float64) float64 {
@@ -106,3 +322,137 @@ func cosine(a, b []float64) float64 {
 	}
 	return dot / (math.Sqrt(na) * math.Sqrt(nb))
 }
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func euclidean(a, b []float64) float64 {
+	var sq float64
+	for i := range a {
+		d := a[i] - b[i]
+		sq += d * d
+	}
+	return math.Sqrt(sq)
+}
+
+// Problems ListEmbeddingHealth reports for an unusable embedding row.
+const (
+	EmbeddingInvalidJSON   = "invalid JSON"
+	EmbeddingEmpty         = "empty vector"
+	EmbeddingNonFinite     = "NaN or Inf component"
+	EmbeddingZeroNorm      = "zero norm"
+	EmbeddingDimensionSkew = "dimension differs from the majority"
+)
+
+// EmbeddingHealth is the validity of one snapshot_embeddings row. Problem
+// is empty for a usable vector.
+type EmbeddingHealth struct {
+	ID         int64
+	SnapshotTS string
+	Location   string
+	Summary    string
+	Dimensions int
+	Norm       float64
+	Problem    string
+}
+
+// Valid reports whether the row's vector can be searched.
+func (h EmbeddingHealth) Valid() bool {
+	return h.Problem == ""
+}
+
+// ListEmbeddingHealth checks every embedding row (for one location, or all
+// when location is empty): the JSON must decode to a non-empty vector of
+// finite components with a non-zero norm and the same length as most other
+// rows. Rows that fail are reported rather than skipped, oldest first.
+func (s *SQLiteStore) ListEmbeddingHealth(location string) ([]EmbeddingHealth, error) {
+	return s.ListEmbeddingHealthContext(context.Background(), location)
+}
+
+// ListEmbeddingHealthContext is ListEmbeddingHealth with a caller-supplied context.
+func (s *SQLiteStore) ListEmbeddingHealthContext(ctx context.Context, location string) ([]EmbeddingHealth, error) {
+	q := `SELECT id, snapshot_ts, location, summary, embedding FROM snapshot_embeddings`
+	var args []interface{}
+	if location != "" {
+		q += ` WHERE location = ?`
+		args = append(args, location)
+	}
+	q += ` ORDER BY id`
+	rows, err := s.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []EmbeddingHealth
+	dims := make(map[int]int)
+	for rows.Next() {
+		var h EmbeddingHealth
+		var embText string
+		if err := rows.Scan(&h.ID, &h.SnapshotTS, &h.Location, &h.Summary, &embText); err != nil {
+			return nil, err
+		}
+		checkEmbedding(&h, embText)
+		if h.Valid() {
+			dims[h.Dimensions]++
+		}
+		out = append(out, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A vector of another length cannot be compared with the rest, e.g. one
+	// left over from a previous model.
+	majority, best := 0, 0
+	for d, n := range dims {
+		if n > best || (n == best && d > majority) {
+			majority, best = d, n
+		}
+	}
+	for i := range out {
+		if out[i].Valid() && out[i].Dimensions != majority {
+			out[i].Problem = EmbeddingDimensionSkew
+		}
+	}
+	return out, nil
+}
+
+// checkEmbedding decodes embText into h's dimensions and norm, setting
+// Problem when the vector is unusable.
+func checkEmbedding(h *EmbeddingHealth, embText string) {
+	var vec []float64
+	if err := json.Unmarshal([]byte(embText), &vec); err != nil {
+		// JSON has no NaN or Infinity, but Python's encoder writes them.
+		h.Problem = EmbeddingInvalidJSON
+		if strings.Contains(embText, "NaN") || strings.Contains(embText, "Infinity") {
+			h.Problem = EmbeddingNonFinite
+		}
+		return
+	}
+	h.Dimensions = len(vec)
+	if len(vec) == 0 {
+		h.Problem = EmbeddingEmpty
+		return
+	}
+	var sq float64
+	for _, v := range vec {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			h.Problem = EmbeddingNonFinite
+			return
+		}
+		sq += v * v
+	}
+	h.Norm = math.Sqrt(sq)
+	switch {
+	case math.IsInf(h.Norm, 0):
+		h.Problem = EmbeddingNonFinite
+	case h.Norm == 0:
+		h.Problem = EmbeddingZeroNorm
+	}
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListEmbeddingHealthFlagsCorruptRows(t *testing.T) {
+	s := newTestStore(t)
+	created := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	for i, vec := range [][]float64{{0.6, 0.8, 0}, {0, 1, 0}, {1, 0, 0}} {
+		e := SnapshotEmbedding{SnapshotTS: created.Add(time.Duration(i) * time.Hour).Format(time.RFC3339), Location: "Denver", Summary: "ok", Embedding: vec, CreatedAt: created}
+		if err := s.InsertEmbedding(e); err != nil {
+			t.Fatalf("InsertEmbedding: %v", err)
+		}
+	}
+	// Rows a truncated write, Python's NaN encoding, a blank vector and an
+	// older model could leave behind.
+	for _, blob := range []string{`[0.12, 0.53, 0.`, `[NaN, 0.1, 0.2]`, `[0, 0, 0]`, `[1, 2]`} {
+		if _, err := s.DB.Exec(`INSERT INTO snapshot_embeddings (snapshot_ts, location, summary, embedding, created_at) VALUES (?, ?, ?, ?, ?)`,
+			"2026-10-17T12:00:00Z", "Denver", "bad", blob, created.Format(time.RFC3339)); err != nil {
+			t.Fatalf("insert raw row: %v", err)
+		}
+	}
+
+	health, err := s.ListEmbeddingHealth("Denver")
+	if err != nil {
+		t.Fatalf("ListEmbeddingHealth: %v", err)
+	}
+	want := []string{"", "", "", EmbeddingInvalidJSON, EmbeddingNonFinite, EmbeddingZeroNorm, EmbeddingDimensionSkew}
+	if len(health) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(health), len(want))
+	}
+	for i, h := range health {
+		if h.Problem != want[i] {
+			t.Errorf("row %d problem = %q, want %q", i, h.Problem, want[i])
+		}
+	}
+	if h := health[0]; h.Dimensions != 3 || h.Norm != 1 {
+		t.Errorf("valid row = %+v, want 3 dimensions with norm 1", h)
+	}
+
+	// Reads skip rows that are not valid JSON instead of failing.
+	embs, err := s.GetEmbeddingsByLocation("Denver", 0)
+	if err != nil {
+		t.Fatalf("GetEmbeddingsByLocation with a corrupt row: %v", err)
+	}
+	// The zero and short vectors still decode; the other two do not.
+	if len(embs) != 5 {
+		t.Errorf("GetEmbeddingsByLocation returned %d rows, want 5", len(embs))
+	}
+	results, err := s.SearchEmbeddings("Denver", []float64{1, 0, 0}, 1, MetricCosine)
+	if err != nil {
+		t.Fatalf("SearchEmbeddings with a corrupt row: %v", err)
+	}
+	if len(results) != 1 || results[0].Embedding[0] != 1 {
+		t.Errorf("SearchEmbeddings = %+v, want the [1 0 0] row", results)
+	}
+}
+
+// mustEmbed stores an embedding for location at ts.
+func mustEmbed(t *testing.T, s *SQLiteStore, location string, ts time.Time, vec []float64) {
+	t.Helper()
+	e := SnapshotEmbedding{SnapshotTS: ts.Format(time.RFC3339), Location: location, Summary: location + " " + ts.Format(time.Kitchen), Embedding: vec, CreatedAt: ts}
+	if err := s.InsertEmbedding(e); err != nil {
+		t.Fatalf("InsertEmbedding: %v", err)
+	}
+}
+
+func TestSearchEmbeddingsMulti(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	mustEmbed(t, s, "Denver", base, []float64{0.6, 0.8})
+	mustEmbed(t, s, "Denver", base.Add(time.Hour), []float64{0, 1})
+	mustEmbed(t, s, "Boston", base, []float64{1, 0})
+	mustEmbed(t, s, "Boston", base.Add(time.Hour), []float64{0.8, 0.6})
+	mustEmbed(t, s, "Austin", base, []float64{1, 0.01})
+
+	results, err := s.SearchEmbeddingsMulti([]string{"Denver", "Boston"}, []float64{1, 0}, 3, MetricCosine)
+	if err != nil {
+		t.Fatalf("SearchEmbeddingsMulti: %v", err)
+	}
+	// Ranked together by score; Austin's near-perfect match is not searched.
+	want := []struct {
+		location string
+		score    float64
+	}{{"Boston", 1}, {"Boston", 0.8}, {"Denver", 0.6}}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		if results[i].Location != w.location || math.Abs(results[i].Score-w.score) > 1e-9 {
+			t.Errorf("result %d = %s %.3f, want %s %.3f", i, results[i].Location, results[i].Score, w.location, w.score)
+		}
+	}
+}
+
+func TestSearchEmbeddingsRankingByMetric(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	// Against the query [1 0]: long exactly aligned (cosine 1, dot 10,
+	// distance 9), close (cosine 0.995, dot 1, distance 0.1) and diagonal
+	// (cosine 0.707, dot 2, distance 2.236).
+	mustEmbed(t, s, "Denver", base, []float64{10, 0})
+	mustEmbed(t, s, "Denver", base.Add(time.Hour), []float64{1, 0.1})
+	mustEmbed(t, s, "Denver", base.Add(2*time.Hour), []float64{2, 2})
+	name := map[string]string{
+		base.Format(time.RFC3339):                    "aligned",
+		base.Add(time.Hour).Format(time.RFC3339):     "close",
+		base.Add(2 * time.Hour).Format(time.RFC3339): "diagonal",
+	}
+
+	for metric, want := range map[Metric][]string{
+		MetricCosine:    {"aligned", "close", "diagonal"},
+		MetricDot:       {"aligned", "diagonal", "close"},
+		MetricEuclidean: {"close", "diagonal", "aligned"},
+	} {
+		results, err := s.SearchEmbeddings("Denver", []float64{1, 0}, 3, metric)
+		if err != nil {
+			t.Fatalf("SearchEmbeddings %s: %v", metric, err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, name[r.SnapshotTS])
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s ranked %v, want %v", metric, got, want)
+		}
+	}
+
+	// top_k keeps the best by the metric's direction
+	results, err := s.SearchEmbeddings("Denver", []float64{1, 0}, 1, MetricEuclidean)
+	if err != nil {
+		t.Fatalf("SearchEmbeddings: %v", err)
+	}
+	if len(results) != 1 || math.Abs(results[0].Score-0.1) > 1e-9 {
+		t.Errorf("nearest = %+v, want the close row at distance 0.1", results)
+	}
+}
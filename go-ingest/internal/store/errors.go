@@ -0,0 +1,14 @@
+package store
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by store methods. Callers should test
+// for them with errors.Is.
+var (
+	// ErrNotFound means the requested record does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidInput means an argument was rejected before querying, e.g.
+	// an unknown metric column.
+	ErrInvalidInput = errors.New("invalid input")
+)
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// Event is a notable occurrence detected for a location (e.g. an air
+// quality spike or a declared disaster).
+type Event struct {
+	ID            int64     `json:"id"`
+	Location      string    `json:"location"`
+	Timestamp     time.Time `json:"timestamp"`
+	EventType     string    `json:"event_type"`
+	Severity      float64   `json:"severity"`
+	SeverityLabel string    `json:"severity_label,omitempty"` // from models.SeverityLevels; not stored
+	Description   string    `json:"description"`
+}
+
+// InsertEvent stores an event and returns its ID.
+func (s *SQLiteStore) InsertEvent(e Event) (int64, error) {
+	return s.InsertEventContext(context.Background(), e)
+}
+
+// InsertEventContext is InsertEvent with a caller-supplied context.
+func (s *SQLiteStore) InsertEventContext(ctx context.Context, e Event) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `INSERT INTO events (location, ts, event_type, severity, description) VALUES (?, ?, ?, ?, ?)`,
+		e.Location, e.Timestamp.UTC().Format(time.RFC3339), e.EventType, e.Severity, e.Description)
+	if err != nil {
+		return 0, fmt.Errorf("insert event: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// GetEventsSince returns events for a location at or after since, newest first.
+func (s *SQLiteStore) GetEventsSince(location string, since time.Time) ([]Event, error) {
+	return s.GetEventsSinceContext(context.Background(), location, since)
+}
+
+// GetEventsSinceContext is GetEventsSince with a caller-supplied context.
+func (s *SQLiteStore) GetEventsSinceContext(ctx context.Context, location string, since time.Time) ([]Event, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, location, ts, event_type, severity, description FROM events
+	          WHERE location = ? AND ts >= ?
+	          ORDER BY ts DESC`, location, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		var tsStr string
+		if err := rows.Scan(&e.ID, &e.Location, &tsStr, &e.EventType, &e.Severity, &e.Description); err != nil {
+			return nil, err
+		}
+		if e.Timestamp, err = time.Parse(time.RFC3339, tsStr); err != nil {
+			return nil, err
+		}
+		e.SeverityLabel = models.SeverityLabel(e.Severity)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// insertOldRow writes a snapshot row using only the columns the old schema
+// has, the way that version's InsertSnapshot filled them.
+func insertOldRow(t *testing.T, db *sql.DB, ts time.Time) {
+	t.Helper()
+	rows, err := db.Query(`PRAGMA table_info(snapshot)`)
+	if err != nil {
+		t.Fatalf("table_info: %v", err)
+	}
+	var cols, vals []string
+	var args []interface{}
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			t.Fatalf("scan table_info: %v", err)
+		}
+		var v interface{}
+		switch {
+		case name == "ts":
+			v = ts.Format(time.RFC3339)
+		case name == "location":
+			v = "Denver"
+		case name == "temp_c":
+			v = 21.5
+		case strings.HasPrefix(name, "aq_") || strings.HasSuffix(name, "_observed_at"):
+			continue // nullable
+		case colType == "TEXT":
+			v = ""
+		default:
+			v = 0
+		}
+		cols, vals, args = append(cols, name), append(vals, "?"), append(args, v)
+	}
+	rows.Close()
+
+	q := fmt.Sprintf(`INSERT INTO snapshot (%s) VALUES (%s)`, strings.Join(cols, ", "), strings.Join(vals, ", "))
+	if _, err := db.Exec(q, args...); err != nil {
+		t.Fatalf("insert old row: %v", err)
+	}
+}
+
+func TestMigratePreservesRows(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+	ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, db *sql.DB)
+	}{
+		{"versioned at 6", func(t *testing.T, db *sql.DB) {
+			ctx := context.Background()
+			if _, err := db.Exec(`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TEXT NOT NULL)`); err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range migrations[:6] {
+				if err := applyMigration(ctx, db, m); err != nil {
+					t.Fatalf("apply %d: %v", m.Version, err)
+				}
+			}
+		}},
+		{"legacy without schema_version", func(t *testing.T, db *sql.DB) {
+			// The inline schema from before migrations, which predates aq_raw_units.
+			legacy := strings.Replace(migrations[0].SQL, "aq_raw_units TEXT,", "", 1)
+			if legacy == migrations[0].SQL {
+				t.Fatal("baseline migration no longer declares aq_raw_units")
+			}
+			if _, err := db.Exec(legacy); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "old.db")
+			db, err := sql.Open("sqlite", buildDSN(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.setup(t, db)
+			insertOldRow(t, db, ts)
+			db.Close()
+
+			s, err := NewSQLiteStore(path)
+			if err != nil {
+				t.Fatalf("NewSQLiteStore on old database: %v", err)
+			}
+			defer s.Close()
+
+			if v, err := s.SchemaVersion(context.Background()); err != nil || v != latest {
+				t.Errorf("SchemaVersion = %d, %v; want %d", v, err, latest)
+			}
+			snap, err := s.GetLatestSnapshot("Denver")
+			if err != nil {
+				t.Fatalf("old row unreadable after migration: %v", err)
+			}
+			if !snap.Timestamp.Equal(ts) || snap.Weather.TemperatureC != 21.5 {
+				t.Errorf("old row = %s at %v°C, want %s at 21.5°C", snap.Timestamp, snap.Weather.TemperatureC, ts)
+			}
+			if snap.Health.RSVTests != 0 || snap.Mobility.BikeShareBikesAvailable != 0 {
+				t.Errorf("columns added later read %d RSV tests, %d bikes; want 0", snap.Health.RSVTests, snap.Mobility.BikeShareBikesAvailable)
+			}
+		})
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "edgesight.db")
+	for i := 0; i < 2; i++ {
+		s, err := NewSQLiteStore(path)
+		if err != nil {
+			t.Fatalf("open %d: %v", i+1, err)
+		}
+		var applied int
+		s.DB.QueryRow(`SELECT COUNT(*) FROM schema_version`).Scan(&applied)
+		s.Close()
+		if migrations, _ := loadMigrations(); applied != len(migrations) {
+			t.Errorf("open %d: %d schema_version rows, want %d", i+1, applied, len(migrations))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+-- Baseline schema. Later schema changes go in new numbered files; never edit
+-- a migration that has shipped.
+CREATE TABLE IF NOT EXISTS raw (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp TEXT NOT NULL,
+	source TEXT NOT NULL,
+	payload BLOB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS snapshot (
+	ts TEXT PRIMARY KEY,
+	location TEXT NOT NULL,
+
+	-- Weather (OpenMeteo)
+	temp_c REAL,
+	humidity REAL,
+	wind REAL,
+	precip REAL,
+	cloud_cover REAL,
+	visibility_km REAL,
+
+	-- Environment / Air Quality (OpenAQ)
+	pm25 REAL,
+	pm10 REAL,
+	ozone REAL,
+	no2 REAL,
+	so2 REAL,
+	co REAL,
+	aq_raw_units TEXT,
+
+	-- Mobility (HERE, OpenSky, Movebank)
+	traffic_speed_kmh REAL,
+	traffic_jam_factor REAL,
+	flight_count INTEGER,
+	avg_altitude_m REAL,
+	active_species INTEGER,
+	animals_tracked INTEGER,
+	avg_migration_pace_km_day REAL,
+
+	-- Finance (AlphaVantage, NASDAQ)
+	stock_price REAL,
+	stock_symbol TEXT,
+	commodity_price REAL,
+	commodity_symbol TEXT,
+	market_cap REAL,
+	volume INTEGER,
+	nasdaq_index REAL,
+	volume_traded BIGINT,
+
+	-- Energy (Grid, US Energy Info, Ember)
+	electricity_price_usd REAL,
+	generation_mwh REAL,
+	renewable_percent REAL,
+	grid_load REAL,
+	carbon_intensity_gco2_kwh REAL,
+	grid_utilization_percent REAL,
+	natural_gas_price_mmbtu REAL,
+	coal_percent REAL,
+	gas_percent REAL,
+	nuclear_percent REAL,
+
+	-- Health (CDC FluView)
+	flu_cases INTEGER,
+	ili_percent REAL,
+	hospital_admissions INTEGER,
+
+	-- Agriculture (USDA NASS)
+	crop_yield REAL,
+	crop_type TEXT,
+	soil_moisture_percent REAL,
+	precip_forecast_mm REAL,
+	production_bushels REAL,
+	price_per_bushel REAL,
+	harvested_acres REAL,
+
+	-- Disasters (FEMA)
+	active_disasters INTEGER,
+	disaster_type TEXT,
+	severity INTEGER,
+	affected_counties INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS semantic_record (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	ts TEXT NOT NULL,
+	category TEXT NOT NULL,
+	summary TEXT NOT NULL,
+	snapshot_ts TEXT,
+	FOREIGN KEY (snapshot_ts) REFERENCES snapshot(ts)
+);
+
+CREATE INDEX IF NOT EXISTS idx_semantic_location_ts ON semantic_record(location, ts);
+CREATE INDEX IF NOT EXISTS idx_semantic_category ON semantic_record(category);
+
+CREATE TABLE IF NOT EXISTS agg_metrics (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	metric TEXT NOT NULL,
+	timeframe TEXT NOT NULL,
+	value REAL NOT NULL,
+	computed_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_agg_location_metric ON agg_metrics(location, metric, timeframe);
+
+CREATE TABLE IF NOT EXISTS events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	ts TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	severity REAL NOT NULL,
+	description TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_location_ts ON events(location, ts);
+
+-- Embeddings: store vector as JSON text for portability
+CREATE TABLE IF NOT EXISTS snapshot_embeddings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	snapshot_ts TEXT NOT NULL,
+	location TEXT NOT NULL,
+	summary TEXT NOT NULL,
+	embedding TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY (snapshot_ts) REFERENCES snapshot(ts)
+);
+CREATE INDEX IF NOT EXISTS idx_embeddings_location_ts ON snapshot_embeddings(location, snapshot_ts);
+
+-- Embedding re-index jobs; the cursor columns make a job resumable
+CREATE TABLE IF NOT EXISTS reindex_jobs (
+	id TEXT PRIMARY KEY,
+	location TEXT NOT NULL,
+	status TEXT NOT NULL,
+	total INTEGER NOT NULL,
+	processed INTEGER NOT NULL,
+	last_ts TEXT NOT NULL,
+	last_location TEXT NOT NULL,
+	error TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
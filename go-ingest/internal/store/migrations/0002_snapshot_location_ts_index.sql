@@ -0,0 +1,3 @@
+-- Per-location latest/range lookups (leaderboards, dashboards) otherwise scan
+-- the whole snapshot table.
+CREATE INDEX IF NOT EXISTS idx_snapshot_location_ts ON snapshot(location, ts);
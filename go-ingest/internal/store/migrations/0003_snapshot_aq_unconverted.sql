@@ -0,0 +1,3 @@
+-- JSON array of air-quality parameters whose reported units could not be
+-- converted to the canonical unit; NULL when every value was converted.
+ALTER TABLE snapshot ADD COLUMN aq_unconverted TEXT;
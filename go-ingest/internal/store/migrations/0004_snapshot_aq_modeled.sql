@@ -0,0 +1,3 @@
+-- JSON array of air-quality parameters filled from Open-Meteo's model
+-- because no sensor reported them; NULL when every value was measured.
+ALTER TABLE snapshot ADD COLUMN aq_modeled TEXT;
@@ -0,0 +1,17 @@
+-- FEMA detail behind the snapshot's coarse disaster columns, one row per
+-- ingest run. county is the three-digit FIPS code, or '' for a statewide
+-- summary; incident_counts and recent hold JSON.
+CREATE TABLE IF NOT EXISTS disasters (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	ts TEXT NOT NULL,
+	state TEXT NOT NULL,
+	county TEXT NOT NULL DEFAULT '',
+	active_disasters INTEGER,
+	top_incident_type TEXT,
+	severity INTEGER,
+	affected_counties INTEGER,
+	incident_counts TEXT,
+	recent TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_disasters_location_ts ON disasters(location, ts);
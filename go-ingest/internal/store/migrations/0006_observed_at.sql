@@ -0,0 +1,11 @@
+-- When each section's sources say their data was observed (RFC 3339), as
+-- opposed to ts, when the snapshot was built. NULL when no source reported
+-- a time.
+ALTER TABLE snapshot ADD COLUMN weather_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN environment_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN mobility_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN finance_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN energy_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN health_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN agriculture_observed_at TEXT;
+ALTER TABLE snapshot ADD COLUMN disasters_observed_at TEXT;
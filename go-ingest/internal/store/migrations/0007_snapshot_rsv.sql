@@ -0,0 +1,5 @@
+-- NREVSS RSV laboratory results, previously stored in the flu columns.
+-- Earlier snapshots read as 0, like a snapshot without RSV data.
+ALTER TABLE snapshot ADD COLUMN rsv_percent_positive REAL NOT NULL DEFAULT 0;
+ALTER TABLE snapshot ADD COLUMN rsv_detections INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE snapshot ADD COLUMN rsv_tests INTEGER NOT NULL DEFAULT 0;
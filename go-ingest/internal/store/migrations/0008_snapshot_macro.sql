@@ -0,0 +1,5 @@
+-- FRED macro indicators alongside the market data in the finance section.
+-- Earlier snapshots read as 0, like a snapshot taken without FRED_API_KEY.
+ALTER TABLE snapshot ADD COLUMN cpi REAL NOT NULL DEFAULT 0;
+ALTER TABLE snapshot ADD COLUMN unemployment_rate REAL NOT NULL DEFAULT 0;
+ALTER TABLE snapshot ADD COLUMN treasury_10y REAL NOT NULL DEFAULT 0;
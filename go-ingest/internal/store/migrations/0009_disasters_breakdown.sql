@@ -0,0 +1,5 @@
+-- Declaration-type mix (JSON, like incident_counts) and the newest incident
+-- begin date (RFC3339, '' when none) for the FEMA detail. Earlier rows read
+-- as having neither.
+ALTER TABLE disasters ADD COLUMN declaration_counts TEXT;
+ALTER TABLE disasters ADD COLUMN latest_incident_begin TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- CityBikes bike-share availability in the mobility section. Earlier
+-- snapshots read as 0, like a location without a nearby network.
+ALTER TABLE snapshot ADD COLUMN bike_share_bikes_available INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE snapshot ADD COLUMN bike_share_utilization_percent REAL NOT NULL DEFAULT 0;
@@ -1,8 +1,12 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
@@ -17,57 +21,109 @@ type TimeSeriesPoint struct {
 // SQL column list for SELECT queries
 const snapshotColumns = `ts, location,
 	temp_c, humidity, wind, precip, cloud_cover, visibility_km,
-	pm25, pm10, ozone, no2, so2, co,
-	traffic_speed_kmh, traffic_jam_factor, flight_count, avg_altitude_m, active_species, animals_tracked, avg_migration_pace_km_day,
-	stock_price, stock_symbol, commodity_price, commodity_symbol, market_cap, volume, nasdaq_index, volume_traded,
+	pm25, pm10, ozone, no2, so2, co, aq_raw_units, aq_unconverted, aq_modeled,
+	traffic_speed_kmh, traffic_jam_factor, flight_count, avg_altitude_m, active_species, animals_tracked, avg_migration_pace_km_day, bike_share_bikes_available, bike_share_utilization_percent,
+	stock_price, stock_symbol, commodity_price, commodity_symbol, market_cap, volume, nasdaq_index, volume_traded, cpi, unemployment_rate, treasury_10y,
 	electricity_price_usd, generation_mwh, renewable_percent, grid_load, carbon_intensity_gco2_kwh, grid_utilization_percent, natural_gas_price_mmbtu, coal_percent, gas_percent, nuclear_percent,
-	flu_cases, ili_percent, hospital_admissions,
+	flu_cases, ili_percent, hospital_admissions, rsv_percent_positive, rsv_detections, rsv_tests,
 	crop_yield, crop_type, soil_moisture_percent, precip_forecast_mm, production_bushels, price_per_bushel, harvested_acres,
-	active_disasters, disaster_type, severity, affected_counties`
+	active_disasters, disaster_type, severity, affected_counties,
+	weather_observed_at, environment_observed_at, mobility_observed_at, finance_observed_at, energy_observed_at, health_observed_at, agriculture_observed_at, disasters_observed_at`
 
-// GetLatestSnapshot retrieves the most recent snapshot for a location
+// GetLatestSnapshot retrieves the most recent snapshot for a location, or
+// ErrNotFound if it has none.
 func (s *SQLiteStore) GetLatestSnapshot(location string) (*models.Snapshot, error) {
+	return s.GetLatestSnapshotContext(context.Background(), location)
+}
+
+// GetLatestSnapshotContext is GetLatestSnapshot with a caller-supplied context.
+func (s *SQLiteStore) GetLatestSnapshotContext(ctx context.Context, location string) (*models.Snapshot, error) {
 	query := fmt.Sprintf(`SELECT %s FROM snapshot WHERE location = ? ORDER BY ts DESC LIMIT 1`, snapshotColumns)
 
-	row := s.DB.QueryRow(query, location)
+	row := s.DB.QueryRowContext(ctx, query, location)
 	snap, err := scanSnapshot(row)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("no snapshots found for location: %s", location)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("no snapshots found for location %s: %w", location, err)
+	}
+	return snap, err
+}
+
+// GetSnapshotAtOrBefore returns the newest snapshot for a location taken at
+// or before t, or ErrNotFound if there is none.
+func (s *SQLiteStore) GetSnapshotAtOrBefore(location string, t time.Time) (*models.Snapshot, error) {
+	return s.GetSnapshotAtOrBeforeContext(context.Background(), location, t)
+}
+
+// GetSnapshotAtOrBeforeContext is GetSnapshotAtOrBefore with a caller-supplied context.
+func (s *SQLiteStore) GetSnapshotAtOrBeforeContext(ctx context.Context, location string, t time.Time) (*models.Snapshot, error) {
+	query := fmt.Sprintf(`SELECT %s FROM snapshot WHERE location = ? AND ts <= ? ORDER BY ts DESC LIMIT 1`, snapshotColumns)
+
+	row := s.DB.QueryRowContext(ctx, query, location, t.UTC().Format(time.RFC3339))
+	snap, err := scanSnapshot(row)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("no snapshot for location %s at or before %s: %w", location, t.UTC().Format(time.RFC3339), err)
 	}
 	return snap, err
 }
 
 // GetSnapshotsByTimeRange retrieves all snapshots for a location within a time range
 func (s *SQLiteStore) GetSnapshotsByTimeRange(location string, start, end time.Time) ([]models.Snapshot, error) {
+	return s.GetSnapshotsByTimeRangeContext(context.Background(), location, start, end)
+}
+
+// GetSnapshotsByTimeRangeContext is GetSnapshotsByTimeRange with a caller-supplied context.
+func (s *SQLiteStore) GetSnapshotsByTimeRangeContext(ctx context.Context, location string, start, end time.Time) ([]models.Snapshot, error) {
+	var snapshots []models.Snapshot
+	err := s.EachSnapshotInRangeContext(ctx, location, start, end, func(snap models.Snapshot) error {
+		snapshots = append(snapshots, snap)
+		return nil
+	})
+	return snapshots, err
+}
+
+// EachSnapshotInRangeContext calls fn for each snapshot of a location within
+// a time range, oldest first, straight from the row iterator so large ranges
+// are never held in memory. An error from fn stops the scan and is returned.
+func (s *SQLiteStore) EachSnapshotInRangeContext(ctx context.Context, location string, start, end time.Time, fn func(models.Snapshot) error) error {
 	query := fmt.Sprintf(`SELECT %s FROM snapshot 
 	          WHERE location = ? AND ts >= ? AND ts <= ? 
 	          ORDER BY ts ASC`, snapshotColumns)
 
-	rows, err := s.DB.Query(query, location, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	rows, err := s.DB.QueryContext(ctx, query, location, start.Format(time.RFC3339), end.Format(time.RFC3339))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var snapshots []models.Snapshot
 	for rows.Next() {
 		snap, err := scanSnapshotRow(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(*snap); err != nil {
+			return err
 		}
-		snapshots = append(snapshots, *snap)
 	}
 
-	return snapshots, rows.Err()
+	return rows.Err()
 }
 
-// GetMetricSeries retrieves a time series for a specific metric
+// GetMetricSeries retrieves a time series for a specific metric. The metric
+// must be a numeric snapshot column (see IsMetricColumn).
 func (s *SQLiteStore) GetMetricSeries(metric, location string, start, end time.Time) ([]TimeSeriesPoint, error) {
+	return s.GetMetricSeriesContext(context.Background(), metric, location, start, end)
+}
+
+// GetMetricSeriesContext is GetMetricSeries with a caller-supplied context.
+func (s *SQLiteStore) GetMetricSeriesContext(ctx context.Context, metric, location string, start, end time.Time) ([]TimeSeriesPoint, error) {
+	if !IsMetricColumn(metric) {
+		return nil, fmt.Errorf("unknown metric %q: %w", metric, ErrInvalidInput)
+	}
 	query := fmt.Sprintf(`SELECT ts, %s FROM snapshot 
 	                      WHERE location = ? AND ts >= ? AND ts <= ? AND %s IS NOT NULL
 	                      ORDER BY ts ASC`, metric, metric)
 
-	rows, err := s.DB.Query(query, location, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	rows, err := s.DB.QueryContext(ctx, query, location, start.Format(time.RFC3339), end.Format(time.RFC3339))
 	if err != nil {
 		return nil, err
 	}
@@ -95,21 +151,45 @@ func (s *SQLiteStore) GetMetricSeries(metric, location string, start, end time.T
 	return series, rows.Err()
 }
 
-// scanSnapshot scans a single row into a Snapshot
+// rowScanner is the Scan method shared by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSnapshot scans a single row into a Snapshot. An empty result is
+// reported as ErrNotFound rather than sql.ErrNoRows, so callers only add
+// context to the message.
 func scanSnapshot(row *sql.Row) (*models.Snapshot, error) {
+	snap, err := scanSnapshotFrom(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return snap, err
+}
+
+// scanSnapshotRow scans a Rows iterator into a Snapshot
+func scanSnapshotRow(rows *sql.Rows) (*models.Snapshot, error) {
+	return scanSnapshotFrom(rows)
+}
+
+// scanSnapshotFrom scans the snapshotColumns of one row.
+func scanSnapshotFrom(row rowScanner) (*models.Snapshot, error) {
 	var snap models.Snapshot
 	var tsStr string
+	var rawUnits, unconverted, modeled sql.NullString
+	var observed [8]sql.NullString
 
 	err := row.Scan(
 		&tsStr, &snap.Location,
 		&snap.Weather.TemperatureC, &snap.Weather.Humidity, &snap.Weather.WindSpeedMS, &snap.Weather.PrecipMM, &snap.Weather.CloudCover, &snap.Weather.Visibility,
-		&snap.Environment.PM25, &snap.Environment.PM10, &snap.Environment.Ozone, &snap.Environment.NO2, &snap.Environment.SO2, &snap.Environment.CO,
-		&snap.Mobility.TrafficSpeedKmH, &snap.Mobility.TrafficJamFactor, &snap.Mobility.FlightCount, &snap.Mobility.AvgAltitudeM, &snap.Mobility.ActiveSpecies, &snap.Mobility.AnimalsTracked, &snap.Mobility.AvgMigrationPaceKMDay,
-		&snap.Finance.StockPrice, &snap.Finance.StockSymbol, &snap.Finance.CommodityPrice, &snap.Finance.CommoditySymbol, &snap.Finance.MarketCap, &snap.Finance.Volume, &snap.Finance.NASDAQIndex, &snap.Finance.VolumeTraded,
+		&snap.Environment.PM25, &snap.Environment.PM10, &snap.Environment.Ozone, &snap.Environment.NO2, &snap.Environment.SO2, &snap.Environment.CO, &rawUnits, &unconverted, &modeled,
+		&snap.Mobility.TrafficSpeedKmH, &snap.Mobility.TrafficJamFactor, &snap.Mobility.FlightCount, &snap.Mobility.AvgAltitudeM, &snap.Mobility.ActiveSpecies, &snap.Mobility.AnimalsTracked, &snap.Mobility.AvgMigrationPaceKMDay, &snap.Mobility.BikeShareBikesAvailable, &snap.Mobility.BikeShareUtilizationPercent,
+		&snap.Finance.StockPrice, &snap.Finance.StockSymbol, &snap.Finance.CommodityPrice, &snap.Finance.CommoditySymbol, &snap.Finance.MarketCap, &snap.Finance.Volume, &snap.Finance.NASDAQIndex, &snap.Finance.VolumeTraded, &snap.Finance.CPI, &snap.Finance.UnemploymentRate, &snap.Finance.Treasury10Y,
 		&snap.Energy.ElectricityPriceUSD, &snap.Energy.GenerationMWh, &snap.Energy.RenewablePercent, &snap.Energy.GridLoad, &snap.Energy.CarbonIntensity, &snap.Energy.GridUtilizationPercent, &snap.Energy.NaturalGasPriceMmbtu, &snap.Energy.CoalPercent, &snap.Energy.GasPercent, &snap.Energy.NuclearPercent,
-		&snap.Health.FluCases, &snap.Health.ILIPercent, &snap.Health.HospitalAdmissions,
+		&snap.Health.FluCases, &snap.Health.ILIPercent, &snap.Health.HospitalAdmissions, &snap.Health.RSVPercentPositive, &snap.Health.RSVDetections, &snap.Health.RSVTests,
 		&snap.Agriculture.CropYield, &snap.Agriculture.CropType, &snap.Agriculture.SoilMoisture, &snap.Agriculture.PrecipForecast, &snap.Agriculture.ProductionBushels, &snap.Agriculture.PricePerBushel, &snap.Agriculture.HarvestedAcres,
 		&snap.Disasters.ActiveDisasters, &snap.Disasters.DisasterType, &snap.Disasters.Severity, &snap.Disasters.AffectedCounties,
+		&observed[0], &observed[1], &observed[2], &observed[3], &observed[4], &observed[5], &observed[6], &observed[7],
 	)
 
 	if err != nil {
@@ -120,35 +200,383 @@ func scanSnapshot(row *sql.Row) (*models.Snapshot, error) {
 	if err != nil {
 		return nil, err
 	}
+	if rawUnits.Valid {
+		if err := json.Unmarshal([]byte(rawUnits.String), &snap.Environment.RawUnits); err != nil {
+			return nil, fmt.Errorf("decode aq_raw_units: %w", err)
+		}
+	}
+	if unconverted.Valid {
+		if err := json.Unmarshal([]byte(unconverted.String), &snap.Environment.UnconvertedUnits); err != nil {
+			return nil, fmt.Errorf("decode aq_unconverted: %w", err)
+		}
+	}
+	if modeled.Valid {
+		if err := json.Unmarshal([]byte(modeled.String), &snap.Environment.Modeled); err != nil {
+			return nil, fmt.Errorf("decode aq_modeled: %w", err)
+		}
+	}
+
+	// Same order as the *_observed_at columns.
+	sections := []**time.Time{
+		&snap.Weather.ObservedAt, &snap.Environment.ObservedAt, &snap.Mobility.ObservedAt, &snap.Finance.ObservedAt,
+		&snap.Energy.ObservedAt, &snap.Health.ObservedAt, &snap.Agriculture.ObservedAt, &snap.Disasters.ObservedAt,
+	}
+	for i, dst := range sections {
+		if !observed[i].Valid {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, observed[i].String)
+		if err != nil {
+			return nil, fmt.Errorf("decode observed_at: %w", err)
+		}
+		*dst = &t
+	}
 
 	return &snap, nil
 }
 
-// scanSnapshotRow scans a Rows iterator into a Snapshot
-func scanSnapshotRow(rows *sql.Rows) (*models.Snapshot, error) {
-	var snap models.Snapshot
+// GetSnapshotsWithoutEmbedding returns snapshots that have no matching
+// snapshot_embeddings row, oldest first. An empty location matches every
+// location and a zero since disables the time filter.
+func (s *SQLiteStore) GetSnapshotsWithoutEmbedding(location string, since time.Time) ([]models.Snapshot, error) {
+	query := fmt.Sprintf(`SELECT %s FROM snapshot s
+	          WHERE NOT EXISTS (
+	              SELECT 1 FROM snapshot_embeddings e
+	              WHERE e.snapshot_ts = s.ts AND e.location = s.location)
+	          AND (? = '' OR s.location = ?)
+	          AND s.ts >= ?
+	          ORDER BY s.ts ASC`, snapshotColumns)
+
+	sinceStr := ""
+	if !since.IsZero() {
+		sinceStr = since.UTC().Format(time.RFC3339)
+	}
+
+	rows, err := s.DB.Query(query, location, location, sinceStr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []models.Snapshot
+	for rows.Next() {
+		snap, err := scanSnapshotRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// GetSnapshotsInsertedAfter returns the snapshots for every location written
+// after the row whose insertion sequence number (its rowid) is seq, in
+// insertion order, along with the sequence number of the last one returned
+// (seq itself when there are none). Unlike a ts cursor it also picks up
+// backfilled and late rows older than ones already seen.
+func (s *SQLiteStore) GetSnapshotsInsertedAfter(seq int64) ([]models.Snapshot, int64, error) {
+	return s.GetSnapshotsInsertedAfterContext(context.Background(), seq)
+}
+
+// GetSnapshotsInsertedAfterContext is GetSnapshotsInsertedAfter with a caller-supplied context.
+func (s *SQLiteStore) GetSnapshotsInsertedAfterContext(ctx context.Context, seq int64) ([]models.Snapshot, int64, error) {
+	query := fmt.Sprintf(`SELECT rowid, %s FROM snapshot WHERE rowid > ? ORDER BY rowid ASC`, snapshotColumns)
+
+	rows, err := s.DB.QueryContext(ctx, query, seq)
+	if err != nil {
+		return nil, seq, err
+	}
+	defer rows.Close()
+
+	var snapshots []models.Snapshot
+	for rows.Next() {
+		snap, err := scanSnapshotFrom(seqScanner{rows, &seq})
+		if err != nil {
+			return nil, seq, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+
+	return snapshots, seq, rows.Err()
+}
+
+// LastSnapshotSeq returns the insertion sequence number of the most recently
+// written snapshot, or 0 when there are none; see GetSnapshotsInsertedAfter.
+func (s *SQLiteStore) LastSnapshotSeq() (int64, error) {
+	return s.LastSnapshotSeqContext(context.Background())
+}
+
+// LastSnapshotSeqContext is LastSnapshotSeq with a caller-supplied context.
+func (s *SQLiteStore) LastSnapshotSeqContext(ctx context.Context) (int64, error) {
+	var seq int64
+	err := s.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(rowid), 0) FROM snapshot`).Scan(&seq)
+	return seq, err
+}
+
+// seqScanner scans a leading rowid column into seq before the row's
+// snapshotColumns.
+type seqScanner struct {
+	rowScanner
+	seq *int64
+}
+
+func (s seqScanner) Scan(dest ...interface{}) error {
+	return s.rowScanner.Scan(append([]interface{}{s.seq}, dest...)...)
+}
+
+// GetLatestMetricValue returns the newest non-NULL value of a metric at a
+// location and when it was recorded, or ErrNotFound when there is none.
+func (s *SQLiteStore) GetLatestMetricValue(metric, location string) (float64, time.Time, error) {
+	return s.GetLatestMetricValueContext(context.Background(), metric, location)
+}
+
+// GetLatestMetricValueContext is GetLatestMetricValue with a caller-supplied context.
+func (s *SQLiteStore) GetLatestMetricValueContext(ctx context.Context, metric, location string) (float64, time.Time, error) {
+	if !IsMetricColumn(metric) {
+		return 0, time.Time{}, fmt.Errorf("unknown metric %q: %w", metric, ErrInvalidInput)
+	}
+	query := fmt.Sprintf(`SELECT ts, %s FROM snapshot
+	                      WHERE location = ? AND %s IS NOT NULL
+	                      ORDER BY ts DESC LIMIT 1`, metric, metric)
+
 	var tsStr string
+	var value float64
+	err := s.DB.QueryRowContext(ctx, query, location).Scan(&tsStr, &value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, fmt.Errorf("no %s value for location %s: %w", metric, location, ErrNotFound)
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	ts, err := time.Parse(time.RFC3339, tsStr)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return value, ts, nil
+}
 
-	err := rows.Scan(
-		&tsStr, &snap.Location,
-		&snap.Weather.TemperatureC, &snap.Weather.Humidity, &snap.Weather.WindSpeedMS, &snap.Weather.PrecipMM, &snap.Weather.CloudCover, &snap.Weather.Visibility,
-		&snap.Environment.PM25, &snap.Environment.PM10, &snap.Environment.Ozone, &snap.Environment.NO2, &snap.Environment.SO2, &snap.Environment.CO,
-		&snap.Mobility.TrafficSpeedKmH, &snap.Mobility.TrafficJamFactor, &snap.Mobility.FlightCount, &snap.Mobility.AvgAltitudeM, &snap.Mobility.ActiveSpecies, &snap.Mobility.AnimalsTracked, &snap.Mobility.AvgMigrationPaceKMDay,
-		&snap.Finance.StockPrice, &snap.Finance.StockSymbol, &snap.Finance.CommodityPrice, &snap.Finance.CommoditySymbol, &snap.Finance.MarketCap, &snap.Finance.Volume, &snap.Finance.NASDAQIndex, &snap.Finance.VolumeTraded,
-		&snap.Energy.ElectricityPriceUSD, &snap.Energy.GenerationMWh, &snap.Energy.RenewablePercent, &snap.Energy.GridLoad, &snap.Energy.CarbonIntensity, &snap.Energy.GridUtilizationPercent, &snap.Energy.NaturalGasPriceMmbtu, &snap.Energy.CoalPercent, &snap.Energy.GasPercent, &snap.Energy.NuclearPercent,
-		&snap.Health.FluCases, &snap.Health.ILIPercent, &snap.Health.HospitalAdmissions,
-		&snap.Agriculture.CropYield, &snap.Agriculture.CropType, &snap.Agriculture.SoilMoisture, &snap.Agriculture.PrecipForecast, &snap.Agriculture.ProductionBushels, &snap.Agriculture.PricePerBushel, &snap.Agriculture.HarvestedAcres,
-		&snap.Disasters.ActiveDisasters, &snap.Disasters.DisasterType, &snap.Disasters.Severity, &snap.Disasters.AffectedCounties,
-	)
+// GetLatestSnapshotTime returns the timestamp of the newest snapshot for a
+// location, or the zero time when there is none.
+func (s *SQLiteStore) GetLatestSnapshotTime(location string) (time.Time, error) {
+	return s.GetLatestSnapshotTimeContext(context.Background(), location)
+}
 
+// GetLatestSnapshotTimeContext is GetLatestSnapshotTime with a caller-supplied context.
+func (s *SQLiteStore) GetLatestSnapshotTimeContext(ctx context.Context, location string) (time.Time, error) {
+	var tsStr sql.NullString
+	q := `SELECT MAX(ts) FROM snapshot`
+	var args []interface{}
+	if location != "" {
+		q += ` WHERE location = ?`
+		args = append(args, location)
+	}
+	err := s.DB.QueryRowContext(ctx, q, args...).Scan(&tsStr)
+	if err != nil || !tsStr.Valid {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, tsStr.String)
+}
+
+// GetRecentSnapshots returns up to limit of the newest snapshots for a
+// location, newest first.
+func (s *SQLiteStore) GetRecentSnapshots(location string, limit int) ([]models.Snapshot, error) {
+	return s.GetRecentSnapshotsContext(context.Background(), location, limit)
+}
+
+// GetRecentSnapshotsContext is GetRecentSnapshots with a caller-supplied context.
+func (s *SQLiteStore) GetRecentSnapshotsContext(ctx context.Context, location string, limit int) ([]models.Snapshot, error) {
+	query := fmt.Sprintf(`SELECT %s FROM snapshot WHERE location = ? ORDER BY ts DESC LIMIT ?`, snapshotColumns)
+
+	rows, err := s.DB.QueryContext(ctx, query, location, limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	snap.Timestamp, err = time.Parse(time.RFC3339, tsStr)
+	var snapshots []models.Snapshot
+	for rows.Next() {
+		snap, err := scanSnapshotRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// MetricAggregate summarizes one metric over a time window.
+type MetricAggregate struct {
+	Avg   float64 `json:"avg"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// textSnapshotColumns are the non-numeric columns in snapshotColumns.
+var textSnapshotColumns = map[string]bool{
+	"ts": true, "location": true, "stock_symbol": true, "commodity_symbol": true,
+	"crop_type": true, "disaster_type": true, "aq_raw_units": true,
+	"aq_unconverted": true, "aq_modeled": true,
+	"weather_observed_at": true, "environment_observed_at": true, "mobility_observed_at": true,
+	"finance_observed_at": true, "energy_observed_at": true, "health_observed_at": true,
+	"agriculture_observed_at": true, "disasters_observed_at": true,
+}
+
+// IsMetricColumn reports whether name is a numeric snapshot column, which
+// makes it safe to interpolate into SQL.
+func IsMetricColumn(name string) bool {
+	if textSnapshotColumns[name] {
+		return false
+	}
+	for _, col := range strings.Split(snapshotColumns, ",") {
+		if strings.TrimSpace(col) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMetricAggregates computes avg/min/max for each metric over snapshots
+// for a location within [start, end] in a single query.
+func (s *SQLiteStore) GetMetricAggregates(location string, metrics []string, start, end time.Time) (map[string]MetricAggregate, error) {
+	return s.GetMetricAggregatesContext(context.Background(), location, metrics, start, end)
+}
+
+// GetMetricAggregatesContext is GetMetricAggregates with a caller-supplied context.
+func (s *SQLiteStore) GetMetricAggregatesContext(ctx context.Context, location string, metrics []string, start, end time.Time) (map[string]MetricAggregate, error) {
+	if len(metrics) == 0 {
+		return map[string]MetricAggregate{}, nil
+	}
+
+	selects := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		if !IsMetricColumn(m) {
+			return nil, fmt.Errorf("unknown metric %q: %w", m, ErrInvalidInput)
+		}
+		selects = append(selects, fmt.Sprintf("AVG(%[1]s), MIN(%[1]s), MAX(%[1]s), COUNT(%[1]s)", m))
+	}
+	query := fmt.Sprintf(`SELECT %s FROM snapshot WHERE location = ? AND ts >= ? AND ts <= ?`, strings.Join(selects, ", "))
+
+	vals := make([]sql.NullFloat64, len(metrics)*3)
+	counts := make([]int, len(metrics))
+	dest := make([]interface{}, 0, len(metrics)*4)
+	for i := range metrics {
+		dest = append(dest, &vals[i*3], &vals[i*3+1], &vals[i*3+2], &counts[i])
+	}
+
+	err := s.DB.QueryRowContext(ctx, query, location, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339)).Scan(dest...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &snap, nil
+	out := make(map[string]MetricAggregate, len(metrics))
+	for i, m := range metrics {
+		if counts[i] == 0 {
+			continue
+		}
+		out[m] = MetricAggregate{
+			Avg:   vals[i*3].Float64,
+			Min:   vals[i*3+1].Float64,
+			Max:   vals[i*3+2].Float64,
+			Count: counts[i],
+		}
+	}
+	return out, nil
+}
+
+// LocationSummary describes the stored data for one location.
+type LocationSummary struct {
+	Location      string    `json:"location"`
+	SnapshotCount int       `json:"snapshot_count"`
+	FirstTS       time.Time `json:"first_ts"`
+	LatestTS      time.Time `json:"latest_ts"`
+}
+
+// GetLocations lists every location with at least one snapshot, by name.
+func (s *SQLiteStore) GetLocations() ([]LocationSummary, error) {
+	return s.GetLocationsContext(context.Background())
+}
+
+// GetLocationsContext is GetLocations with a caller-supplied context.
+func (s *SQLiteStore) GetLocationsContext(ctx context.Context) ([]LocationSummary, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT location, COUNT(*), MIN(ts), MAX(ts) FROM snapshot GROUP BY location ORDER BY location`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []LocationSummary{}
+	for rows.Next() {
+		var loc LocationSummary
+		var firstStr, latestStr string
+		if err := rows.Scan(&loc.Location, &loc.SnapshotCount, &firstStr, &latestStr); err != nil {
+			return nil, err
+		}
+		if loc.FirstTS, err = time.Parse(time.RFC3339, firstStr); err != nil {
+			return nil, err
+		}
+		if loc.LatestTS, err = time.Parse(time.RFC3339, latestStr); err != nil {
+			return nil, err
+		}
+		locations = append(locations, loc)
+	}
+	return locations, rows.Err()
+}
+
+// LocationMetricValue is one location's latest value of a metric.
+type LocationMetricValue struct {
+	Location  string    `json:"location"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// GetTopLocationsByMetric ranks locations by the metric's value in their
+// latest snapshot, highest first unless ascending is set, returning at most
+// limit rows. Locations whose latest snapshot has no value for the metric
+// are left out.
+func (s *SQLiteStore) GetTopLocationsByMetric(metric string, ascending bool, limit int) ([]LocationMetricValue, error) {
+	return s.GetTopLocationsByMetricContext(context.Background(), metric, ascending, limit)
+}
+
+// GetTopLocationsByMetricContext is GetTopLocationsByMetric with a caller-supplied context.
+func (s *SQLiteStore) GetTopLocationsByMetricContext(ctx context.Context, metric string, ascending bool, limit int) ([]LocationMetricValue, error) {
+	if !IsMetricColumn(metric) {
+		return nil, fmt.Errorf("unknown metric %q: %w", metric, ErrInvalidInput)
+	}
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be positive: %w", ErrInvalidInput)
+	}
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+
+	query := fmt.Sprintf(`SELECT s.location, s.ts, s.%[1]s FROM snapshot s
+	                      JOIN (SELECT location, MAX(ts) AS ts FROM snapshot GROUP BY location) latest
+	                        ON s.location = latest.location AND s.ts = latest.ts
+	                      WHERE s.%[1]s IS NOT NULL
+	                      ORDER BY s.%[1]s %[2]s, s.location ASC
+	                      LIMIT ?`, metric, order)
+
+	rows, err := s.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []LocationMetricValue{}
+	for rows.Next() {
+		var v LocationMetricValue
+		var tsStr string
+		if err := rows.Scan(&v.Location, &tsStr, &v.Value); err != nil {
+			return nil, err
+		}
+		if v.Timestamp, err = time.Parse(time.RFC3339, tsStr); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
 }
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// newTestStore opens a fresh, fully migrated store in a temp directory.
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "edgesight.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// mustInsert stores a snapshot for location at ts and returns it.
+func mustInsert(t *testing.T, s *SQLiteStore, location string, ts time.Time) models.Snapshot {
+	t.Helper()
+	snap := models.Snapshot{Location: location, Timestamp: ts}
+	snap.Weather.TemperatureC = 20
+	if err := s.InsertSnapshot(snap); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+	return snap
+}
+
+func TestGetSnapshotsWithoutEmbedding(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	embedded := mustInsert(t, s, "Denver", base)
+	mustInsert(t, s, "Denver", base.Add(time.Hour))
+	mustInsert(t, s, "Denver", base.Add(2*time.Hour))
+	mustInsert(t, s, "Boston", base.Add(90*time.Minute))
+
+	err := s.InsertEmbedding(SnapshotEmbedding{
+		SnapshotTS: embedded.Timestamp.Format(time.RFC3339),
+		Location:   "Denver",
+		Summary:    "embedded",
+		Embedding:  []float64{1, 0},
+		CreatedAt:  base,
+	})
+	if err != nil {
+		t.Fatalf("InsertEmbedding: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		location string
+		since    time.Time
+		want     []string // location@minutes after base, oldest first
+	}{
+		{"one location", "Denver", time.Time{}, []string{"Denver@60", "Denver@120"}},
+		{"all locations", "", time.Time{}, []string{"Denver@60", "Boston@90", "Denver@120"}},
+		{"since", "Denver", base.Add(2 * time.Hour), []string{"Denver@120"}},
+		{"none missing", "Nowhere", time.Time{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snaps, err := s.GetSnapshotsWithoutEmbedding(tt.location, tt.since)
+			if err != nil {
+				t.Fatalf("GetSnapshotsWithoutEmbedding: %v", err)
+			}
+			var got []string
+			for _, snap := range snaps {
+				got = append(got, fmt.Sprintf("%s@%.0f", snap.Location, snap.Timestamp.Sub(base).Minutes()))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSnapshotsInsertedAfter(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	if seq, err := s.LastSnapshotSeq(); err != nil || seq != 0 {
+		t.Fatalf("LastSnapshotSeq on an empty table = %d, %v; want 0", seq, err)
+	}
+	mustInsert(t, s, "Denver", base)
+	cursor, err := s.LastSnapshotSeq()
+	if err != nil {
+		t.Fatalf("LastSnapshotSeq: %v", err)
+	}
+
+	// An older-timestamped row written after a newer one still follows it
+	mustInsert(t, s, "Denver", base.Add(time.Hour))
+	mustInsert(t, s, "Boston", base.Add(-2*time.Hour))
+	snaps, next, err := s.GetSnapshotsInsertedAfter(cursor)
+	if err != nil {
+		t.Fatalf("GetSnapshotsInsertedAfter: %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].Location != "Denver" || snaps[1].Location != "Boston" || !snaps[1].Timestamp.Equal(base.Add(-2*time.Hour)) {
+		t.Fatalf("got %v, want Denver at +1h then Boston at -2h", snaps)
+	}
+
+	if snaps, again, err := s.GetSnapshotsInsertedAfter(next); err != nil || len(snaps) != 0 || again != next {
+		t.Errorf("after the last row: %d snapshots, cursor %d -> %d, %v; want none and the cursor unchanged", len(snaps), next, again, err)
+	}
+}
+
+func TestGetTopLocationsByMetric(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	insert := func(location string, minutes int, pm25 float64) {
+		t.Helper()
+		snap := models.Snapshot{Location: location, Timestamp: base.Add(time.Duration(minutes) * time.Minute)}
+		snap.Environment.PM25 = pm25
+		if err := s.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+	// Older snapshots rank differently from the latest ones, which are what count.
+	insert("Denver", 0, 90)
+	insert("Denver", 60, 12)
+	insert("Boston", 10, 1)
+	insert("Boston", 70, 35)
+	insert("Phoenix", 20, 50)
+	insert("Phoenix", 80, 8)
+	insert("Seattle", 90, 0)
+	if _, err := s.DB.Exec(`UPDATE snapshot SET pm25 = NULL WHERE location = 'Seattle'`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		ascending bool
+		limit     int
+		want      []string
+	}{
+		{"worst first", false, 10, []string{"Boston=35", "Denver=12", "Phoenix=8"}},
+		{"best first", true, 10, []string{"Phoenix=8", "Denver=12", "Boston=35"}},
+		{"limited", false, 2, []string{"Boston=35", "Denver=12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := s.GetTopLocationsByMetric("pm25", tt.ascending, tt.limit)
+			if err != nil {
+				t.Fatalf("GetTopLocationsByMetric: %v", err)
+			}
+			var got []string
+			for _, r := range rows {
+				got = append(got, fmt.Sprintf("%s=%.0f", r.Location, r.Value))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, metric := range []string{"pm25; DROP TABLE snapshot", "stock_symbol", "nope"} {
+		if _, err := s.GetTopLocationsByMetric(metric, false, 5); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("metric %q: err = %v, want ErrInvalidInput", metric, err)
+		}
+	}
+}
+
+func TestSnapshotLookupsReportErrNotFound(t *testing.T) {
+	s := newTestStore(t)
+	ts := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mustInsert(t, s, "Denver", ts)
+
+	lookups := map[string]func() (*models.Snapshot, error){
+		"latest for unknown location": func() (*models.Snapshot, error) { return s.GetLatestSnapshot("Atlantis") },
+		"at-or-before for unknown location": func() (*models.Snapshot, error) {
+			return s.GetSnapshotAtOrBefore("Atlantis", ts)
+		},
+		"at-or-before earlier than any row": func() (*models.Snapshot, error) {
+			return s.GetSnapshotAtOrBefore("Denver", ts.Add(-time.Hour))
+		},
+	}
+	for name, lookup := range lookups {
+		snap, err := lookup()
+		if !errors.Is(err, ErrNotFound) || snap != nil {
+			t.Errorf("%s = %v, %v; want nil and ErrNotFound", name, snap, err)
+		}
+	}
+
+	if snap, err := s.GetLatestSnapshot("Denver"); err != nil || !snap.Timestamp.Equal(ts) {
+		t.Errorf("GetLatestSnapshot(Denver) = %v, %v", snap, err)
+	}
+}
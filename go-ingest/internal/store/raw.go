@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// InsertRaw archives a source payload that has no snapshot column (e.g. the
+// extra symbols of a stock watchlist). Data is stored as JSON.
+func (s *SQLiteStore) InsertRaw(r models.RawData) error {
+	return s.InsertRawContext(context.Background(), r)
+}
+
+// InsertRawContext is InsertRaw with a caller-supplied context.
+func (s *SQLiteStore) InsertRawContext(ctx context.Context, r models.RawData) error {
+	payload, err := json.Marshal(r.Data)
+	if err != nil {
+		return fmt.Errorf("marshal raw payload: %w", err)
+	}
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO raw (timestamp, source, payload) VALUES (?, ?, ?)`,
+		r.Timestamp.UTC().Format(time.RFC3339), r.Source, payload)
+	if err != nil {
+		return fmt.Errorf("insert raw: %w", err)
+	}
+	return nil
+}
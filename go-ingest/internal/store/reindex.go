@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+// Reindex job statuses.
+const (
+	ReindexRunning     = "running"
+	ReindexCompleted   = "completed"
+	ReindexFailed      = "failed"
+	ReindexInterrupted = "interrupted"
+)
+
+// ReindexJob tracks an embedding re-index over one location (or all, when
+// Location is empty). LastTS/LastLocation is the cursor of the last snapshot
+// whose embedding was rewritten, so a failed or interrupted job can resume.
+type ReindexJob struct {
+	ID           string    `json:"id"`
+	Location     string    `json:"location,omitempty"`
+	Status       string    `json:"status"`
+	Total        int       `json:"total"`
+	Processed    int       `json:"processed"`
+	LastTS       string    `json:"last_ts,omitempty"`
+	LastLocation string    `json:"last_location,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SaveReindexJob inserts or replaces a job row.
+func (s *SQLiteStore) SaveReindexJob(j ReindexJob) error {
+	_, err := s.DB.Exec(`INSERT OR REPLACE INTO reindex_jobs
+		(id, location, status, total, processed, last_ts, last_location, error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		j.ID, j.Location, j.Status, j.Total, j.Processed, j.LastTS, j.LastLocation, j.Error,
+		j.CreatedAt.UTC().Format(time.RFC3339), j.UpdatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("save reindex job: %w", err)
+	}
+	return nil
+}
+
+// GetReindexJob loads a job by ID, returning ErrNotFound if there is none.
+func (s *SQLiteStore) GetReindexJob(id string) (*ReindexJob, error) {
+	return s.GetReindexJobContext(context.Background(), id)
+}
+
+// GetReindexJobContext is GetReindexJob with a caller-supplied context.
+func (s *SQLiteStore) GetReindexJobContext(ctx context.Context, id string) (*ReindexJob, error) {
+	var j ReindexJob
+	var created, updated string
+	err := s.DB.QueryRowContext(ctx, `SELECT id, location, status, total, processed, last_ts, last_location, error, created_at, updated_at
+		FROM reindex_jobs WHERE id = ?`, id).
+		Scan(&j.ID, &j.Location, &j.Status, &j.Total, &j.Processed, &j.LastTS, &j.LastLocation, &j.Error, &created, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no reindex job %q: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if j.CreatedAt, err = time.Parse(time.RFC3339, created); err != nil {
+		return nil, err
+	}
+	if j.UpdatedAt, err = time.Parse(time.RFC3339, updated); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// InterruptRunningReindexJobs marks jobs left running by a previous process
+// as interrupted, so they can be resumed.
+func (s *SQLiteStore) InterruptRunningReindexJobs() error {
+	_, err := s.DB.Exec(`UPDATE reindex_jobs SET status = ?, updated_at = ? WHERE status = ?`,
+		ReindexInterrupted, time.Now().UTC().Format(time.RFC3339), ReindexRunning)
+	return err
+}
+
+// CountSnapshotsAfter counts snapshots after the (ts, location) cursor. An
+// empty location matches every location; an empty cursor starts at the
+// beginning.
+func (s *SQLiteStore) CountSnapshotsAfter(location, afterTS, afterLocation string) (int, error) {
+	var n int
+	err := s.DB.QueryRow(`SELECT COUNT(*) FROM snapshot
+		WHERE (? = '' OR location = ?) AND (ts > ? OR (ts = ? AND location > ?))`,
+		location, location, afterTS, afterTS, afterLocation).Scan(&n)
+	return n, err
+}
+
+// GetSnapshotsPage returns up to limit snapshots after the (ts, location)
+// cursor, ordered by ts then location, for walking the table in batches.
+func (s *SQLiteStore) GetSnapshotsPage(location, afterTS, afterLocation string, limit int) ([]models.Snapshot, error) {
+	query := fmt.Sprintf(`SELECT %s FROM snapshot
+		WHERE (? = '' OR location = ?) AND (ts > ? OR (ts = ? AND location > ?))
+		ORDER BY ts ASC, location ASC
+		LIMIT ?`, snapshotColumns)
+
+	rows, err := s.DB.Query(query, location, location, afterTS, afterTS, afterLocation, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []models.Snapshot
+	for rows.Next() {
+		snap, err := scanSnapshotRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+	return snapshots, rows.Err()
+}
@@ -1,7 +1,10 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,150 +16,27 @@ import (
 // SQLiteStore handles SQLite database operations
 type SQLiteStore struct {
 	DB *sql.DB
+
+	// onInsert, when set, is called after each successful InsertSnapshot.
+	onInsert func(models.Snapshot)
 }
 
-// NewSQLiteStore creates a new SQLite store and initializes schema
+// NewSQLiteStore opens a SQLite store and applies pending migrations. Each
+// connection runs in WAL mode with a busy timeout (see defaultPragmas); a
+// _pragma option in dbPath overrides the default for that pragma.
 func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", buildDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
+	configurePool(db, dbPath)
 
-	// Initialize schema
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	schema := `
-	CREATE TABLE IF NOT EXISTS raw (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp TEXT NOT NULL,
-		source TEXT NOT NULL,
-		payload BLOB NOT NULL
-	);
-
-	CREATE TABLE IF NOT EXISTS snapshot (
-		ts TEXT PRIMARY KEY,
-		location TEXT NOT NULL,
-
-		-- Weather (OpenMeteo)
-		temp_c REAL,
-		humidity REAL,
-		wind REAL,
-		precip REAL,
-		cloud_cover REAL,
-		visibility_km REAL,
-
-		-- Environment / Air Quality (OpenAQ)
-		pm25 REAL,
-		pm10 REAL,
-		ozone REAL,
-		no2 REAL,
-		so2 REAL,
-		co REAL,
-
-		-- Mobility (HERE, OpenSky, Movebank)
-		traffic_speed_kmh REAL,
-		traffic_jam_factor REAL,
-		flight_count INTEGER,
-		avg_altitude_m REAL,
-		active_species INTEGER,
-		animals_tracked INTEGER,
-		avg_migration_pace_km_day REAL,
-
-		-- Finance (AlphaVantage, NASDAQ)
-		stock_price REAL,
-		stock_symbol TEXT,
-		commodity_price REAL,
-		commodity_symbol TEXT,
-		market_cap REAL,
-		volume INTEGER,
-		nasdaq_index REAL,
-		volume_traded BIGINT,
-
-		-- Energy (Grid, US Energy Info, Ember)
-		electricity_price_usd REAL,
-		generation_mwh REAL,
-		renewable_percent REAL,
-		grid_load REAL,
-		carbon_intensity_gco2_kwh REAL,
-		grid_utilization_percent REAL,
-		natural_gas_price_mmbtu REAL,
-		coal_percent REAL,
-		gas_percent REAL,
-		nuclear_percent REAL,
-
-		-- Health (CDC FluView)
-		flu_cases INTEGER,
-		ili_percent REAL,
-		hospital_admissions INTEGER,
-
-		-- Agriculture (USDA NASS)
-		crop_yield REAL,
-		crop_type TEXT,
-		soil_moisture_percent REAL,
-		precip_forecast_mm REAL,
-		production_bushels REAL,
-		price_per_bushel REAL,
-		harvested_acres REAL,
-
-		-- Disasters (FEMA)
-		active_disasters INTEGER,
-		disaster_type TEXT,
-		severity INTEGER,
-		affected_counties INTEGER
-	);
-
-	CREATE TABLE IF NOT EXISTS semantic_record (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		location TEXT NOT NULL,
-		ts TEXT NOT NULL,
-		category TEXT NOT NULL,
-		summary TEXT NOT NULL,
-		snapshot_ts TEXT,
-		FOREIGN KEY (snapshot_ts) REFERENCES snapshot(ts)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_semantic_location_ts ON semantic_record(location, ts);
-	CREATE INDEX IF NOT EXISTS idx_semantic_category ON semantic_record(category);
-
-	CREATE TABLE IF NOT EXISTS agg_metrics (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		location TEXT NOT NULL,
-		metric TEXT NOT NULL,
-		timeframe TEXT NOT NULL,
-		value REAL NOT NULL,
-		computed_at TEXT NOT NULL
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_agg_location_metric ON agg_metrics(location, metric, timeframe);
-
-	CREATE TABLE IF NOT EXISTS events (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		location TEXT NOT NULL,
-		ts TEXT NOT NULL,
-		event_type TEXT NOT NULL,
-		severity REAL NOT NULL,
-		description TEXT NOT NULL
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_events_location_ts ON events(location, ts);
-
-	-- Embeddings: store vector as JSON text for portability
-	CREATE TABLE IF NOT EXISTS snapshot_embeddings (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		snapshot_ts TEXT NOT NULL,
-		location TEXT NOT NULL,
-		summary TEXT NOT NULL,
-		embedding TEXT NOT NULL,
-		created_at TEXT NOT NULL,
-		FOREIGN KEY (snapshot_ts) REFERENCES snapshot(ts)
-	);
-	CREATE INDEX IF NOT EXISTS idx_embeddings_location_ts ON snapshot_embeddings(location, snapshot_ts);
-	`
-
-	if _, err := db.Exec(schema); err != nil {
-		return nil, fmt.Errorf("create schema: %w", err)
+	if err := migrate(context.Background(), db); err != nil {
+		return nil, fmt.Errorf("migrate schema: %w", err)
 	}
 
 	return &SQLiteStore{DB: db}, nil
@@ -164,21 +44,40 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 
 // InsertSnapshot persists a unified snapshot to the database
 func (s *SQLiteStore) InsertSnapshot(snap models.Snapshot) error {
-	placeholder := strings.Repeat("?,", 52) + "?" // 53 placeholders for 53 columns
+	return s.InsertSnapshotContext(context.Background(), snap)
+}
+
+// InsertSnapshotContext is InsertSnapshot with a caller-supplied context.
+func (s *SQLiteStore) InsertSnapshotContext(ctx context.Context, snap models.Snapshot) error {
+	placeholder := strings.Repeat("?,", 71) + "?" // 72 placeholders for 72 columns
+
+	rawUnits, err := marshalRawUnits(snap.Environment.RawUnits)
+	if err != nil {
+		return err
+	}
+	unconverted, err := marshalParamList(snap.Environment.UnconvertedUnits)
+	if err != nil {
+		return err
+	}
+	modeled, err := marshalParamList(snap.Environment.Modeled)
+	if err != nil {
+		return err
+	}
 
 	sql := fmt.Sprintf(`INSERT INTO snapshot
 		(ts, location,
 		 temp_c, humidity, wind, precip, cloud_cover, visibility_km,
-		 pm25, pm10, ozone, no2, so2, co,
-		 traffic_speed_kmh, traffic_jam_factor, flight_count, avg_altitude_m, active_species, animals_tracked, avg_migration_pace_km_day,
-		 stock_price, stock_symbol, commodity_price, commodity_symbol, market_cap, volume, nasdaq_index, volume_traded,
+		 pm25, pm10, ozone, no2, so2, co, aq_raw_units, aq_unconverted, aq_modeled,
+		 traffic_speed_kmh, traffic_jam_factor, flight_count, avg_altitude_m, active_species, animals_tracked, avg_migration_pace_km_day, bike_share_bikes_available, bike_share_utilization_percent,
+		 stock_price, stock_symbol, commodity_price, commodity_symbol, market_cap, volume, nasdaq_index, volume_traded, cpi, unemployment_rate, treasury_10y,
 		 electricity_price_usd, generation_mwh, renewable_percent, grid_load, carbon_intensity_gco2_kwh, grid_utilization_percent, natural_gas_price_mmbtu, coal_percent, gas_percent, nuclear_percent,
-		 flu_cases, ili_percent, hospital_admissions,
+		 flu_cases, ili_percent, hospital_admissions, rsv_percent_positive, rsv_detections, rsv_tests,
 		 crop_yield, crop_type, soil_moisture_percent, precip_forecast_mm, production_bushels, price_per_bushel, harvested_acres,
-		 active_disasters, disaster_type, severity, affected_counties)
+		 active_disasters, disaster_type, severity, affected_counties,
+		 weather_observed_at, environment_observed_at, mobility_observed_at, finance_observed_at, energy_observed_at, health_observed_at, agriculture_observed_at, disasters_observed_at)
 		VALUES (%s)`, placeholder)
 
-	_, err := s.DB.Exec(
+	_, err = s.DB.ExecContext(ctx,
 		sql,
 		snap.Timestamp.Format(time.RFC3339),
 		snap.Location,
@@ -196,6 +95,9 @@ func (s *SQLiteStore) InsertSnapshot(snap models.Snapshot) error {
 		snap.Environment.NO2,
 		snap.Environment.SO2,
 		snap.Environment.CO,
+		rawUnits,
+		unconverted,
+		modeled,
 
 		snap.Mobility.TrafficSpeedKmH,
 		snap.Mobility.TrafficJamFactor,
@@ -204,6 +106,8 @@ func (s *SQLiteStore) InsertSnapshot(snap models.Snapshot) error {
 		snap.Mobility.ActiveSpecies,
 		snap.Mobility.AnimalsTracked,
 		snap.Mobility.AvgMigrationPaceKMDay,
+		snap.Mobility.BikeShareBikesAvailable,
+		snap.Mobility.BikeShareUtilizationPercent,
 
 		snap.Finance.StockPrice,
 		snap.Finance.StockSymbol,
@@ -213,6 +117,9 @@ func (s *SQLiteStore) InsertSnapshot(snap models.Snapshot) error {
 		snap.Finance.Volume,
 		snap.Finance.NASDAQIndex,
 		snap.Finance.VolumeTraded,
+		snap.Finance.CPI,
+		snap.Finance.UnemploymentRate,
+		snap.Finance.Treasury10Y,
 
 		snap.Energy.ElectricityPriceUSD,
 		snap.Energy.GenerationMWh,
@@ -228,6 +135,9 @@ func (s *SQLiteStore) InsertSnapshot(snap models.Snapshot) error {
 		snap.Health.FluCases,
 		snap.Health.ILIPercent,
 		snap.Health.HospitalAdmissions,
+		snap.Health.RSVPercentPositive,
+		snap.Health.RSVDetections,
+		snap.Health.RSVTests,
 
 		snap.Agriculture.CropYield,
 		snap.Agriculture.CropType,
@@ -241,9 +151,64 @@ func (s *SQLiteStore) InsertSnapshot(snap models.Snapshot) error {
 		snap.Disasters.DisasterType,
 		snap.Disasters.Severity,
 		snap.Disasters.AffectedCounties,
+
+		observedAtValue(snap.Weather.ObservedAt),
+		observedAtValue(snap.Environment.ObservedAt),
+		observedAtValue(snap.Mobility.ObservedAt),
+		observedAtValue(snap.Finance.ObservedAt),
+		observedAtValue(snap.Energy.ObservedAt),
+		observedAtValue(snap.Health.ObservedAt),
+		observedAtValue(snap.Agriculture.ObservedAt),
+		observedAtValue(snap.Disasters.ObservedAt),
 	)
+	if err != nil {
+		return err
+	}
+
+	if s.onInsert != nil {
+		s.onInsert(snap)
+	}
+	return nil
+}
+
+// InsertSnapshotIfChanged inserts snap unless the latest stored snapshot for
+// its location is unchanged up to tol (see models.Snapshot.Unchanged). It
+// reports whether snap was inserted; the first snapshot for a location always
+// is.
+func (s *SQLiteStore) InsertSnapshotIfChanged(snap models.Snapshot, tol models.Tolerance) (bool, error) {
+	return s.InsertSnapshotIfChangedContext(context.Background(), snap, tol)
+}
+
+// InsertSnapshotIfChangedContext is InsertSnapshotIfChanged with a caller-supplied context.
+func (s *SQLiteStore) InsertSnapshotIfChangedContext(ctx context.Context, snap models.Snapshot, tol models.Tolerance) (bool, error) {
+	prev, err := s.GetLatestSnapshotContext(ctx, snap.Location)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return false, err
+	case prev.Unchanged(snap, tol):
+		return false, nil
+	}
+
+	if err := s.InsertSnapshotContext(ctx, snap); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetInsertHook registers fn to be called after every successful
+// InsertSnapshot (e.g. to publish the snapshot to live subscribers).
+func (s *SQLiteStore) SetInsertHook(fn func(models.Snapshot)) {
+	s.onInsert = fn
+}
 
-	return err
+// Ping verifies the database is reachable and can answer a trivial query.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	if err := s.DB.PingContext(ctx); err != nil {
+		return err
+	}
+	var one int
+	return s.DB.QueryRowContext(ctx, "SELECT 1").Scan(&one)
 }
 
 // Close closes the database connection
@@ -253,3 +218,39 @@ func (s *SQLiteStore) Close() error {
 	}
 	return nil
 }
+
+// marshalRawUnits encodes Environment.RawUnits for the aq_raw_units column;
+// an empty map is stored as NULL.
+func marshalRawUnits(units map[string]string) (interface{}, error) {
+	if len(units) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(units)
+	if err != nil {
+		return nil, fmt.Errorf("marshal raw units: %w", err)
+	}
+	return string(b), nil
+}
+
+// marshalParamList encodes a list of air-quality parameters
+// (Environment.UnconvertedUnits, Environment.Modeled) for its JSON column; an
+// empty list is stored as NULL.
+func marshalParamList(params []string) (interface{}, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal parameter list: %w", err)
+	}
+	return string(b), nil
+}
+
+// observedAtValue encodes a section's ObservedAt for its *_observed_at
+// column; nil is stored as NULL.
+func observedAtValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ColonelToad/EdgeSight/go-ingest/internal/models"
+)
+
+func TestInsertSnapshotIfChanged(t *testing.T) {
+	s := newTestStore(t)
+	tol := models.Tolerance{Absolute: 0.05}
+	base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	snap := models.Snapshot{Location: "Denver", Timestamp: base}
+	snap.Weather.TemperatureC = 20
+	snap.Finance.StockSymbol = "SPY"
+	if inserted, err := s.InsertSnapshotIfChanged(snap, tol); err != nil || !inserted {
+		t.Fatalf("first snapshot: inserted %v, err %v; want inserted", inserted, err)
+	}
+
+	unchanged := snap
+	unchanged.Timestamp = base.Add(time.Minute)
+	unchanged.Weather.TemperatureC = 20.04
+	if inserted, err := s.InsertSnapshotIfChanged(unchanged, tol); err != nil || inserted {
+		t.Errorf("snapshot within tolerance: inserted %v, err %v; want skipped", inserted, err)
+	}
+
+	changed := snap
+	changed.Timestamp = base.Add(2 * time.Minute)
+	changed.Weather.TemperatureC = 21
+	if inserted, err := s.InsertSnapshotIfChanged(changed, tol); err != nil || !inserted {
+		t.Errorf("changed snapshot: inserted %v, err %v; want inserted", inserted, err)
+	}
+
+	renamed := changed
+	renamed.Timestamp = base.Add(3 * time.Minute)
+	renamed.Finance.StockSymbol = "QQQ"
+	if inserted, err := s.InsertSnapshotIfChanged(renamed, tol); err != nil || !inserted {
+		t.Errorf("changed text field: inserted %v, err %v; want inserted", inserted, err)
+	}
+
+	snaps, err := s.GetSnapshotsByTimeRange("Denver", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetSnapshotsByTimeRange: %v", err)
+	}
+	if len(snaps) != 3 {
+		t.Errorf("stored %d snapshots, want 3", len(snaps))
+	}
+}
+
+func TestObservedAtRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	denver := time.FixedZone("MDT", -6*3600)
+	weather := time.Date(2026, 10, 17, 8, 0, 0, 0, denver)
+	finance := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	agriculture := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	snap := models.Snapshot{Location: "Denver", Timestamp: time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)}
+	snap.Weather.ObservedAt = &weather
+	snap.Finance.ObservedAt = &finance
+	snap.Agriculture.ObservedAt = &agriculture
+	if err := s.InsertSnapshot(snap); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+
+	got, err := s.GetLatestSnapshot("Denver")
+	if err != nil {
+		t.Fatalf("GetLatestSnapshot: %v", err)
+	}
+	for name, tt := range map[string]struct{ got, want *time.Time }{
+		"weather":     {got.Weather.ObservedAt, &weather},
+		"finance":     {got.Finance.ObservedAt, &finance},
+		"agriculture": {got.Agriculture.ObservedAt, &agriculture},
+		"environment": {got.Environment.ObservedAt, nil},
+		"mobility":    {got.Mobility.ObservedAt, nil},
+		"energy":      {got.Energy.ObservedAt, nil},
+		"health":      {got.Health.ObservedAt, nil},
+		"disasters":   {got.Disasters.ObservedAt, nil},
+	} {
+		switch {
+		case tt.want == nil && tt.got != nil:
+			t.Errorf("%s ObservedAt = %v, want nil", name, *tt.got)
+		case tt.want != nil && (tt.got == nil || !tt.got.Equal(*tt.want) || tt.got.Location() != time.UTC):
+			t.Errorf("%s ObservedAt = %v, want %v in UTC", name, tt.got, *tt.want)
+		}
+	}
+}